@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.24;
+
+/// @title RelayAttestationLog
+/// @notice Append-only log of relayed recovery VAAs, written by relayers after each relay
+/// @dev Stores nothing; indexers read the events. Anyone can attest, so consumers should
+///      filter on the relayer addresses they trust.
+contract RelayAttestationLog {
+    /// @notice A relayer delivered a VAA
+    /// @param relayer Account that wrote the record
+    /// @param vaaDigest Wormhole signing digest of the VAA
+    /// @param sequence Sequence of the VAA
+    /// @param safe Safe the recovery was for
+    /// @param timestamp Timestamp of the VAA
+    event RelayAttested(
+        address indexed relayer,
+        bytes32 indexed vaaDigest,
+        uint64 sequence,
+        address indexed safe,
+        uint64 timestamp
+    );
+
+    /// @notice Record a relayed VAA
+    function attest(bytes32 vaaDigest, uint64 sequence, address safe, uint64 timestamp) external {
+        emit RelayAttested(msg.sender, vaaDigest, sequence, safe, timestamp);
+    }
+}
@@ -25,6 +25,7 @@ contract SafeRecoveryModule {
 
     event RecoveryApplied(address indexed safe, uint256 threshold, bytes32 nonce);
     event AztecRecoveryContractSet(address indexed safe, bytes32 aztecContract);
+    event AztecRecoveryContractRemoved(address indexed safe, bytes32 aztecContract);
     event Paused(bool value);
     event DebugPayload(uint256 payloadLen, address safe, address candidate, uint256 chainId, bytes32 emitter);
 
@@ -60,6 +61,14 @@ contract SafeRecoveryModule {
         emit AztecRecoveryContractSet(msg.sender, aztecContract);
     }
 
+    /// @notice Remove the Aztec recovery contract address for the caller Safe
+    /// @dev Must be called by the Safe itself. Relayers stop forwarding VAAs from the removed contract.
+    function removeAztecRecoveryContract() external {
+        bytes32 aztecContract = aztecRecoveryContract[msg.sender];
+        delete aztecRecoveryContract[msg.sender];
+        emit AztecRecoveryContractRemoved(msg.sender, aztecContract);
+    }
+
     /// @notice Get the Aztec recovery contract address for a Safe
     /// @param safe The Safe address to query
     /// @return The Aztec recovery contract address (32 bytes)
@@ -155,8 +164,8 @@ contract SafeRecoveryModule {
         consumedVaas[vaaHash] = true;
 
         // 3. Extract from payload (Wormhole adds 32-byte txHash, then Aztec 31-byte LE fields compressed)
-        // Payload layout: [txHash(32), module(20), chainId(3), safe(20), zeros(21), candidate(20), padding(17)]
-        // Total: 32 + 20 + 3 + 20 + 21 + 20 + 17 = 133 bytes
+        // Payload layout: [txHash(32), module(20), chainId(3), safe(20), zeros(21), candidate(20), version(1), padding(16)]
+        // Total: 32 + 20 + 3 + 20 + 21 + 20 + 1 + 16 = 133 bytes. Only version 0 has this layout.
         bytes memory payload = vm.payload;
         require(payload.length >= 116, "payload too short");
 
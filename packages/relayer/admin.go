@@ -0,0 +1,691 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+// AdminServer serves the relayer's operational HTTP endpoints
+type AdminServer struct {
+	server  *http.Server
+	mux     *http.ServeMux
+	relayer *Relayer
+	logger  *zap.Logger
+}
+
+// NewAdminServer creates an admin server for the relayer listening on addr
+func NewAdminServer(addr string, r *Relayer) *AdminServer {
+	mux := http.NewServeMux()
+	s := &AdminServer{
+		server: &http.Server{
+			Addr:              addr,
+			ReadHeaderTimeout: 10 * time.Second,
+			// Bound what a slow or idle client can hold; responses may stream for longer
+			// (profiles, audit export), so there is no write timeout
+			ReadTimeout:    30 * time.Second,
+			IdleTimeout:    2 * time.Minute,
+			MaxHeaderBytes: 64 << 10,
+		},
+		mux:     mux,
+		relayer: r,
+		logger:  logger.With(zap.String("component", "AdminServer")),
+	}
+
+	s.handle("GET /healthz", AdminPermPublic, s.handleHealth)
+	// OpenMetrics carries the correlation ID exemplars; plain scrapes still get text format
+	s.handle("GET /metrics", AdminPermRead, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})).ServeHTTP)
+	s.handle("GET /receipts", AdminPermPublic, s.handleReceipts)
+	s.handle("GET /dashboard", AdminPermPublic, s.handleDashboardPage)
+	s.handle("GET /admin/dashboard", AdminPermRead, s.handleDashboard)
+	s.handle("POST /admin/drain", AdminPermControl, s.handleDrain)
+	s.handle("GET /admin/checkpoints", AdminPermRead, s.handleCheckpoints)
+	s.handle("GET /admin/retries", AdminPermRead, s.handleRetries)
+	s.handle("GET /admin/pending", AdminPermRead, s.handlePendingTxs)
+	s.handle("GET /admin/nonces", AdminPermRead, s.handleNonces)
+	s.handle("POST /admin/nonces/resync", AdminPermControl, s.handleNonceResync)
+	s.handle("GET /admin/emitters", AdminPermRead, s.handleEmitters)
+	s.handle("POST /admin/emitters/override", AdminPermControl, s.handleOverrideEmitter)
+	s.handle("POST /admin/emitters/disable", AdminPermControl, s.handleDisableEmitter)
+	s.handle("DELETE /admin/emitters/override", AdminPermControl, s.handleClearEmitterOverride)
+	s.handle("GET /admin/signers/rotation", AdminPermRead, s.handleKeyRotation)
+	s.handle("POST /admin/signers/rotate", AdminPermControl, s.handleRotateKeys)
+	s.handle("GET /admin/reports/safe-costs", AdminPermRead, s.handleSafeCosts)
+	s.handle("GET /admin/reports/relays", AdminPermRead, s.handleRelays)
+	s.handle("GET /admin/pause", AdminPermRead, s.handlePauseState)
+	s.handle("POST /admin/pause", AdminPermControl, s.handlePause)
+	s.handle("POST /admin/resume", AdminPermControl, s.handleResume)
+	s.handle("GET /admin/maintenance", AdminPermRead, s.handleMaintenance)
+	s.handle("GET /admin/tunables", AdminPermRead, s.handleTunables)
+	s.handle("POST /admin/tunables", AdminPermControl, s.handleSetTunable)
+	s.handle("GET /admin/reconcile", AdminPermRead, s.handleReconcileReport)
+	s.handle("POST /admin/reconcile", AdminPermControl, s.handleReconcile)
+	s.handle("GET /admin/audit", AdminPermRead, s.handleAuditExport)
+	s.handle("GET /admin/audit/verify", AdminPermRead, s.handleAuditVerify)
+	s.handle("GET /debug/info", AdminPermRead, s.handleDebugInfo)
+
+	if r.config.EnablePprof {
+		s.registerPprof()
+	}
+	s.server.Handler = s.guard(mux)
+	if r.adminAuth == nil && !isLoopbackAddr(addr) {
+		s.logger.Warn("Admin API has no authentication and listens beyond localhost; set ADMIN_API_KEYS or ADMIN_JWT_*",
+			zap.String("addr", addr))
+	}
+
+	return s
+}
+
+// isLoopbackAddr reports whether a listen address only accepts local connections
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleHealth reports whether the relayer can receive and submit VAAs; it answers 503
+// while the spy stream is stale or any destination's RPC circuit is open, so load
+// balancers and probes can alert on it
+func (s *AdminServer) handleHealth(w http.ResponseWriter, req *http.Request) {
+	status := http.StatusOK
+	destinations := make([]map[string]any, 0, len(s.relayer.destinations))
+	for _, dest := range s.relayer.destinations {
+		state, failures := dest.client.breaker.State()
+		if state == circuitOpen {
+			status = http.StatusServiceUnavailable
+		}
+		destinations = append(destinations, map[string]any{
+			"name":                dest.Name,
+			"chainId":             dest.ChainID,
+			"circuit":             state.String(),
+			"consecutiveFailures": failures,
+			"stalled":             dest.client.breaker.Stalled(),
+		})
+	}
+
+	lastMessage := time.Unix(0, s.relayer.lastVAAReceived.Load())
+	spyStale := s.relayer.streamStale.Load()
+	if spyStale {
+		status = http.StatusServiceUnavailable
+	}
+
+	canary, canaryHealthy := s.relayer.Canary()
+	if !canaryHealthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	paused, _, queued := s.relayer.PauseState()
+	writeJSON(w, status, map[string]any{
+		"healthy": status == http.StatusOK,
+		"paused":  paused,
+		"queued":  queued,
+		"spy": map[string]any{
+			"stale":             spyStale,
+			"lastMessageAgeSec": int64(time.Since(lastMessage).Seconds()),
+		},
+		"destinations": destinations,
+		"canary":       canary,
+	})
+}
+
+// handleDrain stops VAA intake; the relayer exits once inflight VAAs are confirmed
+func (s *AdminServer) handleDrain(w http.ResponseWriter, req *http.Request) {
+	s.logger.Info("Drain requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principalName(req)))
+	s.relayer.Drain()
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"draining": true,
+		"inflight": s.relayer.inflightCount(),
+	})
+}
+
+// handlePause halts submission while VAAs keep being observed and queued
+func (s *AdminServer) handlePause(w http.ResponseWriter, req *http.Request) {
+	s.logger.Info("Pause requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principalName(req)))
+	s.relayer.Pause()
+	s.handlePauseState(w, req)
+}
+
+// handleResume restarts submission, releasing queued VAAs
+func (s *AdminServer) handleResume(w http.ResponseWriter, req *http.Request) {
+	s.logger.Info("Resume requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principalName(req)))
+	s.relayer.Resume()
+	s.handlePauseState(w, req)
+}
+
+// handlePauseState reports the current pause state
+func (s *AdminServer) handlePauseState(w http.ResponseWriter, req *http.Request) {
+	paused, since, queued := s.relayer.PauseState()
+	resp := map[string]any{
+		"paused": paused,
+		"queued": queued,
+	}
+	if paused {
+		resp["pausedSince"] = since.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleMaintenance lists the maintenance windows, which are open and when each next opens
+func (s *AdminServer) handleMaintenance(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"windows":  s.relayer.MaintenanceState(),
+		"deferred": s.relayer.deferredVAAs.Load(),
+	})
+}
+
+// handleTunables reports the current value of every runtime-tunable parameter
+func (s *AdminServer) handleTunables(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, s.relayer.Tunables())
+}
+
+// handleSetTunable changes a runtime-tunable parameter until the next restart. Query
+// parameters: name, value and, for per-destination parameters, destination (all when left out).
+func (s *AdminServer) handleSetTunable(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if query.Get("name") == "" || query.Get("value") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "name and value are required"})
+		return
+	}
+	if err := s.relayer.SetTunable(query.Get("name"), query.Get("destination"), query.Get("value"), principalName(req)); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.relayer.Tunables())
+}
+
+// handleReconcile runs a reconciliation now and returns its report. Only the active instance
+// reconciles.
+func (s *AdminServer) handleReconcile(w http.ResponseWriter, req *http.Request) {
+	if !s.relayer.isActive() {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "instance is on standby"})
+		return
+	}
+	s.logger.Info("Reconciliation requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principalName(req)))
+	writeJSON(w, http.StatusOK, s.relayer.reconcile(req.Context()))
+}
+
+// handleReconcileReport returns the latest reconciliation report
+func (s *AdminServer) handleReconcileReport(w http.ResponseWriter, req *http.Request) {
+	report := s.relayer.LastReconcile()
+	if report == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no reconciliation has run"})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleCheckpoints lists the last processed sequence per emitter
+func (s *AdminServer) handleCheckpoints(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, s.relayer.Checkpoints())
+}
+
+// handleRetries lists VAAs waiting for another processing attempt
+func (s *AdminServer) handleRetries(w http.ResponseWriter, req *http.Request) {
+	type retryView struct {
+		VAAHash       string    `json:"vaaHash"`
+		CorrelationID string    `json:"correlationId"`
+		Attempts      int       `json:"attempts"`
+		NextAttempt   time.Time `json:"nextAttempt"`
+		LastError     string    `json:"lastError"`
+		ErrorKind     ErrorKind `json:"errorKind"`
+		Parked        bool      `json:"parked"`
+		DeferredAt    time.Time `json:"deferredAt,omitzero"`
+	}
+	entries := s.relayer.Retries()
+	views := make([]retryView, 0, len(entries))
+	for _, entry := range entries {
+		views = append(views, retryView{
+			VAAHash:       entry.Key,
+			CorrelationID: entry.CorrelationID,
+			Attempts:      entry.Attempts,
+			NextAttempt:   entry.NextAttempt,
+			LastError:     entry.LastError,
+			ErrorKind:     entry.ErrorKind,
+			Parked:        entry.parked(),
+			DeferredAt:    entry.DeferredAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// handlePendingTxs lists the broadcast transactions not yet seen mined, oldest first per
+// destination, with how long they have been pending
+func (s *AdminServer) handlePendingTxs(w http.ResponseWriter, req *http.Request) {
+	type pendingView struct {
+		TxHash        string    `json:"txHash"`
+		Signer        string    `json:"signer"`
+		Nonce         uint64    `json:"nonce"`
+		Tip           string    `json:"maxPriorityFeePerGas"`
+		FeeCap        string    `json:"maxFeePerGas"`
+		Gas           uint64    `json:"gas"`
+		Description   string    `json:"description"`
+		CorrelationID string    `json:"correlationId"`
+		SentAt        time.Time `json:"sentAt"`
+		AgeSec        int64     `json:"ageSec"`
+		SeenPending   bool      `json:"seenPending"`
+		LastSeen      time.Time `json:"lastSeen,omitzero"`
+	}
+	result := make(map[string][]pendingView)
+	for destination, txs := range s.relayer.PendingTxs() {
+		views := make([]pendingView, 0, len(txs))
+		for _, tx := range txs {
+			views = append(views, pendingView{
+				TxHash:        tx.Hash.Hex(),
+				Signer:        tx.Signer.Hex(),
+				Nonce:         tx.Nonce,
+				Tip:           tx.Tip.String(),
+				FeeCap:        tx.FeeCap.String(),
+				Gas:           tx.Gas,
+				Description:   tx.Description,
+				CorrelationID: tx.CorrelationID,
+				SentAt:        tx.SentAt,
+				AgeSec:        int64(time.Since(tx.SentAt).Seconds()),
+				SeenPending:   !tx.LastSeen.IsZero(),
+				LastSeen:      tx.LastSeen,
+			})
+		}
+		result[destination] = views
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleNonces audits each signer's tracked transactions against its nonces on chain.
+// Query parameter: destination (optional).
+func (s *AdminServer) handleNonces(w http.ResponseWriter, req *http.Request) {
+	s.writeNonceAudit(w, req, false)
+}
+
+// handleNonceResync audits nonces like handleNonces and drops tracking the chain contradicts
+func (s *AdminServer) handleNonceResync(w http.ResponseWriter, req *http.Request) {
+	s.logger.Info("Nonce resync requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("destination", req.URL.Query().Get("destination")))
+	s.writeNonceAudit(w, req, true)
+}
+
+func (s *AdminServer) writeNonceAudit(w http.ResponseWriter, req *http.Request, resync bool) {
+	destination := req.URL.Query().Get("destination")
+	known := destination == ""
+	for _, dest := range s.relayer.destinations {
+		known = known || dest.Name == destination
+	}
+	if !known {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown destination"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 30*time.Second)
+	defer cancel()
+	audits, err := s.relayer.AuditNonces(ctx, destination, resync)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, audits)
+}
+
+// handleEmitters lists each tenant's registered emitters and the operator overrides in effect.
+// Query parameter: tenant (optional).
+func (s *AdminServer) handleEmitters(w http.ResponseWriter, req *http.Request) {
+	type registrationView struct {
+		Emitter    string         `json:"emitter"`
+		Safe       common.Address `json:"safe"`
+		Overridden bool           `json:"overridden"`
+	}
+	name := req.URL.Query().Get("tenant")
+	result := make(map[string]any)
+	for _, tenant := range s.relayer.tenants {
+		if name != "" && tenant.Name != name {
+			continue
+		}
+		tenant.emittersMu.RLock()
+		registered := make([]registrationView, 0, len(tenant.registeredEmitters))
+		for aztecContract, safe := range tenant.registeredEmitters {
+			_, overridden := tenant.overrides[normalizeEmitter(aztecContract)]
+			registered = append(registered, registrationView{Emitter: aztecContract, Safe: safe, Overridden: overridden})
+		}
+		tenant.emittersMu.RUnlock()
+		result[tenant.Name] = map[string]any{
+			"registered": registered,
+			"overrides":  tenant.emitterOverrides(),
+		}
+	}
+	if name != "" && len(result) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "unknown tenant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleOverrideEmitter maps an emitter to a Safe in a tenant's registry, ahead of the module's
+// registrations. Query parameters: tenant (optional with one tenant), emitter, safe and reason.
+func (s *AdminServer) handleOverrideEmitter(w http.ResponseWriter, req *http.Request) {
+	safe := req.URL.Query().Get("safe")
+	if !common.IsHexAddress(safe) || common.HexToAddress(safe) == (common.Address{}) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "safe must be a non-zero address"})
+		return
+	}
+	s.writeEmitterOverride(w, req, common.HexToAddress(safe), false)
+}
+
+// handleDisableEmitter refuses an emitter's VAAs for a tenant whatever the module has
+// registered. Query parameters: tenant (optional with one tenant), emitter and reason.
+func (s *AdminServer) handleDisableEmitter(w http.ResponseWriter, req *http.Request) {
+	s.writeEmitterOverride(w, req, common.Address{}, true)
+}
+
+func (s *AdminServer) writeEmitterOverride(w http.ResponseWriter, req *http.Request, safe common.Address, disabled bool) {
+	query := req.URL.Query()
+	tenant, err := s.relayer.overrideTenant(query.Get("tenant"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	if normalizeEmitter(query.Get("emitter")) == "" || query.Get("reason") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "emitter and reason are required"})
+		return
+	}
+	override, err := s.relayer.OverrideEmitter(tenant, query.Get("emitter"), safe, disabled, query.Get("reason"), principalName(req))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, override)
+}
+
+// handleClearEmitterOverride returns an emitter to the module's registry. Query parameters:
+// tenant (optional with one tenant) and emitter.
+func (s *AdminServer) handleClearEmitterOverride(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	tenant, err := s.relayer.overrideTenant(query.Get("tenant"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	cleared, err := s.relayer.ClearEmitterOverride(tenant, query.Get("emitter"), principalName(req))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	if !cleared {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no override of that emitter"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"cleared": true})
+}
+
+// handleKeyRotation reports the progress of the cutover to NEXT_PRIVATE_KEYS
+func (s *AdminServer) handleKeyRotation(w http.ResponseWriter, req *http.Request) {
+	status, ok := s.relayer.KeyRotation()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no NEXT_PRIVATE_KEYS configured"})
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleRotateKeys moves new submissions to NEXT_PRIVATE_KEYS and starts draining the old keys
+func (s *AdminServer) handleRotateKeys(w http.ResponseWriter, req *http.Request) {
+	if _, ok := s.relayer.KeyRotation(); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "no NEXT_PRIVATE_KEYS configured"})
+		return
+	}
+	s.logger.Info("Signer key rotation requested via admin API",
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principalName(req)))
+	status, err := s.relayer.StartKeyRotation(principalName(req))
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "rotation": status})
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleSafeCosts reports gas spent per Safe. Query parameters: safe (optional) and
+// from/to as YYYY-MM-DD, defaulting to the last 30 days.
+func (s *AdminServer) handleSafeCosts(w http.ResponseWriter, req *http.Request) {
+	safe, from, to, ok := parseReportQuery(w, req)
+	if !ok {
+		return
+	}
+
+	report, err := s.relayer.SafeCostReport(safe, from, to)
+	if err != nil {
+		s.logger.Error("Failed to build Safe cost report", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from":  from.Format(time.DateOnly),
+		"to":    to.Format(time.DateOnly),
+		"safes": report,
+	})
+}
+
+// handleRelays lists the gas used and fee paid by each confirmed relay transaction. Query
+// parameters are those of handleSafeCosts; to covers the whole day.
+func (s *AdminServer) handleRelays(w http.ResponseWriter, req *http.Request) {
+	safe, from, to, ok := parseReportQuery(w, req)
+	if !ok {
+		return
+	}
+
+	records, err := s.relayer.store.RelayRecords(safe, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		s.logger.Error("Failed to read relay records", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	totalGas := uint64(0)
+	totalCost := new(big.Int)
+	for i, record := range records {
+		totalGas += record.GasUsed
+		totalCost.Add(totalCost, record.CostWei)
+		records[i].VAA = nil
+	}
+	if records == nil {
+		records = []RelayRecord{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from":    from.Format(time.DateOnly),
+		"to":      to.Format(time.DateOnly),
+		"gasUsed": totalGas,
+		"costWei": totalCost.String(),
+		"relays":  records,
+	})
+}
+
+// handleReceipts lists the signed receipts of the relays confirmed in a range, for users and
+// auditors to check who delivered a recovery. Query parameters are those of handleRelays, plus
+// txHash and vaaDigest to pick out one relay.
+func (s *AdminServer) handleReceipts(w http.ResponseWriter, req *http.Request) {
+	safe, from, to, ok := parseReportQuery(w, req)
+	if !ok {
+		return
+	}
+	query := req.URL.Query()
+	var filters []common.Hash
+	for _, name := range []string{"txHash", "vaaDigest"} {
+		value := query.Get(name)
+		if value == "" {
+			filters = append(filters, common.Hash{})
+			continue
+		}
+		decoded, err := hexutil.Decode(value)
+		if err != nil || len(decoded) != common.HashLength {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid " + name})
+			return
+		}
+		filters = append(filters, common.BytesToHash(decoded))
+	}
+	txHash, vaaDigest := filters[0], filters[1]
+
+	records, err := s.relayer.store.RelayRecords(safe, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		s.logger.Error("Failed to read relay records", zap.Error(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	receipts := []*RelayReceipt{}
+	for _, record := range records {
+		switch {
+		case record.Receipt == nil:
+		case txHash != (common.Hash{}) && record.Receipt.TxHash != txHash:
+		case vaaDigest != (common.Hash{}) && record.Receipt.VAADigest != vaaDigest:
+		default:
+			receipts = append(receipts, record.Receipt)
+		}
+	}
+
+	response := map[string]any{
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"domain":   map[string]string{"name": sdk.ReceiptDomainName, "version": sdk.ReceiptDomainVersion},
+		"receipts": receipts,
+	}
+	if signer := s.relayer.receiptSigner; signer != nil {
+		response["signer"] = signer.address
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseReportQuery reads the safe filter and the from/to days of a report, answering 400 and
+// returning false when one is invalid
+func parseReportQuery(w http.ResponseWriter, req *http.Request) (*common.Address, time.Time, time.Time, bool) {
+	query := req.URL.Query()
+	safe, from, to, err := parseReportRange(query.Get("safe"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return nil, time.Time{}, time.Time{}, false
+	}
+	return safe, from, to, true
+}
+
+// parseReportRange parses the safe filter and the from/to days of a report. Empty days cover
+// the last 30 days.
+func parseReportRange(safeValue, fromValue, toValue string) (*common.Address, time.Time, time.Time, error) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -30)
+	for _, day := range []struct {
+		name, value string
+		target      *time.Time
+	}{{"from", fromValue, &from}, {"to", toValue, &to}} {
+		if day.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, day.value)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("invalid %s date, want YYYY-MM-DD", day.name)
+		}
+		*day.target = parsed
+	}
+
+	var safe *common.Address
+	if safeValue != "" {
+		if !common.IsHexAddress(safeValue) {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("invalid safe address")
+		}
+		address := common.HexToAddress(safeValue)
+		safe = &address
+	}
+	return safe, from, to, nil
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	// Encoded without HTML escaping so URLs in error messages are seen whole by the redaction
+	var body strings.Builder
+	encoder := json.NewEncoder(&body)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, logSecrets.redact(body.String()))
+}
+
+// handleAuditExport streams the whole audit log as JSON lines, for compliance review
+func (s *AdminServer) handleAuditExport(w http.ResponseWriter, req *http.Request) {
+	if s.relayer.audit == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "audit log is disabled"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	if err := s.relayer.audit.Export(w); err != nil {
+		s.logger.Error("Failed to export audit log", zap.Error(err))
+	}
+}
+
+// handleAuditVerify checks the audit log's hash chain
+func (s *AdminServer) handleAuditVerify(w http.ResponseWriter, req *http.Request) {
+	if s.relayer.audit == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "audit log is disabled"})
+		return
+	}
+	result, err := s.relayer.audit.Verify()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// registerPprof exposes the net/http/pprof profiles under /debug/pprof/
+func (s *AdminServer) registerPprof() {
+	// Profiles cost CPU and expose memory contents, so they need control permission
+	s.handle("/debug/pprof/", AdminPermControl, pprof.Index)
+	s.handle("/debug/pprof/cmdline", AdminPermControl, pprof.Cmdline)
+	s.handle("/debug/pprof/profile", AdminPermControl, pprof.Profile)
+	s.handle("/debug/pprof/symbol", AdminPermControl, pprof.Symbol)
+	s.handle("/debug/pprof/trace", AdminPermControl, pprof.Trace)
+	s.logger.Info("pprof profiling endpoints enabled", zap.String("path", "/debug/pprof/"))
+}
+
+// Start serves requests in the background until ctx is cancelled
+func (s *AdminServer) Start(ctx context.Context) {
+	go func() {
+		s.logger.Info("Admin server listening", zap.String("addr", s.server.Addr))
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Admin server stopped", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn("Admin server shutdown failed", zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Permissions on the admin API. Control implies read.
+const (
+	AdminPermPublic  = "public"  // No credentials needed
+	AdminPermRead    = "read"    // Reports and diagnostics
+	AdminPermControl = "control" // Pause, resume, drain, reconcile, profiling
+)
+
+// jwtClockSkew is the leeway given to a token's exp and nbf claims
+const jwtClockSkew = time.Minute
+
+// adminPrincipal is who an admin request authenticated as
+type adminPrincipal struct {
+	name string // API key name or JWT subject
+	perm string // AdminPermRead or AdminPermControl
+}
+
+// adminPrincipalKey is the context key of the request's principal
+type adminPrincipalKey struct{}
+
+// adminAPIKey is a configured API key; only its hash is kept
+type adminAPIKey struct {
+	name string
+	perm string
+	hash [32]byte
+}
+
+// adminAuth checks admin API credentials: API keys and JWTs signed with a shared secret
+// (HS256) or a public key (RS256, ES256)
+type adminAuth struct {
+	keys []adminAPIKey
+
+	jwtSecret    []byte
+	jwtPublicKey crypto.PublicKey
+	jwtIssuer    string
+	jwtAudience  string
+	jwtRoleClaim string
+}
+
+// newAdminAuth creates the admin API authenticator from the ADMIN_API_KEYS* and ADMIN_JWT_*
+// settings, or returns nil when no credentials are configured and the API is open
+func newAdminAuth(config Config) (*adminAuth, error) {
+	entries := append([]string{}, config.AdminAPIKeys...)
+	if config.AdminAPIKeysFile != "" {
+		fileEntries, err := readAdminKeysFile(config.AdminAPIKeysFile, config.AllowInsecureKeyFiles)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range fileEntries {
+			logSecrets.add(entry[strings.LastIndex(entry, ":")+1:])
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	auth := &adminAuth{
+		jwtIssuer:    config.AdminJWTIssuer,
+		jwtAudience:  config.AdminJWTAudience,
+		jwtRoleClaim: config.AdminJWTRoleClaim,
+	}
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		key, err := parseAdminAPIKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		if names[key.name] {
+			return nil, fmt.Errorf("admin API key %q is configured more than once", key.name)
+		}
+		names[key.name] = true
+		auth.keys = append(auth.keys, key)
+	}
+
+	if config.AdminJWTSecret != "" && config.AdminJWTPublicKeyFile != "" {
+		return nil, fmt.Errorf("ADMIN_JWT_SECRET and ADMIN_JWT_PUBLIC_KEY_FILE are mutually exclusive")
+	}
+	if config.AdminJWTSecret != "" {
+		auth.jwtSecret = []byte(config.AdminJWTSecret)
+	}
+	if config.AdminJWTPublicKeyFile != "" {
+		key, err := readJWTPublicKey(config.AdminJWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.jwtPublicKey = key
+	}
+
+	if len(auth.keys) == 0 && auth.jwtSecret == nil && auth.jwtPublicKey == nil {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// parseAdminAPIKey parses a name:permission:key entry
+func parseAdminAPIKey(entry string) (adminAPIKey, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return adminAPIKey{}, fmt.Errorf("invalid admin API key entry %q, want name:permission:key", redactAdminKeyEntry(entry))
+	}
+	perm := strings.ToLower(parts[1])
+	if perm != AdminPermRead && perm != AdminPermControl {
+		return adminAPIKey{}, fmt.Errorf("admin API key %q has unknown permission %q (want %s or %s)", parts[0], parts[1], AdminPermRead, AdminPermControl)
+	}
+	if len(parts[2]) < 16 {
+		return adminAPIKey{}, fmt.Errorf("admin API key %q is shorter than 16 characters", parts[0])
+	}
+	return adminAPIKey{name: parts[0], perm: perm, hash: sha256.Sum256([]byte(parts[2]))}, nil
+}
+
+// redactAdminKeyEntry drops the key from an entry quoted in an error
+func redactAdminKeyEntry(entry string) string {
+	if i := strings.LastIndex(entry, ":"); i >= 0 {
+		return entry[:i+1] + redacted
+	}
+	return redacted
+}
+
+// readAdminKeysFile reads name:permission:key entries, one per line; blank lines and lines
+// starting with # are skipped
+func readAdminKeysFile(path string, allowInsecure bool) ([]string, error) {
+	data, err := readSecretFile(path, allowInsecure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin API keys file: %v", err)
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
+
+// readJWTPublicKey reads a PEM-encoded RSA or ECDSA P-256 public key
+func readJWTPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT public key file %s holds no PEM block", path)
+	}
+	var key any
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT public key: %v", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("JWT public key uses curve %s, only P-256 (ES256) is supported", k.Curve.Params().Name)
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("JWT public key is a %T, want RSA or ECDSA", key)
+	}
+}
+
+// authenticate returns who the request's credentials belong to. Credentials are sent as
+// "Authorization: Bearer <API key or JWT>" or in an X-API-Key header.
+func (a *adminAuth) authenticate(req *http.Request) (adminPrincipal, error) {
+	return a.authenticateCredentials(req.Header.Get("X-API-Key"), req.Header.Get("Authorization"))
+}
+
+// authenticateCredentials returns who an X-API-Key or Authorization value belongs to, for the
+// HTTP headers and the gRPC metadata of the same names
+func (a *adminAuth) authenticateCredentials(apiKey, authorization string) (adminPrincipal, error) {
+	credential := apiKey
+	if credential == "" && authorization != "" {
+		scheme, value, ok := strings.Cut(authorization, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return adminPrincipal{}, fmt.Errorf("unsupported authorization scheme")
+		}
+		credential = strings.TrimSpace(value)
+	}
+	if credential == "" {
+		return adminPrincipal{}, fmt.Errorf("no credentials")
+	}
+
+	// A JWT has three dot-separated parts; API keys are matched by hash in constant time
+	if strings.Count(credential, ".") == 2 && (a.jwtSecret != nil || a.jwtPublicKey != nil) {
+		return a.verifyJWT(credential)
+	}
+	hash := sha256.Sum256([]byte(credential))
+	for _, key := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			return adminPrincipal{name: key.name, perm: key.perm}, nil
+		}
+	}
+	return adminPrincipal{}, fmt.Errorf("unknown API key")
+}
+
+// verifyJWT checks a compact JWT's signature and claims and maps its role claim to a permission
+func (a *adminAuth) verifyJWT(token string) (adminPrincipal, error) {
+	parts := strings.Split(token, ".")
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return adminPrincipal{}, fmt.Errorf("invalid JWT header encoding")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return adminPrincipal{}, fmt.Errorf("invalid JWT header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return adminPrincipal{}, fmt.Errorf("invalid JWT signature encoding")
+	}
+	if err := a.verifyJWTSignature(header.Alg, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return adminPrincipal{}, err
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return adminPrincipal{}, fmt.Errorf("invalid JWT claims encoding")
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return adminPrincipal{}, fmt.Errorf("invalid JWT claims")
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return adminPrincipal{}, fmt.Errorf("JWT has no exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtClockSkew)) {
+		return adminPrincipal{}, fmt.Errorf("JWT expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return adminPrincipal{}, fmt.Errorf("JWT not valid yet")
+	}
+	if a.jwtIssuer != "" && claims["iss"] != a.jwtIssuer {
+		return adminPrincipal{}, fmt.Errorf("JWT issuer %v is not %s", claims["iss"], a.jwtIssuer)
+	}
+	if a.jwtAudience != "" && !claimContains(claims["aud"], a.jwtAudience) {
+		return adminPrincipal{}, fmt.Errorf("JWT is not for audience %s", a.jwtAudience)
+	}
+
+	principal := adminPrincipal{name: fmt.Sprint(claims["sub"])}
+	switch {
+	case claimContains(claims[a.jwtRoleClaim], AdminPermControl):
+		principal.perm = AdminPermControl
+	case claimContains(claims[a.jwtRoleClaim], AdminPermRead):
+		principal.perm = AdminPermRead
+	default:
+		return adminPrincipal{}, fmt.Errorf("JWT grants no %s or %s in claim %q", AdminPermRead, AdminPermControl, a.jwtRoleClaim)
+	}
+	return principal, nil
+}
+
+// verifyJWTSignature checks signature over signed with the configured key, which decides the
+// algorithms accepted
+func (a *adminAuth) verifyJWTSignature(alg string, signed, signature []byte) error {
+	digest := sha256.Sum256(signed)
+	switch key := a.jwtPublicKey.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			return fmt.Errorf("JWT algorithm %q not accepted, want RS256", alg)
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return fmt.Errorf("invalid JWT signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			return fmt.Errorf("JWT algorithm %q not accepted, want ES256", alg)
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid JWT signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return fmt.Errorf("invalid JWT signature")
+		}
+		return nil
+	}
+
+	if alg != "HS256" {
+		return fmt.Errorf("JWT algorithm %q not accepted, want HS256", alg)
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write(signed)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return fmt.Errorf("invalid JWT signature")
+	}
+	return nil
+}
+
+// claimContains reports whether a string claim equals want, or an array or space-separated
+// claim (like scope) includes it
+func claimContains(claim any, want string) bool {
+	switch v := claim.(type) {
+	case string:
+		for _, item := range strings.Fields(v) {
+			if item == want {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if item == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handle registers handler for pattern, requiring perm. Without configured credentials every
+// endpoint is open.
+func (s *AdminServer) handle(pattern, perm string, handler http.HandlerFunc) {
+	if perm == AdminPermPublic || s.relayer.adminAuth == nil {
+		s.mux.HandleFunc(pattern, handler)
+		return
+	}
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
+		principal, err := s.relayer.adminAuth.authenticate(req)
+		if err != nil {
+			s.deny(w, req, http.StatusUnauthorized, "", err.Error())
+			return
+		}
+		if perm == AdminPermControl && principal.perm != AdminPermControl {
+			s.deny(w, req, http.StatusForbidden, principal.name, "needs control permission")
+			return
+		}
+		if perm == AdminPermControl {
+			s.relayer.audit.Record(AuditAdminRequest, map[string]string{
+				"principal": principal.name,
+				"method":    req.Method,
+				"path":      req.URL.Path,
+				"remote":    req.RemoteAddr,
+			})
+		}
+		handler(w, req.WithContext(context.WithValue(req.Context(), adminPrincipalKey{}, principal)))
+	})
+}
+
+// deny answers an unauthenticated or unauthorized request and audits it
+func (s *AdminServer) deny(w http.ResponseWriter, req *http.Request, status int, principal, reason string) {
+	s.logger.Warn("Admin API request denied",
+		zap.String("method", req.Method),
+		zap.String("path", req.URL.Path),
+		zap.String("remote", req.RemoteAddr),
+		zap.String("principal", principal),
+		zap.String("reason", reason))
+	adminAuthFailures.WithLabelValues(fmt.Sprint(status)).Inc()
+	s.relayer.audit.Record(AuditAdminDenied, map[string]string{
+		"principal": principal,
+		"method":    req.Method,
+		"path":      req.URL.Path,
+		"remote":    req.RemoteAddr,
+		"reason":    reason,
+	})
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="relayer-admin"`)
+	}
+	writeJSON(w, status, map[string]any{"error": http.StatusText(status)})
+}
+
+// principalName returns the authenticated caller of an admin request, for logs
+func principalName(req *http.Request) string {
+	if principal, ok := req.Context().Value(adminPrincipalKey{}).(adminPrincipal); ok {
+		return principal.name
+	}
+	return ""
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	relayerv1 "github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/proto/relayer/v1"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// adminGRPCPerms is the permission each RelayerService method needs, as its admin HTTP
+// endpoint does
+var adminGRPCPerms = map[string]string{
+	relayerv1.RelayerService_GetHealth_FullMethodName:     AdminPermPublic,
+	relayerv1.RelayerService_ListRelays_FullMethodName:    AdminPermRead,
+	relayerv1.RelayerService_ListReceipts_FullMethodName:  AdminPermPublic,
+	relayerv1.RelayerService_ListRetries_FullMethodName:   AdminPermRead,
+	relayerv1.RelayerService_GetPauseState_FullMethodName: AdminPermRead,
+	relayerv1.RelayerService_Pause_FullMethodName:         AdminPermControl,
+	relayerv1.RelayerService_Resume_FullMethodName:        AdminPermControl,
+	relayerv1.RelayerService_Drain_FullMethodName:         AdminPermControl,
+}
+
+// AdminGRPCServer serves the admin API's status and control endpoints as the RelayerService
+// of proto/relayer/v1, for consumers that integrate against the .proto rather than the JSON
+type AdminGRPCServer struct {
+	relayerv1.UnimplementedRelayerServiceServer
+
+	addr    string
+	server  *grpc.Server
+	relayer *Relayer
+	logger  *zap.Logger
+}
+
+// NewAdminGRPCServer creates a gRPC server for the relayer listening on addr
+func NewAdminGRPCServer(addr string, r *Relayer) *AdminGRPCServer {
+	s := &AdminGRPCServer{
+		addr:    addr,
+		relayer: r,
+		logger:  logger.With(zap.String("component", "AdminGRPCServer")),
+	}
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	relayerv1.RegisterRelayerServiceServer(s.server, s)
+	if r.adminAuth == nil && !isLoopbackAddr(addr) {
+		s.logger.Warn("Admin gRPC API has no authentication and listens beyond localhost; set ADMIN_API_KEYS or ADMIN_JWT_*",
+			zap.String("addr", addr))
+	}
+	return s
+}
+
+// Start serves in the background until ctx is done
+func (s *AdminGRPCServer) Start(ctx context.Context) {
+	go func() {
+		listener, err := net.Listen("tcp", s.addr)
+		if err != nil {
+			s.logger.Error("Admin gRPC server stopped", zap.Error(err))
+			return
+		}
+		s.logger.Info("Admin gRPC server listening", zap.String("addr", s.addr))
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			s.logger.Error("Admin gRPC server stopped", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			s.server.Stop()
+		}
+	}()
+}
+
+// intercept applies the admin client allowlist, rate limit, authentication and permissions to
+// a call. Clients are told apart by their peer address: X-Forwarded-For has no gRPC equivalent.
+func (s *AdminGRPCServer) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+
+	if g := s.relayer.adminGuard; g != nil {
+		client, ok := peerAddr(remote)
+		if !ok || (len(g.allowed) > 0 && !containsAddr(g.allowed, client)) {
+			s.logger.Debug("Admin gRPC call from disallowed address",
+				zap.String("remote", remote),
+				zap.String("method", info.FullMethod))
+			adminRequestsRejected.WithLabelValues("not_allowed").Inc()
+			return nil, status.Error(codes.PermissionDenied, "client not allowed")
+		}
+		if ok, wait := g.allow(client, time.Now()); !ok {
+			adminRequestsRejected.WithLabelValues("rate_limited").Inc()
+			return nil, status.Errorf(codes.ResourceExhausted, "rate limited, retry in %s", wait.Round(time.Millisecond))
+		}
+	}
+
+	perm, ok := adminGRPCPerms[info.FullMethod]
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "unknown method")
+	}
+	if perm == AdminPermPublic || s.relayer.adminAuth == nil {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	principal, err := s.relayer.adminAuth.authenticateCredentials(firstMetadata(md, "x-api-key"), firstMetadata(md, "authorization"))
+	if err != nil {
+		return nil, s.deny(info.FullMethod, remote, "", codes.Unauthenticated, err.Error())
+	}
+	if perm == AdminPermControl && principal.perm != AdminPermControl {
+		return nil, s.deny(info.FullMethod, remote, principal.name, codes.PermissionDenied, "needs control permission")
+	}
+	if perm == AdminPermControl {
+		s.relayer.audit.Record(AuditAdminRequest, map[string]string{
+			"principal": principal.name,
+			"method":    "gRPC",
+			"path":      info.FullMethod,
+			"remote":    remote,
+		})
+	}
+	return handler(context.WithValue(ctx, adminPrincipalKey{}, principal), req)
+}
+
+// deny logs, counts and audits a call refused for its credentials, returning its error
+func (s *AdminGRPCServer) deny(method, remote, principal string, code codes.Code, reason string) error {
+	s.logger.Warn("Admin gRPC call denied",
+		zap.String("method", method),
+		zap.String("remote", remote),
+		zap.String("principal", principal),
+		zap.String("reason", reason))
+	// Counted under the HTTP status of the same refusal, so one alert covers both APIs
+	httpStatus := "401"
+	if code == codes.PermissionDenied {
+		httpStatus = "403"
+	}
+	adminAuthFailures.WithLabelValues(httpStatus).Inc()
+	s.relayer.audit.Record(AuditAdminDenied, map[string]string{
+		"principal": principal,
+		"method":    "gRPC",
+		"path":      method,
+		"remote":    remote,
+		"reason":    reason,
+	})
+	return status.Error(code, code.String())
+}
+
+// peerAddr parses the address of a gRPC peer
+func peerAddr(remote string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// firstMetadata returns the first value of a metadata key, or "" when it is not set
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcPrincipal returns the authenticated caller of an admin gRPC call, for logs
+func grpcPrincipal(ctx context.Context) string {
+	if principal, ok := ctx.Value(adminPrincipalKey{}).(adminPrincipal); ok {
+		return principal.name
+	}
+	return ""
+}
+
+// GetHealth reports what GET /healthz does
+func (s *AdminGRPCServer) GetHealth(ctx context.Context, _ *relayerv1.GetHealthRequest) (*relayerv1.GetHealthResponse, error) {
+	healthy := true
+	resp := &relayerv1.GetHealthResponse{}
+	for _, dest := range s.relayer.destinations {
+		state, failures := dest.client.breaker.State()
+		if state == circuitOpen {
+			healthy = false
+		}
+		resp.Destinations = append(resp.Destinations, &relayerv1.DestinationHealth{
+			Name:                dest.Name,
+			ChainId:             dest.ChainID,
+			Circuit:             state.String(),
+			ConsecutiveFailures: int64(failures),
+			Stalled:             dest.client.breaker.Stalled(),
+		})
+	}
+
+	lastMessage := time.Unix(0, s.relayer.lastVAAReceived.Load())
+	resp.Spy = &relayerv1.SpyHealth{
+		Stale:             s.relayer.streamStale.Load(),
+		LastMessageAgeSec: int64(time.Since(lastMessage).Seconds()),
+	}
+	healthy = healthy && !resp.Spy.Stale
+
+	canary, canaryHealthy := s.relayer.Canary()
+	for _, run := range canary {
+		resp.Canary = append(resp.Canary, &relayerv1.CanaryStatus{
+			Source:      run.Source,
+			LastRun:     optionalTimestamp(run.LastRun),
+			LastSuccess: optionalTimestamp(run.LastSuccess),
+			Healthy:     run.Healthy,
+			Stage:       run.Stage,
+			Error:       run.Error,
+			Sequence:    run.Sequence,
+			LatencySec:  run.LatencySec,
+		})
+	}
+	resp.Healthy = healthy && canaryHealthy
+	resp.Paused, _, resp.Queued = s.relayer.PauseState()
+	return resp, nil
+}
+
+// ListRelays reports what GET /admin/reports/relays does
+func (s *AdminGRPCServer) ListRelays(ctx context.Context, req *relayerv1.ListRelaysRequest) (*relayerv1.ListRelaysResponse, error) {
+	from, to, records, err := s.relayRecords(req.GetSafe(), req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	totalGas := uint64(0)
+	totalCost := new(big.Int)
+	resp := &relayerv1.ListRelaysResponse{
+		From:   from.Format(time.DateOnly),
+		To:     to.Format(time.DateOnly),
+		Relays: make([]*relayerv1.RelayRecord, 0, len(records)),
+	}
+	for _, record := range records {
+		totalGas += record.GasUsed
+		totalCost.Add(totalCost, record.CostWei)
+		resp.Relays = append(resp.Relays, relayRecordProto(record))
+	}
+	resp.GasUsed = totalGas
+	resp.CostWei = totalCost.String()
+	return resp, nil
+}
+
+// ListReceipts reports what GET /receipts does
+func (s *AdminGRPCServer) ListReceipts(ctx context.Context, req *relayerv1.ListReceiptsRequest) (*relayerv1.ListReceiptsResponse, error) {
+	txHash, err := parseHashFilter("tx_hash", req.GetTxHash())
+	if err != nil {
+		return nil, err
+	}
+	vaaDigest, err := parseHashFilter("vaa_digest", req.GetVaaDigest())
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, records, err := s.relayRecords(req.GetSafe(), req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, err
+	}
+	resp := &relayerv1.ListReceiptsResponse{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Domain:   &relayerv1.ReceiptDomain{Name: sdk.ReceiptDomainName, Version: sdk.ReceiptDomainVersion},
+		Receipts: []*relayerv1.RelayReceipt{},
+	}
+	if signer := s.relayer.receiptSigner; signer != nil {
+		resp.Signer = signer.address.Hex()
+	}
+	for _, record := range records {
+		switch {
+		case record.Receipt == nil:
+		case txHash != (common.Hash{}) && record.Receipt.TxHash != txHash:
+		case vaaDigest != (common.Hash{}) && record.Receipt.VAADigest != vaaDigest:
+		default:
+			resp.Receipts = append(resp.Receipts, relayReceiptProto(record.Receipt))
+		}
+	}
+	return resp, nil
+}
+
+// ListRetries reports what GET /admin/retries does
+func (s *AdminGRPCServer) ListRetries(ctx context.Context, _ *relayerv1.ListRetriesRequest) (*relayerv1.ListRetriesResponse, error) {
+	entries := s.relayer.Retries()
+	resp := &relayerv1.ListRetriesResponse{Retries: make([]*relayerv1.Retry, 0, len(entries))}
+	for _, entry := range entries {
+		resp.Retries = append(resp.Retries, &relayerv1.Retry{
+			VaaHash:       entry.Key,
+			CorrelationId: entry.CorrelationID,
+			Attempts:      int64(entry.Attempts),
+			NextAttempt:   optionalTimestamp(entry.NextAttempt),
+			LastError:     entry.LastError,
+			ErrorKind:     string(entry.ErrorKind),
+			Parked:        entry.parked(),
+			DeferredAt:    optionalTimestamp(entry.DeferredAt),
+		})
+	}
+	return resp, nil
+}
+
+// GetPauseState reports what GET /admin/pause does
+func (s *AdminGRPCServer) GetPauseState(ctx context.Context, _ *relayerv1.GetPauseStateRequest) (*relayerv1.GetPauseStateResponse, error) {
+	return &relayerv1.GetPauseStateResponse{State: s.pauseState()}, nil
+}
+
+// Pause halts submission like POST /admin/pause
+func (s *AdminGRPCServer) Pause(ctx context.Context, _ *relayerv1.PauseRequest) (*relayerv1.PauseResponse, error) {
+	s.logger.Info("Pause requested via admin gRPC API", zap.String("principal", grpcPrincipal(ctx)))
+	s.relayer.Pause()
+	return &relayerv1.PauseResponse{State: s.pauseState()}, nil
+}
+
+// Resume restarts submission like POST /admin/resume
+func (s *AdminGRPCServer) Resume(ctx context.Context, _ *relayerv1.ResumeRequest) (*relayerv1.ResumeResponse, error) {
+	s.logger.Info("Resume requested via admin gRPC API", zap.String("principal", grpcPrincipal(ctx)))
+	s.relayer.Resume()
+	return &relayerv1.ResumeResponse{State: s.pauseState()}, nil
+}
+
+// Drain stops VAA intake like POST /admin/drain
+func (s *AdminGRPCServer) Drain(ctx context.Context, _ *relayerv1.DrainRequest) (*relayerv1.DrainResponse, error) {
+	s.logger.Info("Drain requested via admin gRPC API", zap.String("principal", grpcPrincipal(ctx)))
+	s.relayer.Drain()
+	return &relayerv1.DrainResponse{Draining: true, Inflight: int64(s.relayer.inflightCount())}, nil
+}
+
+// relayRecords reads the relay records of a report range, whose to day is covered whole
+func (s *AdminGRPCServer) relayRecords(safeValue, fromValue, toValue string) (time.Time, time.Time, []RelayRecord, error) {
+	safe, from, to, err := parseReportRange(safeValue, fromValue, toValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	records, err := s.relayer.store.RelayRecords(safe, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		s.logger.Error("Failed to read relay records", zap.Error(err))
+		return time.Time{}, time.Time{}, nil, status.Error(codes.Internal, err.Error())
+	}
+	return from, to, records, nil
+}
+
+// parseHashFilter parses an optional 0x hash argument, the zero hash when it is empty
+func parseHashFilter(name, value string) (common.Hash, error) {
+	if value == "" {
+		return common.Hash{}, nil
+	}
+	decoded, err := hexutil.Decode(value)
+	if err != nil || len(decoded) != common.HashLength {
+		return common.Hash{}, status.Errorf(codes.InvalidArgument, "invalid %s", name)
+	}
+	return common.BytesToHash(decoded), nil
+}
+
+// pauseState returns the current pause state
+func (s *AdminGRPCServer) pauseState() *relayerv1.PauseState {
+	paused, since, queued := s.relayer.PauseState()
+	state := &relayerv1.PauseState{Paused: paused, Queued: queued}
+	if paused {
+		state.PausedSince = timestamppb.New(since)
+	}
+	return state
+}
+
+// relayRecordProto converts a relay record, leaving out the relayed VAA as the HTTP report does
+func relayRecordProto(record RelayRecord) *relayerv1.RelayRecord {
+	msg := &relayerv1.RelayRecord{
+		TxHash:        record.TxHash.Hex(),
+		VaaHash:       record.VAAHash,
+		CorrelationId: record.CorrelationID,
+		Tenant:        record.Tenant,
+		Function:      record.Function,
+		Safe:          record.Safe.Hex(),
+		ChainId:       record.ChainID,
+		Block:         record.Block,
+		GasUsed:       record.GasUsed,
+		CostWei:       record.CostWei.String(),
+		ConfirmedAt:   optionalTimestamp(record.ConfirmedAt),
+	}
+	if record.EffectiveGasPrice != nil {
+		msg.EffectiveGasPrice = record.EffectiveGasPrice.String()
+	}
+	if record.Receipt != nil {
+		msg.Receipt = relayReceiptProto(record.Receipt)
+	}
+	return msg
+}
+
+// relayReceiptProto converts a signed relay receipt
+func relayReceiptProto(receipt *RelayReceipt) *relayerv1.RelayReceipt {
+	return &relayerv1.RelayReceipt{
+		VaaDigest: receipt.VAADigest.Hex(),
+		ChainId:   receipt.ChainID,
+		TxHash:    receipt.TxHash.Hex(),
+		Safe:      receipt.Safe.Hex(),
+		Timestamp: receipt.Timestamp,
+		Signer:    receipt.Signer.Hex(),
+		Signature: receipt.Signature,
+	}
+}
+
+// optionalTimestamp converts t, leaving the zero time unset
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// adminLimiterIdle is how long a client's rate limit bucket is kept after its last request
+const adminLimiterIdle = 10 * time.Minute
+
+// adminGuard filters admin HTTP requests by client address and rate before they reach any
+// handler, /healthz and /metrics included
+type adminGuard struct {
+	allowed []netip.Prefix // Clients accepted; empty accepts any
+	proxies []netip.Prefix // Proxies whose X-Forwarded-For names the client
+
+	rate  float64 // Requests per second per client (0 for no limit)
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[netip.Addr]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket holds a client's remaining request allowance
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newAdminGuard creates the guard from ADMIN_ALLOWED_CIDRS, ADMIN_TRUSTED_PROXIES and
+// ADMIN_RATE_*, or returns nil when none of them restricts anything
+func newAdminGuard(config Config) (*adminGuard, error) {
+	allowed, err := parsePrefixes("ADMIN_ALLOWED_CIDRS", config.AdminAllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parsePrefixes("ADMIN_TRUSTED_PROXIES", config.AdminTrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if config.AdminRateLimit < 0 || config.AdminRateBurst < 0 {
+		return nil, fmt.Errorf("ADMIN_RATE_LIMIT and ADMIN_RATE_BURST must not be negative")
+	}
+	if len(allowed) == 0 && config.AdminRateLimit == 0 {
+		return nil, nil
+	}
+
+	burst := float64(config.AdminRateBurst)
+	if burst < 1 {
+		burst = math.Max(1, float64(config.AdminRateLimit))
+	}
+	return &adminGuard{
+		allowed: allowed,
+		proxies: proxies,
+		rate:    float64(config.AdminRateLimit),
+		burst:   burst,
+		buckets: make(map[netip.Addr]*tokenBucket),
+	}, nil
+}
+
+// parsePrefixes parses CIDRs and single IP addresses
+func parsePrefixes(setting string, values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid address %q", setting, value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid CIDR %q", setting, value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// containsAddr reports whether any of prefixes contains addr
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address of the client behind req: the peer, or when the peer is a
+// trusted proxy, the nearest X-Forwarded-For address that isn't one
+func (g *adminGuard) clientAddr(req *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if !containsAddr(g.proxies, addr) {
+		return addr, true
+	}
+
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		hop = hop.Unmap()
+		if !containsAddr(g.proxies, hop) {
+			return hop, true
+		}
+	}
+	return addr, true
+}
+
+// allow takes a token from the client's bucket, returning how long to wait when it is empty
+func (g *adminGuard) allow(client netip.Addr, now time.Time) (bool, time.Duration) {
+	if g.rate == 0 {
+		return true, 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Sub(g.lastSweep) > adminLimiterIdle {
+		for addr, bucket := range g.buckets {
+			if now.Sub(bucket.last) > adminLimiterIdle {
+				delete(g.buckets, addr)
+			}
+		}
+		g.lastSweep = now
+	}
+
+	bucket, ok := g.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: g.burst, last: now}
+		g.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(g.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*g.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / g.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// guard applies the allowlist and rate limit in front of next
+func (s *AdminServer) guard(next http.Handler) http.Handler {
+	g := s.relayer.adminGuard
+	if g == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		client, ok := g.clientAddr(req)
+		if !ok || (len(g.allowed) > 0 && !containsAddr(g.allowed, client)) {
+			s.logger.Debug("Admin API request from disallowed address",
+				zap.String("remote", req.RemoteAddr),
+				zap.String("client", client.String()),
+				zap.String("path", req.URL.Path))
+			adminRequestsRejected.WithLabelValues("not_allowed").Inc()
+			writeJSON(w, http.StatusForbidden, map[string]any{"error": http.StatusText(http.StatusForbidden)})
+			return
+		}
+		if ok, wait := g.allow(client, time.Now()); !ok {
+			adminRequestsRejected.WithLabelValues("rate_limited").Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeJSON(w, http.StatusTooManyRequests, map[string]any{"error": http.StatusText(http.StatusTooManyRequests)})
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
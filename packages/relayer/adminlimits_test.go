@@ -0,0 +1,194 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestAdminGuardClientAddr(t *testing.T) {
+	guard, err := newAdminGuard(Config{
+		AdminAllowedCIDRs:   []string{"10.0.0.0/8"},
+		AdminTrustedProxies: []string{"192.168.1.1", "172.16.0.0/12"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string // Empty when the request has no usable client address
+	}{
+		{name: "direct peer", remote: "10.1.2.3:4000", want: "10.1.2.3"},
+		{name: "IPv4-mapped peer", remote: "[::ffff:10.1.2.3]:4000", want: "10.1.2.3"},
+		{name: "spoofed header from an untrusted peer", remote: "203.0.113.7:4000", forwarded: []string{"10.1.2.3"}, want: "203.0.113.7"},
+		{name: "client behind a trusted proxy", remote: "192.168.1.1:4000", forwarded: []string{"10.1.2.3"}, want: "10.1.2.3"},
+		{name: "spoofed hop before the trusted proxies", remote: "192.168.1.1:4000", forwarded: []string{"10.1.2.3, 203.0.113.7, 172.16.0.5"}, want: "203.0.113.7"},
+		{name: "repeated headers", remote: "192.168.1.1:4000", forwarded: []string{"10.1.2.3", "172.16.0.5"}, want: "10.1.2.3"},
+		{name: "only proxies", remote: "192.168.1.1:4000", forwarded: []string{"172.16.0.5"}, want: "192.168.1.1"},
+		{name: "malformed hop", remote: "192.168.1.1:4000", forwarded: []string{"10.1.2.3, not-an-ip"}},
+		{name: "malformed peer", remote: "not-an-ip:4000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			client, ok := guard.clientAddr(req)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("got client %v, want none", client)
+				}
+				return
+			}
+			if !ok || client != netip.MustParseAddr(tt.want) {
+				t.Fatalf("got client %v (ok %v), want %s", client, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminGuardAllowedCIDRs(t *testing.T) {
+	guard, err := newAdminGuard(Config{
+		AdminAllowedCIDRs:   []string{"10.0.0.0/8", "2001:db8::/32", "198.51.100.9"},
+		AdminTrustedProxies: []string{"192.168.1.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &AdminServer{relayer: &Relayer{adminGuard: guard}, logger: zap.NewNop()}
+	handler := server.guard(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      int
+	}{
+		{name: "allowed CIDR", remote: "10.1.2.3:4000", want: http.StatusOK},
+		{name: "allowed IPv6 CIDR", remote: "[2001:db8::1]:4000", want: http.StatusOK},
+		{name: "allowed single address", remote: "198.51.100.9:4000", want: http.StatusOK},
+		{name: "denied CIDR", remote: "11.0.0.1:4000", want: http.StatusForbidden},
+		{name: "denied neighbour of a single address", remote: "198.51.100.10:4000", want: http.StatusForbidden},
+		{name: "allowed client behind a trusted proxy", remote: "192.168.1.1:4000", forwarded: "10.1.2.3", want: http.StatusOK},
+		{name: "denied client behind a trusted proxy", remote: "192.168.1.1:4000", forwarded: "11.0.0.1", want: http.StatusForbidden},
+		{name: "spoofed header from a denied peer", remote: "11.0.0.1:4000", forwarded: "10.1.2.3", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminGuardAllow(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	alice := netip.MustParseAddr("10.0.0.1")
+	bob := netip.MustParseAddr("10.0.0.2")
+
+	type request struct {
+		client   netip.Addr
+		at       time.Duration // Offset from start
+		want     bool
+		wantWait time.Duration
+	}
+	tests := []struct {
+		name     string
+		rate     int
+		burst    int
+		requests []request
+	}{
+		{
+			name: "burst then exhausted",
+			rate: 1, burst: 3,
+			requests: []request{
+				{client: alice, want: true},
+				{client: alice, want: true},
+				{client: alice, want: true},
+				{client: alice, want: false, wantWait: time.Second},
+				{client: alice, at: 500 * time.Millisecond, want: false, wantWait: 500 * time.Millisecond},
+				{client: alice, at: time.Second, want: true},
+				{client: alice, at: time.Second, want: false, wantWait: time.Second},
+			},
+		},
+		{
+			name: "buckets are per client",
+			rate: 1, burst: 1,
+			requests: []request{
+				{client: alice, want: true},
+				{client: alice, want: false, wantWait: time.Second},
+				{client: bob, want: true},
+			},
+		},
+		{
+			name: "refill is capped at the burst",
+			rate: 2, burst: 2,
+			requests: []request{
+				{client: alice, want: true},
+				{client: alice, at: time.Hour, want: true},
+				{client: alice, at: time.Hour, want: true},
+				{client: alice, at: time.Hour, want: false, wantWait: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "burst defaults to the rate",
+			rate: 2,
+			requests: []request{
+				{client: alice, want: true},
+				{client: alice, want: true},
+				{client: alice, want: false, wantWait: 500 * time.Millisecond},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := newAdminGuard(Config{AdminRateLimit: tt.rate, AdminRateBurst: tt.burst})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, r := range tt.requests {
+				ok, wait := guard.allow(r.client, start.Add(r.at))
+				if ok != r.want || wait != r.wantWait {
+					t.Fatalf("request %d: got (%v, %v), want (%v, %v)", i, ok, wait, r.want, r.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminGuardSweepsIdleBuckets(t *testing.T) {
+	guard, err := newAdminGuard(Config{AdminRateLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1_700_000_000, 0)
+	guard.allow(netip.MustParseAddr("10.0.0.1"), start)
+	guard.allow(netip.MustParseAddr("10.0.0.2"), start.Add(adminLimiterIdle))
+	guard.allow(netip.MustParseAddr("10.0.0.2"), start.Add(adminLimiterIdle+time.Second))
+
+	if _, ok := guard.buckets[netip.MustParseAddr("10.0.0.1")]; ok {
+		t.Fatal("idle bucket was not swept")
+	}
+	if _, ok := guard.buckets[netip.MustParseAddr("10.0.0.2")]; !ok {
+		t.Fatal("active bucket was swept")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// Gas limit of an attest call, which only emits an event
+const attestationGasLimit = 60000
+
+// attestationLogABI is the RelayAttestationLog contract in packages/evm-contracts
+const attestationLogABI = `[{
+    "inputs": [
+        {"internalType": "bytes32", "name": "vaaDigest", "type": "bytes32"},
+        {"internalType": "uint64", "name": "sequence", "type": "uint64"},
+        {"internalType": "address", "name": "safe", "type": "address"},
+        {"internalType": "uint64", "name": "timestamp", "type": "uint64"}
+    ],
+    "name": "attest",
+    "outputs": [],
+    "stateMutability": "nonpayable",
+    "type": "function"
+}]`
+
+var parsedAttestationLogABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(attestationLogABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// writeAttestation records a relayed VAA in the destination's attestation log: its digest,
+// sequence, Safe and timestamp. Like an acknowledgment, it is not waited for, and a failure is
+// logged and never fails the relay itself.
+func (r *Relayer) writeAttestation(ctx context.Context, dest *Destination, vaaData *VAAData) {
+	logger := withCorrelation(r.logger, vaaData.CorrelationID).With(
+		zap.String("destination", dest.Name),
+		zap.Uint64("sequence", vaaData.Sequence))
+
+	digest := vaaData.VAA.SigningDigest()
+	data, err := parsedAttestationLogABI.Pack("attest", [32]byte(digest), vaaData.Sequence,
+		vaaData.Payload.Safe, uint64(vaaData.VAA.Timestamp.Unix()))
+	if err != nil {
+		attestations.WithLabelValues(dest.Name, "failed").Inc()
+		logger.Error("Failed to write relay attestation", zap.Error(fmt.Errorf("ABI pack error: %v", err)))
+		return
+	}
+
+	strategy := r.feeStrategyFor(dest, vaaData.Payload)
+	txHash, err := dest.client.sendTransaction(ctx, common.HexToAddress(dest.AttestationLog), big.NewInt(0), data,
+		attestationGasLimit, strategy, "relay attestation for "+describeVAA(vaaData.RawBytes))
+	if err != nil {
+		attestations.WithLabelValues(dest.Name, "failed").Inc()
+		logger.Error("Failed to write relay attestation", zap.Error(err))
+		return
+	}
+	attestations.WithLabelValues(dest.Name, "sent").Inc()
+	logger.Info("Wrote relay attestation",
+		zap.String("vaaDigest", digest.Hex()),
+		zap.String("txHash", txHash))
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditVAAAccepted     = "vaa_accepted"   // A VAA passed every check and is being relayed
+	AuditVAARejected     = "vaa_rejected"   // A VAA was refused, with the reason
+	AuditTxSigned        = "tx_signed"      // A transaction was signed by a relayer or treasury key
+	AuditConfigLoaded    = "config_loaded"  // The relayer started with the same configuration as last time
+	AuditConfigChanged   = "config_changed" // The relayer started with a different configuration
+	AuditPause           = "pause"
+	AuditResume          = "resume"
+	AuditDrain           = "drain"
+	AuditKeyRotation     = "key_rotation"     // Submissions moved to NEXT_PRIVATE_KEYS, or the old keys left the pool
+	AuditEmitterOverride = "emitter_override" // An operator mapped, disabled or restored an emitter in a tenant's registry
+	AuditTunable         = "tunable"          // An operator changed a runtime-tunable parameter
+	AuditMaintenance     = "maintenance"      // A scheduled maintenance window opened or closed
+	AuditAdminRequest    = "admin_request"    // An authenticated caller used a control endpoint of the admin API
+	AuditAdminDenied     = "admin_denied"     // An admin API request was refused for missing or insufficient credentials
+)
+
+// AuditEntry is one line of the audit log. Hash is the SHA-256 of the entry's JSON encoding
+// with Hash empty, and PrevHash the Hash of the entry before it, so editing, removing or
+// reordering entries breaks the chain from there on.
+type AuditEntry struct {
+	Seq      uint64            `json:"seq"`
+	Time     time.Time         `json:"time"`
+	Action   string            `json:"action"`
+	Details  map[string]string `json:"details,omitempty"`
+	PrevHash string            `json:"prevHash"`
+	Hash     string            `json:"hash"`
+}
+
+// computeHash returns the hash the entry should carry
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	encoded, _ := json.Marshal(e)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditVerification is the outcome of checking an audit log's hash chain
+type AuditVerification struct {
+	Intact   bool   `json:"intact"`
+	Entries  uint64 `json:"entries"`
+	LastHash string `json:"lastHash,omitempty"`
+	BrokenAt uint64 `json:"brokenAt,omitempty"` // Line of the first entry that doesn't chain
+	Error    string `json:"error,omitempty"`
+}
+
+// AuditLog appends hash-chained entries to AUDIT_LOG_FILE. A nil *AuditLog records nothing.
+type AuditLog struct {
+	path   string
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	file       *os.File
+	seq        uint64
+	lastHash   string
+	lastConfig map[string]any // Configuration recorded by the last config entry
+}
+
+// openAuditLog opens the audit log for appending, or returns nil when AUDIT_LOG_FILE is not
+// set. The existing chain is verified; a broken one is reported and appended to regardless,
+// so the break stays visible.
+func openAuditLog(path string) (*AuditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	a := &AuditLog{path: path, logger: logger.With(zap.String("component", "AuditLog"))}
+
+	result, err := readAuditLogFile(path, func(entry AuditEntry) {
+		a.seq = entry.Seq
+		a.lastHash = entry.Hash
+		if entry.Action == AuditConfigLoaded || entry.Action == AuditConfigChanged {
+			var config map[string]any
+			if json.Unmarshal([]byte(entry.Details["config"]), &config) == nil {
+				a.lastConfig = config
+			}
+		}
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	if result.Intact {
+		auditLogIntact.Set(1)
+	} else {
+		auditLogIntact.Set(0)
+		a.logger.Error("Audit log hash chain is broken",
+			zap.String("path", path),
+			zap.Uint64("line", result.BrokenAt),
+			zap.String("error", result.Error))
+	}
+
+	a.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return a, nil
+}
+
+// readAuditLogFile checks the hash chain of the log at path, passing each entry to fn in order
+func readAuditLogFile(path string, fn func(AuditEntry)) (AuditVerification, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AuditVerification{Intact: true}, err
+	}
+	defer f.Close()
+	return readAuditLog(f, fn)
+}
+
+// readAuditLog checks the hash chain of the log read from in, passing each entry to fn in order
+func readAuditLog(in io.Reader, fn func(AuditEntry)) (AuditVerification, error) {
+	result := AuditVerification{Intact: true}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	prev := ""
+	var line uint64
+	for scanner.Scan() {
+		line++
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			result.breakAt(line, fmt.Sprintf("unreadable entry: %v", err))
+			continue
+		}
+		switch {
+		case entry.PrevHash != prev:
+			result.breakAt(line, fmt.Sprintf("entry %d does not follow the entry before it", entry.Seq))
+		case entry.Hash != entry.computeHash():
+			result.breakAt(line, fmt.Sprintf("entry %d was modified", entry.Seq))
+		}
+		prev = entry.Hash
+		result.Entries++
+		result.LastHash = entry.Hash
+		if fn != nil {
+			fn(entry)
+		}
+	}
+	return result, scanner.Err()
+}
+
+// breakAt records the first line the chain breaks at
+func (v *AuditVerification) breakAt(line uint64, reason string) {
+	if !v.Intact {
+		return
+	}
+	v.Intact = false
+	v.BrokenAt = line
+	v.Error = reason
+}
+
+// Record appends an entry, synced to disk before it returns. Failing to write is logged, not
+// returned: auditing never stops a relay.
+func (a *AuditLog) Record(action string, details map[string]string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry := AuditEntry{
+		Seq:      a.seq + 1,
+		Time:     time.Now().UTC(),
+		Action:   action,
+		Details:  details,
+		PrevHash: a.lastHash,
+	}
+	entry.Hash = entry.computeHash()
+	line, err := json.Marshal(entry)
+	if err == nil {
+		_, err = a.file.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = a.file.Sync()
+	}
+	if err != nil {
+		a.logger.Error("Failed to write audit log entry", zap.String("action", action), zap.Error(err))
+		auditLogWriteErrors.Inc()
+		return
+	}
+	a.seq = entry.Seq
+	a.lastHash = entry.Hash
+}
+
+// RecordConfig records the configuration the relayer starts with, listing the fields that
+// changed since the last recorded start. Secrets are redacted.
+func (a *AuditLog) RecordConfig(config Config) {
+	if a == nil {
+		return
+	}
+	current := redactedConfig(config)
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		a.logger.Error("Failed to encode configuration for the audit log", zap.Error(err))
+		return
+	}
+	// Compare through JSON, as the previous configuration was read back from it
+	var normalized map[string]any
+	_ = json.Unmarshal(encoded, &normalized)
+
+	a.mu.Lock()
+	previous := a.lastConfig
+	a.lastConfig = normalized
+	a.mu.Unlock()
+
+	details := map[string]string{"config": string(encoded)}
+	if previous == nil {
+		a.Record(AuditConfigLoaded, details)
+		return
+	}
+	var changed []string
+	for name, value := range normalized {
+		old, _ := json.Marshal(previous[name])
+		now, _ := json.Marshal(value)
+		if string(old) != string(now) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := normalized[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) == 0 {
+		a.Record(AuditConfigLoaded, details)
+		return
+	}
+	sort.Strings(changed)
+	encodedChanged, _ := json.Marshal(changed)
+	details["changed"] = string(encodedChanged)
+	a.Record(AuditConfigChanged, details)
+}
+
+// snapshot opens the log for reading, limited to the entries written so far, so readers don't
+// hold up writers or see a partial entry
+func (a *AuditLog) snapshot() (*os.File, io.Reader, error) {
+	a.mu.Lock()
+	info, err := a.file.Stat()
+	a.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, io.LimitReader(f, info.Size()), nil
+}
+
+// Export copies the whole log to w, as JSON lines
+func (a *AuditLog) Export(w io.Writer) error {
+	f, in, err := a.snapshot()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// Verify checks the hash chain of the whole log
+func (a *AuditLog) Verify() (AuditVerification, error) {
+	f, in, err := a.snapshot()
+	if err != nil {
+		return AuditVerification{}, err
+	}
+	defer f.Close()
+	return readAuditLog(in, nil)
+}
+
+// Close closes the audit log file
+func (a *AuditLog) Close() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.file.Close()
+}
+
+// recordTxSigned audits a signed transaction
+func (a *AuditLog) recordTxSigned(ctx context.Context, chainID uint64, signer, to, txHash string, nonce uint64, description string) {
+	a.Record(AuditTxSigned, map[string]string{
+		"chainId":       fmt.Sprint(chainID),
+		"signer":        signer,
+		"to":            to,
+		"nonce":         fmt.Sprint(nonce),
+		"txHash":        txHash,
+		"description":   description,
+		"correlationId": correlationIDFrom(ctx),
+	})
+}
+
+// runVerifyAuditLog checks the hash chain of an audit log file
+func runVerifyAuditLog(ctx context.Context, config Config, args []string) int {
+	path := config.AuditLogFile
+	if len(args) == 1 {
+		path = args[0]
+	}
+	if len(args) > 1 || path == "" {
+		fmt.Fprintln(os.Stderr, "Usage: relayer verify-audit-log [file]   (defaults to AUDIT_LOG_FILE)")
+		return 2
+	}
+
+	result, err := readAuditLogFile(path, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read audit log: %v\n", err)
+		return 2
+	}
+	if !result.Intact {
+		fmt.Fprintf(os.Stderr, "audit log is broken at line %d: %s\n", result.BrokenAt, result.Error)
+		return 1
+	}
+	fmt.Printf("Audit log intact: %d entries, last hash %s\n", result.Entries, result.LastHash)
+	return 0
+}
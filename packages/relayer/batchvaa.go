@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+	"go.uber.org/zap"
+)
+
+// VAA version of a batch VAA, whose guardians sign the hashes of several observations at once
+const batchVAAVersion uint8 = 2
+
+// BatchVAA is a decoded batch (v2) VAA. Layout, big-endian:
+//
+//	[version(1), guardianSetIndex(4), len(signatures)(1), signatures(66 each),
+//	 len(hashes)(1), hashes(32 each), len(observations)(1),
+//	 observations(index(1), len(4), body(len) each)]
+//
+// Each observation body is a v1 VAA body and its hash is the body's signing digest.
+type BatchVAA struct {
+	GuardianSetIndex uint32
+	Signatures       []*vaaLib.Signature
+	Hashes           []common.Hash
+	Observations     []*vaaLib.Observation
+}
+
+// isBatchVAA reports whether vaaBytes carries the batch VAA version
+func isBatchVAA(vaaBytes []byte) bool {
+	return len(vaaBytes) > 0 && vaaBytes[0] == batchVAAVersion
+}
+
+// parseBatchVAA decodes a batch VAA and checks every observation against the hash it is
+// signed under
+func parseBatchVAA(data []byte) (*BatchVAA, error) {
+	reader := bytes.NewReader(data)
+	var version uint8
+	if err := binary.Read(reader, binary.BigEndian, &version); err != nil || version != batchVAAVersion {
+		return nil, fmt.Errorf("not a batch VAA")
+	}
+
+	batch := &BatchVAA{}
+	if err := binary.Read(reader, binary.BigEndian, &batch.GuardianSetIndex); err != nil {
+		return nil, fmt.Errorf("failed to read guardian set index: %v", err)
+	}
+
+	var count uint8
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read signature count: %v", err)
+	}
+	for i := 0; i < int(count); i++ {
+		sig := &vaaLib.Signature{}
+		if err := binary.Read(reader, binary.BigEndian, &sig.Index); err != nil {
+			return nil, fmt.Errorf("failed to read signature %d: %v", i, err)
+		}
+		if n, err := reader.Read(sig.Signature[:]); err != nil || n != len(sig.Signature) {
+			return nil, fmt.Errorf("failed to read signature %d: truncated", i)
+		}
+		batch.Signatures = append(batch.Signatures, sig)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read hash count: %v", err)
+	}
+	for i := 0; i < int(count); i++ {
+		var hash common.Hash
+		if n, err := reader.Read(hash[:]); err != nil || n != len(hash) {
+			return nil, fmt.Errorf("failed to read hash %d: truncated", i)
+		}
+		batch.Hashes = append(batch.Hashes, hash)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read observation count: %v", err)
+	}
+	for i := 0; i < int(count); i++ {
+		var index uint8
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &index); err != nil {
+			return nil, fmt.Errorf("failed to read observation %d: %v", i, err)
+		}
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return nil, fmt.Errorf("failed to read observation %d length: %v", i, err)
+		}
+		if int64(length) > int64(reader.Len()) {
+			return nil, fmt.Errorf("observation %d length %d exceeds remaining %d bytes", i, length, reader.Len())
+		}
+		body := make([]byte, length)
+		if _, err := reader.Read(body); err != nil {
+			return nil, fmt.Errorf("failed to read observation %d: %v", i, err)
+		}
+
+		if int(index) >= len(batch.Hashes) {
+			return nil, fmt.Errorf("observation %d has index %d but the batch has %d hashes", i, index, len(batch.Hashes))
+		}
+		if digest := vaaLib.DeprecatedSigningDigest(body); digest != batch.Hashes[index] {
+			return nil, fmt.Errorf("observation %d does not match hash %d", i, index)
+		}
+
+		observation, err := vaaLib.UnmarshalBody(body, bytes.NewReader(body), &vaaLib.VAA{
+			Version:          vaaLib.SupportedVAAVersion,
+			GuardianSetIndex: batch.GuardianSetIndex,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode observation %d: %v", i, err)
+		}
+		batch.Observations = append(batch.Observations, &vaaLib.Observation{Index: index, Observation: observation})
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after observations", reader.Len())
+	}
+	return batch, nil
+}
+
+// verifySignatures checks the batch's guardian signatures over its hashes against keys
+func (b *BatchVAA) verifySignatures(keys []common.Address) error {
+	if len(b.Signatures) < vaaLib.CalculateQuorum(len(keys)) {
+		return fmt.Errorf("batch did not have a quorum")
+	}
+	var body []byte
+	for _, hash := range b.Hashes {
+		body = append(body, hash.Bytes()...)
+	}
+	if !vaaLib.DeprecatedVerifySignatures(body, b.Signatures, keys) {
+		return fmt.Errorf("batch had bad signatures")
+	}
+	return nil
+}
+
+// processBatchVAA unpacks a batch VAA and filters each observation against the source chain
+// and emitter registry on its own. Destination modules only verify v1 VAAs and the batch
+// signatures cover the hash list, not an observation on its own, so each relevant
+// observation is relayed through its individually signed VAA, fetched from the guardian API.
+// Without an API the observation is left to the stream, which carries that VAA too.
+func (r *Relayer) processBatchVAA(ctx context.Context, vaaBytes []byte) error {
+	log := correlatedLogger(ctx, r.logger)
+
+	batch, err := parseBatchVAA(vaaBytes)
+	if err != nil {
+		log.Error("Failed to parse batch VAA", zap.Error(err))
+		incWithExemplar(pipelineErrors.WithLabelValues(string(ErrorKindDecode)), correlationIDFrom(ctx))
+		return pipelineError(ErrorKindDecode, err)
+	}
+
+	if current := r.guardians.Current(); current != nil && current.Index == batch.GuardianSetIndex {
+		if err := batch.verifySignatures(current.Keys); err != nil {
+			log.Warn("Dropping batch VAA with invalid guardian signatures",
+				zap.Uint32("guardianSet", batch.GuardianSetIndex),
+				zap.Error(err))
+			batchObservations.WithLabelValues("invalid").Add(float64(len(batch.Observations)))
+			return nil
+		}
+	}
+
+	relevant := 0
+	for _, obs := range batch.Observations {
+		vaa := obs.Observation
+		emitterHex := vaa.EmitterAddress.String()
+		if uint16(vaa.EmitterChain) != r.config.SourceChainID ||
+			(!r.config.AcceptAnyEmitter && !r.isRegisteredEmitter(emitterHex)) {
+			batchObservations.WithLabelValues("skipped").Inc()
+			continue
+		}
+
+		relevant++
+		batchObservations.WithLabelValues("relevant").Inc()
+		r.relayBatchObservation(ctx, batch.Hashes[obs.Index], vaa, emitterHex)
+	}
+
+	log.Debug("Processed batch VAA",
+		zap.Uint32("guardianSet", batch.GuardianSetIndex),
+		zap.Int("observations", len(batch.Observations)),
+		zap.Int("relevant", relevant))
+	return nil
+}
+
+// relayBatchObservation fetches the individually signed VAA of a relevant batch observation
+// and hands it to the normal relay path, unless it is already handled or in flight
+func (r *Relayer) relayBatchObservation(ctx context.Context, digest common.Hash, observation *vaaLib.VAA, emitterHex string) {
+	log := correlatedLogger(ctx, r.logger).With(
+		zap.Uint64("sequence", observation.Sequence),
+		zap.String("emitter", emitterHex),
+		zap.String("digest", digest.Hex()))
+
+	if r.guardianAPI == nil {
+		log.Info("Batch contains a recovery observation, waiting for its individual VAA on the stream")
+		return
+	}
+	vaaBytes, err := r.guardianAPI.SignedVAA(ctx, uint16(observation.EmitterChain), emitterHex, observation.Sequence)
+	if err != nil {
+		log.Warn("Failed to fetch the individual VAA of a batch observation, waiting for it on the stream", zap.Error(err))
+		batchObservations.WithLabelValues("unavailable").Inc()
+		return
+	}
+	parsed, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil || parsed.SigningDigest() != digest {
+		log.Warn("Guardian API returned a different VAA for a batch observation, waiting for it on the stream")
+		batchObservations.WithLabelValues("unavailable").Inc()
+		return
+	}
+
+	key := r.dedupeKey(vaaBytes)
+	if !r.beginProcessingVAA(key) {
+		log.Debug("Individual VAA of a batch observation is already handled", zap.String("vaaHash", key))
+		return
+	}
+	log.Info("Relaying the individual VAA of a batch observation", zap.String("vaaHash", key))
+	batchObservations.WithLabelValues("relayed").Inc()
+	r.handleVAA(ctx, vaaBytes, key, correlationIDFrom(ctx))
+}
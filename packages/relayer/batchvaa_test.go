@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+	"go.uber.org/zap"
+)
+
+// encodeBatchVAA packs observations into a batch VAA signed by keys[i] under each index
+func encodeBatchVAA(t *testing.T, setIndex uint32, observations []*vaaLib.VAA, keys []*ecdsa.PrivateKey, indexes ...int) []byte {
+	t.Helper()
+	var hashes, bodies [][]byte
+	for _, v := range observations {
+		marshalled, err := v.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := marshalled[6+len(v.Signatures)*66:]
+		bodies = append(bodies, body)
+		hashes = append(hashes, v.SigningDigest().Bytes())
+	}
+	digest := vaaLib.DeprecatedSigningDigest(bytes.Join(hashes, nil))
+
+	var buf bytes.Buffer
+	buf.WriteByte(batchVAAVersion)
+	binary.Write(&buf, binary.BigEndian, setIndex)
+	buf.WriteByte(uint8(len(indexes)))
+	for _, i := range indexes {
+		signature, err := crypto.Sign(digest.Bytes(), keys[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.WriteByte(uint8(i))
+		buf.Write(signature)
+	}
+	buf.WriteByte(uint8(len(hashes)))
+	for _, hash := range hashes {
+		buf.Write(hash)
+	}
+	buf.WriteByte(uint8(len(bodies)))
+	for i, body := range bodies {
+		buf.WriteByte(uint8(i))
+		binary.Write(&buf, binary.BigEndian, uint32(len(body)))
+		buf.Write(body)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessBatchVAARelaysRelevantObservations(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5) // Quorum is 4
+
+	recovery := newTestVAA(1, 7)
+	other := newTestVAA(1, 8)
+	other.EmitterChain = vaaLib.ChainIDEthereum
+	batch := encodeBatchVAA(t, 1, []*vaaLib.VAA{recovery, other}, keys, 0, 1, 2, 3)
+
+	signed, err := signTestVAA(newTestVAA(1, 7), keys, 0, 1, 2, 3).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var requested []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requested = append(requested, req.URL.Path)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"vaaBytes": base64.StdEncoding.EncodeToString(signed)})
+	}))
+	defer api.Close()
+
+	var relayed []*VAAData
+	r := &Relayer{
+		config:        Config{SourceChainID: 56, AcceptAnyEmitter: true},
+		logger:        zap.NewNop(),
+		store:         NewMemoryStore(),
+		inflightVAAs:  make(map[string]struct{}),
+		processedVAAs: newDedupeCache(time.Hour, 0),
+		dedupePolicy:  dedupePolicies[DedupePolicyBytes],
+		checkpoints:   make(map[string]Checkpoint),
+		retries:       make(map[string]RetryEntry),
+		guardianAPI:   &GuardianAPIClient{url: api.URL, httpClient: api.Client(), logger: zap.NewNop()},
+		vaaProcessor: func(_ context.Context, _ *Relayer, vaaData *VAAData) error {
+			relayed = append(relayed, vaaData)
+			return nil
+		},
+	}
+	r.active.Store(true)
+	r.guardians.put(&GuardianSet{Index: 1, Keys: addresses}, true)
+
+	if err := r.processVAA(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	wantPath := "/v1/signed_vaa/56/" + recovery.EmitterAddress.String() + "/7"
+	if len(requested) != 1 || requested[0] != wantPath {
+		t.Fatalf("requested %v, want only %s", requested, wantPath)
+	}
+	if len(relayed) != 1 || relayed[0].Sequence != 7 || relayed[0].ChainID != 56 {
+		t.Fatalf("relayed %d VAAs, want only the recovery observation's", len(relayed))
+	}
+	if !r.processedVAAs.contains(r.dedupeKey(signed)) {
+		t.Fatal("relayed VAA was not marked processed")
+	}
+
+	// The same batch again finds the observation already handled
+	if err := r.processVAA(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(relayed) != 1 {
+		t.Fatalf("relayed %d VAAs after a repeated batch, want 1", len(relayed))
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// Ways a destination's verify calls are submitted
+const (
+	SubmitterEOA      = "eoa"      // Transactions from the relayer's signer pool
+	SubmitterBiconomy = "biconomy" // Sponsored user operations through a Biconomy bundler and paymaster
+)
+
+// ERC-4337 v0.6 EntryPoint, which Biconomy smart accounts v2 are deployed against
+var entryPointV06 = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+// userOpPollInterval is how often a sent user operation's receipt is polled for
+const userOpPollInterval = 2 * time.Second
+
+// biconomyDummySignature is a well-formed ECDSA signature gas is estimated with before the
+// user operation is final and can be signed
+var biconomyDummySignature = common.FromHex("0x73c3ac716c487ca34bb858247b5ccf1dc354fbaabdd089af3b2ac8e78ba85a4959a2d76250325bd67c11771c31fccda87c33ceec17cc0de912690521bb95ffcb1b")
+
+// biconomyABI covers the smart account's execute call and the EntryPoint's nonce query
+const biconomyABI = `[{
+    "inputs": [
+        {"name": "dest", "type": "address"},
+        {"name": "value", "type": "uint256"},
+        {"name": "func", "type": "bytes"}
+    ],
+    "name": "execute",
+    "outputs": [],
+    "stateMutability": "nonpayable",
+    "type": "function"
+}, {
+    "inputs": [
+        {"name": "sender", "type": "address"},
+        {"name": "key", "type": "uint192"}
+    ],
+    "name": "getNonce",
+    "outputs": [{"name": "nonce", "type": "uint256"}],
+    "stateMutability": "view",
+    "type": "function"
+}]`
+
+// biconomySubmitter sends verify calls as user operations from a Biconomy smart account,
+// with gas sponsored by a Biconomy paymaster, so the destination needs no funded EOA. The
+// bundle transaction a user operation lands in goes through the usual receipt checks.
+type biconomySubmitter struct {
+	client    *EVMClient // Destination RPC, for the account nonce and fees
+	bundler   *rpc.Client
+	paymaster *rpc.Client
+	account   common.Address    // Smart account the user operations are sent from
+	owner     *ecdsa.PrivateKey // Key the smart account's ECDSA ownership module accepts
+	module    common.Address    // ECDSA ownership module validating the signature
+	parsedABI abi.ABI
+	audit     *AuditLog
+	logger    *zap.Logger
+}
+
+// userOperation is an ERC-4337 v0.6 user operation
+type userOperation struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+	Signature            []byte
+}
+
+// MarshalJSON encodes the operation the way bundlers expect it, every field hex
+func (op *userOperation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"sender":               op.Sender,
+		"nonce":                (*hexutil.Big)(op.Nonce),
+		"initCode":             hexutil.Bytes(op.InitCode),
+		"callData":             hexutil.Bytes(op.CallData),
+		"callGasLimit":         (*hexutil.Big)(op.CallGasLimit),
+		"verificationGasLimit": (*hexutil.Big)(op.VerificationGasLimit),
+		"preVerificationGas":   (*hexutil.Big)(op.PreVerificationGas),
+		"maxFeePerGas":         (*hexutil.Big)(op.MaxFeePerGas),
+		"maxPriorityFeePerGas": (*hexutil.Big)(op.MaxPriorityFeePerGas),
+		"paymasterAndData":     hexutil.Bytes(op.PaymasterAndData),
+		"signature":            hexutil.Bytes(op.Signature),
+	})
+}
+
+// hash returns the user operation hash the EntryPoint computes, which the owner signs
+func (op *userOperation) hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	word := func(n *big.Int) []byte { return common.LeftPadBytes(n.Bytes(), 32) }
+	packed := crypto.Keccak256(
+		common.LeftPadBytes(op.Sender.Bytes(), 32),
+		word(op.Nonce),
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		word(op.CallGasLimit),
+		word(op.VerificationGasLimit),
+		word(op.PreVerificationGas),
+		word(op.MaxFeePerGas),
+		word(op.MaxPriorityFeePerGas),
+		crypto.Keccak256(op.PaymasterAndData),
+	)
+	return crypto.Keccak256Hash(packed, common.LeftPadBytes(entryPoint.Bytes(), 32), word(chainID))
+}
+
+// gasValue decodes a gas amount sent as a JSON number or a hex or decimal string
+type gasValue big.Int
+
+func (g *gasValue) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	value, ok := new(big.Int).SetString(text, 0)
+	if !ok {
+		return fmt.Errorf("invalid gas value %s", data)
+	}
+	*g = gasValue(*value)
+	return nil
+}
+
+func (g *gasValue) int() *big.Int {
+	return (*big.Int)(g)
+}
+
+// newBiconomySubmitter connects to a destination's bundler and paymaster, or returns nil when
+// the destination submits from the signer pool
+func newBiconomySubmitter(config Config, dest DestinationConfig, client *EVMClient, proxy proxyFunc) (*biconomySubmitter, error) {
+	switch strings.ToLower(dest.Submitter) {
+	case "", SubmitterEOA:
+		return nil, nil
+	case SubmitterBiconomy:
+	default:
+		return nil, fmt.Errorf("unknown submitter %q (want %s or %s)", dest.Submitter, SubmitterEOA, SubmitterBiconomy)
+	}
+	if dest.BundlerURL == "" || dest.PaymasterURL == "" || !common.IsHexAddress(dest.SmartAccount) {
+		return nil, fmt.Errorf("the %s submitter needs a bundler URL, a paymaster URL and a smart account address", SubmitterBiconomy)
+	}
+	if !common.IsHexAddress(config.BiconomyValidationModule) {
+		return nil, fmt.Errorf("invalid BICONOMY_VALIDATION_MODULE %q", config.BiconomyValidationModule)
+	}
+	ownerHex := config.BiconomyOwnerKey
+	if ownerHex == "" {
+		keys := config.signingKeys()
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("the %s submitter needs BICONOMY_OWNER_KEY or a local signing key", SubmitterBiconomy)
+		}
+		ownerHex = keys[0]
+	}
+	owner, err := crypto.HexToECDSA(strings.TrimPrefix(ownerHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Biconomy owner key: %v", err)
+	}
+	parsedABI, err := abi.JSON(strings.NewReader(biconomyABI))
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bundler, err := rpc.DialOptions(ctx, dest.BundlerURL, rpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Biconomy bundler: %v", err)
+	}
+	paymaster, err := rpc.DialOptions(ctx, dest.PaymasterURL, rpc.WithHTTPClient(httpClient))
+	if err != nil {
+		bundler.Close()
+		return nil, fmt.Errorf("failed to connect to Biconomy paymaster: %v", err)
+	}
+
+	s := &biconomySubmitter{
+		client:    client,
+		bundler:   bundler,
+		paymaster: paymaster,
+		account:   common.HexToAddress(dest.SmartAccount),
+		owner:     owner,
+		module:    common.HexToAddress(config.BiconomyValidationModule),
+		parsedABI: parsedABI,
+		logger: logger.With(
+			zap.String("component", "BiconomySubmitter"),
+			zap.String("destination", dest.Name),
+			zap.String("smartAccount", dest.SmartAccount)),
+	}
+	s.logger.Info("Verify calls will be sponsored through Biconomy",
+		zap.String("owner", crypto.PubkeyToAddress(owner.PublicKey).Hex()))
+	return s, nil
+}
+
+// errUserOpNotSent marks a submission that failed before the bundler accepted the user
+// operation, so nothing was sponsored
+var errUserOpNotSent = errors.New("user operation not sent")
+
+// submit sends a call to target as a sponsored user operation and waits for it to be bundled,
+// returning the bundle transaction's hash. A user operation that was included but whose call
+// reverted fails as a revert.
+func (s *biconomySubmitter) submit(ctx context.Context, target common.Address, data []byte, strategy FeeStrategy, description string) (string, error) {
+	log := correlatedLogger(ctx, s.logger)
+
+	op, chainID, err := s.signedOperation(ctx, target, data, strategy)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errUserOpNotSent, err)
+	}
+	var sentHash common.Hash
+	if err := s.bundler.CallContext(ctx, &sentHash, "eth_sendUserOperation", op, entryPointV06); err != nil {
+		return "", fmt.Errorf("%w: bundler rejected user operation: %v", errUserOpNotSent, err)
+	}
+	s.audit.recordTxSigned(ctx, chainID.Uint64(), crypto.PubkeyToAddress(s.owner.PublicKey).Hex(),
+		target.Hex(), sentHash.Hex(), op.Nonce.Uint64(), description+" (sponsored user operation)")
+	log.Info("Sent sponsored user operation",
+		zap.String("userOpHash", sentHash.Hex()),
+		zap.String("target", target.Hex()),
+		zap.String("vaa", description))
+
+	return s.waitForBundle(ctx, sentHash)
+}
+
+// signedOperation builds the user operation calling target, has the paymaster sponsor it and
+// signs it with the owner key
+func (s *biconomySubmitter) signedOperation(ctx context.Context, target common.Address, data []byte, strategy FeeStrategy) (*userOperation, *big.Int, error) {
+	callData, err := s.parsedABI.Pack("execute", target, big.NewInt(0), data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ABI pack error: %v", err)
+	}
+	// A random nonce key per operation, so concurrent relays don't race on one sequence
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	nonce, err := s.nonce(ctx, new(big.Int).SetBytes(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	params, err := s.client.fetchTxParams(ctx, s.account, strategy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fees: %v", err)
+	}
+
+	op := &userOperation{
+		Sender:               s.account,
+		Nonce:                nonce,
+		CallData:             callData,
+		CallGasLimit:         big.NewInt(0),
+		VerificationGasLimit: big.NewInt(0),
+		PreVerificationGas:   big.NewInt(0),
+		MaxFeePerGas:         params.feeCap,
+		MaxPriorityFeePerGas: params.tip,
+	}
+	if op.Signature, err = s.wrapSignature(biconomyDummySignature); err != nil {
+		return nil, nil, err
+	}
+	if err := s.sponsor(ctx, op); err != nil {
+		return nil, nil, err
+	}
+
+	opHash := op.hash(entryPointV06, params.chain.chainID)
+	sig, err := crypto.Sign(accounts.TextHash(opHash.Bytes()), s.owner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign user operation: %v", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	if op.Signature, err = s.wrapSignature(sig); err != nil {
+		return nil, nil, err
+	}
+	return op, params.chain.chainID, nil
+}
+
+// nonce reads the smart account's next nonce under key from the EntryPoint
+func (s *biconomySubmitter) nonce(ctx context.Context, key *big.Int) (*big.Int, error) {
+	input, err := s.parsedABI.Pack("getNonce", s.account, key)
+	if err != nil {
+		return nil, fmt.Errorf("ABI pack error: %v", err)
+	}
+	output, err := s.client.client.CallContract(ctx, ethereum.CallMsg{To: &entryPointV06, Data: input}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read smart account nonce: %v", err)
+	}
+	values, err := s.parsedABI.Unpack("getNonce", output)
+	if err != nil || len(values) != 1 {
+		return nil, fmt.Errorf("unexpected getNonce result %x", output)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// sponsor has the paymaster sponsor the operation, filling in its gas limits and paymaster data
+func (s *biconomySubmitter) sponsor(ctx context.Context, op *userOperation) error {
+	var result struct {
+		PaymasterAndData     hexutil.Bytes `json:"paymasterAndData"`
+		CallGasLimit         *gasValue     `json:"callGasLimit"`
+		VerificationGasLimit *gasValue     `json:"verificationGasLimit"`
+		PreVerificationGas   *gasValue     `json:"preVerificationGas"`
+	}
+	options := map[string]any{
+		"mode":               "SPONSORED",
+		"calculateGasLimits": true,
+		"sponsorshipInfo": map[string]any{
+			"webhookData":      map[string]any{},
+			"smartAccountInfo": map[string]string{"name": "BICONOMY", "version": "2.0.0"},
+		},
+	}
+	if err := s.paymaster.CallContext(ctx, &result, "pm_sponsorUserOperation", op, options); err != nil {
+		return fmt.Errorf("paymaster refused to sponsor user operation: %v", err)
+	}
+	if len(result.PaymasterAndData) == 0 || result.CallGasLimit == nil || result.VerificationGasLimit == nil || result.PreVerificationGas == nil {
+		return fmt.Errorf("paymaster returned an incomplete sponsorship")
+	}
+	op.PaymasterAndData = result.PaymasterAndData
+	op.CallGasLimit = result.CallGasLimit.int()
+	op.VerificationGasLimit = result.VerificationGasLimit.int()
+	op.PreVerificationGas = result.PreVerificationGas.int()
+	return nil
+}
+
+// wrapSignature encodes an ECDSA signature with the validation module that checks it, as
+// Biconomy smart accounts v2 expect
+func (s *biconomySubmitter) wrapSignature(sig []byte) ([]byte, error) {
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	addressType, _ := abi.NewType("address", "", nil)
+	return abi.Arguments{{Type: bytesType}, {Type: addressType}}.Pack(sig, s.module)
+}
+
+// waitForBundle polls for the user operation's receipt until it is included or ctx expires
+func (s *biconomySubmitter) waitForBundle(ctx context.Context, opHash common.Hash) (string, error) {
+	ticker := time.NewTicker(userOpPollInterval)
+	defer ticker.Stop()
+	for {
+		var receipt *struct {
+			Success bool   `json:"success"`
+			Reason  string `json:"reason"`
+			Receipt struct {
+				TransactionHash common.Hash `json:"transactionHash"`
+			} `json:"receipt"`
+		}
+		err := s.bundler.CallContext(ctx, &receipt, "eth_getUserOperationReceipt", opHash)
+		switch {
+		case err != nil:
+			correlatedLogger(ctx, s.logger).Debug("Failed to poll user operation receipt", zap.Error(err))
+		case receipt != nil && !receipt.Success:
+			return "", pipelineError(ErrorKindReverted, fmt.Errorf("user operation %s in transaction %s reverted: %s",
+				opHash.Hex(), receipt.Receipt.TransactionHash.Hex(), receipt.Reason))
+		case receipt != nil:
+			return receipt.Receipt.TransactionHash.Hex(), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("user operation %s not bundled: %v", opHash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
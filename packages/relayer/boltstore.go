@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt bucket names
+var (
+	processedBucket   = []byte("processed")
+	emittersBucket    = []byte("emitters")
+	checkpointsBucket = []byte("checkpoints")
+	retriesBucket     = []byte("retries")
+	inflightBucket    = []byte("inflight")
+	safeCostsBucket   = []byte("safeCosts")
+	relaysBucket      = []byte("relays")
+	leaseBucket       = []byte("lease")
+	cursorsBucket     = []byte("scanCursors")
+	overridesBucket   = []byte("emitterOverrides")
+)
+
+// Key of the lease record in leaseBucket
+var leaseKey = []byte("lease")
+
+// BoltStore persists relayer state in a local BoltDB file
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens (creating if needed) the state database at path
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{processedBucket, emittersBucket, checkpointsBucket, retriesBucket, inflightBucket, safeCostsBucket, relaysBucket, leaseBucket, cursorsBucket, overridesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize state database: %v", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close closes the database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// MarkProcessed records a handled VAA
+func (s *BoltStore) MarkProcessed(key string, at time.Time) error {
+	value, err := at.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(processedBucket).Put([]byte(key), value)
+	})
+}
+
+// LoadProcessed returns dedupe entries recorded at or after since
+func (s *BoltStore) LoadProcessed(since time.Time) (map[string]time.Time, error) {
+	processed := make(map[string]time.Time)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(processedBucket).ForEach(func(key, value []byte) error {
+			var at time.Time
+			if err := at.UnmarshalBinary(value); err != nil {
+				return fmt.Errorf("corrupt dedupe entry %s: %v", key, err)
+			}
+			if !at.Before(since) {
+				processed[string(key)] = at
+			}
+			return nil
+		})
+	})
+	return processed, err
+}
+
+// PruneProcessed deletes dedupe entries recorded before the given time
+func (s *BoltStore) PruneProcessed(before time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(processedBucket).Cursor()
+		for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+			var at time.Time
+			if err := at.UnmarshalBinary(value); err != nil || at.Before(before) {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// emitterKey identifies a Safe's registration with a tenant's module
+func emitterKey(tenant string, safe common.Address) []byte {
+	return append([]byte(tenant+"/"), safe.Bytes()...)
+}
+
+// SaveEmitter records the Aztec contract registered by a Safe with a tenant's module
+func (s *BoltStore) SaveEmitter(tenant string, safe common.Address, aztecContract string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(emittersBucket).Put(emitterKey(tenant, safe), []byte(aztecContract))
+	})
+}
+
+// RemoveEmitter deletes the Safe's registration with the tenant's module
+func (s *BoltStore) RemoveEmitter(tenant string, safe common.Address) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(emittersBucket).Delete(emitterKey(tenant, safe))
+	})
+}
+
+// LoadEmitters returns the Aztec contract registered by each Safe with the tenant's module
+func (s *BoltStore) LoadEmitters(tenant string) (map[common.Address]string, error) {
+	emitters := make(map[common.Address]string)
+	prefix := []byte(tenant + "/")
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(emittersBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			if len(key) != len(prefix)+common.AddressLength {
+				continue
+			}
+			emitters[common.BytesToAddress(key[len(prefix):])] = string(value)
+		}
+		return nil
+	})
+	return emitters, err
+}
+
+// SaveEmitterOverride inserts or replaces an operator's override of a tenant's registry
+func (s *BoltStore) SaveEmitterOverride(override EmitterOverride) error {
+	value, err := json.Marshal(override)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(overridesBucket).Put([]byte(override.Tenant+"/"+override.Emitter), value)
+	})
+}
+
+// RemoveEmitterOverride deletes the override of the emitter in the tenant's registry
+func (s *BoltStore) RemoveEmitterOverride(tenant, emitter string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(overridesBucket).Delete([]byte(tenant + "/" + emitter))
+	})
+}
+
+// LoadEmitterOverrides returns the overrides of the tenant's registry
+func (s *BoltStore) LoadEmitterOverrides(tenant string) ([]EmitterOverride, error) {
+	var overrides []EmitterOverride
+	prefix := []byte(tenant + "/")
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(overridesBucket).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var override EmitterOverride
+			if err := json.Unmarshal(value, &override); err != nil {
+				return fmt.Errorf("corrupt emitter override %s: %v", key, err)
+			}
+			if override.Tenant == tenant {
+				overrides = append(overrides, override)
+			}
+		}
+		return nil
+	})
+	return overrides, err
+}
+
+// SaveScanCursor records the last block whose registry events were applied for the tenant
+func (s *BoltStore) SaveScanCursor(tenant string, block uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cursorsBucket).Put([]byte(tenant), binary.BigEndian.AppendUint64(nil, block))
+	})
+}
+
+// LoadScanCursor returns the tenant's scan cursor, and false when none was saved
+func (s *BoltStore) LoadScanCursor(tenant string) (uint64, bool, error) {
+	var block uint64
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(cursorsBucket).Get([]byte(tenant))
+		if len(value) == 8 {
+			block, ok = binary.BigEndian.Uint64(value), true
+		}
+		return nil
+	})
+	return block, ok, err
+}
+
+// SaveCheckpoint stores cp unless a higher sequence is already recorded for the emitter
+func (s *BoltStore) SaveCheckpoint(cp Checkpoint) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(checkpointsBucket)
+		key := []byte(checkpointKey(cp.EmitterChain, cp.EmitterAddress))
+
+		if existing := bucket.Get(key); existing != nil {
+			var current Checkpoint
+			if err := json.Unmarshal(existing, &current); err == nil && current.Sequence >= cp.Sequence {
+				return nil
+			}
+		}
+
+		value, err := json.Marshal(cp)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+// LoadCheckpoints returns all stored checkpoints keyed by checkpointKey
+func (s *BoltStore) LoadCheckpoints() (map[string]Checkpoint, error) {
+	checkpoints := make(map[string]Checkpoint)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(checkpointsBucket).ForEach(func(key, value []byte) error {
+			var cp Checkpoint
+			if err := json.Unmarshal(value, &cp); err != nil {
+				return fmt.Errorf("corrupt checkpoint %s: %v", key, err)
+			}
+			checkpoints[string(key)] = cp
+			return nil
+		})
+	})
+	return checkpoints, err
+}
+
+// SaveRetry inserts or replaces a retry queue entry
+func (s *BoltStore) SaveRetry(entry RetryEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(retriesBucket).Put([]byte(entry.Key), value)
+	})
+}
+
+// RemoveRetry deletes the retry queue entry with the dedupe key
+func (s *BoltStore) RemoveRetry(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(retriesBucket).Delete([]byte(key))
+	})
+}
+
+// LoadRetries returns every queued retry
+func (s *BoltStore) LoadRetries() ([]RetryEntry, error) {
+	var entries []RetryEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(retriesBucket).ForEach(func(key, value []byte) error {
+			var entry RetryEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("corrupt retry entry %s: %v", key, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// SaveInflight records a VAA that has entered processing
+func (s *BoltStore) SaveInflight(entry InflightEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(inflightBucket).Put([]byte(entry.Key), value)
+	})
+}
+
+// RemoveInflight deletes the inflight record with the dedupe key
+func (s *BoltStore) RemoveInflight(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(inflightBucket).Delete([]byte(key))
+	})
+}
+
+// LoadInflight returns every inflight record
+func (s *BoltStore) LoadInflight() ([]InflightEntry, error) {
+	var entries []InflightEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(inflightBucket).ForEach(func(key, value []byte) error {
+			var entry InflightEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("corrupt inflight entry %s: %v", key, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// ClaimLease renews or takes over the lease for holder in a single transaction
+func (s *BoltStore) ClaimLease(holder string, ttl time.Duration) (Lease, error) {
+	var lease Lease
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(leaseBucket)
+		if value := bucket.Get(leaseKey); value != nil {
+			if err := json.Unmarshal(value, &lease); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		if lease.Holder != "" && lease.Holder != holder && now.Sub(lease.RenewedAt) <= ttl {
+			return nil
+		}
+		lease = Lease{Holder: holder, RenewedAt: now}
+		value, err := json.Marshal(lease)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(leaseKey, value)
+	})
+	return lease, err
+}
+
+// AddSafeCost adds a confirmed relay to the Safe's daily gas totals on its chain
+func (s *BoltStore) AddSafeCost(cost SafeCost) error {
+	cost.Day = safeCostDay(cost.Day)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(safeCostsBucket)
+		key := []byte(safeCostKey(cost.Safe, cost.ChainID, cost.Day))
+
+		total := SafeCost{Safe: cost.Safe, ChainID: cost.ChainID, Day: cost.Day}
+		if existing := bucket.Get(key); existing != nil {
+			if err := json.Unmarshal(existing, &total); err != nil {
+				return fmt.Errorf("corrupt Safe cost %s: %v", key, err)
+			}
+		}
+		addSafeCost(&total, cost)
+
+		value, err := json.Marshal(total)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+// SafeCosts returns daily totals for one Safe, or all Safes when safe is nil
+func (s *BoltStore) SafeCosts(safe *common.Address, from, to time.Time) ([]SafeCost, error) {
+	var costs []SafeCost
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(safeCostsBucket).ForEach(func(key, value []byte) error {
+			var cost SafeCost
+			if err := json.Unmarshal(value, &cost); err != nil {
+				return fmt.Errorf("corrupt Safe cost %s: %v", key, err)
+			}
+			if safeCostMatches(cost, safe, from, to) {
+				costs = append(costs, cost)
+			}
+			return nil
+		})
+	})
+	sortSafeCosts(costs)
+	return costs, err
+}
+
+// AddRelayRecord stores the gas and fee of a confirmed relay transaction, keyed by its hash
+func (s *BoltStore) AddRelayRecord(record RelayRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(relaysBucket).Put(record.TxHash.Bytes(), value)
+	})
+}
+
+// RelayRecords returns the transactions confirmed between from and to, oldest first
+func (s *BoltStore) RelayRecords(safe *common.Address, from, to time.Time) ([]RelayRecord, error) {
+	var records []RelayRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(relaysBucket).ForEach(func(key, value []byte) error {
+			var record RelayRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return fmt.Errorf("corrupt relay record %x: %v", key, err)
+			}
+			if relayRecordMatches(record, safe, from, to) {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	sortRelayRecords(records)
+	return records, err
+}
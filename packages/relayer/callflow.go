@@ -0,0 +1,649 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+// Values a flow step can pass as call arguments
+const (
+	FlowArgVAA       = "vaa"       // bytes: the raw VAA
+	FlowArgVAAHash   = "vaaHash"   // bytes32: keccak256 of the VAA, the module's consumedVaas key
+	FlowArgSafe      = "safe"      // address: the payload Safe
+	FlowArgCandidate = "candidate" // address: the payload candidate
+	FlowArgTxID      = "txID"      // bytes32: the source transaction ID
+)
+
+// defaultGasLimit is the gas limit of a step that doesn't set one
+const defaultGasLimit = 3000000
+
+// errVAAConsumed means the module already consumed the VAA a re-driven step would submit,
+// through a transaction an earlier attempt sent
+var errVAAConsumed = errors.New("VAA already consumed by an earlier submission")
+
+// flowWaitError hands a VAA back to the retry queue until a call flow wait has elapsed,
+// either its own flow's or that of an earlier message for its Safe. It is not a failure.
+type flowWaitError struct {
+	reason string
+	until  time.Time
+}
+
+func (e *flowWaitError) Error() string {
+	return fmt.Sprintf("%s until %s", e.reason, e.until.UTC().Format(time.RFC3339))
+}
+
+// FlowStep is one transaction of a tenant's call flow, as configured
+type FlowStep struct {
+	Function    string            `json:"function"`    // Solidity signature, e.g. "execute(bytes32)"
+	Args        []string          `json:"args"`        // One value per parameter: a FlowArg* or a captured name
+	Wait        string            `json:"wait"`        // Delay after the previous step is mined, e.g. "24h"
+	GasLimit    uint64            `json:"gasLimit"`    // Defaults to defaultGasLimit
+	FeeStrategy string            `json:"feeStrategy"` // Defaults to the VAA's strategy
+	Capture     map[string]string `json:"capture"`     // Name -> "Event(types)#topic" read from the receipt
+}
+
+// flowStep is a FlowStep checked and ready to pack
+type flowStep struct {
+	FlowStep
+	selector []byte
+	inputs   abi.Arguments
+	literals map[int][]byte // Argument index -> value of a static argument
+	wait     time.Duration
+	captures map[string]flowCapture
+}
+
+// flowCapture reads a value for later steps from an event the step's transaction emits
+type flowCapture struct {
+	topic common.Hash // Event signature hash
+	index int         // Topic holding the value (1 for the first indexed parameter)
+}
+
+// defaultFlow is the single call of TARGET_FUNCTION with TARGET_FUNCTION_ARGS, by default
+// verify(bytes) with the VAA, what every module version to date expects
+func defaultFlow(config Config) ([]*flowStep, error) {
+	flow, err := compileFlow([]FlowStep{{Function: config.TargetFunction, Args: config.TargetFunctionArgs}})
+	if err != nil {
+		return nil, fmt.Errorf("TARGET_FUNCTION: %v", err)
+	}
+	return flow, nil
+}
+
+// compileFlow checks steps: signatures parse, arguments match them and are available by the
+// time each step runs, waits and fee strategies are valid
+func compileFlow(steps []FlowStep) ([]*flowStep, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("flow has no steps")
+	}
+
+	available := map[string]bool{FlowArgVAA: true, FlowArgVAAHash: true, FlowArgSafe: true, FlowArgCandidate: true, FlowArgTxID: true}
+	compiled := make([]*flowStep, 0, len(steps))
+	for i, step := range steps {
+		name, paramTypes, ok := parseSignature(step.Function)
+		if !ok {
+			return nil, fmt.Errorf("step %d: invalid function signature %q", i+1, step.Function)
+		}
+		if len(paramTypes) != len(step.Args) {
+			return nil, fmt.Errorf("step %d: %s takes %d arguments, %d given", i+1, name, len(paramTypes), len(step.Args))
+		}
+
+		s := &flowStep{
+			FlowStep: step,
+			selector: crypto.Keccak256([]byte(step.Function))[:4],
+			literals: make(map[int][]byte),
+			captures: make(map[string]flowCapture, len(step.Capture)),
+		}
+		for j, typeName := range paramTypes {
+			argType, err := abi.NewType(typeName, "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: argument %d: %v", i+1, j+1, err)
+			}
+			switch {
+			case argType.T == abi.BytesTy, argType.T == abi.AddressTy:
+			case argType.T == abi.FixedBytesTy && argType.Size == 32:
+			case argType.T == abi.UintTy && argType.Size == 256:
+			default:
+				return nil, fmt.Errorf("step %d: argument %d has unsupported type %s (want bytes, bytes32, address or uint256)", i+1, j+1, typeName)
+			}
+			if step.Args[j] == FlowArgVAA && argType.T != abi.BytesTy {
+				return nil, fmt.Errorf("step %d: argument %d: %s can only be passed as bytes", i+1, j+1, FlowArgVAA)
+			}
+			literal, isLiteral, err := parseFlowLiteral(step.Args[j], argType)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: argument %d: %v", i+1, j+1, err)
+			}
+			if isLiteral {
+				s.literals[j] = literal
+			} else if !available[step.Args[j]] {
+				return nil, fmt.Errorf("step %d: argument %q is not a VAA value, a static value or captured by an earlier step", i+1, step.Args[j])
+			}
+			s.inputs = append(s.inputs, abi.Argument{Type: argType})
+		}
+
+		if step.Wait != "" {
+			wait, err := time.ParseDuration(step.Wait)
+			if err != nil || wait < 0 {
+				return nil, fmt.Errorf("step %d: invalid wait %q", i+1, step.Wait)
+			}
+			s.wait = wait
+		}
+		if step.FeeStrategy != "" {
+			if _, err := lookupFeeStrategy(step.FeeStrategy); err != nil {
+				return nil, fmt.Errorf("step %d: %v", i+1, err)
+			}
+		}
+		if s.GasLimit == 0 {
+			s.GasLimit = defaultGasLimit
+		}
+
+		for capture, spec := range step.Capture {
+			event, index, ok := strings.Cut(spec, "#")
+			topicIndex, err := strconv.Atoi(index)
+			if _, _, sigOK := parseSignature(event); !ok || err != nil || !sigOK {
+				return nil, fmt.Errorf("step %d: capture %q: want \"Event(types)#topic\", got %q", i+1, capture, spec)
+			}
+			if topicIndex < 1 || topicIndex > 3 {
+				return nil, fmt.Errorf("step %d: capture %q: topic must be 1 to 3", i+1, capture)
+			}
+			s.captures[capture] = flowCapture{topic: crypto.Keccak256Hash([]byte(event)), index: topicIndex}
+			available[capture] = true
+		}
+		compiled = append(compiled, s)
+	}
+	return compiled, nil
+}
+
+// parseFlowLiteral reads a static argument: 0x-prefixed hex for any type, or a decimal
+// number for uint256. Other values are not literals.
+func parseFlowLiteral(arg string, argType abi.Type) ([]byte, bool, error) {
+	switch {
+	case strings.HasPrefix(arg, "0x"):
+		raw, err := hexutil.Decode(arg)
+		if err != nil {
+			return nil, true, fmt.Errorf("invalid hex value %q: %v", arg, err)
+		}
+		switch {
+		case argType.T == abi.AddressTy && len(raw) != common.AddressLength:
+			return nil, true, fmt.Errorf("address value %q is not %d bytes", arg, common.AddressLength)
+		case argType.T == abi.FixedBytesTy && len(raw) != 32:
+			return nil, true, fmt.Errorf("bytes32 value %q is not 32 bytes", arg)
+		case argType.T == abi.UintTy && len(raw) > 32:
+			return nil, true, fmt.Errorf("uint256 value %q is wider than 32 bytes", arg)
+		}
+		return raw, true, nil
+	case arg != "" && strings.Trim(arg, "0123456789") == "":
+		if argType.T != abi.UintTy {
+			return nil, true, fmt.Errorf("decimal value %s can only be passed as uint256", arg)
+		}
+		n, _ := new(big.Int).SetString(arg, 10)
+		if n.BitLen() > 256 {
+			return nil, true, fmt.Errorf("uint256 value %s overflows", arg)
+		}
+		return n.Bytes(), true, nil
+	}
+	return nil, false, nil
+}
+
+// parseSignature splits "name(type1,type2)" into its name and parameter types
+func parseSignature(sig string) (string, []string, bool) {
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") || strings.ContainsAny(sig, " \t") {
+		return "", nil, false
+	}
+	params := sig[open+1 : len(sig)-1]
+	if params == "" {
+		return sig[:open], nil, true
+	}
+	return sig[:open], strings.Split(params, ","), true
+}
+
+// pack encodes the step's call for vaaData, taking captured values from earlier steps. It
+// refuses a VAA whose payload isn't exactly its version's size.
+func (s *flowStep) pack(vaaData *VAAData, captured map[string]common.Hash) ([]byte, error) {
+	if err := sdk.CheckPayloadSize(vaaData.VAA.Payload); err != nil {
+		return nil, fmt.Errorf("refusing to pack VAA: %v", err)
+	}
+
+	values := make([]any, len(s.Args))
+	for i, arg := range s.Args {
+		raw, isLiteral := s.literals[i]
+		switch {
+		case isLiteral:
+		case arg == FlowArgVAA:
+			raw = vaaData.RawBytes
+		case arg == FlowArgVAAHash:
+			raw = crypto.Keccak256(vaaData.RawBytes)
+		case arg == FlowArgSafe:
+			raw = vaaData.Payload.Safe.Bytes()
+		case arg == FlowArgCandidate:
+			raw = vaaData.Payload.Candidate.Bytes()
+		case arg == FlowArgTxID:
+			raw = vaaData.Payload.TxID.Bytes()
+		default:
+			value, ok := captured[arg]
+			if !ok {
+				return nil, fmt.Errorf("%s: value %q was not captured", s.Function, arg)
+			}
+			raw = value.Bytes()
+		}
+
+		argType := s.inputs[i].Type
+		switch argType.T {
+		case abi.BytesTy:
+			values[i] = raw
+		case abi.AddressTy:
+			values[i] = common.BytesToAddress(raw)
+		case abi.UintTy:
+			values[i] = new(big.Int).SetBytes(raw)
+		case abi.FixedBytesTy:
+			var word [32]byte
+			copy(word[32-len(raw):], raw)
+			values[i] = word
+		}
+	}
+
+	encoded, err := s.inputs.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("ABI pack error: %v", err)
+	}
+	return append(append([]byte{}, s.selector...), encoded...), nil
+}
+
+// submitsVAA reports whether the step passes the raw VAA, which the module consumes
+func (s *flowStep) submitsVAA() bool {
+	for _, arg := range s.Args {
+		if arg == FlowArgVAA {
+			return true
+		}
+	}
+	return false
+}
+
+// capture reads the step's captures from its receipt, keeping the first matching log of the
+// target contract for each
+func (s *flowStep) capture(receipt *types.Receipt, target common.Address, captured map[string]common.Hash) error {
+	for name, c := range s.captures {
+		found := false
+		for _, log := range receipt.Logs {
+			if log.Address == target && len(log.Topics) > c.index && log.Topics[0] == c.topic {
+				captured[name] = log.Topics[c.index]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: transaction %s emitted no event to capture %q from", s.Function, receipt.TxHash.Hex(), name)
+		}
+	}
+	return nil
+}
+
+// loadCallFlows sets the flow of each tenant named in CALL_FLOWS_FILE, a JSON object of tenant
+// name to steps, or to an object of payload type to steps with an optional "default" entry for
+// the other types. Other tenants call TARGET_FUNCTION once per VAA.
+func loadCallFlows(config Config, tenants []*Tenant) error {
+	flow, err := defaultFlow(config)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		tenant.flow = flow
+	}
+	path := config.CallFlowsFile
+	if path == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read call flows: %v", err)
+	}
+	var byTenant map[string]json.RawMessage
+	if err := json.Unmarshal(content, &byTenant); err != nil {
+		return fmt.Errorf("failed to parse call flows: %v", err)
+	}
+
+	for name, raw := range byTenant {
+		var tenant *Tenant
+		for _, candidate := range tenants {
+			if candidate.Name == name {
+				tenant = candidate
+			}
+		}
+		if tenant == nil {
+			return fmt.Errorf("call flow for unknown tenant %q", name)
+		}
+		if err := tenant.setCallFlows(raw); err != nil {
+			return fmt.Errorf("tenant %q call flow: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setCallFlows compiles the tenant's entry in CALL_FLOWS_FILE: a list of steps for every payload
+// type, or an object of payload type ("0" to "255", or "default") to steps
+func (t *Tenant) setCallFlows(raw json.RawMessage) error {
+	var steps []FlowStep
+	if err := json.Unmarshal(raw, &steps); err == nil {
+		flow, err := compileFlow(steps)
+		if err != nil {
+			return err
+		}
+		t.flow = flow
+		t.logger.Info("Using configured call flow", zap.Strings("steps", flowFunctions(flow)))
+		return nil
+	}
+
+	var byType map[string][]FlowStep
+	if err := json.Unmarshal(raw, &byType); err != nil {
+		return fmt.Errorf("want a list of steps or an object of payload type to steps: %v", err)
+	}
+	if _, ok := byType["default"]; !ok {
+		// Types without a flow of their own are rejected
+		t.flow = nil
+	}
+	t.flowsByType = make(map[uint8][]*flowStep, len(byType))
+	for key, steps := range byType {
+		flow, err := compileFlow(steps)
+		if err != nil {
+			return fmt.Errorf("payload type %s: %v", key, err)
+		}
+		if key == "default" {
+			t.flow = flow
+			t.logger.Info("Using configured call flow for other payload types", zap.Strings("steps", flowFunctions(flow)))
+			continue
+		}
+		payloadType, err := strconv.ParseUint(key, 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid payload type %q (want 0 to 255 or \"default\")", key)
+		}
+		t.flowsByType[uint8(payloadType)] = flow
+		t.logger.Info("Using configured call flow",
+			zap.Uint64("payloadType", payloadType),
+			zap.Strings("steps", flowFunctions(flow)))
+	}
+	return nil
+}
+
+// flowFor returns the flow run for payloads of the given type
+func (t *Tenant) flowFor(payloadType uint8) ([]*flowStep, bool) {
+	if flow, ok := t.flowsByType[payloadType]; ok {
+		return flow, true
+	}
+	return t.flow, t.flow != nil
+}
+
+// flowFunctions lists the functions the tenant's flows call, once each
+func (t *Tenant) flowFunctions() []string {
+	var functions []string
+	seen := make(map[string]bool)
+	add := func(flow []*flowStep) {
+		for _, function := range flowFunctions(flow) {
+			if !seen[function] {
+				seen[function] = true
+				functions = append(functions, function)
+			}
+		}
+	}
+	add(t.flow)
+	for _, flow := range t.flowsByType {
+		add(flow)
+	}
+	return functions
+}
+
+// flowFunctions lists the functions a flow calls, for logs
+func flowFunctions(flow []*flowStep) []string {
+	functions := make([]string, len(flow))
+	for i, step := range flow {
+		functions[i] = step.Function
+	}
+	return functions
+}
+
+// flowProgress is how far a VAA's flow got, so a retry resumes after the last mined step
+type flowProgress struct {
+	next     int                    // Index of the next step to send
+	captured map[string]common.Hash // Values captured so far
+	readyAt  time.Time              // When the next step's wait is over (zero without a wait)
+}
+
+// flowProgressStore keeps the progress of flows interrupted after their first step. The
+// retry queue persists it with the VAA's entry.
+type flowProgressStore struct {
+	mu       sync.Mutex
+	progress map[string]*flowProgress // Dedupe key -> progress
+}
+
+// load returns the VAA's progress, starting a new flow if there is none
+func (s *flowProgressStore) load(key string) *flowProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.progress[key]; ok {
+		return p
+	}
+	return &flowProgress{captured: make(map[string]common.Hash)}
+}
+
+// peek returns the VAA's progress, if its flow got past the first step
+func (s *flowProgressStore) peek(key string) (flowProgress, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.progress[key]
+	if !ok {
+		return flowProgress{}, false
+	}
+	return *p, true
+}
+
+// save records the VAA's progress, or forgets it once the flow is done
+func (s *flowProgressStore) save(key string, p *flowProgress, done bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress == nil {
+		s.progress = make(map[string]*flowProgress)
+	}
+	if done {
+		delete(s.progress, key)
+		return
+	}
+	s.progress[key] = p
+}
+
+// runCallFlow sends the tenant's flow for vaaData one step at a time, waiting for each to be
+// mined, and returns the last step's receipt. A step with a wait that has not elapsed ends
+// the run with a flowWaitError, and the retry queue resumes the flow once it has. A VAA
+// retried after a failed step resumes with that step.
+func (r *Relayer) runCallFlow(ctx context.Context, dest *Destination, tenant *Tenant, vaaData *VAAData, strategy FeeStrategy) (*types.Receipt, error) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	flow, ok := tenant.flowFor(vaaData.Payload.Type)
+	if !ok {
+		return nil, fmt.Errorf("tenant %s has no call flow for payload type %d", tenant.Name, vaaData.Payload.Type)
+	}
+
+	key := r.dedupeKey(vaaData.RawBytes)
+	progress := r.flowProgress.load(key)
+	if progress.next > 0 {
+		log.Info("Resuming call flow",
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("tenant", tenant.Name),
+			zap.Int("step", progress.next+1))
+	}
+
+	var receipt *types.Receipt
+	for i := progress.next; i < len(flow); i++ {
+		step := flow[i]
+		if time.Now().Before(progress.readyAt) {
+			log.Info("Scheduling next call flow step after its wait",
+				zap.Uint64("sequence", vaaData.Sequence),
+				zap.String("function", step.Function),
+				zap.Time("at", progress.readyAt))
+			r.flowProgress.save(key, progress, false)
+			return nil, pipelineError(ErrorKindFlowWait, &flowWaitError{reason: "waiting to send " + step.Function, until: progress.readyAt})
+		}
+
+		stepStrategy := strategy
+		if step.FeeStrategy != "" && !vaaData.Priority {
+			stepStrategy, _ = lookupFeeStrategy(step.FeeStrategy)
+		}
+
+		var err error
+		receipt, err = r.sendFlowStep(ctx, dest, tenant, vaaData, step, progress.captured, stepStrategy)
+		if errors.Is(err, errVAAConsumed) {
+			if i == len(flow)-1 {
+				r.flowProgress.save(key, nil, true)
+				return nil, err
+			}
+			if len(step.captures) > 0 {
+				return nil, pipelineError(ErrorKindReverted, fmt.Errorf("%s: %v, and later steps need values captured from its receipt", step.Function, err))
+			}
+			log.Info("Call flow step already executed by an earlier submission",
+				zap.Uint64("sequence", vaaData.Sequence),
+				zap.String("function", step.Function))
+			progress.next = i + 1
+			progress.readyAt = flowStepReadyAt(flow, progress.next)
+			r.flowProgress.save(key, progress, false)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l1Fee := dest.client.receiptL1Fee(ctx, receipt)
+		r.recordSafeCost(dest, vaaData.Payload, receipt, l1Fee, i == len(flow)-1)
+		r.recordRelay(dest, tenant, vaaData, step, receipt, l1Fee)
+
+		if err := step.capture(receipt, tenant.target, progress.captured); err != nil {
+			return nil, pipelineError(ErrorKindReverted, err)
+		}
+		progress.next = i + 1
+		progress.readyAt = flowStepReadyAt(flow, progress.next)
+		r.flowProgress.save(key, progress, progress.next == len(flow))
+	}
+	return receipt, nil
+}
+
+// flowStepReadyAt returns when step next may be sent, the previous step having just been mined
+func flowStepReadyAt(flow []*flowStep, next int) time.Time {
+	if next >= len(flow) || flow[next].wait <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(flow[next].wait)
+}
+
+// sendFlowStep sends one step and waits for it to be mined successfully
+func (r *Relayer) sendFlowStep(ctx context.Context, dest *Destination, tenant *Tenant, vaaData *VAAData, step *flowStep, captured map[string]common.Hash, strategy FeeStrategy) (*types.Receipt, error) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	data, err := step.pack(vaaData, captured)
+	if err != nil {
+		return nil, err
+	}
+
+	// A re-driven VAA may have been sent already, by a run that crashed or an attempt whose
+	// receipt timed out; sending it again would revert as already consumed
+	if r.isQueuedForRetry(r.dedupeKey(vaaData.RawBytes)) {
+		if tx, ok := dest.client.pending.forDescription(describeVAA(vaaData.RawBytes)); ok {
+			log.Info("Earlier transaction for the VAA is still pending, waiting for it instead of resending",
+				zap.String("function", step.Function),
+				zap.Uint64("sequence", vaaData.Sequence),
+				zap.String("txHash", tx.Hash.Hex()))
+			vaaData.TxHash = tx.Hash.Hex()
+			return r.confirmFlowStep(ctx, dest, vaaData, step, tx.Hash.Hex())
+		}
+		if step.submitsVAA() {
+			consumed, err := r.isVAAConsumed(ctx, tenant, crypto.Keccak256Hash(vaaData.RawBytes))
+			if err != nil {
+				log.Warn("Failed to check whether the VAA was consumed, sending it again",
+					zap.Uint64("sequence", vaaData.Sequence),
+					zap.Error(err))
+			} else if consumed {
+				return nil, errVAAConsumed
+			}
+		}
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, r.config.SendTimeout)
+	defer cancel()
+
+	correlatedLogger(ctx, dest.client.logger).Debug("Sending call flow step to EVM",
+		zap.String("function", step.Function),
+		zap.Int("vaaLength", len(vaaData.RawBytes)))
+	var sponsored []string
+	if dest.sponsor != nil {
+		if sponsored, err = r.reserveSponsorship(dest, vaaData); err != nil {
+			return nil, err
+		}
+	}
+	var txHash string
+	if sponsored != nil {
+		txHash, err = dest.sponsor.submit(sendCtx, tenant.target, data, strategy, describeVAA(vaaData.RawBytes))
+		if errors.Is(err, errUserOpNotSent) {
+			r.sponsorship.unreserve(sponsored)
+		}
+	} else {
+		txHash, err = dest.client.sendTransaction(sendCtx, tenant.target, big.NewInt(0), data, step.GasLimit, strategy, describeVAA(vaaData.RawBytes))
+	}
+	if err != nil {
+		if sendCtx.Err() != nil {
+			log.Warn("Transaction sending cancelled or timed out", zap.Error(sendCtx.Err()))
+			return nil, pipelineError(ErrorKindTimeout, fmt.Errorf("transaction interrupted: %v", sendCtx.Err()))
+		}
+
+		log.Error("Failed to send transaction",
+			zap.String("function", step.Function),
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("sourceTxID", vaaData.TxID),
+			zap.Error(err))
+		return nil, pipelineError(errorKindOf(err), fmt.Errorf("transaction failed: %v", err))
+	}
+	vaaData.TxHash = txHash
+	return r.confirmFlowStep(ctx, dest, vaaData, step, txHash)
+}
+
+// confirmFlowStep waits for a step's transaction to be mined successfully
+func (r *Relayer) confirmFlowStep(ctx context.Context, dest *Destination, vaaData *VAAData, step *flowStep, txHash string) (*types.Receipt, error) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	// Only count the step as done once the transaction is mined successfully
+	receiptCtx, cancelReceipt := context.WithTimeout(ctx, dest.ReceiptTimeout)
+	defer cancelReceipt()
+
+	receipt, err := dest.client.WaitForReceipt(receiptCtx, common.HexToHash(txHash))
+	if err != nil {
+		log.Error("Transaction not confirmed",
+			zap.String("function", step.Function),
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("txHash", txHash),
+			zap.Error(err))
+		return nil, pipelineError(ErrorKindTimeout, fmt.Errorf("transaction not confirmed: %v", err))
+	}
+	vaaData.Receipt = receipt
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		revert := dest.client.replayRevert(receiptCtx, receipt)
+		log.Error("Transaction reverted",
+			zap.String("function", step.Function),
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("txHash", txHash),
+			zap.Uint64("block", receipt.BlockNumber.Uint64()),
+			zap.String("revertClass", revert.Class),
+			zap.String("revertReason", revert.Reason))
+		return nil, pipelineError(ErrorKindReverted, revertError(revert, "transaction", vaaData.CorrelationID, "transaction %s", txHash))
+	}
+	return receipt, nil
+}
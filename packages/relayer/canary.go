@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Where a canary VAA entered the pipeline
+const (
+	CanarySourceInjected = "injected" // CANARY_VAA, fed to the pipeline every CANARY_INTERVAL
+	CanarySourceSpy      = "spy"      // Emitted on Aztec by the canary emitter and streamed by the spy
+)
+
+// Pipeline stages a canary VAA passes, in order
+const (
+	CanaryStageParse      = "parse"
+	CanaryStageSignatures = "signatures"
+	CanaryStageRoute      = "route"
+	CanaryStagePack       = "pack"
+	CanaryStageSimulate   = "simulate"
+)
+
+// CanaryStatus is the outcome of the last canary check from one source
+type CanaryStatus struct {
+	Source      string    `json:"source"`
+	LastRun     time.Time `json:"lastRun"`
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	Healthy     bool      `json:"healthy"`
+	Stage       string    `json:"stage,omitempty"` // Stage the last check failed at
+	Error       string    `json:"error,omitempty"`
+	Sequence    uint64    `json:"sequence"`
+	LatencySec  float64   `json:"latencySec,omitempty"` // From the VAA's timestamp to a passing simulation (spy only)
+}
+
+// canaryEmitters returns the normalized emitters whose VAAs are canaries: CANARY_EMITTER and
+// the emitter of CANARY_VAA, so the injected VAA is never relayed when the spy streams it too
+func canaryEmitters(config Config, canaryVAA []byte) (map[string]bool, error) {
+	emitters := make(map[string]bool)
+	if config.CanaryEmitter != "" {
+		emitters[strings.TrimLeft(strings.TrimPrefix(strings.ToLower(config.CanaryEmitter), "0x"), "0")] = true
+	}
+	if canaryVAA != nil {
+		vaaData, err := parseVAAData(canaryVAA)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CANARY_VAA: %v", err)
+		}
+		if vaaData.ChainID != config.SourceChainID {
+			return nil, fmt.Errorf("CANARY_VAA is from chain %d, not the source chain %d", vaaData.ChainID, config.SourceChainID)
+		}
+		emitters[strings.TrimLeft(vaaData.EmitterHex, "0")] = true
+	}
+	return emitters, nil
+}
+
+// decodeCanaryVAA decodes CANARY_VAA, or returns nil when it is not set
+func decodeCanaryVAA(config Config) ([]byte, error) {
+	if config.CanaryVAA == "" {
+		return nil, nil
+	}
+	vaaBytes, err := hex.DecodeString(strings.TrimPrefix(config.CanaryVAA, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CANARY_VAA: %v", err)
+	}
+	return vaaBytes, nil
+}
+
+// isCanary reports whether the VAA was published by a canary emitter
+func (r *Relayer) isCanary(vaaData *VAAData) bool {
+	if len(r.canaryEmitters) == 0 || vaaData.ChainID != r.config.SourceChainID {
+		return false
+	}
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(vaaData.EmitterHex)
+	return r.canaryEmitters[normalizedEmitter] || r.canaryEmitters[strings.TrimLeft(strings.ToLower(decodedEmitter), "0")]
+}
+
+// runCanary feeds CANARY_VAA through the pipeline every CANARY_INTERVAL until ctx is done.
+// A standby runs it too, so its health reflects whether it could take over.
+func (r *Relayer) runCanary(ctx context.Context) {
+	ticker := time.NewTicker(r.config.CanaryInterval)
+	defer ticker.Stop()
+
+	for {
+		r.injectCanary(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// injectCanary runs one check of CANARY_VAA
+func (r *Relayer) injectCanary(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, r.config.SendTimeout)
+	defer cancel()
+
+	vaaData, err := parseVAAData(r.canaryVAA)
+	if err != nil {
+		r.recordCanary(CanarySourceInjected, &VAAData{}, CanaryStageParse, err, 0)
+		return
+	}
+	vaaData.CorrelationID = newCorrelationID()
+	stage, err := r.canaryCheck(withCorrelationID(checkCtx, vaaData.CorrelationID), vaaData)
+	if ctx.Err() != nil {
+		return
+	}
+	r.recordCanary(CanarySourceInjected, vaaData, stage, err, 0)
+}
+
+// handleCanary checks a canary VAA streamed by the spy instead of relaying it
+func (r *Relayer) handleCanary(ctx context.Context, vaaData *VAAData) {
+	checkCtx, cancel := context.WithTimeout(ctx, r.config.SendTimeout)
+	defer cancel()
+
+	stage, err := r.canaryCheck(checkCtx, vaaData)
+	if ctx.Err() != nil {
+		return
+	}
+	var latency time.Duration
+	if err == nil && !vaaData.VAA.Timestamp.IsZero() {
+		latency = time.Since(vaaData.VAA.Timestamp)
+	}
+	r.recordCanary(CanarySourceSpy, vaaData, stage, err, latency)
+}
+
+// canaryCheck takes a canary VAA through every stage a real one passes up to submission,
+// ending with a simulation of the first call on the destination. Nothing is sent, and the
+// Safe preflight is skipped since it holds messages it fails. It returns the stage that
+// failed, if any.
+func (r *Relayer) canaryCheck(ctx context.Context, vaaData *VAAData) (string, error) {
+	if err := r.verifyGuardianSignatures(ctx, vaaData); err != nil {
+		return CanaryStageSignatures, err
+	}
+
+	dest, tenant, err := r.routeVAA(vaaData)
+	if err != nil {
+		return CanaryStageRoute, err
+	}
+
+	flow, _ := tenant.flowFor(vaaData.Payload.Type)
+	data, err := flow[0].pack(vaaData, nil)
+	if err != nil {
+		return CanaryStagePack, err
+	}
+
+	if _, err := dest.client.SimulateCall(ctx, tenant.target, data); err != nil {
+		return CanaryStageSimulate, err
+	}
+	return "", nil
+}
+
+// recordCanary stores and reports the outcome of a canary check
+func (r *Relayer) recordCanary(source string, vaaData *VAAData, stage string, err error, latency time.Duration) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID).With(
+		zap.String("source", source),
+		zap.Uint64("sequence", vaaData.Sequence))
+
+	now := time.Now()
+	r.canaryMu.Lock()
+	status, ok := r.canary[source]
+	if !ok {
+		status = &CanaryStatus{Source: source}
+		r.canary[source] = status
+	}
+	status.LastRun = now
+	status.Healthy = err == nil
+	status.Sequence = vaaData.Sequence
+	status.Stage, status.Error = "", ""
+	if err != nil {
+		status.Stage, status.Error = stage, err.Error()
+	} else {
+		status.LastSuccess = now
+		status.LatencySec = latency.Seconds()
+	}
+	r.canaryMu.Unlock()
+
+	if err != nil {
+		log.Error("Canary check failed", zap.String("stage", stage), zap.Error(err))
+		canarySuccess.WithLabelValues(source).Set(0)
+		return
+	}
+	log.Debug("Canary check passed", zap.Duration("latency", latency))
+	canarySuccess.WithLabelValues(source).Set(1)
+	canaryLastSuccess.WithLabelValues(source).Set(float64(now.Unix()))
+	if latency > 0 {
+		canaryLatency.Observe(latency.Seconds())
+	}
+}
+
+// Canary returns the last canary outcome per source, and whether the canary reports the
+// pipeline healthy: no check failed last, and a spy canary passed within CANARY_MAX_AGE
+func (r *Relayer) Canary() ([]CanaryStatus, bool) {
+	r.canaryMu.Lock()
+	defer r.canaryMu.Unlock()
+
+	healthy := true
+	statuses := make([]CanaryStatus, 0, len(r.canary))
+	for _, source := range []string{CanarySourceInjected, CanarySourceSpy} {
+		if status, ok := r.canary[source]; ok {
+			statuses = append(statuses, *status)
+			healthy = healthy && status.Healthy
+		}
+	}
+	if r.config.CanaryMaxAge > 0 && r.config.CanaryEmitter != "" {
+		last := r.canarySince
+		if status, ok := r.canary[CanarySourceSpy]; ok && status.LastSuccess.After(last) {
+			last = status.LastSuccess
+		}
+		if time.Since(last) > r.config.CanaryMaxAge {
+			healthy = false
+		}
+	}
+	return statuses, healthy
+}
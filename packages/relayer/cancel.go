@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Gas of a plain transfer, used where a cancellation's gas can't be estimated
+const transferGas = 21000
+
+// runCancel clears a signer's queue stuck behind a transaction that won't be mined, replacing it
+// with a zero-value transfer from the signer to itself at the same nonce, priced above it
+func runCancel(ctx context.Context, config Config, args []string) int {
+	flags := flag.NewFlagSet("cancel", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	nonce := flags.Int64("nonce", -1, "nonce of the stuck transaction (required)")
+	signerFlag := flags.String("signer", "", "signer whose transaction is stuck (required with several signers)")
+	destFlag := flags.String("destination", "", "destination the transaction is stuck on (default the primary)")
+	stuckFlag := flags.String("tx", "", "hash of the stuck transaction, to price the cancellation above its fees")
+	bump := flags.Int64("bump", 20, "percent added to the fees (at least 10)")
+	wait := flags.Bool("wait", true, "wait for the cancellation to be mined")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: relayer cancel --nonce N [--signer address] [--destination name] [--tx hash] [--bump percent]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *nonce < 0 || flags.NArg() > 0 || *bump < 10 {
+		flags.Usage()
+		return 2
+	}
+	if *signerFlag != "" && !common.IsHexAddress(*signerFlag) {
+		fmt.Fprintf(os.Stderr, "invalid signer address %q\n", *signerFlag)
+		return 2
+	}
+	var stuck common.Hash
+	if *stuckFlag != "" {
+		raw, err := hexutil.Decode(*stuckFlag)
+		if err != nil || len(raw) != common.HashLength {
+			fmt.Fprintf(os.Stderr, "invalid transaction hash %q\n", *stuckFlag)
+			return 2
+		}
+		stuck = common.BytesToHash(raw)
+	}
+
+	config.StateStore = StateStoreMemory
+	r, err := NewRelayer(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer r.Close()
+
+	dest := r.destinations[0]
+	if *destFlag != "" {
+		dest = nil
+		for _, candidate := range r.destinations {
+			if candidate.Name == *destFlag {
+				dest = candidate
+			}
+		}
+		if dest == nil {
+			fmt.Fprintf(os.Stderr, "unknown destination %q\n", *destFlag)
+			return 2
+		}
+	}
+
+	accounts := dest.client.signers.accounts
+	var signer Signer
+	switch {
+	case *signerFlag != "":
+		for _, account := range accounts {
+			if account.Address() == common.HexToAddress(*signerFlag) {
+				signer = account
+			}
+		}
+		if signer == nil {
+			fmt.Fprintf(os.Stderr, "%s is not one of the configured signers\n", *signerFlag)
+			return 2
+		}
+	case len(accounts) == 1:
+		signer = accounts[0]
+	default:
+		fmt.Fprintln(os.Stderr, "several signers are configured; name the stuck one with --signer")
+		return 2
+	}
+
+	fmt.Printf("Cancelling nonce %d of %s on %s\n", *nonce, signer.Address().Hex(), dest.Name)
+	tx, txHash, err := dest.client.cancelNonce(ctx, signer, uint64(*nonce), stuck, *bump)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cancellation failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("tx hash: %s (max fee %s gwei, tip %s gwei, gas %d)\n",
+		txHash.Hex(), formatGwei(tx.GasFeeCap()), formatGwei(tx.GasTipCap()), tx.Gas())
+	if !*wait {
+		return 0
+	}
+
+	receiptCtx, cancel := context.WithTimeout(ctx, dest.ReceiptTimeout)
+	defer cancel()
+	receipt, err := dest.client.WaitForReceipt(receiptCtx, txHash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cancellation not confirmed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("receipt: mined in block %d; nonce %d is used and the queue can move again\n", receipt.BlockNumber.Uint64(), *nonce)
+	return 0
+}
+
+// cancelNonce signs and broadcasts a zero-value transfer from signer to itself at nonce, with
+// fees bumpPct above the urgent estimate or the stuck transaction's, whichever is higher. The
+// stuck transaction's fees are only known when its hash is given.
+func (c *EVMClient) cancelNonce(ctx context.Context, signer Signer, nonce uint64, stuck common.Hash, bumpPct int64) (*types.Transaction, common.Hash, error) {
+	address := signer.Address()
+	confirmed, err := c.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("failed to get confirmed nonce: %v", err)
+	}
+	if nonce < confirmed {
+		return nil, common.Hash{}, fmt.Errorf("nonce %d is already mined; the signer's next nonce is %d", nonce, confirmed)
+	}
+
+	params, err := c.fetchTxParams(ctx, address, feeStrategies[FeeStrategyUrgent])
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if stuck != (common.Hash{}) {
+		stuckTx, isPending, err := c.client.TransactionByHash(ctx, stuck)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			return nil, common.Hash{}, fmt.Errorf("transaction %s is not known to the RPC", stuck.Hex())
+		case err != nil:
+			return nil, common.Hash{}, fmt.Errorf("failed to look up transaction %s: %v", stuck.Hex(), err)
+		case !isPending:
+			return nil, common.Hash{}, fmt.Errorf("transaction %s is already mined", stuck.Hex())
+		case stuckTx.Nonce() != nonce:
+			return nil, common.Hash{}, fmt.Errorf("transaction %s has nonce %d, not %d", stuck.Hex(), stuckTx.Nonce(), nonce)
+		}
+		params.tip = bigMax(params.tip, stuckTx.GasTipCap())
+		params.feeCap = bigMax(params.feeCap, stuckTx.GasFeeCap())
+	}
+	params.nonce = nonce
+
+	gas, err := c.client.EstimateGas(ctx, ethereum.CallMsg{From: address, To: &address, Value: new(big.Int)})
+	if err != nil {
+		gas = transferGas
+	}
+	tx := newTransaction(params, address, new(big.Int), gas, nil, bumpPct)
+	description := fmt.Sprintf("cancel nonce %d", nonce)
+	rawTx, txHash, err := c.signTx(ctx, signer, params, tx, description)
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	c.audit.recordTxSigned(ctx, params.chain.chainID.Uint64(), address.Hex(), address.Hex(), txHash.Hex(), nonce, description)
+
+	if err := c.client.Client().CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(rawTx)); err != nil {
+		if strings.Contains(err.Error(), "replacement transaction underpriced") {
+			return nil, common.Hash{}, fmt.Errorf("%v; pass the stuck transaction's hash with --tx or raise --bump", err)
+		}
+		return nil, common.Hash{}, fmt.Errorf("failed to send transaction: %v", err)
+	}
+	return tx, txHash, nil
+}
+
+// bigMax returns the larger of a and b
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
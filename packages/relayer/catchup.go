@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// errVAANotFound means the API has no signed VAA for the sequence (yet)
+var errVAANotFound = errors.New("VAA not found")
+
+// GuardianAPIClient reads signed VAAs from a Wormhole API (a guardian's public REST endpoint
+// or Wormholescan)
+type GuardianAPIClient struct {
+	url        string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// newGuardianAPIClient creates a client for GUARDIAN_API_URL, or returns nil when it is not set
+func newGuardianAPIClient(config Config) (*GuardianAPIClient, error) {
+	if config.GuardianAPIURL == "" {
+		return nil, nil
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return &GuardianAPIClient{
+		url:        strings.TrimSuffix(config.GuardianAPIURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		logger:     logger.With(zap.String("component", "GuardianAPIClient")),
+	}, nil
+}
+
+// get fetches path and decodes the JSON answer into out
+func (c *GuardianAPIClient) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("guardian API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read guardian API response: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errVAANotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("guardian API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid guardian API response: %v", err)
+	}
+	return nil
+}
+
+// LatestSequence returns the highest sequence the API has a signed VAA for
+func (c *GuardianAPIClient) LatestSequence(ctx context.Context, chain uint16, emitterHex string) (uint64, error) {
+	var decoded struct {
+		Data []struct {
+			Sequence json.RawMessage `json:"sequence"` // A number or a decimal string
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("/api/v1/vaas/%d/%s?page=0&pageSize=1&sortOrder=DESC", chain, url.PathEscape(emitterHex))
+	if err := c.get(ctx, path, &decoded); err != nil {
+		return 0, err
+	}
+	if len(decoded.Data) == 0 {
+		return 0, errVAANotFound
+	}
+	raw := strings.Trim(string(decoded.Data[0].Sequence), `"`)
+	sequence, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sequence %q: %v", raw, err)
+	}
+	return sequence, nil
+}
+
+// SignedVAA returns the signed VAA the emitter published with the given sequence
+func (c *GuardianAPIClient) SignedVAA(ctx context.Context, chain uint16, emitterHex string, sequence uint64) ([]byte, error) {
+	var decoded struct {
+		VAABytes string `json:"vaaBytes"`
+	}
+	path := fmt.Sprintf("/v1/signed_vaa/%d/%s/%d", chain, url.PathEscape(emitterHex), sequence)
+	if err := c.get(ctx, path, &decoded); err != nil {
+		return nil, err
+	}
+	vaaBytes, err := base64.StdEncoding.DecodeString(decoded.VAABytes)
+	if err != nil || len(vaaBytes) == 0 {
+		return nil, fmt.Errorf("invalid VAA bytes in guardian API response")
+	}
+	return vaaBytes, nil
+}
+
+// apiObservation is a guardian's signature over a message digest, as reported by the API
+type apiObservation struct {
+	Hash         []byte `json:"hash"`
+	GuardianAddr string `json:"guardianAddr"`
+	Signature    []byte `json:"signature"`
+}
+
+// Observations returns the guardian observations the API has seen for the message with the
+// given sequence, none when it has no record of them (guardian endpoints don't serve them)
+func (c *GuardianAPIClient) Observations(ctx context.Context, chain uint16, emitterHex string, sequence uint64) ([]apiObservation, error) {
+	var observations []apiObservation
+	path := fmt.Sprintf("/api/v1/observations/%d/%s/%d", chain, url.PathEscape(emitterHex), sequence)
+	if err := c.get(ctx, path, &observations); err != nil {
+		if errors.Is(err, errVAANotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return observations, nil
+}
+
+// catchUp fetches the VAAs each checkpointed source emitter published while the relayer was
+// down and processes them like streamed ones, returning once they have all been handled or
+// queued for retry. Emitters without a checkpoint have no known resume point and are left to
+// the live stream.
+func (r *Relayer) catchUp(ctx, processingCtx context.Context, wg *sync.WaitGroup) {
+	if r.guardianAPI == nil {
+		return
+	}
+	checkpoints := r.Checkpoints()
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].EmitterAddress < checkpoints[j].EmitterAddress
+	})
+
+	started := time.Now()
+	var pending sync.WaitGroup
+	dispatched := 0
+	for _, cp := range checkpoints {
+		if cp.EmitterChain != r.config.SourceChainID {
+			continue
+		}
+		dispatched += r.catchUpEmitter(ctx, processingCtx, wg, &pending, cp)
+	}
+
+	if dispatched > 0 {
+		r.logger.Info("Waiting for caught-up VAAs to be handled", zap.Int("count", dispatched))
+	}
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return
+	}
+	r.logger.Info("Catch-up complete",
+		zap.Int("vaas", dispatched),
+		zap.Duration("took", time.Since(started)))
+}
+
+// catchUpEmitter dispatches the VAAs between the emitter's checkpoint and the latest sequence
+// the API reports, oldest first and at most CATCHUP_MAX_VAAS of them, and returns how many it
+// dispatched
+func (r *Relayer) catchUpEmitter(ctx, processingCtx context.Context, wg, pending *sync.WaitGroup, cp Checkpoint) int {
+	log := r.logger.With(zap.String("emitter", cp.EmitterAddress))
+
+	latest, err := r.guardianAPI.LatestSequence(ctx, cp.EmitterChain, cp.EmitterAddress)
+	if err != nil {
+		log.Warn("Failed to read latest sequence from guardian API, not catching up emitter", zap.Error(err))
+		return 0
+	}
+	if latest <= cp.Sequence {
+		log.Debug("Emitter is up to date", zap.Uint64("sequence", cp.Sequence))
+		return 0
+	}
+
+	last := latest
+	if limit := uint64(r.config.CatchupMaxVAAs); limit > 0 && latest-cp.Sequence > limit {
+		last = cp.Sequence + limit
+		log.Warn("Missed more VAAs than CATCHUP_MAX_VAAS, catching up the oldest",
+			zap.Uint64("latestSequence", latest),
+			zap.Uint64("upToSequence", last))
+	}
+	log.Info("Catching up VAAs missed during downtime",
+		zap.Uint64("fromSequence", cp.Sequence+1),
+		zap.Uint64("toSequence", last))
+
+	dispatched := 0
+	for seq := cp.Sequence + 1; seq <= last; seq++ {
+		if ctx.Err() != nil || r.isDraining() {
+			break
+		}
+		vaaBytes, err := r.guardianAPI.SignedVAA(ctx, cp.EmitterChain, cp.EmitterAddress, seq)
+		if err != nil {
+			// A sequence can be missing for good, e.g. a message the guardians never signed
+			log.Warn("Failed to fetch missed VAA", zap.Uint64("sequence", seq), zap.Error(err))
+			catchupVAAs.WithLabelValues("unavailable").Inc()
+			continue
+		}
+		catchupVAAs.WithLabelValues("fetched").Inc()
+
+		key := r.dedupeKey(vaaBytes)
+		if !r.beginProcessingVAA(key) {
+			continue
+		}
+		correlationID := newCorrelationID()
+		log.Debug("Dispatching missed VAA",
+			zap.Uint64("sequence", seq),
+			zap.String("vaaHash", key),
+			zap.String("correlationId", correlationID))
+
+		wg.Add(1)
+		pending.Add(1)
+		go func() {
+			defer wg.Done()
+			defer pending.Done()
+			r.handleVAA(processingCtx, vaaBytes, key, correlationID)
+		}()
+		dispatched++
+	}
+	return dispatched
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+	"go.uber.org/zap"
+)
+
+// Wormhole Queries (CCQ) wire format, see node/pkg/query in the Wormhole repository
+const (
+	queryMessageVersion uint8 = 1
+	queryTypeEthCall    uint8 = 1
+
+	// Guardian signatures in query server responses are 65 bytes followed by the guardian index
+	querySignatureSize = 66
+)
+
+// Prefix of the digest guardians sign over a query response
+var queryResponsePrefix = []byte("query_response_0000000000000000000|")
+
+// EthCall is one eth_call made by a query
+type EthCall struct {
+	To   common.Address
+	Data []byte
+}
+
+// EthCallQuery asks the guardians to run calls on a chain at a block ("latest" or a 0x block
+// number or hash)
+type EthCallQuery struct {
+	ChainID uint16 // Wormhole chain ID
+	BlockID string
+	Calls   []EthCall
+}
+
+// EthCallResult is the guardians' answer to an EthCallQuery
+type EthCallResult struct {
+	ChainID     uint16
+	BlockNumber uint64
+	BlockHash   common.Hash
+	BlockTime   time.Time
+	Results     [][]byte // One per call, in request order
+}
+
+// QueryResponse is a query response signed by the guardians
+type QueryResponse struct {
+	Bytes      []byte // Serialized response, as verified on-chain
+	Signatures []QuerySignature
+	Request    []byte // Serialized request the response answers
+	Results    []EthCallResult
+}
+
+// QuerySignature is one guardian's signature over a query response
+type QuerySignature struct {
+	Index     uint8
+	Signature [65]byte
+}
+
+// encodeEthCallQueries serializes an off-chain query request
+func encodeEthCallQueries(nonce uint32, queries []EthCallQuery) ([]byte, error) {
+	if len(queries) == 0 || len(queries) > 255 {
+		return nil, fmt.Errorf("a query request needs 1 to 255 per-chain queries, got %d", len(queries))
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(queryMessageVersion)
+	binary.Write(buf, binary.BigEndian, nonce)
+	buf.WriteByte(uint8(len(queries)))
+	for _, query := range queries {
+		if len(query.Calls) == 0 || len(query.Calls) > 255 {
+			return nil, fmt.Errorf("an eth_call query needs 1 to 255 calls, got %d", len(query.Calls))
+		}
+		if query.BlockID != "latest" && !strings.HasPrefix(query.BlockID, "0x") {
+			return nil, fmt.Errorf("invalid block ID %q", query.BlockID)
+		}
+
+		body := new(bytes.Buffer)
+		binary.Write(body, binary.BigEndian, uint32(len(query.BlockID)))
+		body.WriteString(query.BlockID)
+		body.WriteByte(uint8(len(query.Calls)))
+		for _, call := range query.Calls {
+			body.Write(call.To.Bytes())
+			binary.Write(body, binary.BigEndian, uint32(len(call.Data)))
+			body.Write(call.Data)
+		}
+
+		binary.Write(buf, binary.BigEndian, query.ChainID)
+		buf.WriteByte(queryTypeEthCall)
+		binary.Write(buf, binary.BigEndian, uint32(body.Len()))
+		buf.Write(body.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+// parseQueryResponse decodes a response to an off-chain request of eth_call queries
+func parseQueryResponse(data []byte) (*QueryResponse, error) {
+	reader := bytes.NewReader(data)
+	var version uint8
+	if err := binary.Read(reader, binary.BigEndian, &version); err != nil || version != queryMessageVersion {
+		return nil, fmt.Errorf("unsupported query response version")
+	}
+	var requestChain uint16
+	if err := binary.Read(reader, binary.BigEndian, &requestChain); err != nil {
+		return nil, fmt.Errorf("failed to read request chain: %v", err)
+	}
+	if requestChain != 0 {
+		return nil, fmt.Errorf("unsupported on-chain request from chain %d", requestChain)
+	}
+	if _, err := reader.Seek(65, io.SeekCurrent); err != nil || reader.Len() == 0 {
+		return nil, fmt.Errorf("failed to read request signature")
+	}
+
+	response := &QueryResponse{Bytes: data}
+	request, err := readQueryBytes(reader, "request")
+	if err != nil {
+		return nil, err
+	}
+	response.Request = request
+
+	var count uint8
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read response count: %v", err)
+	}
+	for i := 0; i < int(count); i++ {
+		var result EthCallResult
+		var queryType uint8
+		if err := binary.Read(reader, binary.BigEndian, &result.ChainID); err != nil {
+			return nil, fmt.Errorf("failed to read response %d chain: %v", i, err)
+		}
+		if err := binary.Read(reader, binary.BigEndian, &queryType); err != nil {
+			return nil, fmt.Errorf("failed to read response %d type: %v", i, err)
+		}
+		if queryType != queryTypeEthCall {
+			return nil, fmt.Errorf("response %d has unsupported query type %d", i, queryType)
+		}
+		body, err := readQueryBytes(reader, "response")
+		if err != nil {
+			return nil, err
+		}
+		if err := result.unmarshal(body); err != nil {
+			return nil, fmt.Errorf("response %d: %v", i, err)
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes in query response", reader.Len())
+	}
+	return response, nil
+}
+
+// unmarshal decodes the body of an eth_call response
+func (r *EthCallResult) unmarshal(body []byte) error {
+	reader := bytes.NewReader(body)
+	if err := binary.Read(reader, binary.BigEndian, &r.BlockNumber); err != nil {
+		return fmt.Errorf("failed to read block number: %v", err)
+	}
+	if n, err := reader.Read(r.BlockHash[:]); err != nil || n != len(r.BlockHash) {
+		return fmt.Errorf("failed to read block hash")
+	}
+	var micros int64
+	if err := binary.Read(reader, binary.BigEndian, &micros); err != nil {
+		return fmt.Errorf("failed to read block time: %v", err)
+	}
+	r.BlockTime = time.UnixMicro(micros)
+
+	var count uint8
+	if err := binary.Read(reader, binary.BigEndian, &count); err != nil {
+		return fmt.Errorf("failed to read result count: %v", err)
+	}
+	for i := 0; i < int(count); i++ {
+		result, err := readQueryBytes(reader, "result")
+		if err != nil {
+			return err
+		}
+		r.Results = append(r.Results, result)
+	}
+	return nil
+}
+
+// readQueryBytes reads a uint32 length-prefixed field
+func readQueryBytes(reader *bytes.Reader, field string) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("failed to read %s length: %v", field, err)
+	}
+	if int64(length) > int64(reader.Len()) {
+		return nil, fmt.Errorf("%s length %d exceeds remaining %d bytes", field, length, reader.Len())
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", field, err)
+	}
+	return value, nil
+}
+
+// Digest returns the hash the guardians sign for the response
+func (q *QueryResponse) Digest() common.Hash {
+	return crypto.Keccak256Hash(append(append([]byte{}, queryResponsePrefix...), crypto.Keccak256(q.Bytes)...))
+}
+
+// Verify checks that a quorum of the guardian set signed the response
+func (q *QueryResponse) Verify(set *GuardianSet) error {
+	quorum := vaaLib.CalculateQuorum(len(set.Keys))
+	if len(q.Signatures) < quorum {
+		return fmt.Errorf("%d signatures, guardian set %d needs %d", len(q.Signatures), set.Index, quorum)
+	}
+
+	digest := q.Digest()
+	seen := make(map[uint8]bool, len(q.Signatures))
+	for _, sig := range q.Signatures {
+		if int(sig.Index) >= len(set.Keys) {
+			return fmt.Errorf("signature from guardian %d outside set %d", sig.Index, set.Index)
+		}
+		if seen[sig.Index] {
+			return fmt.Errorf("duplicate signature from guardian %d", sig.Index)
+		}
+		seen[sig.Index] = true
+
+		pubKey, err := crypto.SigToPub(digest.Bytes(), sig.Signature[:])
+		if err != nil {
+			return fmt.Errorf("invalid signature from guardian %d: %v", sig.Index, err)
+		}
+		if crypto.PubkeyToAddress(*pubKey) != set.Keys[sig.Index] {
+			return fmt.Errorf("signature from guardian %d does not match its key", sig.Index)
+		}
+	}
+	return nil
+}
+
+// QueryClient issues query requests to a Wormhole query server (CCQ proxy)
+type QueryClient struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// newQueryClient creates a client for QUERY_SERVER_URL, or returns nil when it is not set
+func newQueryClient(config Config) (*QueryClient, error) {
+	if config.QueryServerURL == "" {
+		return nil, nil
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return &QueryClient{
+		url:        strings.TrimSuffix(config.QueryServerURL, "/"),
+		apiKey:     config.QueryAPIKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		logger:     logger.With(zap.String("component", "QueryClient")),
+	}, nil
+}
+
+// Query sends the request to the query server and returns its signed response, checked to
+// answer this request. Signatures are not verified here; see QueryResponse.Verify.
+func (c *QueryClient) Query(ctx context.Context, request []byte) (*QueryResponse, error) {
+	body, err := json.Marshal(map[string]string{"bytes": hex.EncodeToString(request)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query server request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query server response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query server returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var decoded struct {
+		Bytes      string   `json:"bytes"`
+		Signatures []string `json:"signatures"`
+	}
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid query server response: %v", err)
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(decoded.Bytes, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid response bytes: %v", err)
+	}
+	response, err := parseQueryResponse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(response.Request, request) {
+		return nil, fmt.Errorf("query server answered a different request")
+	}
+
+	for _, sigHex := range decoded.Signatures {
+		sigBytes, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+		if err != nil || len(sigBytes) != querySignatureSize {
+			return nil, fmt.Errorf("invalid guardian signature %q", sigHex)
+		}
+		sig := QuerySignature{Index: sigBytes[65]}
+		copy(sig.Signature[:], sigBytes[:65])
+		response.Signatures = append(response.Signatures, sig)
+	}
+
+	c.logger.Debug("Received query response",
+		zap.Int("results", len(response.Results)),
+		zap.Int("signatures", len(response.Signatures)))
+	return response, nil
+}
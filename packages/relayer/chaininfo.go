@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// chainInfo holds what the client learns once about the chain its RPC serves
+type chainInfo struct {
+	chainID *big.Int
+	signer  types.Signer // EIP-155 signer for chainID
+	txType  string       // Transaction type sent, auto resolved
+	l1Fee   string       // L1 fee model, auto resolved
+}
+
+// chain returns the client's chain info, reading it on first use and again after the RPC
+// recovers from an outage. A recovered RPC serving a different chain ID fails every call
+// until it is pointed back at the original chain.
+func (c *EVMClient) chain(ctx context.Context) (*chainInfo, error) {
+	c.chainMu.Lock()
+	defer c.chainMu.Unlock()
+
+	if c.chainInfo != nil && !c.chainStale.Load() {
+		return c.chainInfo, nil
+	}
+	// Cleared before the reads, so a reconnect while they run triggers another check
+	c.chainStale.Store(false)
+
+	chainID, err := c.client.ChainID(ctx)
+	if err != nil {
+		c.chainStale.Store(true)
+		return nil, classifyError(fmt.Errorf("failed to get chain ID: %v", err), ErrorKindRPC)
+	}
+	if c.chainInfo != nil && chainID.Cmp(c.chainInfo.chainID) != 0 {
+		c.chainStale.Store(true)
+		return nil, pipelineError(ErrorKindRPC,
+			fmt.Errorf("RPC now serves chain ID %s instead of %s", chainID, c.chainInfo.chainID))
+	}
+
+	txType := c.txType
+	if txType == TxTypeAuto || txType == "" {
+		header, err := c.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			c.chainStale.Store(true)
+			return nil, classifyError(fmt.Errorf("failed to detect transaction type: %v", err), ErrorKindRPC)
+		}
+		txType = TxTypeLegacy
+		if header.BaseFee != nil {
+			txType = TxTypeDynamic
+		}
+	}
+
+	l1Fee := c.l1FeeModel
+	if l1Fee == L1FeeAuto || l1Fee == "" {
+		if l1Fee, err = c.detectL1FeeModel(ctx); err != nil {
+			c.chainStale.Store(true)
+			return nil, classifyError(fmt.Errorf("failed to detect L1 fee model: %v", err), ErrorKindRPC)
+		}
+	}
+
+	if c.chainInfo == nil {
+		c.logger.Debug("Read chain info",
+			zap.String("chainID", chainID.String()),
+			zap.String("txType", txType),
+			zap.String("l1Fee", l1Fee))
+	} else {
+		c.logger.Info("Re-validated chain info after reconnect",
+			zap.String("chainID", chainID.String()),
+			zap.String("txType", txType),
+			zap.String("l1Fee", l1Fee))
+	}
+	c.chainInfo = &chainInfo{
+		chainID: chainID,
+		signer:  types.LatestSignerForChainID(chainID),
+		txType:  txType,
+		l1Fee:   l1Fee,
+	}
+	return c.chainInfo, nil
+}
+
+// invalidateChainInfo has the next call re-read the chain info; the breaker calls it when the
+// RPC recovers
+func (c *EVMClient) invalidateChainInfo() {
+	c.chainStale.Store(true)
+}
+
+// transactionType returns the type the client sends: the configured one, or for auto the
+// type detected from the latest block, dynamic-fee when it has a base fee
+func (c *EVMClient) transactionType(ctx context.Context) (string, error) {
+	info, err := c.chain(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.txType, nil
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"net"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+// Time allowed for each reachability check
+const checkConfigTimeout = 10 * time.Second
+
+// Selector of the registry lookup the relayer calls on a SafeRecoveryModule
+var getAztecRecoveryContractSelector = crypto.Keccak256([]byte("getAztecRecoveryContract(address)"))[:4]
+
+// checkReport prints a readiness report line by line and counts the failures
+type checkReport struct {
+	out      io.Writer
+	failures int
+	warnings int
+}
+
+func (c *checkReport) ok(format string, args ...any) {
+	fmt.Fprintf(c.out, "  ok    %s\n", fmt.Sprintf(format, args...))
+}
+
+func (c *checkReport) warn(format string, args ...any) {
+	c.warnings++
+	fmt.Fprintf(c.out, "  warn  %s\n", fmt.Sprintf(format, args...))
+}
+
+func (c *checkReport) fail(format string, args ...any) {
+	c.failures++
+	fmt.Fprintf(c.out, "  FAIL  %s\n", fmt.Sprintf(format, args...))
+}
+
+func (c *checkReport) section(name string) {
+	fmt.Fprintf(c.out, "\n%s\n", name)
+}
+
+// runCheckConfig verifies that the spy, every destination RPC, the signers and the target
+// contracts are usable with the current configuration. It leaves the state store alone so it
+// can run next to a live relayer.
+func runCheckConfig(ctx context.Context, config Config, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: relayer check-config")
+		return 2
+	}
+
+	report := &checkReport{out: os.Stdout}
+	fmt.Fprintln(report.out, "Relayer readiness report")
+
+	if config.VAASource == vaaSourceGossip {
+		report.section("Gossip")
+		checkGossip(report, config)
+	} else {
+		report.section("Spy")
+		checkSpy(ctx, report, config)
+	}
+
+	report.section("Signers")
+	accounts, backends, err := openSigners(config)
+	if err != nil {
+		report.fail("signers: %v", err)
+	} else {
+		defer backends.Close()
+		for _, account := range accounts {
+			report.ok("signer %s", account.Address().Hex())
+		}
+		if next, err := openNextSigners(config, accounts); err != nil {
+			report.fail("%v", err)
+		} else {
+			for _, account := range next {
+				report.ok("next signer %s (used once a key rotation starts)", account.Address().Hex())
+			}
+		}
+	}
+
+	report.section("Destinations")
+	if configured, err := loadChainFinality(config); err != nil {
+		report.fail("%v", err)
+	} else {
+		config.Destinations = configured
+	}
+	for _, dest := range config.Destinations {
+		if _, err := lookupFeeStrategy(dest.FeeStrategy); err != nil {
+			report.fail("destination %q: %v", dest.Name, err)
+		}
+	}
+	var destinations []*Destination
+	if accounts != nil {
+		destinations, err = connectDestinations(config, accounts)
+		if err != nil {
+			report.fail("%v", err)
+		}
+	}
+	chainIDs := make(map[uint64]string)
+	for _, dest := range destinations {
+		checkDestination(ctx, report, dest, chainIDs)
+	}
+
+	report.section("Tenants")
+	resolver, bindings, err := resolveENSTargets(ctx, &config)
+	if err != nil {
+		report.fail("%v", err)
+	} else if resolver != nil {
+		defer resolver.Close()
+		for _, binding := range bindings {
+			report.ok("tenant %q: target %s resolves to %s", binding.Tenant, binding.Name, binding.Address.Hex())
+		}
+	}
+	if destinations != nil && err == nil {
+		tenants, err := newTenants(config.Tenants, destinations, nil)
+		if err == nil {
+			err = loadCallFlows(config, tenants)
+		}
+		if err != nil {
+			report.fail("%v", err)
+		}
+		if err == nil && len(tenants) == 0 {
+			report.warn("no tenants configured; every VAA would be rejected")
+		}
+		for _, tenant := range tenants {
+			checkTenant(ctx, report, tenant)
+		}
+	}
+
+	if len(config.MaintenanceWindows) > 0 {
+		report.section("Maintenance windows")
+		windows, err := newMaintenanceWindows(config.MaintenanceWindows, config.Tenants)
+		if err != nil {
+			report.fail("%v", err)
+		}
+		for _, window := range windows {
+			if next, ok := window.schedule.next(time.Now().In(window.location)); ok {
+				report.ok("window %q: next opens %s for %s", window.Name, next.Format(time.RFC3339), window.Duration)
+			} else {
+				report.warn("window %q: schedule %q never opens", window.Name, window.Schedule)
+			}
+		}
+	}
+
+	if deferral, err := newCostDeferral(config); err != nil {
+		report.section("Cost deferral")
+		report.fail("%v", err)
+	} else if deferral != nil {
+		report.section("Cost deferral")
+		report.ok("VAAs over %s ETH deferred for up to %s", formatLimit(deferral.threshold, formatWei), deferral.deadline)
+		for _, version := range slices.Sorted(maps.Keys(deferral.byVersion)) {
+			report.ok("payload version %d: over %s ETH deferred", version, formatLimit(deferral.byVersion[version], formatWei))
+		}
+	}
+
+	fmt.Fprintln(report.out)
+	if report.failures > 0 {
+		fmt.Fprintf(report.out, "NOT READY: %d check(s) failed, %d warning(s)\n", report.failures, report.warnings)
+		return 1
+	}
+	fmt.Fprintf(report.out, "READY (%d warning(s))\n", report.warnings)
+	return 0
+}
+
+// checkSpy dials the spy, through the outbound proxy if any and over TLS when SPY_TLS is set,
+// and waits for the connection to come up
+func checkSpy(ctx context.Context, report *checkReport, config Config) {
+	ctx, cancel := context.WithTimeout(ctx, checkConfigTimeout)
+	defer cancel()
+
+	endpoint := config.SpyRPCHost
+	transport, _, err := spyCredentials(config)
+	if err != nil {
+		report.fail("%v", err)
+		return
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		report.fail("%v", err)
+		return
+	}
+	dial := proxyDialer(proxy, "https", (&net.Dialer{}).DialContext)
+	conn, err := grpc.DialContext(ctx, endpoint,
+		grpc.WithTransportCredentials(transport),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp", addr)
+		}),
+		grpc.WithBlock())
+	if err != nil {
+		report.fail("spy %s unreachable: %v", endpoint, err)
+		return
+	}
+	conn.Close()
+	report.ok("spy %s reachable", endpoint)
+}
+
+// checkGossip checks the gossip network settings and node key without joining the network,
+// which would need the gossip port a running relayer holds
+func checkGossip(report *checkReport, config Config) {
+	network, bootstrappers, err := gossipNetworkFor(config)
+	if err != nil {
+		report.fail("gossip network: %v", err)
+		return
+	}
+	report.ok("gossip network %s with %d bootstrap peers", network.ID, len(bootstrappers))
+
+	if config.GossipNodeKeyFile == "" {
+		report.warn("GOSSIP_NODE_KEY_FILE not set; the peer ID changes on every start")
+	} else if _, err := os.Stat(config.GossipNodeKeyFile); err != nil {
+		report.warn("gossip node key %s not found; it is created at start", config.GossipNodeKeyFile)
+	} else if _, err := loadGossipNodeKey(config); err != nil {
+		report.fail("%v", err)
+	} else {
+		report.ok("gossip node key %s", config.GossipNodeKeyFile)
+	}
+}
+
+// checkDestination reads the destination's chain ID and each signer's balance on it
+func checkDestination(ctx context.Context, report *checkReport, dest *Destination, chainIDs map[uint64]string) {
+	ctx, cancel := context.WithTimeout(ctx, checkConfigTimeout)
+	defer cancel()
+
+	// One batch request for the chain ID and every signer's balance
+	addresses := dest.client.GetAddresses()
+	chainID, balances, balanceErrs, err := dest.client.balancesAndChainID(ctx, addresses)
+	if err != nil {
+		report.fail("destination %q: RPC unreachable: %v", dest.Name, err)
+		return
+	}
+	dest.ChainID = chainID.Uint64()
+	if other, ok := chainIDs[dest.ChainID]; ok {
+		report.fail("destination %q: chain ID %d is also used by %q", dest.Name, dest.ChainID, other)
+	} else {
+		chainIDs[dest.ChainID] = dest.Name
+		report.ok("destination %q: RPC reachable, chain ID %d", dest.Name, dest.ChainID)
+	}
+	if err := dest.checkWormholeChainID(); err != nil {
+		report.fail("%v", err)
+	}
+	if dest.AttestationLog != "" {
+		code, err := dest.client.client.CodeAt(ctx, common.HexToAddress(dest.AttestationLog), nil)
+		switch {
+		case !common.IsHexAddress(dest.AttestationLog):
+			report.fail("destination %q: invalid attestation log address %q", dest.Name, dest.AttestationLog)
+		case err != nil:
+			report.fail("destination %q: attestation log: %v", dest.Name, err)
+		case len(code) == 0:
+			report.fail("destination %q: attestation log %s has no contract code", dest.Name, dest.AttestationLog)
+		default:
+			report.ok("destination %q: attestation log %s deployed", dest.Name, dest.AttestationLog)
+		}
+	}
+
+	for i, address := range addresses {
+		balance := balances[i]
+		if err := balanceErrs[i]; err != nil {
+			report.fail("destination %q: balance of %s: %v", dest.Name, address.Hex(), err)
+			continue
+		}
+		if balance.Sign() == 0 {
+			report.fail("destination %q: signer %s has no funds for gas", dest.Name, address.Hex())
+			continue
+		}
+		report.ok("destination %q: signer %s balance %s ETH", dest.Name, address.Hex(), formatWei(balance))
+	}
+}
+
+// checkTenant checks that the tenant's target is a deployed SafeRecoveryModule. The bytecode
+// must carry the selectors the relayer calls and the registry events it indexes, and a
+// registry lookup must succeed.
+func checkTenant(ctx context.Context, report *checkReport, tenant *Tenant) {
+	ctx, cancel := context.WithTimeout(ctx, checkConfigTimeout)
+	defer cancel()
+
+	if tenant.dest.ChainID == 0 {
+		report.fail("tenant %q: destination %q is unreachable", tenant.Name, tenant.Destination)
+		return
+	}
+
+	code, err := tenant.dest.client.client.CodeAt(ctx, tenant.target, nil)
+	if err != nil {
+		report.fail("tenant %q: reading code of %s: %v", tenant.Name, tenant.target.Hex(), err)
+		return
+	}
+	if len(code) == 0 {
+		report.fail("tenant %q: no contract deployed at %s", tenant.Name, tenant.target.Hex())
+		return
+	}
+	report.ok("tenant %q: contract deployed at %s", tenant.Name, tenant.target.Hex())
+
+	for _, function := range tenant.flowFunctions() {
+		if !bytes.Contains(code, crypto.Keccak256([]byte(function))[:4]) {
+			report.fail("tenant %q: contract has no %s function", tenant.Name, function)
+		}
+	}
+	for _, event := range []struct {
+		sig   string
+		topic common.Hash
+	}{
+		{aztecRecoveryContractSetEventSig, aztecRecoveryContractSetTopic},
+		{aztecRecoveryContractRemovedEventSig, aztecRecoveryContractRemovedTopic},
+	} {
+		if !bytes.Contains(code, event.topic.Bytes()) {
+			report.warn("tenant %q: contract does not emit %s", tenant.Name, event.sig)
+		}
+	}
+
+	// Looking up the zero Safe exercises the registry without depending on any registration
+	callData := append(append([]byte{}, getAztecRecoveryContractSelector...), make([]byte, 32)...)
+	result, err := tenant.dest.client.client.CallContract(ctx, ethereum.CallMsg{To: &tenant.target, Data: callData}, nil)
+	if err != nil {
+		report.fail("tenant %q: getAztecRecoveryContract(address) call failed: %v", tenant.Name, err)
+		return
+	}
+	if len(result) != 32 {
+		report.fail("tenant %q: getAztecRecoveryContract(address) returned %d bytes, want 32", tenant.Name, len(result))
+		return
+	}
+	report.ok("tenant %q: module ABI compatible", tenant.Name)
+}
+
+// formatWei renders a wei amount in ether with six decimals
+func formatWei(wei *big.Int) string {
+	ether := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return ether.Text('f', 6)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// loadCheckpoints reads the persisted per-emitter high-water marks; they tell where each
+// emitter's stream resumes after downtime
+func (r *Relayer) loadCheckpoints() error {
+	checkpoints, err := r.store.LoadCheckpoints()
+	if err != nil {
+		return err
+	}
+
+	r.checkpointsMu.Lock()
+	r.checkpoints = checkpoints
+	r.checkpointsMu.Unlock()
+
+	for _, cp := range checkpoints {
+		r.logger.Info("Resuming emitter from checkpoint",
+			zap.Uint16("emitterChain", cp.EmitterChain),
+			zap.String("emitter", cp.EmitterAddress),
+			zap.Uint64("lastSequence", cp.Sequence),
+			zap.Time("updatedAt", cp.UpdatedAt))
+	}
+	r.logger.Info("Loaded sequence checkpoints", zap.Int("count", len(checkpoints)))
+	return nil
+}
+
+// advanceCheckpoint raises the emitter's high-water mark to the VAA's sequence and persists it.
+// A jump of more than one sequence is logged, since the skipped VAAs were never seen and
+// need backfilling.
+func (r *Relayer) advanceCheckpoint(vaaData *VAAData) {
+	key := checkpointKey(vaaData.ChainID, vaaData.EmitterHex)
+
+	r.checkpointsMu.Lock()
+	previous, exists := r.checkpoints[key]
+	if exists && previous.Sequence >= vaaData.Sequence {
+		r.checkpointsMu.Unlock()
+		return
+	}
+	cp := Checkpoint{
+		EmitterChain:   vaaData.ChainID,
+		EmitterAddress: vaaData.EmitterHex,
+		Sequence:       vaaData.Sequence,
+		UpdatedAt:      time.Now(),
+	}
+	r.checkpoints[key] = cp
+	r.checkpointsMu.Unlock()
+
+	if exists && vaaData.Sequence > previous.Sequence+1 {
+		r.logger.Warn("Sequence gap for emitter, VAAs in between were not seen",
+			zap.String("emitter", vaaData.EmitterHex),
+			zap.Uint64("fromSequence", previous.Sequence+1),
+			zap.Uint64("toSequence", vaaData.Sequence-1))
+	}
+
+	if err := r.store.SaveCheckpoint(cp); err != nil {
+		r.logger.Error("Failed to persist checkpoint",
+			zap.String("emitter", vaaData.EmitterHex),
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.Error(err))
+	}
+}
+
+// Checkpoints returns a snapshot of the per-emitter high-water marks
+func (r *Relayer) Checkpoints() []Checkpoint {
+	r.checkpointsMu.Lock()
+	defer r.checkpointsMu.Unlock()
+
+	result := make([]Checkpoint, 0, len(r.checkpoints))
+	for _, cp := range r.checkpoints {
+		result = append(result, cp)
+	}
+	return result
+}
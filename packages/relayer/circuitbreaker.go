@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// circuitState is the state of a CircuitBreaker
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota // Calls flow normally
+	circuitOpen                       // RPC considered down, submissions wait
+)
+
+func (s circuitState) String() string {
+	if s == circuitOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// CircuitBreaker tracks consecutive EVM RPC failures. After threshold failures it opens:
+// submissions wait instead of each retrying against a dead RPC, and a prober checks the RPC
+// every cooldown until a call succeeds and the breaker closes again. A stalled RPC (answering,
+// but with a head block that no longer advances) also holds the breaker open until it moves.
+type CircuitBreaker struct {
+	name      string
+	mu        sync.Mutex
+	state     circuitState
+	failures  int
+	stalled   bool
+	openedAt  time.Time
+	closedCh  chan struct{} // Closed when the breaker closes, replaced on open
+	threshold int
+	cooldown  time.Duration
+	probe     func(ctx context.Context) error
+	onClose   func() // Called when the RPC recovers; must not block
+	logger    *zap.Logger
+}
+
+// NewCircuitBreaker creates a closed breaker for the named RPC endpoint
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	closedCh := make(chan struct{})
+	close(closedCh)
+	evmRPCCircuitOpen.WithLabelValues(name).Set(0)
+	evmRPCStalled.WithLabelValues(name).Set(0)
+	return &CircuitBreaker{
+		name:      name,
+		closedCh:  closedCh,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger.With(zap.String("component", "CircuitBreaker"), zap.String("endpoint", name)),
+	}
+}
+
+// setProbe sets the call the breaker uses to check whether the RPC has recovered
+func (b *CircuitBreaker) setProbe(probe func(ctx context.Context) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probe = probe
+}
+
+// setOnClose sets a callback run whenever the breaker closes again
+func (b *CircuitBreaker) setOnClose(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onClose = fn
+}
+
+// Record feeds the outcome of an RPC call into the breaker
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		// A stalled RPC answers calls; only a moving head block clears it
+		if b.state == circuitOpen && !b.stalled {
+			b.close("EVM RPC recovered, circuit closed")
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == circuitClosed && b.threshold > 0 && b.failures >= b.threshold {
+		b.open()
+		b.logger.Error("EVM RPC failing, circuit opened; submissions paused",
+			zap.Int("consecutiveFailures", b.failures),
+			zap.Error(err))
+		go b.runProbe(b.closedCh)
+	}
+}
+
+// SetStalled reports whether the RPC's head block has stopped advancing. While stalled the
+// breaker stays open; it closes once the stall clears and no failures are outstanding.
+func (b *CircuitBreaker) SetStalled(stalled bool, head uint64, since time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if stalled == b.stalled {
+		return
+	}
+	b.stalled = stalled
+	evmRPCStalled.WithLabelValues(b.name).Set(boolToFloat(stalled))
+
+	if stalled {
+		b.logger.Error("EVM RPC head block stalled; submissions paused",
+			zap.Uint64("headBlock", head),
+			zap.Duration("unchangedFor", since))
+		if b.state == circuitClosed {
+			b.open()
+		}
+		return
+	}
+
+	if b.state == circuitOpen && (b.threshold == 0 || b.failures < b.threshold) {
+		b.close("EVM RPC head block advancing again, circuit closed")
+		return
+	}
+	b.logger.Info("EVM RPC head block advancing again", zap.Uint64("headBlock", head))
+}
+
+// open trips the breaker; callers hold mu
+func (b *CircuitBreaker) open() {
+	b.state = circuitOpen
+	b.openedAt = time.Now()
+	b.closedCh = make(chan struct{})
+	evmRPCCircuitOpen.WithLabelValues(b.name).Set(1)
+}
+
+// close releases waiting submissions; callers hold mu
+func (b *CircuitBreaker) close(msg string) {
+	b.state = circuitClosed
+	close(b.closedCh)
+	evmRPCCircuitOpen.WithLabelValues(b.name).Set(0)
+	b.logger.Info(msg, zap.Duration("openFor", time.Since(b.openedAt)))
+	if b.onClose != nil {
+		b.onClose()
+	}
+}
+
+// runProbe checks the RPC every cooldown until the breaker closes
+func (b *CircuitBreaker) runProbe(closedCh chan struct{}) {
+	ticker := time.NewTicker(b.cooldown)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closedCh:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			probe := b.probe
+			b.mu.Unlock()
+			if probe == nil {
+				continue
+			}
+
+			// The probe's outcome reaches Record through the RPC transport
+			ctx, cancel := context.WithTimeout(context.Background(), b.cooldown)
+			if err := probe(ctx); err != nil {
+				b.logger.Debug("EVM RPC probe failed", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// Wait blocks while the breaker is open
+func (b *CircuitBreaker) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	closedCh := b.closedCh
+	b.mu.Unlock()
+
+	select {
+	case <-closedCh:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("EVM RPC circuit open: %v", ctx.Err())
+	}
+}
+
+// State returns the breaker state and the number of consecutive failures
+func (b *CircuitBreaker) State() (circuitState, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state, b.failures
+}
+
+// Stalled reports whether the RPC's head block is currently considered stalled
+func (b *CircuitBreaker) Stalled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stalled
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
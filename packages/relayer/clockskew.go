@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// checkVAATimestamp compares the VAA's timestamp with local time and with the destination's
+// latest block, warning when either differs by more than ClockSkewWarn. A VAA dated in the
+// future usually means a guardian (or this host) has a wrong clock. With DelayFutureVAAs the
+// submission waits until local time catches up with the VAA, bounded by FutureVAAMaxDelay.
+func (r *Relayer) checkVAATimestamp(ctx context.Context, dest *Destination, vaaData *VAAData) error {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	if r.config.ClockSkewWarn <= 0 {
+		return nil
+	}
+
+	vaaTime := vaaData.VAA.Timestamp
+	localSkew := vaaTime.Sub(time.Now())
+	vaaClockSkew.WithLabelValues("local").Set(localSkew.Seconds())
+	if localSkew > r.config.ClockSkewWarn {
+		log.Warn("VAA timestamp is ahead of local time",
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.Time("vaaTimestamp", vaaTime),
+			zap.Duration("skew", localSkew))
+	}
+
+	header, err := dest.client.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Debug("Failed to read destination head for clock check", zap.Error(err))
+	} else {
+		headTime := time.Unix(int64(header.Time), 0)
+		chainSkew := vaaTime.Sub(headTime)
+		vaaClockSkew.WithLabelValues(dest.Name).Set(chainSkew.Seconds())
+		if chainSkew > r.config.ClockSkewWarn {
+			log.Warn("VAA timestamp is ahead of the destination's latest block",
+				zap.Uint64("sequence", vaaData.Sequence),
+				zap.String("destination", dest.Name),
+				zap.Time("vaaTimestamp", vaaTime),
+				zap.Time("blockTimestamp", headTime),
+				zap.Duration("skew", chainSkew))
+		}
+		if hostSkew := time.Since(headTime); hostSkew < -r.config.ClockSkewWarn {
+			log.Warn("Local clock is behind the destination's latest block",
+				zap.String("destination", dest.Name),
+				zap.Time("blockTimestamp", headTime),
+				zap.Duration("skew", -hostSkew))
+		}
+	}
+
+	if !r.config.DelayFutureVAAs || localSkew <= 0 {
+		return nil
+	}
+	if localSkew > r.config.FutureVAAMaxDelay {
+		return fmt.Errorf("VAA timestamp %s is %s in the future, beyond FUTURE_VAA_MAX_DELAY",
+			vaaTime.UTC().Format(time.RFC3339), localSkew.Round(time.Second))
+	}
+
+	log.Info("Delaying future-dated VAA",
+		zap.Uint64("sequence", vaaData.Sequence),
+		zap.Duration("delay", localSkew))
+	timer := time.NewTimer(localSkew)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// command is a one-shot operation run instead of the relayer daemon
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, config Config, args []string) int // Returns the exit code
+}
+
+// commands lists the subcommands in the order printed by usage
+var commands = []command{
+	{name: "check-config", summary: "Verify the configuration end to end without starting the relayer", run: runCheckConfig},
+	{name: "simulate", summary: "Dry-run a VAA (hex) against its target contract without broadcasting", run: runSimulate},
+	{name: "submit", summary: "Relay one VAA from a file (or - for stdin) and wait for its receipt", run: runSubmit},
+	{name: "cancel", summary: "Clear a stuck nonce with a zero-value self-transfer at a bumped fee", run: runCancel},
+	{name: "query-safe", summary: "Prove a Safe's owners and threshold with a guardian-signed Wormhole query", run: runQuerySafe},
+	{name: "decode-vaa", summary: "Print a VAA (hex, file or - for stdin) and its recovery payload as JSON", run: runDecodeVAA},
+	{name: "verify-receipt", summary: "Check the signature of a relay receipt (JSON file or - for stdin)", run: runVerifyReceipt},
+	{name: "verify-audit-log", summary: "Check the hash chain of an audit log (AUDIT_LOG_FILE by default)", run: runVerifyAuditLog},
+}
+
+// runCommand runs the subcommand named by args[0] and returns the process exit code
+func runCommand(config Config, args []string) int {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(ctx, config, args[1:])
+		}
+	}
+
+	if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	}
+	printUsage()
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return 0
+	}
+	return 2
+}
+
+// printUsage lists the subcommands on stderr
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: relayer [command]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Without a command the relayer daemon starts. Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// openCommandRelayer builds a relayer for a one-shot command with destinations routed and each
+// tenant's emitter registry scanned from chain. State is kept in memory so the store of a
+// running daemon is left alone.
+func openCommandRelayer(ctx context.Context, config Config) (*Relayer, error) {
+	config.StateStore = StateStoreMemory
+	r, err := NewRelayer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.resolveDestinationChainIDs(ctx); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if err := r.indexTenants(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	for _, tenant := range r.tenants {
+		if err := tenant.loadRegisteredEmitters(ctx); err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to load registered emitters for tenant %q: %v", tenant.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// decodeVAAHex decodes a VAA given as hex, with or without a 0x prefix
+func decodeVAAHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	vaaBytes, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAA hex: %v", err)
+	}
+	return vaaBytes, nil
+}
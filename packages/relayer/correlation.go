@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// correlationKey carries the correlation ID of the VAA a context is processing
+type correlationKey struct{}
+
+// newCorrelationID generates the ID that ties together the log lines, metric exemplars and
+// store records of one VAA, from intake through every retry
+func newCorrelationID() string {
+	var b [8]byte
+	rand.Read(b[:]) // Never fails since Go 1.24
+	return hex.EncodeToString(b[:])
+}
+
+// withCorrelationID marks ctx as processing the VAA with the correlation ID
+func withCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// correlationIDFrom returns the correlation ID ctx was marked with, or ""
+func correlationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// withCorrelation adds the correlation ID to every line logged through the returned logger
+func withCorrelation(base *zap.Logger, id string) *zap.Logger {
+	if id == "" {
+		return base
+	}
+	return base.With(zap.String("correlationId", id))
+}
+
+// correlatedLogger returns base with the correlation ID of the VAA ctx is processing
+func correlatedLogger(ctx context.Context, base *zap.Logger) *zap.Logger {
+	return withCorrelation(base, correlationIDFrom(ctx))
+}
+
+// incWithExemplar increments counter, attaching the correlation ID as an exemplar so a
+// spike on a dashboard links to the VAA behind it
+func incWithExemplar(counter prometheus.Counter, id string) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && id != "" {
+		adder.AddWithExemplar(1, prometheus.Labels{"correlation_id": id})
+		return
+	}
+	counter.Inc()
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// cosignatureHeader carries the collected co-signer approvals on remote signer requests, so
+// the custody service holding the key can require them too
+const cosignatureHeader = "X-Relayer-Cosignatures"
+
+// CosignRequest asks a co-signer to approve a transaction before it is signed. Co-signers
+// decode the transaction themselves, VAA included for verify calls, and check it against
+// their own view of the guardian set and the Safe.
+type CosignRequest struct {
+	ChainID       *hexutil.Big   `json:"chainId"`
+	From          common.Address `json:"from"`
+	Transaction   hexutil.Bytes  `json:"transaction"` // Unsigned transaction, in its binary encoding
+	SigningHash   common.Hash    `json:"signingHash"` // Hash the sender's key will sign
+	Description   string         `json:"description"` // The VAA relayed, or what else the transaction is for
+	CorrelationID string         `json:"correlationId,omitempty"`
+}
+
+// CosignResponse is a co-signer's approval: its EIP-191 personal signature over the signing
+// hash. A co-signer refusing answers with a non-2xx status and an error message.
+type CosignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// cosigners collects a threshold of approvals from independent co-signer endpoints
+type cosigners struct {
+	urls      []string
+	approvers map[common.Address]bool // Keys co-signers approve with
+	threshold int
+	timeout   time.Duration
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// cosignedSigner only signs transactions a threshold of co-signers approved
+type cosignedSigner struct {
+	Signer
+	cosigners *cosigners
+}
+
+// newCosigners creates the co-signer set from COSIGNER_*, or returns nil when no co-signers
+// are configured
+func newCosigners(config Config) (*cosigners, error) {
+	if len(config.CosignerURLs) == 0 {
+		return nil, nil
+	}
+	approvers := make(map[common.Address]bool, len(config.CosignerAddresses))
+	for _, hexAddress := range config.CosignerAddresses {
+		if !common.IsHexAddress(hexAddress) {
+			return nil, fmt.Errorf("invalid co-signer address %q", hexAddress)
+		}
+		approvers[common.HexToAddress(hexAddress)] = true
+	}
+	if config.CosignerThreshold < 1 || config.CosignerThreshold > len(approvers) || config.CosignerThreshold > len(config.CosignerURLs) {
+		return nil, fmt.Errorf("COSIGNER_THRESHOLD must be between 1 and the number of co-signers (%d URLs, %d addresses)",
+			len(config.CosignerURLs), len(approvers))
+	}
+	for _, endpoint := range config.CosignerURLs {
+		if _, err := url.ParseRequestURI(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid co-signer URL %q", endpoint)
+		}
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	return &cosigners{
+		urls:      config.CosignerURLs,
+		approvers: approvers,
+		threshold: config.CosignerThreshold,
+		timeout:   config.CosignerTimeout,
+		client:    &http.Client{Transport: transport},
+		logger:    logger.With(zap.String("component", "Cosigners")),
+	}, nil
+}
+
+// SignTx collects the co-signers' approvals and then has the wrapped signer sign, passing the
+// approvals along to a remote signer
+func (s *cosignedSigner) SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error) {
+	approvals, err := s.cosigners.approve(ctx, s.Address(), tx, signer, description)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]string, len(approvals))
+	for i, approval := range approvals {
+		encoded[i] = approval.String()
+	}
+	header := http.Header{}
+	header.Set(cosignatureHeader, strings.Join(encoded, ","))
+	return s.Signer.SignTx(rpc.NewContextWithHeaders(ctx, header), tx, signer, description)
+}
+
+// cosignResult is one co-signer's answer
+type cosignResult struct {
+	endpoint string
+	approver common.Address
+	sig      hexutil.Bytes
+	err      error
+}
+
+// approve asks every co-signer in parallel and returns as soon as a threshold of distinct
+// configured approvers agreed, or fails once that can no longer happen
+func (c *cosigners) approve(ctx context.Context, from common.Address, tx *types.Transaction, signer types.Signer, description string) ([]hexutil.Bytes, error) {
+	unsigned, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	request := CosignRequest{
+		ChainID:       (*hexutil.Big)(signer.ChainID()),
+		From:          from,
+		Transaction:   unsigned,
+		SigningHash:   signer.Hash(tx),
+		Description:   description,
+		CorrelationID: correlationIDFrom(ctx),
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	results := make(chan cosignResult, len(c.urls))
+	for _, endpoint := range c.urls {
+		go func(endpoint string) {
+			approver, sig, err := c.ask(ctx, endpoint, body, request.SigningHash)
+			results <- cosignResult{endpoint: endpoint, approver: approver, sig: sig, err: err}
+		}(endpoint)
+	}
+
+	log := correlatedLogger(ctx, c.logger)
+	approved := make(map[common.Address]bool)
+	var approvals []hexutil.Bytes
+	var refusals []string
+	for range c.urls {
+		result := <-results
+		host := cosignerHost(result.endpoint)
+		switch {
+		case result.err != nil:
+			cosignResponses.WithLabelValues(host, "refused").Inc()
+			log.Warn("Co-signer did not approve transaction",
+				zap.String("cosigner", host),
+				zap.String("vaa", description),
+				zap.Error(result.err))
+			refusals = append(refusals, fmt.Sprintf("%s: %v", host, result.err))
+		case approved[result.approver]:
+			cosignResponses.WithLabelValues(host, "duplicate").Inc()
+			refusals = append(refusals, fmt.Sprintf("%s: approver %s already counted", host, result.approver.Hex()))
+		default:
+			cosignResponses.WithLabelValues(host, "approved").Inc()
+			approved[result.approver] = true
+			approvals = append(approvals, result.sig)
+		}
+		if len(approvals) >= c.threshold {
+			log.Info("Transaction co-signed",
+				zap.Int("approvals", len(approvals)),
+				zap.Int("threshold", c.threshold),
+				zap.String("signingHash", request.SigningHash.Hex()))
+			return approvals, nil
+		}
+		if len(c.urls)-len(refusals) < c.threshold {
+			break
+		}
+	}
+	return nil, fmt.Errorf("co-signing failed: %d of %d required approvals (%s)",
+		len(approvals), c.threshold, strings.Join(refusals, "; "))
+}
+
+// ask requests one co-signer's approval and checks it was made by a configured approver
+func (c *cosigners) ask(ctx context.Context, endpoint string, body []byte, signingHash common.Hash) (common.Address, hexutil.Bytes, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	var response CosignResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&response); err != nil && resp.StatusCode/100 == 2 {
+		return common.Address{}, nil, fmt.Errorf("invalid response: %v", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		if response.Error != "" {
+			return common.Address{}, nil, fmt.Errorf("refused: %s", response.Error)
+		}
+		return common.Address{}, nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if len(response.Signature) != crypto.SignatureLength {
+		return common.Address{}, nil, fmt.Errorf("signature is %d bytes, want %d", len(response.Signature), crypto.SignatureLength)
+	}
+	sig := append([]byte{}, response.Signature...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(signingHash.Bytes()), sig)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	approver := crypto.PubkeyToAddress(*pub)
+	if !c.approvers[approver] {
+		return common.Address{}, nil, fmt.Errorf("signed by %s, which is not a configured co-signer", approver.Hex())
+	}
+	return approver, response.Signature, nil
+}
+
+// cosignerHost names a co-signer by host in logs and metrics
+func cosignerHost(endpoint string) string {
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return endpoint
+}
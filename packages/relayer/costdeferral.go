@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// costDeferral holds back VAAs while relaying them would cost more than their threshold.
+// Deferred VAAs wait in the retry queue, parked like those over MAX_TX_COST, and are relayed
+// once fees allow it or their deadline passes, whichever comes first.
+type costDeferral struct {
+	threshold *big.Int           // Threshold of VAAs without one for their payload version, nil for none
+	byVersion map[uint8]*big.Int // Thresholds by payload version, nil for versions never deferred
+	deadline  time.Duration      // How long a VAA may be deferred before it is relayed regardless
+}
+
+// newCostDeferral parses the DEFER_COST_* settings, returning nil when no threshold is set
+func newCostDeferral(config Config) (*costDeferral, error) {
+	threshold, err := optionalLimit(config.DeferCostThreshold, parseEther)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEFER_COST_THRESHOLD: %v", err)
+	}
+	byVersion, err := parseCostThresholdByVersion(config.DeferCostThresholdByPayloadVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEFER_COST_THRESHOLD_BY_PAYLOAD_VERSION: %v", err)
+	}
+	if threshold == nil && len(byVersion) == 0 {
+		return nil, nil
+	}
+	if config.DeferDeadline <= 0 {
+		return nil, fmt.Errorf("DEFER_DEADLINE must be positive when a deferral threshold is set")
+	}
+	return &costDeferral{threshold: threshold, byVersion: byVersion, deadline: config.DeferDeadline}, nil
+}
+
+// parseCostThresholdByVersion parses "version:eth" pairs, e.g. "1:0.005,2:0", 0 meaning the
+// version is never deferred
+func parseCostThresholdByVersion(entries []string) (map[uint8]*big.Int, error) {
+	result := make(map[uint8]*big.Int, len(entries))
+	for _, entry := range entries {
+		versionStr, amount, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, want <version>:<eth>", entry)
+		}
+		version, err := strconv.ParseUint(strings.TrimSpace(versionStr), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload version in %q: %v", entry, err)
+		}
+		threshold, err := optionalLimit(strings.TrimSpace(amount), parseEther)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold in %q: %v", entry, err)
+		}
+		result[uint8(version)] = threshold
+	}
+	return result, nil
+}
+
+// thresholdFor returns the cost over which a VAA with the payload is deferred, or nil
+func (d *costDeferral) thresholdFor(payload *RecoveryPayload) *big.Int {
+	if d == nil {
+		return nil
+	}
+	if threshold, ok := d.byVersion[payload.Version]; ok {
+		return threshold
+	}
+	return d.threshold
+}
+
+// costThresholdKey marks a context whose transactions are deferred above a cost
+type costThresholdKey struct{}
+
+// costThresholdFrom returns the threshold set by withCostThreshold, or nil
+func costThresholdFrom(ctx context.Context) *big.Int {
+	threshold, _ := ctx.Value(costThresholdKey{}).(*big.Int)
+	return threshold
+}
+
+// withCostThreshold marks ctx with the VAA's deferral threshold, so the transactions relaying
+// it are refused while they would cost more. Priority VAAs are never deferred, nor are VAAs
+// whose deadline has passed.
+func (r *Relayer) withCostThreshold(ctx context.Context, vaaData *VAAData) context.Context {
+	threshold := r.costDeferral.thresholdFor(vaaData.Payload)
+	if threshold == nil || vaaData.Priority {
+		return ctx
+	}
+	key := r.dedupeKey(vaaData.RawBytes)
+	r.retriesMu.Lock()
+	deferredAt := r.retries[key].DeferredAt
+	r.retriesMu.Unlock()
+	if !deferredAt.IsZero() && time.Since(deferredAt) >= r.costDeferral.deadline {
+		withCorrelation(r.logger, vaaData.CorrelationID).Warn("Deferral deadline passed, relaying VAA regardless of cost",
+			zap.String("vaaHash", key),
+			zap.Time("deferredAt", deferredAt),
+			zap.String("threshold", formatWei(threshold)))
+		costDeferralExpiries.Inc()
+		return ctx
+	}
+	return context.WithValue(ctx, costThresholdKey{}, threshold)
+}
+
+// checkCostThreshold defers a transaction that could cost more than the threshold its VAA was
+// marked with
+func checkCostThreshold(ctx context.Context, cost *big.Int) error {
+	threshold := costThresholdFrom(ctx)
+	if threshold == nil || cost.Cmp(threshold) <= 0 {
+		return nil
+	}
+	return pipelineError(ErrorKindCostDeferred, fmt.Errorf("transaction could cost %s ETH, over the deferral threshold of %s ETH",
+		formatWei(cost), formatWei(threshold)))
+}
+
+// deferUntil returns when a deferred entry is checked again: after recheck, or at its deadline
+// if that comes first
+func (d *costDeferral) deferUntil(entry RetryEntry, now time.Time, recheck time.Duration) time.Time {
+	next := now.Add(recheck)
+	if d == nil {
+		return next
+	}
+	if deadline := entry.DeferredAt.Add(d.deadline); deadline.Before(next) {
+		return deadline
+	}
+	return next
+}
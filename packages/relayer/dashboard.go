@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// dashboardPage is the operator dashboard, a single page polling the admin API
+//
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// Number of VAA outcomes kept for the dashboard
+const maxRecordedVAAs = 200
+
+// Number of confirmed relays the dashboard lists
+const dashboardRelays = 25
+
+// vaaOutcome is how the relayer finished with one VAA
+type vaaOutcome struct {
+	Time          time.Time `json:"time"`
+	Result        string    `json:"result"` // relayed, rejected or failed
+	Tenant        string    `json:"tenant"`
+	Emitter       string    `json:"emitter"`
+	Sequence      uint64    `json:"sequence"`
+	Detail        string    `json:"detail"` // Transaction hash, rejection reason or error kind
+	CorrelationID string    `json:"correlationId"`
+}
+
+// vaaOutcomes keeps the latest VAA outcomes and the totals per result since startup
+type vaaOutcomes struct {
+	mu       sync.Mutex
+	outcomes []vaaOutcome
+	totals   map[string]uint64
+}
+
+// record adds the outcome of a VAA
+func (o *vaaOutcomes) record(vaaData *VAAData, result, detail string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.totals == nil {
+		o.totals = make(map[string]uint64)
+	}
+	o.totals[result]++
+	o.outcomes = append(o.outcomes, vaaOutcome{
+		Time:          time.Now(),
+		Result:        result,
+		Tenant:        tenantLabel(vaaData),
+		Emitter:       vaaData.EmitterHex,
+		Sequence:      vaaData.Sequence,
+		Detail:        detail,
+		CorrelationID: vaaData.CorrelationID,
+	})
+	if len(o.outcomes) > maxRecordedVAAs {
+		o.outcomes = o.outcomes[len(o.outcomes)-maxRecordedVAAs:]
+	}
+}
+
+// snapshot returns the kept outcomes, newest first, and the totals
+func (o *vaaOutcomes) snapshot() ([]vaaOutcome, map[string]uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	outcomes := make([]vaaOutcome, len(o.outcomes))
+	for i, outcome := range o.outcomes {
+		outcomes[len(outcomes)-1-i] = outcome
+	}
+	totals := map[string]uint64{"relayed": 0, "rejected": 0, "failed": 0}
+	for result, count := range o.totals {
+		totals[result] = count
+	}
+	return outcomes, totals
+}
+
+// signerBalances reads the balance of each of the client's signers in one batch request
+func (c *EVMClient) signerBalances(ctx context.Context) ([]map[string]any, error) {
+	addresses := c.GetAddresses()
+	balances := make([]hexutil.Big, len(addresses))
+	calls := make([]rpc.BatchElem, len(addresses))
+	for i, address := range addresses {
+		calls[i] = rpc.BatchElem{Method: "eth_getBalance", Args: []any{address, "latest"}, Result: &balances[i]}
+	}
+	if err := c.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]any, 0, len(addresses))
+	for i, address := range addresses {
+		signer := map[string]any{"address": address.Hex()}
+		if calls[i].Error != nil {
+			signer["error"] = calls[i].Error.Error()
+		} else {
+			signer["balanceWei"] = balances[i].ToInt().String()
+			signer["balanceEth"] = weiToFloat(balances[i].ToInt())
+		}
+		result = append(result, signer)
+	}
+	return result, nil
+}
+
+// handleDashboardPage serves the dashboard. The page holds no data itself; it reads the admin
+// API with the credentials entered into it, so it is served without any.
+func (s *AdminServer) handleDashboardPage(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy",
+		"default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; frame-ancestors 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(dashboardPage)
+}
+
+// handleDashboard reports what the dashboard shows besides the retry queue and pending
+// transactions: health, VAA outcomes, signer balances, registered emitters and recent relays
+func (s *AdminServer) handleDashboard(w http.ResponseWriter, req *http.Request) {
+	r := s.relayer
+	ctx, cancel := context.WithTimeout(req.Context(), 10*time.Second)
+	defer cancel()
+
+	destinations := make([]map[string]any, 0, len(r.destinations))
+	for _, dest := range r.destinations {
+		state, _ := dest.client.breaker.State()
+		entry := map[string]any{
+			"name":    dest.Name,
+			"chainId": dest.ChainID,
+			"circuit": state.String(),
+			"stalled": dest.client.breaker.Stalled(),
+		}
+		signers, err := dest.client.signerBalances(ctx)
+		if err != nil {
+			entry["error"] = err.Error()
+		} else {
+			entry["signers"] = signers
+		}
+		destinations = append(destinations, entry)
+	}
+
+	tenants := make([]map[string]any, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		type emitterView struct {
+			AztecContract string         `json:"aztecContract"`
+			Safe          common.Address `json:"safe"`
+		}
+		tenant.emittersMu.RLock()
+		emitters := make([]emitterView, 0, len(tenant.registeredEmitters))
+		for aztecContract, safe := range tenant.registeredEmitters {
+			emitters = append(emitters, emitterView{AztecContract: aztecContract, Safe: safe})
+		}
+		tenant.emittersMu.RUnlock()
+		tenants = append(tenants, map[string]any{
+			"name":           tenant.Name,
+			"destination":    tenant.Destination,
+			"targetContract": tenant.TargetContract,
+			"emitters":       emitters,
+			"overrides":      tenant.emitterOverrides(),
+		})
+	}
+	configured := make([]EmitterConfig, 0, len(r.emitters))
+	for _, emitter := range r.emitters {
+		configured = append(configured, emitter.EmitterConfig)
+	}
+
+	now := time.Now()
+	relays, err := r.store.RelayRecords(nil, now.Add(-24*time.Hour), now)
+	if err != nil {
+		s.logger.Warn("Failed to read relay records for the dashboard", zap.Error(err))
+	}
+	if len(relays) > dashboardRelays {
+		relays = relays[len(relays)-dashboardRelays:]
+	}
+	for i := range relays {
+		relays[i].VAA = nil
+		relays[i].Receipt = nil
+	}
+	if relays == nil {
+		relays = []RelayRecord{}
+	}
+
+	r.rejectionsMu.Lock()
+	rejections := append([]VAARejection{}, r.rejections...)
+	r.rejectionsMu.Unlock()
+
+	outcomes, totals := r.recentVAAs.snapshot()
+	paused, _, queued := r.PauseState()
+	spy := map[string]any{"stale": r.streamStale.Load()}
+	if last := r.lastVAAReceived.Load(); last != 0 {
+		spy["lastMessageAgeSec"] = int64(time.Since(time.Unix(0, last)).Seconds())
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"generatedAt":        now.UTC().Format(time.RFC3339),
+		"paused":             paused,
+		"queued":             queued,
+		"spy":                spy,
+		"vaaTotals":          totals,
+		"vaas":               outcomes,
+		"destinations":       destinations,
+		"tenants":            tenants,
+		"configuredEmitters": configured,
+		"relays":             relays,
+		"rejections":         rejections,
+	})
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Build metadata, stamped with
+// -ldflags "-X main.version=<tag> -X main.gitCommit=<sha> -X main.buildTime=<RFC3339>"
+var (
+	version   = "dev"
+	gitCommit = ""
+	buildTime = ""
+)
+
+// Placeholder for secrets in diagnostics output
+const redacted = "<redacted>"
+
+// buildInfo returns the build metadata, falling back to the VCS stamp the Go toolchain embeds
+// when the ldflags were not set
+func buildInfo() map[string]string {
+	info := map[string]string{
+		"version":   version,
+		"gitCommit": gitCommit,
+		"buildTime": buildTime,
+		"goVersion": runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info["gitCommit"] == "":
+				info["gitCommit"] = setting.Value
+			case setting.Key == "vcs.time" && info["buildTime"] == "":
+				info["buildTime"] = setting.Value
+			case setting.Key == "vcs.modified" && setting.Value == "true":
+				info["dirty"] = "true"
+			}
+		}
+	}
+	return info
+}
+
+// redactURL keeps only the scheme and host of an endpoint; RPC providers put API keys in
+// the path, query or user info
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return redacted
+	}
+	if parsed.User == nil && (parsed.Path == "" || parsed.Path == "/") && parsed.RawQuery == "" {
+		return raw
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/" + redacted
+}
+
+// redactedConfig returns the effective configuration with keys removed and endpoint URLs
+// reduced to their host, keyed by Config field name
+func redactedConfig(config Config) map[string]any {
+	if config.PrivateKey != "" {
+		config.PrivateKey = redacted
+	}
+	if config.TreasuryPrivateKey != "" {
+		config.TreasuryPrivateKey = redacted
+	}
+	if config.ReceiptSigningKey != "" {
+		config.ReceiptSigningKey = redacted
+	}
+	if config.AdminJWTSecret != "" {
+		config.AdminJWTSecret = redacted
+	}
+	adminKeys := make([]string, len(config.AdminAPIKeys))
+	for i, entry := range config.AdminAPIKeys {
+		adminKeys[i] = redactAdminKeyEntry(entry)
+	}
+	config.AdminAPIKeys = adminKeys
+	if config.QueryAPIKey != "" {
+		config.QueryAPIKey = redacted
+	}
+	if config.SpyAuthToken != "" {
+		config.SpyAuthToken = redacted
+	}
+	if config.PushgatewayPassword != "" {
+		config.PushgatewayPassword = redacted
+	}
+	if config.FireblocksAPIKey != "" {
+		config.FireblocksAPIKey = redacted
+	}
+	if config.BiconomyOwnerKey != "" {
+		config.BiconomyOwnerKey = redacted
+	}
+	keys := make([]string, len(config.PrivateKeys))
+	for i := range keys {
+		keys[i] = redacted
+	}
+	config.PrivateKeys = keys
+	nextKeys := make([]string, len(config.NextPrivateKeys))
+	for i := range nextKeys {
+		nextKeys[i] = redacted
+	}
+	config.NextPrivateKeys = nextKeys
+	config.EVMRPCURL = redactURL(config.EVMRPCURL)
+	config.RemoteSignerURL = redactURL(config.RemoteSignerURL)
+	config.StatePostgresURL = redactURL(config.StatePostgresURL)
+	config.QueryServerURL = redactURL(config.QueryServerURL)
+	config.GuardianAPIURL = redactURL(config.GuardianAPIURL)
+	config.PushgatewayURL = redactURL(config.PushgatewayURL)
+	config.OutboundProxy = redactURL(config.OutboundProxy)
+	destinations := make([]DestinationConfig, len(config.Destinations))
+	for i, dest := range config.Destinations {
+		dest.RPCURL = redactURL(dest.RPCURL)
+		dest.BundlerURL = redactURL(dest.BundlerURL)
+		dest.PaymasterURL = redactURL(dest.PaymasterURL)
+		destinations[i] = dest
+	}
+	config.Destinations = destinations
+	cosignerURLs := make([]string, len(config.CosignerURLs))
+	for i, endpoint := range config.CosignerURLs {
+		cosignerURLs[i] = redactURL(endpoint)
+	}
+	config.CosignerURLs = cosignerURLs
+
+	// Exported fields only; durations are rendered as strings rather than nanoseconds
+	out := make(map[string]any)
+	value := reflect.ValueOf(config)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if d, ok := value.Field(i).Interface().(time.Duration); ok {
+			out[field.Name] = d.String()
+			continue
+		}
+		out[field.Name] = value.Field(i).Interface()
+	}
+	return out
+}
+
+// processedCount returns the number of VAAs in the dedupe window
+func (r *Relayer) processedCount() int {
+	r.dedupeMu.Lock()
+	defer r.dedupeMu.Unlock()
+	return r.processedVAAs.len()
+}
+
+// handleDebugInfo reports build metadata, the effective configuration with secrets redacted,
+// connection states and VAA counts, for incident triage
+func (s *AdminServer) handleDebugInfo(w http.ResponseWriter, req *http.Request) {
+	r := s.relayer
+
+	destinations := make([]map[string]any, 0, len(r.destinations))
+	for _, dest := range r.destinations {
+		state, failures := dest.client.breaker.State()
+		signers := make([]string, 0)
+		for _, address := range dest.client.GetAddresses() {
+			signers = append(signers, address.Hex())
+		}
+		destinations = append(destinations, map[string]any{
+			"name":                dest.Name,
+			"chainId":             dest.ChainID,
+			"circuit":             state.String(),
+			"consecutiveFailures": failures,
+			"signers":             signers,
+		})
+	}
+
+	tenants := make([]map[string]any, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		tenant.emittersMu.RLock()
+		emitters := len(tenant.registeredEmitters)
+		tenant.emittersMu.RUnlock()
+		tenants = append(tenants, map[string]any{
+			"name":               tenant.Name,
+			"destination":        tenant.Destination,
+			"targetContract":     tenant.TargetContract,
+			"registeredEmitters": emitters,
+		})
+	}
+
+	spy := map[string]any{
+		"endpoint": r.config.SpyRPCHost,
+		"tls":      r.config.SpyTLS,
+		"stale":    r.streamStale.Load(),
+	}
+	if r.spyClient != nil && r.spyClient.conn != nil {
+		spy["connection"] = r.spyClient.conn.GetState().String()
+	}
+	if r.gossip != nil {
+		spy["gossipNetwork"] = r.gossip.networkID
+		spy["gossipPeers"] = r.gossip.PeerCount()
+	}
+	if last := r.lastVAAReceived.Load(); last != 0 {
+		spy["lastMessage"] = time.Unix(0, last).UTC().Format(time.RFC3339)
+	}
+
+	guardians := map[string]any{}
+	if set := r.guardians.Current(); set != nil {
+		guardians["index"] = set.Index
+		guardians["guardians"] = len(set.Keys)
+		guardians["updatedAt"] = r.guardians.UpdatedAt().UTC().Format(time.RFC3339)
+	}
+
+	paused, _, queued := r.PauseState()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"build":        buildInfo(),
+		"config":       redactedConfig(r.config),
+		"spy":          spy,
+		"destinations": destinations,
+		"tenants":      tenants,
+		"ensTargets":   r.ens.snapshot(),
+		"guardianSet":  guardians,
+		"failover":     r.FailoverState(),
+		"vaas": map[string]any{
+			"inflight":  r.inflightCount(),
+			"processed": r.processedCount(),
+			"retrying":  len(r.Retries()),
+			"paused":    paused,
+			"queued":    queued,
+			"draining":  r.isDraining(),
+		},
+	})
+}
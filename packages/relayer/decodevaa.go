@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+)
+
+// decodedVAA is the JSON form of a VAA printed by decode-vaa
+type decodedVAA struct {
+	Version          uint8                   `json:"version"`
+	GuardianSetIndex uint32                  `json:"guardianSetIndex"`
+	Signatures       []decodedSignature      `json:"signatures"`
+	Timestamp        time.Time               `json:"timestamp"`
+	Nonce            uint32                  `json:"nonce"`
+	EmitterChain     uint16                  `json:"emitterChain"`
+	EmitterAddress   string                  `json:"emitterAddress"`
+	Sequence         uint64                  `json:"sequence"`
+	ConsistencyLevel uint8                   `json:"consistencyLevel"`
+	Digest           string                  `json:"digest"`
+	Payload          string                  `json:"payload"`
+	Recovery         *decodedRecoveryPayload `json:"recovery,omitempty"`
+	RecoveryError    string                  `json:"recoveryError,omitempty"`
+}
+
+// decodedSignature is a guardian signature in decode-vaa output
+type decodedSignature struct {
+	Index     uint8  `json:"index"`
+	Signature string `json:"signature"`
+}
+
+// decodedRecoveryPayload is the JSON form of a RecoveryPayload
+type decodedRecoveryPayload struct {
+	Version   uint8  `json:"version"`
+	TxID      string `json:"txId"`
+	Module    string `json:"module"`
+	ChainID   uint64 `json:"chainId"`
+	Safe      string `json:"safe"`
+	Candidate string `json:"candidate"`
+
+	// Signer of the payload's authorization signature, or why it doesn't verify, when its
+	// layout declares one
+	AuthorizationSigner string `json:"authorizationSigner,omitempty"`
+	AuthorizationError  string `json:"authorizationError,omitempty"`
+}
+
+// runDecodeVAA prints a VAA's header, guardian signatures and decoded recovery payload as JSON
+func runDecodeVAA(ctx context.Context, config Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: relayer decode-vaa <vaa-hex|vaa-file|->")
+		return 2
+	}
+
+	// The argument is the VAA itself when it is hex, otherwise a file to read it from
+	vaaBytes, err := decodeVAAHex(args[0])
+	if err != nil {
+		vaaBytes, err = readVAAFile(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse VAA: %v\n", err)
+		return 1
+	}
+	v := vaaData.VAA
+
+	out := decodedVAA{
+		Version:          v.Version,
+		GuardianSetIndex: v.GuardianSetIndex,
+		Signatures:       make([]decodedSignature, 0, len(v.Signatures)),
+		Timestamp:        v.Timestamp.UTC(),
+		Nonce:            v.Nonce,
+		EmitterChain:     vaaData.ChainID,
+		EmitterAddress:   v.EmitterAddress.String(),
+		Sequence:         v.Sequence,
+		ConsistencyLevel: v.ConsistencyLevel,
+		Digest:           v.SigningDigest().Hex(),
+		Payload:          fmt.Sprintf("0x%x", v.Payload),
+	}
+	for _, sig := range v.Signatures {
+		out.Signatures = append(out.Signatures, decodedSignature{
+			Index:     sig.Index,
+			Signature: fmt.Sprintf("0x%x", sig.Signature[:]),
+		})
+	}
+
+	if payload, err := sdk.DecodeRecoveryPayload(v.Payload); err != nil {
+		out.RecoveryError = err.Error()
+	} else {
+		out.Recovery = &decodedRecoveryPayload{
+			Version:   payload.Version,
+			TxID:      payload.TxID.Hex(),
+			Module:    payload.Module.Hex(),
+			ChainID:   payload.ChainID,
+			Safe:      payload.Safe.Hex(),
+			Candidate: payload.Candidate.Hex(),
+		}
+		if signer, checked, err := sdk.VerifyAuthorization(v.Payload); err != nil {
+			out.Recovery.AuthorizationError = err.Error()
+		} else if checked {
+			out.Recovery.AuthorizationSigner = signer.Hex()
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
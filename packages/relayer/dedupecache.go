@@ -0,0 +1,152 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dedupeExpiryInterval is how often expired dedupe entries are dropped from memory and the store
+const dedupeExpiryInterval = time.Minute
+
+// dedupeCache remembers the VAAs processed within the dedupe TTL, holding at most max entries.
+// When full, the least recently processed entry is evicted. Callers hold dedupeMu.
+type dedupeCache struct {
+	ttl     time.Duration
+	max     int                      // 0 for no bound
+	order   *list.List               // Most recently processed first
+	entries map[string]*list.Element // Dedupe key -> element holding a dedupeEntry
+}
+
+// dedupeEntry is a processed VAA and when it was processed
+type dedupeEntry struct {
+	key string
+	at  time.Time
+}
+
+// newDedupeCache creates an empty cache
+func newDedupeCache(ttl time.Duration, max int) *dedupeCache {
+	return &dedupeCache{
+		ttl:     ttl,
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// add records key as processed at the given time, evicting the least recently processed
+// entries beyond max
+func (c *dedupeCache) add(key string, at time.Time) {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*dedupeEntry)
+		if at.After(entry.at) {
+			entry.at = at
+		}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&dedupeEntry{key: key, at: at})
+
+	for c.max > 0 && c.order.Len() > c.max {
+		c.remove(c.order.Back())
+		dedupeEvictions.WithLabelValues("capacity").Inc()
+	}
+	dedupeEntries.Set(float64(c.order.Len()))
+}
+
+// addAll records entries loaded from the store, oldest first, so the newest survive eviction
+func (c *dedupeCache) addAll(processed map[string]time.Time) {
+	keys := make([]string, 0, len(processed))
+	for key := range processed {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return processed[keys[i]].Before(processed[keys[j]]) })
+	for _, key := range keys {
+		c.add(key, processed[key])
+	}
+}
+
+// seen reports whether key was processed within the TTL of now, dropping it if it expired
+func (c *dedupeCache) seen(key string, now time.Time) bool {
+	elem, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if now.Sub(elem.Value.(*dedupeEntry).at) < c.ttl {
+		return true
+	}
+	c.remove(elem)
+	dedupeEvictions.WithLabelValues("expired").Inc()
+	dedupeEntries.Set(float64(c.order.Len()))
+	return false
+}
+
+// contains reports whether key is in the cache, expired or not
+func (c *dedupeCache) contains(key string) bool {
+	_, ok := c.entries[key]
+	return ok
+}
+
+// forget drops key, so the VAA is handled again when next seen
+func (c *dedupeCache) forget(key string) {
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+		dedupeEntries.Set(float64(c.order.Len()))
+	}
+}
+
+// expire drops the entries processed before cutoff and returns how many it dropped
+func (c *dedupeCache) expire(cutoff time.Time) int {
+	dropped := 0
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*dedupeEntry).at.Before(cutoff) {
+			c.remove(elem)
+			dropped++
+		}
+		elem = prev
+	}
+	dedupeEvictions.WithLabelValues("expired").Add(float64(dropped))
+	dedupeEntries.Set(float64(c.order.Len()))
+	return dropped
+}
+
+// len returns the number of entries
+func (c *dedupeCache) len() int {
+	return c.order.Len()
+}
+
+// remove drops one element
+func (c *dedupeCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*dedupeEntry).key)
+}
+
+// expireDedupe drops dedupe entries past the TTL from memory and the store every
+// dedupeExpiryInterval until ctx is done
+func (r *Relayer) expireDedupe(ctx context.Context) {
+	ticker := time.NewTicker(dedupeExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cutoff := now.Add(-r.dedupeTTL)
+			r.dedupeMu.Lock()
+			dropped := r.processedVAAs.expire(cutoff)
+			r.dedupeMu.Unlock()
+
+			if err := r.store.PruneProcessed(cutoff); err != nil {
+				r.logger.Warn("Failed to prune dedupe entries", zap.Error(err))
+			}
+			if dropped > 0 {
+				r.logger.Debug("Expired dedupe entries", zap.Int("count", dropped))
+			}
+		}
+	}
+}
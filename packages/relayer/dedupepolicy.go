@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+)
+
+// Dedupe policy names
+const (
+	DedupePolicyBytes    = "bytes"
+	DedupePolicyDigest   = "digest"
+	DedupePolicySequence = "sequence"
+)
+
+// DedupePolicy decides which VAAs count as the same message, and how long and how many of them
+// are remembered
+type DedupePolicy struct {
+	Name string
+	// Default dedupe window and in-memory capacity, overridden by DEDUPE_TTL and DEDUPE_MAX_ENTRIES
+	TTL        time.Duration
+	MaxEntries int
+	// Key identifying the message in vaaBytes
+	key func(vaaBytes []byte) string
+}
+
+// dedupePolicies are the named policies operators can select
+var dedupePolicies = map[string]DedupePolicy{
+	// The same bytes: a VAA re-observed from the spy is a duplicate, but one re-signed by a
+	// different guardian quorum is not
+	DedupePolicyBytes: {
+		Name:       DedupePolicyBytes,
+		TTL:        15 * time.Minute,
+		MaxEntries: 100000,
+		key:        computeVAAKey,
+	},
+	// The same signed body, whatever the signatures
+	DedupePolicyDigest: {
+		Name:       DedupePolicyDigest,
+		TTL:        time.Hour,
+		MaxEntries: 100000,
+		key:        digestVAAKey,
+	},
+	// The same emitter chain, address and sequence. An emitter never reuses a sequence, so
+	// entries can be kept much longer.
+	DedupePolicySequence: {
+		Name:       DedupePolicySequence,
+		TTL:        24 * time.Hour,
+		MaxEntries: 250000,
+		key:        sequenceVAAKey,
+	},
+}
+
+// lookupDedupePolicy returns the named policy
+func lookupDedupePolicy(name string) (DedupePolicy, error) {
+	policy, ok := dedupePolicies[strings.ToLower(name)]
+	if !ok {
+		return DedupePolicy{}, fmt.Errorf("unknown dedupe policy %q (want %s, %s or %s)",
+			name, DedupePolicyBytes, DedupePolicyDigest, DedupePolicySequence)
+	}
+	return policy, nil
+}
+
+// dedupeKey returns the key the relayer's dedupe policy gives vaaBytes. Inflight records, the
+// retry queue and call flow progress are kept by the same key.
+func (r *Relayer) dedupeKey(vaaBytes []byte) string {
+	return r.dedupePolicy.key(vaaBytes)
+}
+
+// computeVAAKey hashes the full VAA bytes, signatures included
+func computeVAAKey(vaaBytes []byte) string {
+	hash := sha256.Sum256(vaaBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+// digestVAAKey returns the VAA's signing digest. Batch VAAs and VAAs that fail to parse are
+// keyed by their bytes.
+func digestVAAKey(vaaBytes []byte) string {
+	if isBatchVAA(vaaBytes) {
+		return computeVAAKey(vaaBytes)
+	}
+	parsed, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil {
+		return computeVAAKey(vaaBytes)
+	}
+	return "digest:" + hex.EncodeToString(parsed.SigningDigest().Bytes())
+}
+
+// sequenceVAAKey returns the VAA's message ID, chain/emitter/sequence. Batch VAAs and VAAs that
+// fail to parse are keyed by their bytes.
+func sequenceVAAKey(vaaBytes []byte) string {
+	if isBatchVAA(vaaBytes) {
+		return computeVAAKey(vaaBytes)
+	}
+	parsed, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil {
+		return computeVAAKey(vaaBytes)
+	}
+	return parsed.MessageID()
+}
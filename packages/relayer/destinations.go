@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DestinationConfig describes an EVM chain the relayer can deliver recovery VAAs to
+type DestinationConfig struct {
+	Name            string // Label used in logs
+	WormholeChainID uint16 // Wormhole chain ID of the destination
+	RPCURL          string // RPC URL for the chain
+	TargetContract  string // SafeRecoveryModule contract on the chain
+	FeeStrategy     string // Name of the fee strategy used on the chain
+	TxType          string // Transaction type sent on the chain: auto, legacy or dynamic
+	ScanStartBlock  int64  // First block scanned for the target contract's emitter registry events
+	WormholeCore    string // Wormhole core contract on the chain, for relay acknowledgments
+	AttestationLog  string // Contract each relay is recorded in, if any
+	Finality        string // Block tag the emitter registry is read up to: finalized, safe or latest
+	Confirmations   int    // Depth behind the head read up to with latest, or where the tag is unsupported
+	Submitter       string // Who submits verify calls: eoa (the signer pool) or biconomy
+	BundlerURL      string // Biconomy bundler endpoint, with the biconomy submitter
+	PaymasterURL    string // Biconomy paymaster endpoint, with the biconomy submitter
+	SmartAccount    string // Biconomy smart account verify calls are sent from
+	L1Fee           string // How the chain charges for L1 data: auto, none, optimism or arbitrum
+	GasStationURL   string // Fee endpoint whose tiers floor the RPC's fees, e.g. the Polygon gas station
+	MaxFeePerGas    string // Highest fee cap per gas in gwei, bumps included (0 for no limit)
+
+	// Finality of the chain's registry scans and transactions, alongside Finality and Confirmations
+	ReorgLookback  int           // Blocks behind the last one scanned that registry scans read again
+	ReceiptTimeout time.Duration // How long to wait for a transaction on the chain to be mined
+}
+
+// Destination is a configured chain with a connected client
+type Destination struct {
+	DestinationConfig
+	ChainID uint64 // EVM chain ID reported by the RPC
+	client  *EVMClient
+	sponsor *biconomySubmitter // Sends verify calls as sponsored user operations, if configured
+	// Fee strategy set through the admin API in place of FeeStrategy, if any
+	feeStrategyOverride atomic.Pointer[string]
+}
+
+// feeStrategyName returns the name of the destination's fee strategy
+func (d *Destination) feeStrategyName() string {
+	if name := d.feeStrategyOverride.Load(); name != nil {
+		return *name
+	}
+	return d.FeeStrategy
+}
+
+// loadDestinationsFromEnv builds the destination list. The primary destination comes from
+// EVM_RPC_URL/EVM_TARGET_CONTRACT/DEST_CHAIN_ID; DESTINATIONS lists extra chains by name,
+// each configured through DEST_<NAME>_RPC_URL, DEST_<NAME>_TARGET_CONTRACT and
+// DEST_<NAME>_WORMHOLE_CHAIN_ID, DEST_<NAME>_FEE_STRATEGY (defaults to the primary's strategy),
+// DEST_<NAME>_TX_TYPE (defaults to the primary's type), DEST_<NAME>_SCAN_START_BLOCK,
+// DEST_<NAME>_WORMHOLE_CORE, DEST_<NAME>_FINALITY and DEST_<NAME>_CONFIRMATIONS (default
+// to the primary's), and DEST_<NAME>_SUBMITTER with DEST_<NAME>_BICONOMY_* (default to eoa), and DEST_<NAME>_L1_FEE (default
+// to auto), DEST_<NAME>_GAS_STATION_URL, and DEST_<NAME>_MAX_FEE_PER_GAS,
+// DEST_<NAME>_REORG_LOOKBACK and DEST_<NAME>_RECEIPT_TIMEOUT (default to the primary's).
+func loadDestinationsFromEnv(primary DestinationConfig) []DestinationConfig {
+	destinations := []DestinationConfig{primary}
+
+	for _, name := range strings.Split(getEnvOrDefault("DESTINATIONS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "DEST_" + strings.ToUpper(name) + "_"
+		destinations = append(destinations, DestinationConfig{
+			Name:            name,
+			WormholeChainID: uint16(getEnvIntOrDefault(prefix+"WORMHOLE_CHAIN_ID", 0)),
+			RPCURL:          getEnvOrDefault(prefix+"RPC_URL", ""),
+			TargetContract:  getEnvOrDefault(prefix+"TARGET_CONTRACT", ""),
+			FeeStrategy:     getEnvOrDefault(prefix+"FEE_STRATEGY", primary.FeeStrategy),
+			TxType:          getEnvOrDefault(prefix+"TX_TYPE", primary.TxType),
+			ScanStartBlock:  int64(getEnvIntOrDefault(prefix+"SCAN_START_BLOCK", 0)),
+			WormholeCore:    getEnvOrDefault(prefix+"WORMHOLE_CORE", ""),
+			AttestationLog:  getEnvOrDefault(prefix+"ATTESTATION_LOG", ""),
+			Finality:        getEnvOrDefault(prefix+"FINALITY", primary.Finality),
+			Confirmations:   getEnvIntOrDefault(prefix+"CONFIRMATIONS", primary.Confirmations),
+			Submitter:       getEnvOrDefault(prefix+"SUBMITTER", SubmitterEOA),
+			BundlerURL:      getEnvOrDefault(prefix+"BICONOMY_BUNDLER_URL", ""),
+			PaymasterURL:    getEnvOrDefault(prefix+"BICONOMY_PAYMASTER_URL", ""),
+			SmartAccount:    getEnvOrDefault(prefix+"BICONOMY_SMART_ACCOUNT", ""),
+			L1Fee:           getEnvOrDefault(prefix+"L1_FEE", L1FeeAuto),
+			GasStationURL:   getEnvOrDefault(prefix+"GAS_STATION_URL", ""),
+			MaxFeePerGas:    getEnvOrDefault(prefix+"MAX_FEE_PER_GAS", primary.MaxFeePerGas),
+			ReorgLookback:   getEnvIntOrDefault(prefix+"REORG_LOOKBACK", primary.ReorgLookback),
+			ReceiptTimeout:  getEnvDurationOrDefault(prefix+"RECEIPT_TIMEOUT", primary.ReceiptTimeout),
+		})
+	}
+
+	return destinations
+}
+
+// connectDestinations creates an EVM client for every configured destination, each signing
+// with the given accounts. The first destination is the primary one.
+func connectDestinations(config Config, accounts []Signer) ([]*Destination, error) {
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	maxTxCost, err := parseEther(config.MaxTxCost)
+	if err != nil {
+		return nil, fmt.Errorf("MAX_TX_COST: %v", err)
+	}
+	if maxTxCost.Sign() == 0 {
+		maxTxCost = nil
+	}
+	destinations := make([]*Destination, 0, len(config.Destinations))
+	for _, cfg := range config.Destinations {
+		if cfg.RPCURL == "" {
+			return nil, fmt.Errorf("destination %q has no RPC URL", cfg.Name)
+		}
+		breaker := NewCircuitBreaker(cfg.Name, config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+		client, err := NewEVMClient(cfg.RPCURL, accounts, breaker, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("destination %q: %v", cfg.Name, err)
+		}
+		client.txType, _ = lookupTxType(cfg.TxType)
+		client.l1FeeModel, _ = lookupL1FeeModel(cfg.L1Fee)
+		client.gasPerPubdata = uint64(max(config.ZkSyncGasPerPubdata, 1))
+		if client.txType == TxTypeZkSync {
+			for _, account := range accounts {
+				if _, ok := account.(typedDataSigner); !ok {
+					return nil, fmt.Errorf("destination %q: signer %s cannot sign zkSync transactions (Ledger and co-signed accounts can't)",
+						cfg.Name, account.Address().Hex())
+				}
+			}
+		}
+		client.maxTxCost.Store(maxTxCost)
+		maxFeePerGas, err := parseGwei(cfg.MaxFeePerGas)
+		if err != nil {
+			return nil, fmt.Errorf("destination %q: MAX_FEE_PER_GAS: %v", cfg.Name, err)
+		}
+		if maxFeePerGas.Sign() > 0 {
+			client.maxFeePerGas.Store(maxFeePerGas)
+		}
+		client.gasStation, err = newGasStation(cfg.GasStationURL, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("destination %q: %v", cfg.Name, err)
+		}
+		client.logScanChunk = uint64(max(config.LogScanChunkSize, 1))
+		client.logScanBatch.Store(int64(max(config.LogScanBatchSize, 1)))
+		client.pollInterval = max(config.EmitterPollInterval, time.Second)
+		client.pollMaxInterval = max(config.EmitterPollMaxInterval, client.pollInterval)
+		client.pollMaxBlocks = uint64(max(config.EmitterPollMaxBlocks, 0))
+		client.finality, _ = lookupFinality(cfg.Finality)
+		client.confirmations = uint64(max(cfg.Confirmations, 0))
+		client.reorgLookback = uint64(max(cfg.ReorgLookback, 0))
+		sponsor, err := newBiconomySubmitter(config, cfg, client, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("destination %q: %v", cfg.Name, err)
+		}
+		destinations = append(destinations, &Destination{DestinationConfig: cfg, client: client, sponsor: sponsor})
+	}
+	return destinations, nil
+}
+
+// knownWormholeChainIDs maps the EVM chain IDs of chains Wormhole supports to their Wormhole
+// chain IDs, to catch destinations configured with the wrong one
+var knownWormholeChainIDs = map[uint64]uint16{
+	1:        2,     // Ethereum
+	56:       4,     // BNB Smart Chain
+	137:      5,     // Polygon
+	43114:    6,     // Avalanche
+	42220:    14,    // Celo
+	1284:     16,    // Moonbeam
+	42161:    23,    // Arbitrum
+	10:       24,    // Optimism
+	100:      25,    // Gnosis
+	8453:     30,    // Base
+	534352:   34,    // Scroll
+	5000:     35,    // Mantle
+	81457:    36,    // Blast
+	59144:    38,    // Linea
+	11155111: 10002, // Sepolia
+	421614:   10003, // Arbitrum Sepolia
+	84532:    10004, // Base Sepolia
+	11155420: 10005, // Optimism Sepolia
+	17000:    10006, // Holesky
+	80002:    10007, // Polygon Amoy
+}
+
+// checkWormholeChainID returns an error if the destination's Wormhole chain ID is known to
+// belong to another chain than the one its RPC serves
+func (d *Destination) checkWormholeChainID() error {
+	expected, ok := knownWormholeChainIDs[d.ChainID]
+	if !ok || d.WormholeChainID == expected {
+		return nil
+	}
+	return fmt.Errorf("destination %q is configured as Wormhole chain %d, but its RPC serves chain ID %d, which is Wormhole chain %d",
+		d.Name, d.WormholeChainID, d.ChainID, expected)
+}
+
+// checkPayloadChain returns an error unless the Wormhole chain ID a payload names, if any, is
+// the destination's
+func (d *Destination) checkPayloadChain(payload *RecoveryPayload) error {
+	if payload.WormholeChainID == 0 || payload.WormholeChainID == d.WormholeChainID {
+		return nil
+	}
+	return fmt.Errorf("destination chain mismatch: payload names Wormhole chain %d, but chain ID %d routes to destination %s, configured as Wormhole chain %d",
+		payload.WormholeChainID, payload.ChainID, d.Name, d.WormholeChainID)
+}
+
+// resolveDestinationChainIDs reads each destination's chain info and indexes them by EVM chain ID
+// for routing, refusing destinations configured with another chain's Wormhole chain ID
+func (r *Relayer) resolveDestinationChainIDs(ctx context.Context) error {
+	byChainID := make(map[uint64]*Destination, len(r.destinations))
+	for _, dest := range r.destinations {
+		chain, err := dest.client.chain(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read chain info for destination %q: %v", dest.Name, err)
+		}
+		dest.ChainID = chain.chainID.Uint64()
+		if err := dest.checkWormholeChainID(); err != nil {
+			return err
+		}
+
+		if existing, ok := byChainID[dest.ChainID]; ok {
+			return fmt.Errorf("destinations %q and %q both use chain ID %d", existing.Name, dest.Name, dest.ChainID)
+		}
+		byChainID[dest.ChainID] = dest
+
+		r.logger.Info("Destination ready",
+			zap.String("destination", dest.Name),
+			zap.Uint64("chainID", dest.ChainID),
+			zap.String("txType", chain.txType),
+			zap.String("l1Fee", chain.l1Fee),
+			zap.Uint16("wormholeChainID", dest.WormholeChainID),
+			zap.String("target", dest.TargetContract))
+	}
+	r.destinationsByChainID = byChainID
+	return nil
+}
+
+// destinationForChain returns the destination a payload's chain ID routes to
+func (r *Relayer) destinationForChain(chainID uint64) (*Destination, bool) {
+	dest, ok := r.destinationsByChainID[chainID]
+	return dest, ok
+}
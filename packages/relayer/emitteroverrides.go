@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// EmitterOverride is an operator's runtime change to a tenant's emitter registry, for when the
+// on-chain registry scan lags or is wrong. It takes precedence over the module's registration
+// of the same emitter, and either maps the emitter to a Safe, adding or replacing its
+// registration, or disables it. Overrides are stored apart from the scanned registry, so
+// registry events never undo them.
+type EmitterOverride struct {
+	Tenant    string         `json:"tenant"`
+	Emitter   string         `json:"emitter"` // Aztec contract, normalized by normalizeEmitter
+	Safe      common.Address `json:"safe"`    // Zero when disabled
+	Disabled  bool           `json:"disabled"`
+	Reason    string         `json:"reason"`
+	Principal string         `json:"principal"` // Admin API caller who set it
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// normalizeEmitter lowercases an emitter and strips any 0x prefix and leading zeros, the form
+// VAA emitters are compared in
+func normalizeEmitter(emitter string) string {
+	return strings.TrimLeft(strings.TrimPrefix(strings.ToLower(emitter), "0x"), "0")
+}
+
+// loadEmitterOverrides restores the tenant's overrides from the state store
+func (t *Tenant) loadEmitterOverrides() error {
+	overrides, err := t.store.LoadEmitterOverrides(t.Name)
+	if err != nil {
+		return err
+	}
+
+	t.emittersMu.Lock()
+	defer t.emittersMu.Unlock()
+	t.overrides = make(map[string]EmitterOverride, len(overrides))
+	for _, override := range overrides {
+		t.overrides[override.Emitter] = override
+	}
+	if len(overrides) > 0 {
+		t.logger.Warn("Emitter registry overrides in effect", zap.Int("count", len(overrides)))
+	}
+	return nil
+}
+
+// emitterOverride returns the override of the normalized emitter, if any
+func (t *Tenant) emitterOverride(normalizedEmitter string) (EmitterOverride, bool) {
+	t.emittersMu.RLock()
+	defer t.emittersMu.RUnlock()
+	override, ok := t.overrides[normalizedEmitter]
+	return override, ok
+}
+
+// emitterOverrides returns the tenant's overrides, by emitter
+func (t *Tenant) emitterOverrides() []EmitterOverride {
+	t.emittersMu.RLock()
+	defer t.emittersMu.RUnlock()
+	overrides := make([]EmitterOverride, 0, len(t.overrides))
+	for _, override := range t.overrides {
+		overrides = append(overrides, override)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Emitter < overrides[j].Emitter })
+	return overrides
+}
+
+// overrideTenant returns the tenant an override names; an empty name picks the only tenant,
+// if there is one
+func (r *Relayer) overrideTenant(name string) (*Tenant, error) {
+	if name == "" {
+		if len(r.tenants) == 1 {
+			return r.tenants[0], nil
+		}
+		return nil, fmt.Errorf("tenant is required with %d tenants", len(r.tenants))
+	}
+	if tenant := r.tenantNamed(name); tenant != nil {
+		return tenant, nil
+	}
+	return nil, fmt.Errorf("unknown tenant %q", name)
+}
+
+// OverrideEmitter maps the emitter to safe in the tenant's registry, or disables it, ahead of
+// whatever the module has registered. The override is stored before it takes effect.
+func (r *Relayer) OverrideEmitter(tenant *Tenant, emitter string, safe common.Address, disabled bool, reason, principal string) (EmitterOverride, error) {
+	override := EmitterOverride{
+		Tenant:    tenant.Name,
+		Emitter:   normalizeEmitter(emitter),
+		Safe:      safe,
+		Disabled:  disabled,
+		Reason:    reason,
+		Principal: principal,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if disabled == (safe != common.Address{}) {
+		return EmitterOverride{}, fmt.Errorf("an override either maps the emitter to a Safe or disables it")
+	}
+	if err := r.store.SaveEmitterOverride(override); err != nil {
+		return EmitterOverride{}, fmt.Errorf("failed to store override: %v", err)
+	}
+
+	tenant.emittersMu.Lock()
+	tenant.overrides[override.Emitter] = override
+	tenant.emittersMu.Unlock()
+
+	action := "mapped"
+	if disabled {
+		action = "disabled"
+	}
+	tenant.logger.Warn("Emitter registry overridden",
+		zap.String("emitter", override.Emitter),
+		zap.String("action", action),
+		zap.String("safeAddress", safe.Hex()),
+		zap.String("reason", reason),
+		zap.String("principal", principal))
+	r.audit.Record(AuditEmitterOverride, map[string]string{
+		"tenant":    tenant.Name,
+		"emitter":   override.Emitter,
+		"action":    action,
+		"safe":      safe.Hex(),
+		"reason":    reason,
+		"principal": principal,
+	})
+	return override, nil
+}
+
+// ClearEmitterOverride removes the override of the emitter, returning it to the module's
+// registry, and reports whether there was one
+func (r *Relayer) ClearEmitterOverride(tenant *Tenant, emitter, principal string) (bool, error) {
+	normalized := normalizeEmitter(emitter)
+	if _, ok := tenant.emitterOverride(normalized); !ok {
+		return false, nil
+	}
+	if err := r.store.RemoveEmitterOverride(tenant.Name, normalized); err != nil {
+		return false, fmt.Errorf("failed to remove stored override: %v", err)
+	}
+
+	tenant.emittersMu.Lock()
+	delete(tenant.overrides, normalized)
+	tenant.emittersMu.Unlock()
+
+	tenant.logger.Warn("Emitter registry override cleared",
+		zap.String("emitter", normalized),
+		zap.String("principal", principal))
+	r.audit.Record(AuditEmitterOverride, map[string]string{
+		"tenant":    tenant.Name,
+		"emitter":   normalized,
+		"action":    "cleared",
+		"principal": principal,
+	})
+	return true, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// emitterPollCatchUp is the interval between polls while the scan is more than
+// EMITTER_POLL_MAX_BLOCKS behind the final head
+const emitterPollCatchUp = time.Second
+
+// pollNewEmitters polls the module for registry events where they aren't subscribed to. Each
+// poll scans at most EMITTER_POLL_MAX_BLOCKS blocks past the last one scanned, up to the final
+// head. The interval drops back to EMITTER_POLL_INTERVAL when a poll finds events and doubles,
+// up to EMITTER_POLL_MAX_INTERVAL, while polls find none or fail; a scan that is behind polls
+// again right away. Intervals are jittered so tenants sharing an RPC don't poll in step.
+func (t *Tenant) pollNewEmitters(ctx context.Context) {
+	client := t.dest.client
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{t.target},
+		Topics:    emitterEventTopics,
+	}
+	lastBlock := uint64(max(t.scannedTo, t.ScanStartBlock))
+	interval := client.pollInterval
+
+	for {
+		emitterPollIntervalSeconds.WithLabelValues(t.Name).Set(interval.Seconds())
+		timer := time.NewTimer(jitter(interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		found, behind, err := t.pollEmitterEvents(ctx, query, &lastBlock)
+		switch {
+		case err != nil:
+			t.logger.Warn("Failed to poll for new emitters", zap.Error(err))
+			interval = min(interval*2, client.pollMaxInterval)
+		case behind:
+			interval = emitterPollCatchUp
+		case found:
+			interval = client.pollInterval
+		default:
+			interval = min(interval*2, client.pollMaxInterval)
+		}
+	}
+}
+
+// pollEmitterEvents applies the registry events after lastBlock, and those within the reorg
+// lookback again, advancing it and the saved scan cursor. It reports whether any new events were
+// found, and whether the final head is still ahead because the range was capped.
+func (t *Tenant) pollEmitterEvents(ctx context.Context, query ethereum.FilterQuery, lastBlock *uint64) (found, behind bool, err error) {
+	client := t.dest.client
+	head, err := client.finalHead(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if head <= *lastBlock {
+		return false, false, nil
+	}
+
+	to := head
+	if client.pollMaxBlocks > 0 && head-*lastBlock > client.pollMaxBlocks {
+		to = *lastBlock + client.pollMaxBlocks
+		behind = true
+	}
+	logs, err := client.filterLogsPaged(ctx, query, t.rescanFrom(*lastBlock), to)
+	if err != nil {
+		return false, false, err
+	}
+	for _, log := range logs {
+		t.handleEmitterEvent(log)
+		found = found || log.BlockNumber > *lastBlock
+	}
+
+	*lastBlock = to
+	t.saveScanCursor(to)
+	return found, behind, nil
+}
+
+// rescanFrom is the first block a scan resuming after lastBlock reads: the next one, or up to
+// the destination's reorg lookback before it, so events a reorg moved into blocks already
+// scanned are applied. Events are replayed in chain order, leaving each Safe's registration as
+// its latest event set it.
+func (t *Tenant) rescanFrom(lastBlock uint64) uint64 {
+	from := lastBlock + 1
+	lookback := min(t.dest.client.reorgLookback, from-min(from, uint64(t.ScanStartBlock)))
+	return from - lookback
+}
+
+// saveScanCursor persists the last block whose registry events were applied
+func (t *Tenant) saveScanCursor(block uint64) {
+	if err := t.store.SaveScanCursor(t.Name, block); err != nil {
+		t.logger.Error("Failed to persist registry scan cursor",
+			zap.Uint64("block", block),
+			zap.Error(err))
+	}
+}
+
+// jitter spreads d by up to 20% either way
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration((rand.Float64()*0.4-0.2)*float64(d))
+}
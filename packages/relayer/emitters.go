@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// EmitterConfig describes an Aztec contract whose messages are accepted for every tenant
+// without being in the tenant's emitter registry
+type EmitterConfig struct {
+	Name         string   // Label used in logs
+	Address      string   // Wormhole emitter address, hex (or the hex-encoded ASCII the emitter uses)
+	Destinations []string // Destinations its messages may be routed to (empty for all)
+}
+
+// configuredEmitter is a configured emitter with its address normalized for comparison
+type configuredEmitter struct {
+	EmitterConfig
+	normalized string
+}
+
+// loadEmittersFromEnv builds the configured emitter list. EMITTER_ADDRESS is an emitter named
+// "default" routed anywhere; EMITTERS lists further emitters by name, each configured through
+// EMITTER_<NAME>_ADDRESS and EMITTER_<NAME>_DESTINATIONS.
+func loadEmittersFromEnv() []EmitterConfig {
+	var emitters []EmitterConfig
+	if address := getEnvOrDefault("EMITTER_ADDRESS", ""); address != "" {
+		emitters = append(emitters, EmitterConfig{Name: "default", Address: address})
+	}
+
+	for _, name := range getEnvListOrDefault("EMITTERS", nil) {
+		prefix := "EMITTER_" + strings.ToUpper(name) + "_"
+		emitters = append(emitters, EmitterConfig{
+			Name:         name,
+			Address:      getEnvOrDefault(prefix+"ADDRESS", ""),
+			Destinations: getEnvListOrDefault(prefix+"DESTINATIONS", nil),
+		})
+	}
+
+	return emitters
+}
+
+// newConfiguredEmitters checks each configured emitter against the destinations it routes to
+func newConfiguredEmitters(configs []EmitterConfig, destinations []*Destination) ([]*configuredEmitter, error) {
+	known := make(map[string]bool, len(destinations))
+	for _, dest := range destinations {
+		known[dest.Name] = true
+	}
+
+	names := make(map[string]bool, len(configs))
+	emitters := make([]*configuredEmitter, 0, len(configs))
+	for _, cfg := range configs {
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("emitter %q is configured more than once", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("emitter %q has no address", cfg.Name)
+		}
+		for _, dest := range cfg.Destinations {
+			if !known[dest] {
+				return nil, fmt.Errorf("emitter %q routes to unknown destination %q", cfg.Name, dest)
+			}
+		}
+
+		emitters = append(emitters, &configuredEmitter{
+			EmitterConfig: cfg,
+			normalized:    strings.TrimLeft(strings.TrimPrefix(strings.ToLower(cfg.Address), "0x"), "0"),
+		})
+	}
+	return emitters, nil
+}
+
+// routesTo reports whether the emitter's messages may be delivered to the destination
+func (e *configuredEmitter) routesTo(destination string) bool {
+	return len(e.Destinations) == 0 || slices.Contains(e.Destinations, destination)
+}
+
+// configuredEmitter returns the configured emitter matching the VAA's emitter, if any
+func (r *Relayer) configuredEmitter(normalizedEmitter, decodedEmitter string) (*configuredEmitter, bool) {
+	for _, emitter := range r.emitters {
+		if normalizedEmitter == emitter.normalized || decodedEmitter == emitter.Address {
+			r.logger.Debug("Emitter matches configured Wormhole emitter",
+				zap.String("emitter", decodedEmitter),
+				zap.String("name", emitter.Name))
+			return emitter, true
+		}
+	}
+	return nil, false
+}
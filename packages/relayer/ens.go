@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+	"golang.org/x/net/idna"
+)
+
+// ENS registry, at the same address on mainnet and the ENS testnets
+const defaultENSRegistry = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+
+// Selectors of the two calls a name is resolved with
+var (
+	ensResolverSelector = crypto.Keccak256([]byte("resolver(bytes32)"))[:4]
+	ensAddrSelector     = crypto.Keccak256([]byte("addr(bytes32)"))[:4]
+)
+
+// ensProfile normalizes names the way ENS does for lookups (UTS-46 mapping, non-transitional)
+var ensProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
+// isENSName reports whether a configured address is an ENS name rather than a hex address
+func isENSName(value string) bool {
+	return !common.IsHexAddress(value) && strings.Contains(value, ".")
+}
+
+// normalizeENSName normalizes an ENS name, rejecting empty labels
+func normalizeENSName(name string) (string, error) {
+	normalized, err := ensProfile.ToUnicode(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid ENS name %q: %v", name, err)
+	}
+	for _, label := range strings.Split(normalized, ".") {
+		if label == "" {
+			return "", fmt.Errorf("invalid ENS name %q: empty label", name)
+		}
+	}
+	return normalized, nil
+}
+
+// ensNamehash computes the EIP-137 namehash of a normalized name
+func ensNamehash(name string) common.Hash {
+	var node common.Hash
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node[:], crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// ENSResolver resolves ENS names to addresses through the registry on ENS_RPC_URL
+type ENSResolver struct {
+	client   *ethclient.Client
+	registry common.Address
+}
+
+// newENSResolver connects to ENS_RPC_URL, or to EVM_RPC_URL when it is not set
+func newENSResolver(config Config) (*ENSResolver, error) {
+	if !common.IsHexAddress(config.ENSRegistry) {
+		return nil, fmt.Errorf("invalid ENS_REGISTRY %q", config.ENSRegistry)
+	}
+	rpcURL := config.ENSRPCURL
+	if rpcURL == "" {
+		rpcURL = config.EVMRPCURL
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	rpcClient, err := rpc.DialOptions(context.Background(), rpcURL,
+		rpc.WithHTTPClient(&http.Client{Timeout: 30 * time.Second, Transport: transport}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ENS RPC: %v", err)
+	}
+	return &ENSResolver{
+		client:   ethclient.NewClient(rpcClient),
+		registry: common.HexToAddress(config.ENSRegistry),
+	}, nil
+}
+
+// Close disconnects from the RPC
+func (e *ENSResolver) Close() {
+	e.client.Close()
+}
+
+// Resolve returns the address the name's resolver reports for it
+func (e *ENSResolver) Resolve(ctx context.Context, name string) (common.Address, error) {
+	normalized, err := normalizeENSName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	node := ensNamehash(normalized)
+
+	resolver, err := e.callAddress(ctx, e.registry, ensResolverSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to look up resolver of %s: %v", name, err)
+	}
+	if resolver == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ENS name %s has no resolver", name)
+	}
+	address, err := e.callAddress(ctx, resolver, ensAddrSelector, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ENS name %s resolves to no address", name)
+	}
+	return address, nil
+}
+
+// callAddress calls a function of one bytes32 argument returning an address
+func (e *ENSResolver) callAddress(ctx context.Context, contract common.Address, selector []byte, node common.Hash) (common.Address, error) {
+	data := append(append([]byte{}, selector...), node[:]...)
+	result, err := e.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(result) != 32 {
+		return common.Address{}, fmt.Errorf("unexpected %d-byte result", len(result))
+	}
+	return common.BytesToAddress(result[12:]), nil
+}
+
+// ensBinding is a tenant target configured as an ENS name and the address it resolved to at
+// startup
+type ensBinding struct {
+	Tenant   string         `json:"tenant"`
+	Name     string         `json:"name"`
+	Address  common.Address `json:"address"`
+	Current  common.Address `json:"current"` // Address at the last re-resolution
+	Checked  time.Time      `json:"checked,omitzero"`
+	LastErr  string         `json:"lastError,omitempty"`
+	Mismatch bool           `json:"mismatch"`
+}
+
+// resolveENSTargets replaces every tenant target configured as an ENS name with the address
+// it resolves to, returning the names resolved, or a nil resolver when there are none
+func resolveENSTargets(ctx context.Context, config *Config) (*ENSResolver, []ensBinding, error) {
+	var resolver *ENSResolver
+	var bindings []ensBinding
+	for i := range config.Tenants {
+		tenant := &config.Tenants[i]
+		if !isENSName(tenant.TargetContract) {
+			continue
+		}
+		if resolver == nil {
+			var err error
+			if resolver, err = newENSResolver(*config); err != nil {
+				return nil, nil, err
+			}
+		}
+		address, err := resolver.Resolve(ctx, tenant.TargetContract)
+		if err != nil {
+			resolver.Close()
+			return nil, nil, fmt.Errorf("tenant %q: %v", tenant.Name, err)
+		}
+		logger.Info("Resolved ENS target contract",
+			zap.String("tenant", tenant.Name),
+			zap.String("name", tenant.TargetContract),
+			zap.String("address", address.Hex()))
+		bindings = append(bindings, ensBinding{
+			Tenant:  tenant.Name,
+			Name:    tenant.TargetContract,
+			Address: address,
+			Current: address,
+			Checked: time.Now(),
+		})
+		tenant.TargetContract = address.Hex()
+	}
+	return resolver, bindings, nil
+}
+
+// ensTargets tracks the tenant targets configured as ENS names
+type ensTargets struct {
+	mu       sync.Mutex
+	resolver *ENSResolver
+	bindings []ensBinding
+}
+
+// snapshot returns the bindings as last checked
+func (t *ensTargets) snapshot() []ensBinding {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ensBinding{}, t.bindings...)
+}
+
+// watchENSTargets re-resolves the ENS targets every ENS_REFRESH_INTERVAL. A name that moves
+// to another module is reported but not followed: each module has its own registry and scan
+// cursor, so the relayer keeps serving the one it started with until it is restarted.
+func (r *Relayer) watchENSTargets(ctx context.Context) {
+	if r.ens == nil || r.config.ENSRefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.config.ENSRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.refreshENSTargets(ctx)
+	}
+}
+
+// refreshENSTargets re-resolves each ENS target once
+func (r *Relayer) refreshENSTargets(ctx context.Context) {
+	r.ens.mu.Lock()
+	defer r.ens.mu.Unlock()
+	for i := range r.ens.bindings {
+		binding := &r.ens.bindings[i]
+		callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		address, err := r.ens.resolver.Resolve(callCtx, binding.Name)
+		cancel()
+		binding.Checked = time.Now()
+		if err != nil {
+			binding.LastErr = err.Error()
+			r.logger.Warn("Failed to re-resolve ENS target contract",
+				zap.String("tenant", binding.Tenant),
+				zap.String("name", binding.Name),
+				zap.Error(err))
+			continue
+		}
+		binding.LastErr = ""
+		binding.Current = address
+		binding.Mismatch = address != binding.Address
+		if binding.Mismatch {
+			ensTargetMismatch.WithLabelValues(binding.Tenant).Set(1)
+			r.logger.Error("ENS target contract now resolves to another address; restart to relay to it",
+				zap.String("tenant", binding.Tenant),
+				zap.String("name", binding.Name),
+				zap.String("serving", binding.Address.Hex()),
+				zap.String("resolved", address.Hex()))
+		} else {
+			ensTargetMismatch.WithLabelValues(binding.Tenant).Set(0)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrorKind classifies a pipeline failure for metrics, the retry queue and alerting
+type ErrorKind string
+
+const (
+	ErrorKindSpy               ErrorKind = "spy"                // Spy or gossip subscription or stream failure
+	ErrorKindDecode            ErrorKind = "decode"             // VAA or payload could not be parsed
+	ErrorKindSimulationRevert  ErrorKind = "simulation_revert"  // verify reverts in eth_call or gas estimation
+	ErrorKindReverted          ErrorKind = "reverted"           // verify transaction mined but reverted
+	ErrorKindNonceConflict     ErrorKind = "nonce_conflict"     // Nonce kept colliding after fresh-nonce retries
+	ErrorKindInsufficientFunds ErrorKind = "insufficient_funds" // Signer cannot pay for gas
+	ErrorKindTimeout           ErrorKind = "timeout"            // A send, receipt or RPC deadline ran out
+	ErrorKindRPC               ErrorKind = "rpc"                // Any other EVM RPC failure
+	ErrorKindOutOfOrder        ErrorKind = "out_of_order"       // An earlier message for the same Safe is pending
+	ErrorKindNotSponsored      ErrorKind = "not_sponsored"      // Sponsored gas was refused and SPONSORSHIP_FALLBACK is reject
+	ErrorKindOverBudget        ErrorKind = "over_budget"        // The transaction would cost more than MAX_TX_COST
+	ErrorKindFeeCeiling        ErrorKind = "fee_ceiling"        // The fee cap, bumps included, is over MAX_FEE_PER_GAS
+	ErrorKindCostDeferred      ErrorKind = "cost_deferred"      // Over the VAA's deferral threshold, held until fees drop
+	ErrorKindFlowWait          ErrorKind = "flow_wait"          // A call flow step's wait has not elapsed; not a failure
+	ErrorKindUnknown           ErrorKind = "unknown"
+)
+
+// PipelineError is an error tagged with the kind of failure it represents
+type PipelineError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *PipelineError) Error() string { return e.Err.Error() }
+
+func (e *PipelineError) Unwrap() error { return e.Err }
+
+// Is matches another PipelineError of the same kind, so errors.Is(err, ErrTimeout) works
+func (e *PipelineError) Is(target error) bool {
+	t, ok := target.(*PipelineError)
+	return ok && t.Err == nil && t.Kind == e.Kind
+}
+
+// Sentinels for errors.Is checks against each kind
+var (
+	ErrSpy               = &PipelineError{Kind: ErrorKindSpy}
+	ErrDecode            = &PipelineError{Kind: ErrorKindDecode}
+	ErrSimulationRevert  = &PipelineError{Kind: ErrorKindSimulationRevert}
+	ErrReverted          = &PipelineError{Kind: ErrorKindReverted}
+	ErrNonceConflict     = &PipelineError{Kind: ErrorKindNonceConflict}
+	ErrInsufficientFunds = &PipelineError{Kind: ErrorKindInsufficientFunds}
+	ErrTimeout           = &PipelineError{Kind: ErrorKindTimeout}
+)
+
+// pipelineError tags err with kind, keeping an existing tag from further down the pipeline
+func pipelineError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var tagged *PipelineError
+	if errors.As(err, &tagged) {
+		return err
+	}
+	return &PipelineError{Kind: kind, Err: err}
+}
+
+// errorKindOf returns the kind err was tagged with, classifying untagged errors by their
+// cause (deadlines, node error messages) where that is unambiguous
+func errorKindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var tagged *PipelineError
+	if errors.As(err, &tagged) {
+		return tagged.Kind
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "insufficient funds"):
+		return ErrorKindInsufficientFunds
+	case strings.Contains(msg, "nonce too low"), strings.Contains(msg, "replacement transaction underpriced"):
+		return ErrorKindNonceConflict
+	case strings.Contains(msg, "execution reverted"):
+		return ErrorKindSimulationRevert
+	case strings.Contains(msg, "deadline exceeded"), strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return ErrorKindTimeout
+	}
+	return ErrorKindUnknown
+}
+
+// classifyError tags err with the kind errorKindOf finds, falling back to fallback
+func classifyError(err error, fallback ErrorKind) error {
+	if kind := errorKindOf(err); kind != ErrorKindUnknown && kind != "" {
+		return pipelineError(kind, err)
+	}
+	return pipelineError(fallback, err)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// parseGwei parses a decimal gwei amount such as "150" or "0.5" into wei. Digits past the
+// ninth decimal are dropped.
+func parseGwei(s string) (*big.Int, error) {
+	// Parsed as ETH, a gwei amount is 1e9 times its wei
+	scaled, err := parseEther(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gwei amount %q", s)
+	}
+	return scaled.Div(scaled, big.NewInt(1e9)), nil
+}
+
+// formatGwei formats wei as gwei for messages
+func formatGwei(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Text('f', -1)
+}
+
+// checkFeeCeiling refuses a transaction whose fee cap, bumps for replacing a conflicting
+// transaction included, is above the destination's MAX_FEE_PER_GAS
+func (c *EVMClient) checkFeeCeiling(tx *types.Transaction) error {
+	ceiling := c.maxFeePerGas.Load()
+	if ceiling == nil || tx.GasFeeCap().Cmp(ceiling) <= 0 {
+		return nil
+	}
+	return pipelineError(ErrorKindFeeCeiling, fmt.Errorf("fee cap of %s gwei is over MAX_FEE_PER_GAS of %s gwei",
+		formatGwei(tx.GasFeeCap()), formatGwei(ceiling)))
+}
+
+// parked reports whether a retry is held until fees come down rather than retried with
+// backoff: its last attempt was refused by MAX_FEE_PER_GAS or MAX_TX_COST, or deferred for
+// its cost
+func (e RetryEntry) parked() bool {
+	return e.ErrorKind == ErrorKindFeeCeiling || e.ErrorKind == ErrorKindOverBudget || e.deferred()
+}
+
+// deferred reports whether a retry is held because it was over its deferral threshold
+func (e RetryEntry) deferred() bool {
+	return e.ErrorKind == ErrorKindCostDeferred
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fee strategy names
+const (
+	FeeStrategySlow     = "slow"
+	FeeStrategyStandard = "standard"
+	FeeStrategyUrgent   = "urgent"
+)
+
+// Number of recent blocks sampled for priority fees
+const feeHistoryBlocks = 20
+
+// FeeStrategy controls how aggressively a transaction is priced
+type FeeStrategy struct {
+	Name string
+	// Percentile of recent priority fees to tip with
+	PriorityPercentile float64
+	// Headroom applied to the next block's base fee, in percent (200 = 2x)
+	BaseFeeMultiplierPct int64
+	// Multiplier applied to eth_gasPrice when fee history is unavailable, in percent
+	GasPriceMultiplierPct int64
+}
+
+// feeStrategies are the named strategies operators can select
+var feeStrategies = map[string]FeeStrategy{
+	FeeStrategySlow: {
+		Name:                  FeeStrategySlow,
+		PriorityPercentile:    25,
+		BaseFeeMultiplierPct:  110,
+		GasPriceMultiplierPct: 100,
+	},
+	FeeStrategyStandard: {
+		Name:                  FeeStrategyStandard,
+		PriorityPercentile:    50,
+		BaseFeeMultiplierPct:  150,
+		GasPriceMultiplierPct: 110,
+	},
+	FeeStrategyUrgent: {
+		Name:                  FeeStrategyUrgent,
+		PriorityPercentile:    90,
+		BaseFeeMultiplierPct:  200,
+		GasPriceMultiplierPct: 130,
+	},
+}
+
+// lookupFeeStrategy returns the named strategy
+func lookupFeeStrategy(name string) (FeeStrategy, error) {
+	strategy, ok := feeStrategies[strings.ToLower(name)]
+	if !ok {
+		return FeeStrategy{}, fmt.Errorf("unknown fee strategy %q (want %s, %s or %s)",
+			name, FeeStrategySlow, FeeStrategyStandard, FeeStrategyUrgent)
+	}
+	return strategy, nil
+}
+
+// parseFeeStrategyByVersion parses "version:strategy" pairs, e.g. "0:urgent,1:standard"
+func parseFeeStrategyByVersion(entries []string) (map[uint8]string, error) {
+	result := make(map[uint8]string, len(entries))
+	for _, entry := range entries {
+		versionStr, name, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, want <version>:<strategy>", entry)
+		}
+		version, err := strconv.ParseUint(strings.TrimSpace(versionStr), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload version in %q: %v", entry, err)
+		}
+		name = strings.TrimSpace(name)
+		if _, err := lookupFeeStrategy(name); err != nil {
+			return nil, err
+		}
+		result[uint8(version)] = name
+	}
+	return result, nil
+}
+
+// feeStrategyFor picks the strategy for a payload on a destination. A strategy configured
+// for the payload version wins over the destination's strategy.
+func (r *Relayer) feeStrategyFor(dest *Destination, payload *RecoveryPayload) FeeStrategy {
+	name := dest.feeStrategyName()
+	if byVersion, ok := r.config.FeeStrategyByPayloadVersion[payload.Version]; ok {
+		name = byVersion
+	}
+	strategy, err := lookupFeeStrategy(name)
+	if err != nil {
+		// Names are validated at startup; fall back rather than drop the VAA
+		return feeStrategies[FeeStrategyStandard]
+	}
+	return strategy
+}
+
+// EstimateGasPrice prices a legacy transaction with the given strategy: the next block's base fee
+// with headroom plus a tip at the strategy's percentile of recent priority fees. Chains without
+// eth_feeHistory fall back to a multiple of eth_gasPrice.
+func (c *EVMClient) EstimateGasPrice(ctx context.Context, strategy FeeStrategy) (*big.Int, error) {
+	_, gasPrice, err := c.EstimateFees(ctx, strategy)
+	return gasPrice, err
+}
+
+// EstimateFees prices a transaction with the given strategy, returning the priority tip and the
+// fee cap. The fee cap is the legacy gas price: the next block's base fee with headroom plus the
+// tip. Chains without eth_feeHistory fall back to a multiple of eth_gasPrice, tipping with
+// eth_maxPriorityFeePerGas where available. The lookups go out as one batch request. A
+// destination's gas station, if configured, raises the result to its tier for the strategy.
+func (c *EVMClient) EstimateFees(ctx context.Context, strategy FeeStrategy) (*big.Int, *big.Int, error) {
+	calls := newFeeCalls(strategy)
+	if err := c.batchCall(ctx, calls.elems); err != nil {
+		return nil, nil, err
+	}
+	tip, feeCap, err := calls.fees(c, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	tip, feeCap = c.withGasStation(ctx, strategy, tip, feeCap)
+	return tip, feeCap, nil
+}
+
+// mulPct returns v * pct / 100
+func mulPct(v *big.Int, pct int64) *big.Int {
+	result := new(big.Int).Mul(v, big.NewInt(pct))
+	return result.Div(result, big.NewInt(100))
+}
+
+// medianBig returns the median of values, or zero if there are none
+func medianBig(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+	sorted := make([]*big.Int, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	return new(big.Int).Set(sorted[len(sorted)/2])
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// Block tags a destination's emitter registry is read up to
+const (
+	FinalityFinalized = "finalized" // Blocks the consensus layer finalized
+	FinalitySafe      = "safe"      // Blocks justified by the consensus layer, unlikely to reorg
+	FinalityLatest    = "latest"    // The head, minus the confirmation depth
+)
+
+// lookupFinality validates a configured finality tag
+func lookupFinality(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case FinalityFinalized:
+		return FinalityFinalized, nil
+	case FinalitySafe:
+		return FinalitySafe, nil
+	case "", FinalityLatest:
+		return FinalityLatest, nil
+	default:
+		return "", fmt.Errorf("unknown finality %q (want %s, %s or %s)", name, FinalityFinalized, FinalitySafe, FinalityLatest)
+	}
+}
+
+// finalHead returns the newest block the client treats as final: the block with its finality
+// tag, or the head minus the confirmation depth on chains whose RPC doesn't know the tag (and
+// with the latest tag). An RPC that fails the tag but answers eth_blockNumber is taken not to
+// support it, and the depth is used from then on.
+func (c *EVMClient) finalHead(ctx context.Context) (uint64, error) {
+	if c.finality != FinalityLatest && !c.finalityUnsupported.Load() {
+		tag := rpc.FinalizedBlockNumber
+		if c.finality == FinalitySafe {
+			tag = rpc.SafeBlockNumber
+		}
+		header, tagErr := c.client.HeaderByNumber(ctx, big.NewInt(int64(tag)))
+		if tagErr == nil {
+			return header.Number.Uint64(), nil
+		}
+		if _, err := c.client.BlockNumber(ctx); err != nil {
+			return 0, fmt.Errorf("failed to get %s block: %v", c.finality, tagErr)
+		}
+		c.finalityUnsupported.Store(true)
+		c.logger.Warn("RPC does not support the finality tag, falling back to a confirmation depth",
+			zap.String("finality", c.finality),
+			zap.Uint64("confirmations", c.confirmations),
+			zap.Error(tagErr))
+	}
+
+	head, err := c.client.BlockNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current block: %v", err)
+	}
+	if head < c.confirmations {
+		return 0, nil
+	}
+	return head - c.confirmations, nil
+}
+
+// chainFinality is a destination's entry in CHAIN_FINALITY_FILE. Settings left out keep the
+// destination's environment values.
+type chainFinality struct {
+	Finality       *string `json:"finality"`
+	Confirmations  *int    `json:"confirmations"`
+	ReorgLookback  *int    `json:"reorgLookback"`
+	ReceiptTimeout *string `json:"receiptTimeout"`
+}
+
+// loadChainFinality returns the destinations with the finality settings of CHAIN_FINALITY_FILE
+// applied, a JSON object of destination name to its finality, confirmation depth, reorg lookback
+// and receipt timeout
+func loadChainFinality(config Config) ([]DestinationConfig, error) {
+	destinations := append([]DestinationConfig(nil), config.Destinations...)
+	path := config.ChainFinalityFile
+	if path == "" {
+		return destinations, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain finality: %v", err)
+	}
+	var byDestination map[string]chainFinality
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&byDestination); err != nil {
+		return nil, fmt.Errorf("failed to parse chain finality: %v", err)
+	}
+
+	for name, entry := range byDestination {
+		dest := -1
+		for i := range destinations {
+			if destinations[i].Name == name {
+				dest = i
+			}
+		}
+		if dest < 0 {
+			return nil, fmt.Errorf("chain finality for unknown destination %q", name)
+		}
+		if err := entry.apply(&destinations[dest]); err != nil {
+			return nil, fmt.Errorf("destination %q finality: %v", name, err)
+		}
+	}
+	return destinations, nil
+}
+
+// apply overrides the destination's settings with those the entry sets
+func (f chainFinality) apply(dest *DestinationConfig) error {
+	if f.Finality != nil {
+		dest.Finality = *f.Finality
+	}
+	if f.Confirmations != nil {
+		if *f.Confirmations < 0 {
+			return fmt.Errorf("confirmations must not be negative")
+		}
+		dest.Confirmations = *f.Confirmations
+	}
+	if f.ReorgLookback != nil {
+		if *f.ReorgLookback < 0 {
+			return fmt.Errorf("reorgLookback must not be negative")
+		}
+		dest.ReorgLookback = *f.ReorgLookback
+	}
+	if f.ReceiptTimeout != nil {
+		timeout, err := time.ParseDuration(*f.ReceiptTimeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid receiptTimeout %q", *f.ReceiptTimeout)
+		}
+		dest.ReceiptTimeout = timeout
+	}
+	return nil
+}
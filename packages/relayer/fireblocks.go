@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.uber.org/zap"
+)
+
+// fireblocksPollInterval is how often a pending signing request's status is checked
+const fireblocksPollInterval = 2 * time.Second
+
+// Fireblocks transaction statuses after which a signing request will never complete
+var fireblocksFailedStatuses = map[string]bool{
+	"FAILED":    true,
+	"REJECTED":  true,
+	"BLOCKED":   true,
+	"CANCELLED": true,
+	"TIMEOUT":   true,
+}
+
+// fireblocksSigner signs with a Fireblocks vault account through the raw signing API, so the
+// key stays in Fireblocks custody and every signature passes its transaction authorization
+// policy. The relayer still builds, nonces and broadcasts transactions itself.
+type fireblocksSigner struct {
+	baseURL string
+	apiKey  string
+	key     *rsa.PrivateKey // API user's secret key, signing each request's JWT
+	vaultID string
+	assetID string
+	address common.Address
+	client  *http.Client
+	logger  *zap.Logger
+}
+
+// fireblocksTx is the part of a Fireblocks transaction a signing request needs
+type fireblocksTx struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	SubStatus      string `json:"subStatus"`
+	SignedMessages []struct {
+		Signature struct {
+			R string `json:"r"`
+			S string `json:"s"`
+			V int    `json:"v"`
+		} `json:"signature"`
+	} `json:"signedMessages"`
+}
+
+// openFireblocksSigner creates the signer for the FIREBLOCKS_* vault account, or returns nil
+// when FIREBLOCKS_API_KEY is not set
+func openFireblocksSigner(config Config) (*fireblocksSigner, error) {
+	if config.FireblocksAPIKey == "" {
+		return nil, nil
+	}
+	if config.FireblocksSecretKeyFile == "" || config.FireblocksVaultAccountID == "" {
+		return nil, fmt.Errorf("FIREBLOCKS_API_KEY needs FIREBLOCKS_SECRET_KEY_FILE and FIREBLOCKS_VAULT_ACCOUNT_ID")
+	}
+	data, err := readSecretFile(config.FireblocksSecretKeyFile, config.AllowInsecureKeyFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Fireblocks secret key: %v", err)
+	}
+	key, err := parseRSAPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("Fireblocks secret key: %v", err)
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	s := &fireblocksSigner{
+		baseURL: strings.TrimRight(config.FireblocksAPIURL, "/"),
+		apiKey:  config.FireblocksAPIKey,
+		key:     key,
+		vaultID: config.FireblocksVaultAccountID,
+		assetID: config.FireblocksAssetID,
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		logger:  logger.With(zap.String("component", "FireblocksSigner")),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var addresses struct {
+		Addresses []struct {
+			Address string `json:"address"`
+		} `json:"addresses"`
+	}
+	path := fmt.Sprintf("/v1/vault/accounts/%s/%s/addresses_paginated",
+		url.PathEscape(s.vaultID), url.PathEscape(s.assetID))
+	if err := s.request(ctx, http.MethodGet, path, nil, &addresses); err != nil {
+		return nil, fmt.Errorf("failed to look up Fireblocks vault account address: %v", err)
+	}
+	if len(addresses.Addresses) == 0 || !common.IsHexAddress(addresses.Addresses[0].Address) {
+		return nil, fmt.Errorf("Fireblocks vault account %s has no %s address", s.vaultID, s.assetID)
+	}
+	s.address = common.HexToAddress(addresses.Addresses[0].Address)
+
+	s.logger.Info("Fireblocks signer ready",
+		zap.String("vaultAccount", s.vaultID),
+		zap.String("asset", s.assetID),
+		zap.String("address", s.address.Hex()))
+	return s, nil
+}
+
+// parseRSAPrivateKey parses a PEM-encoded PKCS#8 or PKCS#1 RSA private key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("not an RSA private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA private key")
+	}
+	return key, nil
+}
+
+func (s *fireblocksSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx submits the transaction's signing hash as a raw signing request, noting the VAA it
+// relays for the approvers, and waits for Fireblocks to sign it
+func (s *fireblocksSigner) SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error) {
+	note := fmt.Sprintf("Aztec Safe recovery relayer: %s; to %s, nonce %d, chain %s",
+		description, tx.To().Hex(), tx.Nonce(), signer.ChainID())
+	signature, id, err := s.signHash(ctx, signer.Hash(tx), note)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := tx.WithSignature(signer, signature)
+	if err != nil {
+		return nil, fmt.Errorf("Fireblocks returned an unusable signature: %v", err)
+	}
+	// Never broadcast a transaction from an account other than the vault's
+	if sender, err := types.Sender(signer, signed); err != nil || sender != s.address {
+		return nil, fmt.Errorf("Fireblocks signature for %s does not recover to %s", id, s.address.Hex())
+	}
+	return signed, nil
+}
+
+// SignTypedData signs the EIP-712 digest of data as a raw signing request
+func (s *fireblocksSigner) SignTypedData(ctx context.Context, data apitypes.TypedData, description string) ([]byte, error) {
+	digest, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, err
+	}
+	note := fmt.Sprintf("Aztec Safe recovery relayer: %s; EIP-712 %s for %s", description, data.PrimaryType, data.Domain.Name)
+	signature, _, err := s.signHash(ctx, common.BytesToHash(digest), note)
+	if err != nil {
+		return nil, err
+	}
+	signature[ethcrypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// signHash has Fireblocks sign hash, returning the signature with a recovery ID of 0 or 1 and
+// the Fireblocks transaction ID. The hash doubles as the request's external ID, so a retry
+// after a lost response picks up the earlier request.
+func (s *fireblocksSigner) signHash(ctx context.Context, hash common.Hash, note string) ([]byte, string, error) {
+	if id := correlationIDFrom(ctx); id != "" {
+		note += "; correlation ID " + id
+	}
+	request := map[string]any{
+		"operation":    "RAW",
+		"assetId":      s.assetID,
+		"source":       map[string]string{"type": "VAULT_ACCOUNT", "id": s.vaultID},
+		"note":         note,
+		"externalTxId": hash.Hex(),
+		"extraParameters": map[string]any{
+			"rawMessageData": map[string]any{
+				"messages": []map[string]string{{"content": hex.EncodeToString(hash.Bytes())}},
+			},
+		},
+	}
+
+	var created fireblocksTx
+	if err := s.request(ctx, http.MethodPost, "/v1/transactions", request, &created); err != nil {
+		if lookupErr := s.request(ctx, http.MethodGet, "/v1/transactions/external_tx_id/"+hash.Hex(), nil, &created); lookupErr != nil {
+			return nil, "", fmt.Errorf("Fireblocks signing request failed: %v", err)
+		}
+	}
+	s.logger.Info("Waiting for Fireblocks to sign",
+		zap.String("fireblocksTxId", created.ID),
+		zap.String("note", note))
+
+	result, err := s.waitForSignature(ctx, created.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	sig := result.SignedMessages[0].Signature
+	r, errR := hex.DecodeString(strings.TrimPrefix(sig.R, "0x"))
+	sv, errS := hex.DecodeString(strings.TrimPrefix(sig.S, "0x"))
+	if errR != nil || errS != nil || len(r) > 32 || len(sv) > 32 || (sig.V != 0 && sig.V != 1) {
+		return nil, "", fmt.Errorf("Fireblocks returned a malformed signature for %s", created.ID)
+	}
+	signature := append(common.LeftPadBytes(r, 32), common.LeftPadBytes(sv, 32)...)
+	return append(signature, byte(sig.V)), created.ID, nil
+}
+
+// waitForSignature polls a signing request until it is signed, fails or ctx expires
+func (s *fireblocksSigner) waitForSignature(ctx context.Context, id string) (*fireblocksTx, error) {
+	ticker := time.NewTicker(fireblocksPollInterval)
+	defer ticker.Stop()
+	for {
+		var tx fireblocksTx
+		if err := s.request(ctx, http.MethodGet, "/v1/transactions/"+url.PathEscape(id), nil, &tx); err != nil {
+			s.logger.Warn("Failed to check Fireblocks signing request", zap.String("fireblocksTxId", id), zap.Error(err))
+		} else if tx.Status == "COMPLETED" && len(tx.SignedMessages) > 0 {
+			return &tx, nil
+		} else if fireblocksFailedStatuses[tx.Status] {
+			return nil, fmt.Errorf("Fireblocks signing request %s %s (%s)", id, strings.ToLower(tx.Status), tx.SubStatus)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Fireblocks signing request %s not signed in time: %v", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// request calls the Fireblocks API, authenticating with the API key and a JWT over the URI and
+// body signed with the secret key
+func (s *fireblocksSigner) request(ctx context.Context, method, path string, body, out any) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	token, err := s.token(path, encoded)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", s.apiKey)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(content, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s (HTTP %d)", method, path, apiErr.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(content, out)
+}
+
+// token creates the short-lived RS256 JWT Fireblocks requires on every request
+func (s *fireblocksSigner) token(path string, body []byte) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	bodyHash := sha256.Sum256(body)
+	now := time.Now().Unix()
+	claims, err := json.Marshal(map[string]any{
+		"uri":      path,
+		"nonce":    hex.EncodeToString(nonce),
+		"iat":      now,
+		"exp":      now + 25,
+		"sub":      s.apiKey,
+		"bodyHash": hex.EncodeToString(bodyHash[:]),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	signingInput := encoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign Fireblocks request: %v", err)
+	}
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// How long a gas station reading is reused; the Polygon gas station updates every block
+const gasStationTTL = 5 * time.Second
+
+// gasStationTiers maps fee strategies to the gas station's tiers
+var gasStationTiers = map[string]string{
+	FeeStrategySlow:     "safeLow",
+	FeeStrategyStandard: "standard",
+	FeeStrategyUrgent:   "fast",
+}
+
+// gasStationFees are a tier's fees, in wei
+type gasStationFees struct {
+	tip    *big.Int
+	feeCap *big.Int
+}
+
+// gasStation reads fees from a chain's fee endpoint, such as the Polygon gas station
+// (https://gasstation.polygon.technology/v2). Tiers are objects with maxPriorityFee and maxFee
+// in gwei, or plain gwei gas prices as legacy endpoints report them.
+type gasStation struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	fetched time.Time
+	tiers   map[string]gasStationFees
+}
+
+// newGasStation creates the fee endpoint reader for rawURL, or returns nil when it is empty
+func newGasStation(rawURL string, proxy proxyFunc) (*gasStation, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid gas station URL %q", rawURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return &gasStation{
+		url:    rawURL,
+		client: &http.Client{Timeout: 5 * time.Second, Transport: transport},
+	}, nil
+}
+
+// fees returns the tier for strategy, fetching the endpoint when the last reading is stale
+func (g *gasStation) fees(ctx context.Context, strategy FeeStrategy) (gasStationFees, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.tiers == nil || time.Since(g.fetched) > gasStationTTL {
+		tiers, err := g.fetch(ctx)
+		if err != nil {
+			return gasStationFees{}, err
+		}
+		g.tiers, g.fetched = tiers, time.Now()
+	}
+	tier, ok := g.tiers[gasStationTiers[strategy.Name]]
+	if !ok {
+		return gasStationFees{}, fmt.Errorf("gas station has no %q tier", gasStationTiers[strategy.Name])
+	}
+	return tier, nil
+}
+
+// fetch reads every tier from the endpoint
+func (g *gasStation) fetch(ctx context.Context) (map[string]gasStationFees, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gas station request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gas station returned HTTP %d", resp.StatusCode)
+	}
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid gas station response: %v", err)
+	}
+
+	tiers := make(map[string]gasStationFees)
+	for _, name := range gasStationTiers {
+		raw, ok := body[name]
+		if !ok {
+			continue
+		}
+		tier, err := parseGasStationTier(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gas station %q tier: %v", name, err)
+		}
+		tiers[name] = tier
+	}
+	return tiers, nil
+}
+
+// parseGasStationTier parses a {"maxPriorityFee", "maxFee"} tier, or a plain gas price
+func parseGasStationTier(raw json.RawMessage) (gasStationFees, error) {
+	var dynamic struct {
+		MaxPriorityFee *json.Number `json:"maxPriorityFee"`
+		MaxFee         *json.Number `json:"maxFee"`
+	}
+	if err := json.Unmarshal(raw, &dynamic); err == nil {
+		if dynamic.MaxPriorityFee == nil || dynamic.MaxFee == nil {
+			return gasStationFees{}, fmt.Errorf("missing maxPriorityFee or maxFee")
+		}
+		tip, err := gweiToWei(*dynamic.MaxPriorityFee)
+		if err != nil {
+			return gasStationFees{}, err
+		}
+		feeCap, err := gweiToWei(*dynamic.MaxFee)
+		if err != nil {
+			return gasStationFees{}, err
+		}
+		return gasStationFees{tip: tip, feeCap: feeCap}, nil
+	}
+
+	var gasPrice json.Number
+	if err := json.Unmarshal(raw, &gasPrice); err != nil {
+		return gasStationFees{}, fmt.Errorf("want an object or a number")
+	}
+	price, err := gweiToWei(gasPrice)
+	if err != nil {
+		return gasStationFees{}, err
+	}
+	return gasStationFees{tip: price, feeCap: new(big.Int).Set(price)}, nil
+}
+
+// gweiToWei converts a gwei amount, which gas stations report as floats, to wei
+func gweiToWei(gwei json.Number) (*big.Int, error) {
+	value, err := strconv.ParseFloat(string(gwei), 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid gwei amount %q", gwei)
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(value), big.NewFloat(1e9)).Int(nil)
+	return wei, nil
+}
+
+// withGasStation raises the RPC's tip and fee cap to the destination's gas station tier for
+// strategy, where one is configured. The fee cap keeps its base fee headroom on top of a raised
+// tip. Gas station failures are logged and the RPC's fees used as they are.
+func (c *EVMClient) withGasStation(ctx context.Context, strategy FeeStrategy, tip, feeCap *big.Int) (*big.Int, *big.Int) {
+	if c.gasStation == nil {
+		return tip, feeCap
+	}
+	station, err := c.gasStation.fees(ctx, strategy)
+	if err != nil {
+		gasStationReadings.WithLabelValues(c.breaker.name, "error").Inc()
+		correlatedLogger(ctx, c.logger).Warn("Gas station unavailable, using RPC fees",
+			zap.String("strategy", strategy.Name),
+			zap.Error(err))
+		return tip, feeCap
+	}
+
+	raisedTip, raisedFeeCap := tip, feeCap
+	if station.tip.Cmp(tip) > 0 {
+		raisedTip = station.tip
+		raisedFeeCap = new(big.Int).Add(feeCap, new(big.Int).Sub(station.tip, tip))
+	}
+	if station.feeCap.Cmp(raisedFeeCap) > 0 {
+		raisedFeeCap = station.feeCap
+	}
+	if raisedTip == tip && raisedFeeCap == feeCap {
+		gasStationReadings.WithLabelValues(c.breaker.name, "unchanged").Inc()
+		return tip, feeCap
+	}
+
+	gasStationReadings.WithLabelValues(c.breaker.name, "raised").Inc()
+	c.logger.Debug("Fees raised to the gas station's",
+		zap.String("strategy", strategy.Name),
+		zap.String("rpcTip", tip.String()),
+		zap.String("rpcFeeCap", feeCap.String()),
+		zap.String("tip", raisedTip.String()),
+		zap.String("feeCap", raisedFeeCap.String()))
+	return raisedTip, raisedFeeCap
+}
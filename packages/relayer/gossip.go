@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
+	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
+	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// VAA sources selected with VAA_SOURCE
+const (
+	vaaSourceSpy    = "spy"
+	vaaSourceGossip = "gossip"
+)
+
+// gossipNetwork is a Wormhole guardian gossip network
+type gossipNetwork struct {
+	ID        string
+	Bootstrap string // Comma-separated bootstrap peer multiaddrs
+}
+
+// gossipNetworks are the networks selectable with GOSSIP_NETWORK, as configured in the guardian node
+var gossipNetworks = map[string]gossipNetwork{
+	"mainnet": {
+		ID: "/wormhole/mainnet/2",
+		Bootstrap: "/dns4/wormhole-v2-mainnet-bootstrap.xlabs.xyz/udp/8999/quic/p2p/12D3KooWNQ9tVrcb64tw6bNs2CaNrUGPM7yRrKvBBheQ5yCyPHKC," +
+			"/dns4/wormhole.mcf.rocks/udp/8999/quic/p2p/12D3KooWDZVv7BhZ8yFLkarNdaSWaB43D6UbQwExJ8nnGAEmfHcU," +
+			"/dns4/wormhole-v2-mainnet-bootstrap.staking.fund/udp/8999/quic/p2p/12D3KooWG8obDX9DNi1KUwZNu9xkGwfKqTp2GFwuuHpWZ3nQruS1",
+	},
+	"testnet": {
+		ID: "/wormhole/testnet/2/1",
+		Bootstrap: "/dns4/t-guardian-01.testnet.xlabs.xyz/udp/8999/quic/p2p/12D3KooWCW3LGUtkCVkHZmVSZHzL3C4WRKWfqAiJPz1NR7dT9Bxh," +
+			"/dns4/t-guardian-02.testnet.xlabs.xyz/udp/8999/quic/p2p/12D3KooWJXA6goBCiWM8ucjzc4jVUBSqL9Rri6UpjHbkMPErz5zK," +
+			"/dns4/p2p-guardian-testnet-1.solana.p2p.org/udp/8999/quic/p2p/12D3KooWE4dmZwxhfjCKHLUqSaww96Cf7kmq1ZuKmzPz3MrJgZxp",
+	},
+}
+
+const (
+	gossipSubscriptionBuffer = 1024             // Messages buffered per topic subscription, as in the guardian node
+	gossipConnectTimeout     = 30 * time.Second // Budget for reaching one bootstrap peer
+	gossipPeerSampleInterval = 15 * time.Second // How often relayer_gossip_peers is updated
+)
+
+// GossipListener joins the Wormhole guardian gossip network directly and receives the signed
+// VAAs guardians broadcast, taking the place of a spy
+type GossipListener struct {
+	host      host.Host
+	networkID string
+	topic     *pubsub.Topic
+	observed  *pubsub.Topic // Attestation topic, joined only for observation assembly
+	logger    *zap.Logger
+	stop      context.CancelFunc
+}
+
+// gossipNetworkFor returns GOSSIP_NETWORK with the GOSSIP_NETWORK_ID and GOSSIP_BOOTSTRAP
+// overrides applied, and its parsed bootstrap peers
+func gossipNetworkFor(config Config) (gossipNetwork, []peer.AddrInfo, error) {
+	network, known := gossipNetworks[config.GossipNetwork]
+	if config.GossipNetworkID != "" {
+		network.ID = config.GossipNetworkID
+	}
+	if config.GossipBootstrap != "" {
+		network.Bootstrap = config.GossipBootstrap
+	}
+	if !known && (config.GossipNetworkID == "" || config.GossipBootstrap == "") {
+		return network, nil, fmt.Errorf("unknown GOSSIP_NETWORK %q (GOSSIP_NETWORK_ID and GOSSIP_BOOTSTRAP are required for other networks)", config.GossipNetwork)
+	}
+	bootstrappers, err := parseGossipBootstrap(network.Bootstrap)
+	return network, bootstrappers, err
+}
+
+// NewGossipListener starts a libp2p host for GOSSIP_NETWORK, joins its VAA topic and connects
+// to its bootstrap peers, failing when none of them can be reached
+func NewGossipListener(config Config) (*GossipListener, error) {
+	network, bootstrappers, err := gossipNetworkFor(config)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := loadGossipNodeKey(config)
+	if err != nil {
+		return nil, err
+	}
+	connManager, err := connmgr.NewConnManager(100, 400, connmgr.WithGracePeriod(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection manager: %v", err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	g := &GossipListener{
+		networkID: network.ID,
+		logger:    logger.With(zap.String("component", "GossipListener"), zap.String("network", network.ID)),
+		stop:      stop,
+	}
+
+	// Guardians only speak QUIC with TLS, and their DHT is kept apart from the IPFS one by
+	// the network ID prefix
+	g.host, err = libp2p.New(
+		libp2p.Identity(priv),
+		libp2p.ListenAddrStrings(
+			fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", config.GossipPort),
+			fmt.Sprintf("/ip6/::/udp/%d/quic-v1", config.GossipPort),
+		),
+		libp2p.Security(libp2ptls.ID, libp2ptls.New),
+		libp2p.Transport(libp2pquic.NewTransport),
+		libp2p.ConnectionManager(connManager),
+		libp2p.Routing(func(h host.Host) (routing.PeerRouting, error) {
+			return dht.New(ctx, h,
+				dht.Mode(dht.ModeServer),
+				dht.ProtocolPrefix(protocol.ID("/"+network.ID)),
+				dht.BootstrapPeers(bootstrappers...))
+		}),
+	)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("failed to create libp2p host: %v", err)
+	}
+	g.logger.Info("Joining guardian gossip network",
+		zap.String("peerId", g.host.ID().String()),
+		zap.Int("port", config.GossipPort),
+		zap.Int("bootstrapPeers", len(bootstrappers)))
+
+	ps, err := pubsub.NewGossipSub(ctx, g.host, pubsub.WithGossipSubParams(pubsub.DefaultGossipSubParams()))
+	if err != nil {
+		g.Close()
+		return nil, fmt.Errorf("failed to start gossipsub: %v", err)
+	}
+	if g.topic, err = ps.Join(network.ID + "/broadcast"); err != nil {
+		g.Close()
+		return nil, fmt.Errorf("failed to join VAA topic: %v", err)
+	}
+	if config.ObservationAssembly && slices.Contains(config.ObservationSources, observationSourceGossip) {
+		if g.observed, err = ps.Join(network.ID + "/attestation"); err != nil {
+			g.Close()
+			return nil, fmt.Errorf("failed to join attestation topic: %v", err)
+		}
+	}
+
+	if connected := g.connectBootstrap(ctx, bootstrappers); connected == 0 {
+		g.Close()
+		return nil, fmt.Errorf("failed to connect to any of %d bootstrap peers", len(bootstrappers))
+	}
+	go g.samplePeers(ctx)
+	return g, nil
+}
+
+// PeerCount returns how many peers the listener is connected to
+func (g *GossipListener) PeerCount() int {
+	return len(g.host.Network().Peers())
+}
+
+// Close leaves the gossip network
+func (g *GossipListener) Close() {
+	if g == nil {
+		return
+	}
+	g.stop()
+	if g.topic != nil {
+		g.topic.Close()
+	}
+	if g.observed != nil {
+		g.observed.Close()
+	}
+	if g.host != nil {
+		g.host.Close()
+	}
+}
+
+// connectBootstrap connects to each bootstrap peer and returns how many it reached
+func (g *GossipListener) connectBootstrap(ctx context.Context, bootstrappers []peer.AddrInfo) int {
+	connected := 0
+	for _, p := range bootstrappers {
+		connectCtx, cancel := context.WithTimeout(ctx, gossipConnectTimeout)
+		err := g.host.Connect(connectCtx, p)
+		cancel()
+		if err != nil {
+			g.logger.Warn("Failed to connect to bootstrap peer", zap.String("peer", p.String()), zap.Error(err))
+			continue
+		}
+		connected++
+	}
+	g.logger.Info("Connected to bootstrap peers", zap.Int("connected", connected), zap.Int("total", len(bootstrappers)))
+	return connected
+}
+
+// samplePeers exports the number of connected peers as relayer_gossip_peers until ctx is done
+func (g *GossipListener) samplePeers(ctx context.Context) {
+	ticker := time.NewTicker(gossipPeerSampleInterval)
+	defer ticker.Stop()
+	for {
+		gossipPeers.Set(float64(g.PeerCount()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SubscribeSignedVAA subscribes to the signed VAAs broadcast on the network until ctx is done.
+// The stream only delivers VAAs accept returns no error for.
+func (g *GossipListener) SubscribeSignedVAA(ctx context.Context, accept func(context.Context, []byte) error) (*gossipStream, error) {
+	sub, err := g.topic.Subscribe(pubsub.WithBufferSize(gossipSubscriptionBuffer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to VAA topic: %v", err)
+	}
+	go func() {
+		<-ctx.Done()
+		sub.Cancel()
+	}()
+	return &gossipStream{ctx: ctx, sub: sub, self: g.host.ID(), accept: accept, logger: g.logger}, nil
+}
+
+// ReadObservations hands each observation guardians broadcast of a message from chain to
+// observe, until ctx is done or the subscription fails
+func (g *GossipListener) ReadObservations(ctx context.Context, chain uint16, observe func(digest common.Hash, guardian common.Address, signature []byte)) error {
+	if g.observed == nil {
+		return fmt.Errorf("attestation topic not joined")
+	}
+	sub, err := g.observed.Subscribe(pubsub.WithBufferSize(gossipSubscriptionBuffer))
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to attestation topic: %v", err)
+	}
+	defer sub.Cancel()
+
+	// Message IDs are chain/emitter/sequence
+	prefix := fmt.Sprintf("%d/", chain)
+	for {
+		envelope, err := sub.Next(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to receive gossip message: %v", err)
+		}
+		var msg gossipv1.GossipMessage
+		if err := proto.Unmarshal(envelope.Data, &msg); err != nil {
+			continue
+		}
+		batch, ok := msg.Message.(*gossipv1.GossipMessage_SignedObservationBatch)
+		if !ok {
+			continue
+		}
+		guardian := common.BytesToAddress(batch.SignedObservationBatch.GetAddr())
+		for _, o := range batch.SignedObservationBatch.GetObservations() {
+			if strings.HasPrefix(o.GetMessageId(), prefix) {
+				observe(common.BytesToHash(o.GetHash()), guardian, o.GetSignature())
+			}
+		}
+	}
+}
+
+// gossipStream reads signed VAAs from a VAA topic subscription
+type gossipStream struct {
+	ctx    context.Context
+	sub    *pubsub.Subscription
+	self   peer.ID
+	accept func(context.Context, []byte) error
+	logger *zap.Logger
+}
+
+// Recv blocks until the next accepted VAA arrives, skipping messages that carry none
+func (s *gossipStream) Recv() (*spyv1.SubscribeSignedVAAResponse, error) {
+	for {
+		envelope, err := s.sub.Next(s.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive gossip message: %v", err)
+		}
+		if envelope.GetFrom() == s.self {
+			gossipMessages.WithLabelValues("loopback").Inc()
+			continue
+		}
+
+		var msg gossipv1.GossipMessage
+		if err := proto.Unmarshal(envelope.Data, &msg); err != nil {
+			gossipMessages.WithLabelValues("invalid").Inc()
+			s.logger.Debug("Received invalid gossip message", zap.String("from", envelope.GetFrom().String()))
+			continue
+		}
+		signed, ok := msg.Message.(*gossipv1.GossipMessage_SignedVaaWithQuorum)
+		if !ok {
+			gossipMessages.WithLabelValues("unknown").Inc()
+			continue
+		}
+
+		vaaBytes := signed.SignedVaaWithQuorum.GetVaa()
+		if err := s.accept(s.ctx, vaaBytes); err != nil {
+			gossipMessages.WithLabelValues("rejected").Inc()
+			s.logger.Debug("Rejected gossiped VAA",
+				zap.String("from", envelope.GetFrom().String()),
+				zap.Error(err))
+			continue
+		}
+		gossipMessages.WithLabelValues("signed_vaa").Inc()
+		return &spyv1.SubscribeSignedVAAResponse{VaaBytes: vaaBytes}, nil
+	}
+}
+
+// parseGossipBootstrap parses comma-separated bootstrap multiaddrs, moving the legacy /quic/
+// addresses guardians still publish to /quic-v1/
+func parseGossipBootstrap(bootstrap string) ([]peer.AddrInfo, error) {
+	var peers []peer.AddrInfo
+	for _, addr := range strings.Split(bootstrap, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		ma, err := multiaddr.NewMultiaddr(strings.ReplaceAll(addr, "/quic/", "/quic-v1/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer %q: %v", addr, err)
+		}
+		info, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bootstrap peer %q: %v", addr, err)
+		}
+		peers = append(peers, *info)
+	}
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no gossip bootstrap peers configured")
+	}
+	return peers, nil
+}
+
+// loadGossipNodeKey reads the libp2p node key from GOSSIP_NODE_KEY_FILE, creating the file
+// with a new key when it does not exist. Without a file the key is ephemeral and the relayer
+// gets a new peer ID on every start.
+func loadGossipNodeKey(config Config) (crypto.PrivKey, error) {
+	path := config.GossipNodeKeyFile
+	if path == "" {
+		priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate node key: %v", err)
+		}
+		return priv, nil
+	}
+
+	data, err := readSecretFile(path, config.AllowInsecureKeyFiles)
+	if err == nil {
+		priv, err := crypto.UnmarshalPrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node key file %s: %v", path, err)
+		}
+		return priv, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read node key file: %v", err)
+	}
+
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate node key: %v", err)
+	}
+	data, err = crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode node key: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write node key file: %v", err)
+	}
+	logger.Info("Created gossip node key", zap.String("path", path))
+	return priv, nil
+}
+
+// acceptGossipVAA admits a gossiped VAA to the pipeline only when it parses and its
+// signatures check out against the tracked guardian set. Anyone can publish on the gossip
+// network, and a forged copy of a real VAA must not take its place in the dedupe cache.
+func (r *Relayer) acceptGossipVAA(ctx context.Context, vaaBytes []byte) error {
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		return err
+	}
+	// An under-signed VAA goes on to be held for assembly
+	if r.assembler != nil && r.assembler.partial(vaaData.VAA) {
+		return nil
+	}
+	return r.verifyGuardianSignatures(ctx, vaaData)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// GuardianSet is a Wormhole guardian set as recorded by a core contract
+type GuardianSet struct {
+	Index          uint32
+	Keys           []common.Address
+	ExpirationTime time.Time // When a replaced set stops being accepted (zero for the current set)
+}
+
+// guardianSetCache holds the guardian sets read from the core contract
+type guardianSetCache struct {
+	mu        sync.RWMutex
+	current   *GuardianSet
+	sets      map[uint32]*GuardianSet
+	updatedAt time.Time
+}
+
+// Current returns the current guardian set, or nil before it was first read
+func (c *guardianSetCache) Current() *GuardianSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+// UpdatedAt returns when the current set was last confirmed with the core contract
+func (c *guardianSetCache) UpdatedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.updatedAt
+}
+
+// get returns the cached set with the index
+func (c *guardianSetCache) get(index uint32) (*GuardianSet, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	set, ok := c.sets[index]
+	return set, ok
+}
+
+// put caches set, making it current when it is the core's current set
+func (c *guardianSetCache) put(set *GuardianSet, current bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sets == nil {
+		c.sets = make(map[uint32]*GuardianSet)
+	}
+	c.sets[set.Index] = set
+	if current {
+		c.current = set
+		c.updatedAt = time.Now()
+	}
+}
+
+// guardianCoreDestination returns the first destination with a Wormhole core configured,
+// which guardian sets are read from
+func (r *Relayer) guardianCoreDestination() *Destination {
+	for _, dest := range r.destinations {
+		if common.IsHexAddress(dest.WormholeCore) {
+			return dest
+		}
+	}
+	return nil
+}
+
+// watchGuardianSet reads the current guardian set from the destination's core contract every
+// GuardianSetRefresh until ctx is done
+func (r *Relayer) watchGuardianSet(ctx context.Context, dest *Destination) {
+	logger := r.logger.With(zap.String("destination", dest.Name), zap.String("wormholeCore", dest.WormholeCore))
+	ticker := time.NewTicker(r.config.GuardianSetRefresh)
+	defer ticker.Stop()
+
+	for {
+		if err := r.refreshGuardianSet(ctx, dest, logger); err != nil {
+			logger.Warn("Failed to read guardian set", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshGuardianSet reads the core's current guardian set index and, when it changed, its keys
+func (r *Relayer) refreshGuardianSet(ctx context.Context, dest *Destination, logger *zap.Logger) error {
+	index, err := readCurrentGuardianSetIndex(ctx, dest)
+	if err != nil {
+		return err
+	}
+	previous := r.guardians.Current()
+	if previous != nil && previous.Index == index {
+		r.guardians.put(previous, true)
+		return nil
+	}
+
+	set, err := readGuardianSet(ctx, dest, index)
+	if err != nil {
+		return err
+	}
+	r.guardians.put(set, true)
+	guardianSetIndex.Set(float64(index))
+
+	if previous == nil {
+		logger.Info("Loaded guardian set", zap.Uint32("index", index), zap.Int("guardians", len(set.Keys)))
+	} else {
+		logger.Warn("Guardian set changed",
+			zap.Uint32("previousIndex", previous.Index),
+			zap.Uint32("index", index),
+			zap.Int("guardians", len(set.Keys)))
+	}
+	return nil
+}
+
+// guardianSet returns the set with the index, reading sets other than the cached ones from
+// the core contract
+func (r *Relayer) guardianSet(ctx context.Context, dest *Destination, index uint32) (*GuardianSet, error) {
+	if set, ok := r.guardians.get(index); ok {
+		return set, nil
+	}
+	set, err := readGuardianSet(ctx, dest, index)
+	if err != nil {
+		return nil, err
+	}
+	r.guardians.put(set, false)
+	return set, nil
+}
+
+// verifyGuardianSignatures checks the VAA's signatures against the guardian set it names,
+// warning when that is not the current set. Without a tracked guardian set, or when the
+// set can't be read, the check is skipped and left to the destination contract. The error
+// means the VAA must be rejected.
+func (r *Relayer) verifyGuardianSignatures(ctx context.Context, vaaData *VAAData) error {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	current := r.guardians.Current()
+	if current == nil {
+		return nil
+	}
+	dest := r.guardianCoreDestination()
+	index := vaaData.VAA.GuardianSetIndex
+
+	set := current
+	if index != current.Index {
+		if index > current.Index {
+			return fmt.Errorf("VAA guardian set %d is newer than the current set %d", index, current.Index)
+		}
+
+		incWithExemplar(outdatedGuardianSetVAAs, vaaData.CorrelationID)
+		log.Warn("VAA signed by an outdated guardian set",
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.Uint32("vaaGuardianSet", index),
+			zap.Uint32("currentGuardianSet", current.Index))
+
+		var err error
+		if set, err = r.guardianSet(ctx, dest, index); err != nil {
+			log.Warn("Failed to read guardian set, skipping local signature check",
+				zap.Uint32("index", index),
+				zap.Error(err))
+			return nil
+		}
+		if !set.ExpirationTime.IsZero() && time.Now().After(set.ExpirationTime) {
+			return fmt.Errorf("VAA guardian set %d expired at %s", index, set.ExpirationTime.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if err := vaaData.VAA.Verify(set.Keys); err != nil {
+		return fmt.Errorf("guardian signatures (set %d): %v", index, err)
+	}
+	return nil
+}
+
+// readCurrentGuardianSetIndex calls getCurrentGuardianSetIndex on the destination's core
+func readCurrentGuardianSetIndex(ctx context.Context, dest *Destination) (uint32, error) {
+	out, err := callWormholeCore(ctx, dest, "getCurrentGuardianSetIndex")
+	if err != nil {
+		return 0, err
+	}
+	index, ok := out[0].(uint32)
+	if !ok {
+		return 0, fmt.Errorf("unexpected getCurrentGuardianSetIndex result %T", out[0])
+	}
+	return index, nil
+}
+
+// readGuardianSet calls getGuardianSet on the destination's core
+func readGuardianSet(ctx context.Context, dest *Destination, index uint32) (*GuardianSet, error) {
+	out, err := callWormholeCore(ctx, dest, "getGuardianSet", index)
+	if err != nil {
+		return nil, err
+	}
+	raw := new(struct {
+		Keys           []common.Address
+		ExpirationTime uint32
+	})
+	if err := abi.ConvertType(out[0], raw); err != nil {
+		return nil, fmt.Errorf("unexpected getGuardianSet result: %v", err)
+	}
+	if len(raw.Keys) == 0 {
+		return nil, fmt.Errorf("guardian set %d is empty", index)
+	}
+
+	set := &GuardianSet{Index: index, Keys: raw.Keys}
+	if raw.ExpirationTime != 0 {
+		set.ExpirationTime = time.Unix(int64(raw.ExpirationTime), 0)
+	}
+	return set, nil
+}
+
+// callWormholeCore calls a view function of the destination's core and unpacks the result
+func callWormholeCore(ctx context.Context, dest *Destination, method string, args ...any) ([]any, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(wormholeCoreABI))
+	if err != nil {
+		return nil, fmt.Errorf("ABI parse error: %v", err)
+	}
+	data, err := parsedABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ABI pack error: %v", err)
+	}
+
+	core := common.HexToAddress(dest.WormholeCore)
+	result, err := dest.client.client.CallContract(ctx, ethereum.CallMsg{To: &core, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s call failed: %v", method, err)
+	}
+	out, err := parsedABI.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s result: %v", method, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty %s result", method)
+	}
+	return out, nil
+}
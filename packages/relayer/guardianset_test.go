@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+	"go.uber.org/zap"
+)
+
+// testGuardianKeys generates n guardian keys and their addresses
+func testGuardianKeys(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	t.Helper()
+	keys := make([]*ecdsa.PrivateKey, n)
+	addresses := make([]common.Address, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], addresses[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addresses
+}
+
+// newTestVAA returns an unsigned VAA from a fixed Aztec emitter naming the guardian set index
+func newTestVAA(setIndex uint32, sequence uint64) *vaaLib.VAA {
+	return &vaaLib.VAA{
+		Version:          vaaLib.SupportedVAAVersion,
+		GuardianSetIndex: setIndex,
+		Timestamp:        time.Unix(1_700_000_000, 0),
+		Nonce:            1,
+		Sequence:         sequence,
+		ConsistencyLevel: 2,
+		EmitterChain:     vaaLib.ChainID(56), // Aztec
+		EmitterAddress:   vaaLib.Address{31: 0x42},
+		Payload:          []byte("recovery"),
+	}
+}
+
+// signTestVAA signs v with keys[i] under each signer index in indexes, in that order
+func signTestVAA(v *vaaLib.VAA, keys []*ecdsa.PrivateKey, indexes ...int) *vaaLib.VAA {
+	for _, i := range indexes {
+		v.AddSignature(keys[i], uint8(i))
+	}
+	return v
+}
+
+func TestVerifyGuardianSignatures(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5) // Quorum is 4
+	outsiders, _ := testGuardianKeys(t, 6)
+	oldKeys, oldAddresses := testGuardianKeys(t, 5)
+
+	tests := []struct {
+		name    string
+		vaa     *vaaLib.VAA
+		oldSet  *GuardianSet // Replaced set cached next to the current set 1
+		wantErr string       // Empty when the VAA is accepted
+	}{
+		{name: "exact quorum", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2, 3)},
+		{name: "every guardian", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2, 3, 4)},
+		{name: "quorum with a gap", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 2, 3, 4)},
+		{name: "one short of quorum", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2), wantErr: "did not have a quorum"},
+		{name: "unsigned", vaa: newTestVAA(1, 1), wantErr: "was not signed"},
+		{name: "duplicate signer index", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 1, 1, 2), wantErr: "bad signatures"},
+		{name: "out of order signer indexes", vaa: signTestVAA(newTestVAA(1, 1), keys, 1, 0, 2, 3), wantErr: "bad signatures"},
+		{name: "signer index out of range", vaa: signTestVAA(signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2), outsiders, 5), wantErr: "bad signatures"},
+		{name: "signer outside the set", vaa: signTestVAA(signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2), outsiders, 3), wantErr: "bad signatures"},
+		{
+			name:    "signature over another body",
+			vaa:     func() *vaaLib.VAA { v := signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2, 3); v.Sequence++; return v }(),
+			wantErr: "bad signatures",
+		},
+		{name: "newer guardian set", vaa: signTestVAA(newTestVAA(2, 1), keys, 0, 1, 2, 3), wantErr: "newer than the current set 1"},
+		{
+			name:   "outdated set within its expiry",
+			vaa:    signTestVAA(newTestVAA(0, 1), oldKeys, 0, 1, 2, 3),
+			oldSet: &GuardianSet{Index: 0, Keys: oldAddresses, ExpirationTime: time.Now().Add(time.Hour)},
+		},
+		{
+			name:    "outdated set signed by the current guardians",
+			vaa:     signTestVAA(newTestVAA(0, 1), keys, 0, 1, 2, 3),
+			oldSet:  &GuardianSet{Index: 0, Keys: oldAddresses, ExpirationTime: time.Now().Add(time.Hour)},
+			wantErr: "guardian signatures (set 0)",
+		},
+		{
+			name:    "expired set",
+			vaa:     signTestVAA(newTestVAA(0, 1), oldKeys, 0, 1, 2, 3),
+			oldSet:  &GuardianSet{Index: 0, Keys: oldAddresses, ExpirationTime: time.Now().Add(-time.Hour)},
+			wantErr: "VAA guardian set 0 expired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Relayer{logger: zap.NewNop()}
+			r.guardians.put(&GuardianSet{Index: 1, Keys: addresses}, true)
+			if tt.oldSet != nil {
+				r.guardians.put(tt.oldSet, false)
+			}
+
+			err := r.verifyGuardianSignatures(context.Background(), &VAAData{VAAData: sdk.VAAData{VAA: tt.vaa}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyGuardianSignaturesWithoutSet(t *testing.T) {
+	r := &Relayer{logger: zap.NewNop()}
+	if err := r.verifyGuardianSignatures(context.Background(), &VAAData{VAAData: sdk.VAAData{VAA: newTestVAA(1, 1)}}); err != nil {
+		t.Fatalf("check without a tracked guardian set failed: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Instance roles for hot-standby failover
+const (
+	RoleStandalone = "standalone" // No failover; the instance always submits
+	RolePrimary    = "primary"    // Takes the lease at startup unless a live instance holds it
+	RoleStandby    = "standby"    // Mirrors state and takes the lease over when it goes stale
+)
+
+// standbyVAA is a VAA observed while standing by, replayed if this instance is promoted
+type standbyVAA struct {
+	bytes         []byte
+	receivedAt    time.Time
+	correlationID string
+}
+
+// defaultInstanceID names the instance after its host
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "relayer"
+	}
+	return hostname
+}
+
+// failoverEnabled reports whether the instance takes part in lease-based failover
+func (r *Relayer) failoverEnabled() bool {
+	return r.config.Role == RolePrimary || r.config.Role == RoleStandby
+}
+
+// validateFailover checks the failover settings before the daemon starts
+func (r *Relayer) validateFailover() error {
+	switch r.config.Role {
+	case RoleStandalone:
+		return nil
+	case RolePrimary, RoleStandby:
+	default:
+		return fmt.Errorf("unknown RELAYER_ROLE %q (want %s, %s or %s)",
+			r.config.Role, RoleStandalone, RolePrimary, RoleStandby)
+	}
+	if r.config.StateStore != StateStorePostgres {
+		return fmt.Errorf("RELAYER_ROLE=%s needs STATE_STORE=%s, shared with the other instance",
+			r.config.Role, StateStorePostgres)
+	}
+	if r.config.InstanceID == "" {
+		return fmt.Errorf("INSTANCE_ID must not be empty")
+	}
+	if r.config.HeartbeatInterval <= 0 || r.config.FailoverTimeout < 2*r.config.HeartbeatInterval {
+		return fmt.Errorf("FAILOVER_TIMEOUT (%s) must be at least twice HEARTBEAT_INTERVAL (%s)",
+			r.config.FailoverTimeout, r.config.HeartbeatInterval)
+	}
+	return nil
+}
+
+// isActive reports whether this instance may submit transactions
+func (r *Relayer) isActive() bool {
+	return r.active.Load()
+}
+
+// claimInitialLease decides at startup whether this instance is active. A standalone
+// instance always is; otherwise it is active only if it got the lease.
+func (r *Relayer) claimInitialLease() error {
+	if !r.failoverEnabled() {
+		r.active.Store(true)
+		failoverActive.Set(1)
+		return nil
+	}
+
+	lease, err := r.store.ClaimLease(r.config.InstanceID, r.config.FailoverTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to claim failover lease: %v", err)
+	}
+	r.haMu.Lock()
+	r.lease = lease
+	r.haMu.Unlock()
+
+	if lease.Holder == r.config.InstanceID {
+		r.haMu.Lock()
+		r.leaseRenewed = time.Now()
+		r.haMu.Unlock()
+		r.active.Store(true)
+		failoverActive.Set(1)
+		r.logger.Info("Holding the failover lease, starting as active relayer",
+			zap.String("role", r.config.Role),
+			zap.String("instance", r.config.InstanceID))
+		return nil
+	}
+
+	r.active.Store(false)
+	failoverActive.Set(0)
+	fields := []zap.Field{
+		zap.String("role", r.config.Role),
+		zap.String("instance", r.config.InstanceID),
+		zap.String("activeInstance", lease.Holder),
+		zap.Time("leaseRenewedAt", lease.RenewedAt),
+	}
+	if r.config.Role == RolePrimary {
+		r.logger.Warn("Another instance holds the failover lease, starting as standby", fields...)
+	} else {
+		r.logger.Info("Starting as standby", fields...)
+	}
+	return nil
+}
+
+// whileActive runs fn for as long as this instance is active: right away for a standalone
+// instance, otherwise from each promotion until the following demotion
+func (r *Relayer) whileActive(ctx context.Context, fn func(context.Context)) {
+	if !r.failoverEnabled() {
+		go fn(ctx)
+		return
+	}
+
+	r.haMu.Lock()
+	defer r.haMu.Unlock()
+	r.activeFuncs = append(r.activeFuncs, fn)
+	if r.isActive() {
+		if r.cancelActive == nil {
+			r.activeCtx, r.cancelActive = context.WithCancel(ctx)
+		}
+		go fn(r.activeCtx)
+	}
+}
+
+// runFailover renews or watches the lease every HeartbeatInterval until ctx is done
+func (r *Relayer) runFailover(ctx context.Context) {
+	ticker := time.NewTicker(r.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.heartbeat(ctx)
+		}
+	}
+}
+
+// heartbeat claims the lease once and promotes or demotes this instance accordingly. An
+// active instance that can't renew for half the failover timeout stops submitting, so it
+// has stepped down before a standby may take over.
+func (r *Relayer) heartbeat(ctx context.Context) {
+	lease, err := r.store.ClaimLease(r.config.InstanceID, r.config.FailoverTimeout)
+	if err != nil {
+		r.logger.Warn("Failed to renew failover lease", zap.Error(err))
+		r.haMu.Lock()
+		lapsed := time.Since(r.leaseRenewed) > r.config.FailoverTimeout/2
+		r.haMu.Unlock()
+		if r.isActive() && lapsed {
+			r.demote("failover lease could not be renewed")
+		}
+		return
+	}
+
+	r.haMu.Lock()
+	previous := r.lease
+	r.lease = lease
+	if lease.Holder == r.config.InstanceID {
+		r.leaseRenewed = time.Now()
+	}
+	r.haMu.Unlock()
+
+	switch {
+	case lease.Holder == r.config.InstanceID && !r.isActive():
+		r.promote(ctx, previous)
+	case lease.Holder != r.config.InstanceID && r.isActive():
+		r.demote(fmt.Sprintf("failover lease taken over by %s", lease.Holder))
+	case !r.isActive():
+		r.mirrorState()
+	}
+}
+
+// promote takes over the state the previous holder left in the store, replays the VAAs
+// observed while standing by and starts submitting
+func (r *Relayer) promote(ctx context.Context, previous Lease) {
+	r.logger.Warn("Promoted to active relayer",
+		zap.String("instance", r.config.InstanceID),
+		zap.String("previousInstance", previous.Holder),
+		zap.Time("previousRenewedAt", previous.RenewedAt))
+	failoverPromotions.Inc()
+
+	if err := r.loadProcessedVAAs(); err != nil {
+		r.logger.Error("Failed to take over dedupe entries", zap.Error(err))
+	}
+	if err := r.loadCheckpoints(); err != nil {
+		r.logger.Error("Failed to take over checkpoints", zap.Error(err))
+	}
+	r.retriesMu.Lock()
+	r.retries = make(map[string]RetryEntry)
+	r.retriesMu.Unlock()
+	if err := r.loadRetries(); err != nil {
+		r.logger.Error("Failed to take over retry queue", zap.Error(err))
+	}
+	if err := r.recoverInflight(); err != nil {
+		r.logger.Error("Failed to take over inflight VAAs", zap.Error(err))
+	}
+
+	// Activate and take the held VAAs together, so none is held after the replay
+	r.haMu.Lock()
+	r.active.Store(true)
+	failoverActive.Set(1)
+	held := r.standbyVAAs
+	r.standbyVAAs = make(map[string]standbyVAA)
+	r.activeCtx, r.cancelActive = context.WithCancel(ctx)
+	for _, fn := range r.activeFuncs {
+		go fn(r.activeCtx)
+	}
+	r.haMu.Unlock()
+
+	r.replayStandbyVAAs(held)
+}
+
+// demote stops new submissions; transactions already sent still run to confirmation
+func (r *Relayer) demote(reason string) {
+	r.haMu.Lock()
+	defer r.haMu.Unlock()
+	if !r.isActive() {
+		return
+	}
+	r.active.Store(false)
+	failoverActive.Set(0)
+	if r.cancelActive != nil {
+		r.cancelActive()
+		r.cancelActive = nil
+	}
+	r.logger.Error("Demoted to standby, no longer submitting",
+		zap.String("instance", r.config.InstanceID),
+		zap.String("reason", reason))
+}
+
+// mirrorState refreshes the standby's dedupe entries and checkpoints from the shared store and
+// forgets observed VAAs the active instance has handled or that aged past the dedupe TTL
+func (r *Relayer) mirrorState() {
+	processed, err := r.store.LoadProcessed(time.Now().Add(-r.dedupeTTL))
+	if err != nil {
+		r.logger.Warn("Failed to mirror dedupe entries", zap.Error(err))
+		return
+	}
+	checkpoints, err := r.store.LoadCheckpoints()
+	if err != nil {
+		r.logger.Warn("Failed to mirror checkpoints", zap.Error(err))
+		return
+	}
+
+	r.dedupeMu.Lock()
+	r.processedVAAs.addAll(processed)
+	r.dedupeMu.Unlock()
+	r.checkpointsMu.Lock()
+	r.checkpoints = checkpoints
+	r.checkpointsMu.Unlock()
+
+	cutoff := time.Now().Add(-r.dedupeTTL)
+	r.haMu.Lock()
+	for key, vaa := range r.standbyVAAs {
+		if _, done := processed[key]; done || vaa.receivedAt.Before(cutoff) {
+			delete(r.standbyVAAs, key)
+		}
+	}
+	pending := len(r.standbyVAAs)
+	r.haMu.Unlock()
+	standbyPendingVAAs.Set(float64(pending))
+}
+
+// holdWhileStandby keeps a VAA instead of processing it when this instance is not active,
+// reporting whether it did
+func (r *Relayer) holdWhileStandby(key string, vaaBytes []byte, correlationID string) bool {
+	if r.isActive() {
+		return false
+	}
+	r.haMu.Lock()
+	defer r.haMu.Unlock()
+	if r.isActive() {
+		return false
+	}
+	r.standbyVAAs[key] = standbyVAA{bytes: vaaBytes, receivedAt: time.Now(), correlationID: correlationID}
+	standbyPendingVAAs.Set(float64(len(r.standbyVAAs)))
+	return true
+}
+
+// replayStandbyVAAs queues the VAAs held while standing by that the previous holder did not
+// handle, covering the gap between its last heartbeat and the promotion
+func (r *Relayer) replayStandbyVAAs(held map[string]standbyVAA) {
+	standbyPendingVAAs.Set(0)
+
+	replayed := 0
+	for key, vaa := range held {
+		r.dedupeMu.Lock()
+		processed := r.processedVAAs.contains(key)
+		r.dedupeMu.Unlock()
+		r.retriesMu.Lock()
+		_, queued := r.retries[key]
+		r.retriesMu.Unlock()
+		if processed || queued {
+			continue
+		}
+
+		entry := RetryEntry{Key: key, VAABytes: vaa.bytes, NextAttempt: time.Now(), CorrelationID: vaa.correlationID}
+		r.retriesMu.Lock()
+		r.retries[key] = entry
+		r.retriesMu.Unlock()
+		if err := r.store.SaveRetry(entry); err != nil {
+			r.logger.Error("Failed to persist replayed VAA",
+				zap.String("vaaHash", key),
+				zap.String("correlationId", vaa.correlationID),
+				zap.Error(err))
+		}
+		replayed++
+	}
+	if replayed > 0 {
+		r.logger.Info("Replaying VAAs observed while standing by", zap.Int("count", replayed))
+	}
+}
+
+// FailoverState reports the instance's role, whether it is active, the last lease seen and
+// how many VAAs it holds while standing by
+func (r *Relayer) FailoverState() map[string]any {
+	r.haMu.Lock()
+	defer r.haMu.Unlock()
+	state := map[string]any{
+		"role":     r.config.Role,
+		"instance": r.config.InstanceID,
+		"active":   r.isActive(),
+	}
+	if r.failoverEnabled() {
+		state["leaseHolder"] = r.lease.Holder
+		state["leaseRenewedAt"] = r.lease.RenewedAt.UTC().Format(time.RFC3339)
+		state["standbyVAAs"] = len(r.standbyVAAs)
+	}
+	return state
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Overflow policies of the intake queue, applied when a VAA arrives from the spy while it is full
+const (
+	IntakeOverflowBlock = "block" // Stop reading the spy until a worker frees a slot
+	IntakeOverflowSpill = "spill" // Write the VAA to INTAKE_SPILL_DIR, fed back in order as slots free up
+	IntakeOverflowShed  = "shed"  // Drop the VAA and raise an alert
+)
+
+// intakeItem is a VAA read from the spy and waiting for a worker
+type intakeItem struct {
+	VAABytes      []byte `json:"vaa"`
+	Key           string `json:"key"`
+	CorrelationID string `json:"correlationId"`
+	spillPath     string // File holding the item while it was spilled, removed once handled
+}
+
+// intakeQueue bounds the work the spy reader hands to the processing workers. VAAs wait in a
+// channel of INTAKE_QUEUE_SIZE for one of INTAKE_WORKERS; the overflow policy decides what
+// happens to a VAA arriving while it is full.
+type intakeQueue struct {
+	items    chan intakeItem
+	policy   string
+	workers  int
+	spillDir string
+	logger   *zap.Logger
+
+	mu          sync.Mutex
+	spilled     []string // Spill files not yet fed back, oldest first
+	overflowing bool     // Whether the last VAA found the queue full, to alert once per episode
+	wake        chan struct{}
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// newIntakeQueue creates the intake queue for the INTAKE_* settings
+func newIntakeQueue(config Config) (*intakeQueue, error) {
+	if config.IntakeQueueSize <= 0 {
+		return nil, fmt.Errorf("INTAKE_QUEUE_SIZE must be positive")
+	}
+	if config.IntakeWorkers <= 0 {
+		return nil, fmt.Errorf("INTAKE_WORKERS must be positive")
+	}
+	q := &intakeQueue{
+		items:   make(chan intakeItem, config.IntakeQueueSize),
+		policy:  config.IntakeOverflow,
+		workers: config.IntakeWorkers,
+		logger:  logger.With(zap.String("component", "IntakeQueue")),
+		wake:    make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+	switch config.IntakeOverflow {
+	case IntakeOverflowBlock, IntakeOverflowShed:
+	case IntakeOverflowSpill:
+		if config.IntakeSpillDir == "" {
+			return nil, fmt.Errorf("INTAKE_SPILL_DIR is required with INTAKE_OVERFLOW=%s", IntakeOverflowSpill)
+		}
+		if err := os.MkdirAll(config.IntakeSpillDir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create intake spill directory: %v", err)
+		}
+		q.spillDir = config.IntakeSpillDir
+	default:
+		return nil, fmt.Errorf("unknown INTAKE_OVERFLOW %q (want %s, %s or %s)", config.IntakeOverflow,
+			IntakeOverflowBlock, IntakeOverflowSpill, IntakeOverflowShed)
+	}
+	return q, nil
+}
+
+// enqueue hands a VAA to the workers, applying the overflow policy when the queue is full. It
+// reports false when the VAA was shed, or when ctx ended while blocked.
+func (q *intakeQueue) enqueue(ctx context.Context, item intakeItem) bool {
+	// Spilled VAAs are fed back in order, so newer ones queue behind them
+	if q.policy != IntakeOverflowSpill || q.spilledCount() == 0 {
+		select {
+		case q.items <- item:
+			q.setOverflowing(false)
+			return true
+		default:
+		}
+	}
+
+	q.setOverflowing(true)
+	switch q.policy {
+	case IntakeOverflowSpill:
+		if err := q.spill(item); err != nil {
+			// Blocking beats losing the VAA
+			q.logger.Error("Failed to spill VAA, waiting for room instead",
+				zap.String("vaaHash", item.Key),
+				zap.Error(err))
+			break
+		}
+		intakeOverflows.WithLabelValues(IntakeOverflowSpill).Inc()
+		return true
+	case IntakeOverflowShed:
+		intakeOverflows.WithLabelValues(IntakeOverflowShed).Inc()
+		q.logger.Debug("Shed VAA from full intake queue",
+			zap.String("vaaHash", item.Key),
+			zap.String("correlationId", item.CorrelationID))
+		return false
+	}
+
+	intakeOverflows.WithLabelValues(IntakeOverflowBlock).Inc()
+	select {
+	case q.items <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// setOverflowing logs when the queue starts and stops overflowing
+func (q *intakeQueue) setOverflowing(overflowing bool) {
+	q.mu.Lock()
+	changed := q.overflowing != overflowing
+	q.overflowing = overflowing
+	q.mu.Unlock()
+	if !changed {
+		return
+	}
+	intakeOverflowing.Set(boolToFloat(overflowing))
+	if overflowing {
+		q.logger.Error("Intake queue full; VAAs are arriving faster than they are processed",
+			zap.Int("capacity", cap(q.items)),
+			zap.Int("workers", q.workers),
+			zap.String("policy", q.policy))
+	} else {
+		q.logger.Info("Intake queue has room again")
+	}
+}
+
+// spill writes the item to the spill directory and queues it to be fed back
+func (q *intakeQueue) spill(item intakeItem) error {
+	content, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%020d-%s.json", time.Now().UnixNano(), item.Key)
+	path := filepath.Join(q.spillDir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	q.mu.Lock()
+	q.spilled = append(q.spilled, path)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// spilledCount returns the number of spilled VAAs not yet fed back
+func (q *intakeQueue) spilledCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.spilled)
+}
+
+// loadSpilled returns the VAAs left in the spill directory by an earlier run, oldest first
+func (q *intakeQueue) loadSpilled() ([]intakeItem, error) {
+	if q.spillDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(q.spillDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read intake spill directory: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	items := make([]intakeItem, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(q.spillDir, name)
+		item, err := readSpilled(path)
+		if err != nil {
+			q.logger.Error("Skipping unreadable spilled VAA", zap.String("file", path), zap.Error(err))
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readSpilled reads one spill file
+func readSpilled(path string) (intakeItem, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return intakeItem{}, err
+	}
+	var item intakeItem
+	if err := json.Unmarshal(content, &item); err != nil {
+		return intakeItem{}, err
+	}
+	item.spillPath = path
+	return item, nil
+}
+
+// feedSpilled moves spilled VAAs back into the queue as slots free up, until intake closes
+// or ctx is done. Files stay on disk until the VAA is handled, so a restart picks up any
+// still waiting.
+func (q *intakeQueue) feedSpilled(ctx context.Context) {
+	for {
+		q.mu.Lock()
+		var path string
+		if len(q.spilled) > 0 {
+			path = q.spilled[0]
+		}
+		q.mu.Unlock()
+
+		if path == "" {
+			select {
+			case <-q.wake:
+				continue
+			case <-q.closed:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		item, err := readSpilled(path)
+		if err != nil {
+			q.logger.Error("Dropping unreadable spilled VAA", zap.String("file", path), zap.Error(err))
+			os.Remove(path)
+		} else {
+			select {
+			case q.items <- item:
+			case <-q.closed:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+		q.mu.Lock()
+		q.spilled = q.spilled[1:]
+		q.mu.Unlock()
+	}
+}
+
+// close stops intake; workers finish what is queued and exit. Spilled VAAs not yet fed back
+// stay on disk for the next start.
+func (q *intakeQueue) close() {
+	q.closeOnce.Do(func() { close(q.closed) })
+}
+
+// runIntakeWorker handles queued VAAs until intake closes and the queue is empty, or ctx is done
+func (r *Relayer) runIntakeWorker(ctx context.Context) {
+	q := r.intake
+	for {
+		select {
+		case item := <-q.items:
+			r.handleIntakeItem(ctx, item)
+			continue
+		case <-ctx.Done():
+			return
+		case <-q.closed:
+		}
+		select {
+		case item := <-q.items:
+			r.handleIntakeItem(ctx, item)
+		default:
+			return
+		}
+	}
+}
+
+// handleIntakeItem processes a queued VAA, then removes its spill file if it had one
+func (r *Relayer) handleIntakeItem(ctx context.Context, item intakeItem) {
+	r.handleVAA(ctx, item.VAABytes, item.Key, item.CorrelationID)
+	if item.spillPath != "" {
+		if err := os.Remove(item.spillPath); err != nil && !os.IsNotExist(err) {
+			r.logger.Error("Failed to remove spilled VAA", zap.String("file", item.spillPath), zap.Error(err))
+		}
+	}
+}
+
+// startIntake starts the workers and, with the spill policy, queues the VAAs spilled before a
+// restart ahead of new ones. Workers and the feeder are tracked by wg.
+func (r *Relayer) startIntake(processingCtx context.Context, wg *sync.WaitGroup) error {
+	q := r.intake
+	leftover, err := q.loadSpilled()
+	if err != nil {
+		return err
+	}
+	resumed := 0
+	for _, item := range leftover {
+		if !r.beginProcessingVAA(item.Key) {
+			// Handled before the restart
+			os.Remove(item.spillPath)
+			continue
+		}
+		q.spilled = append(q.spilled, item.spillPath)
+		resumed++
+	}
+	if resumed > 0 {
+		r.logger.Info("Resuming VAAs spilled before restart", zap.Int("count", resumed))
+	}
+
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runIntakeWorker(processingCtx)
+		}()
+	}
+	if q.spillDir != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.feedSpilled(processingCtx)
+		}()
+	}
+	return nil
+}
+
+// flushIntake saves the VAAs still queued once the workers have stopped as inflight, so the
+// next start re-drives them. Spilled VAAs stay in their files.
+func (r *Relayer) flushIntake() {
+	for {
+		select {
+		case item := <-r.intake.items:
+			if item.spillPath == "" {
+				inflight := InflightEntry{Key: item.Key, VAABytes: item.VAABytes, StartedAt: time.Now(), CorrelationID: item.CorrelationID}
+				if err := r.store.SaveInflight(inflight); err != nil {
+					r.logger.Error("Failed to persist queued VAA", zap.String("vaaHash", item.Key), zap.Error(err))
+				}
+			}
+			r.finishProcessingVAA(item.Key, false)
+		default:
+			return
+		}
+	}
+}
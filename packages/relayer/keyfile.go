@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+)
+
+// loadPrivateKeyFile reads PRIVATE_KEY_FILE, if set, into the config's PrivateKey
+func loadPrivateKeyFile(config *Config) error {
+	if config.PrivateKeyFile == "" {
+		return nil
+	}
+	if config.PrivateKey != "" || config.KeyFile != "" {
+		return fmt.Errorf("PRIVATE_KEY_FILE, PRIVATE_KEY and KEY_FILE are mutually exclusive")
+	}
+	data, err := readSecretFile(config.PrivateKeyFile, config.AllowInsecureKeyFiles)
+	if err != nil {
+		return fmt.Errorf("failed to read private key file: %v", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if _, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x")); err != nil {
+		return fmt.Errorf("private key file %s does not hold a hex private key", config.PrivateKeyFile)
+	}
+	config.PrivateKey = key
+	logger.Info("Loaded private key file", zap.String("path", config.PrivateKeyFile))
+	return nil
+}
+
+// unlockKeyFile decrypts KEY_FILE, if set, into the config's PrivateKey
+func unlockKeyFile(config *Config) error {
+	if config.KeyFile == "" {
+		return nil
+	}
+	if config.PrivateKey != "" {
+		return fmt.Errorf("PRIVATE_KEY and KEY_FILE are mutually exclusive")
+	}
+	privateKey, err := loadKeyFile(config.KeyFile, config.KeyPassphraseFile, config.AllowInsecureKeyFiles)
+	if err != nil {
+		return err
+	}
+	config.PrivateKey = privateKey
+	logger.Info("Unlocked key file", zap.String("path", config.KeyFile))
+	return nil
+}
+
+// loadKeyFile decrypts an encrypted JSON key file (Web3 Secret Storage, as written by geth,
+// clef or `cast wallet import`) and returns the private key in hex. The passphrase comes from
+// passphraseFile if set, otherwise from an interactive prompt on the terminal.
+func loadKeyFile(path, passphraseFile string, allowInsecure bool) (string, error) {
+	keyJSON, err := readSecretFile(path, allowInsecure)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %v", err)
+	}
+
+	passphrase, err := readPassphrase(path, passphraseFile, allowInsecure)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt key file %s: %v", path, err)
+	}
+
+	return hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)), nil
+}
+
+// readPassphrase reads the key file passphrase from passphraseFile or prompts for it
+func readPassphrase(keyFile, passphraseFile string, allowInsecure bool) (string, error) {
+	if passphraseFile != "" {
+		data, err := readSecretFile(passphraseFile, allowInsecure)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("KEY_FILE_PASSPHRASE_FILE is not set and stdin is not a terminal to prompt on")
+	}
+
+	fmt.Fprintf(os.Stderr, "Passphrase for %s: ", keyFile)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return string(passphrase), nil
+}
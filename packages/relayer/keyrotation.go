@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// Phases of a signer key rotation
+const (
+	RotationReady    = "ready"    // NEXT_PRIVATE_KEYS are loaded, submissions still use the old keys
+	RotationDraining = "draining" // Submissions use the next keys; the old keys' transactions are still pending
+	RotationComplete = "complete" // The old keys are out of the pool
+)
+
+// How often a draining rotation checks whether the old keys are done
+const keyRotationCheckInterval = 5 * time.Second
+
+// keyRotation moves submissions from the configured signers to NEXT_PRIVATE_KEYS without a
+// restart. Starting it stops lending the old keys on every destination, so they send nothing
+// new; once none of them has a transaction pending, tracked or on chain, they leave the pool.
+type keyRotation struct {
+	mu          sync.Mutex
+	next        []Signer
+	old         []common.Address
+	phase       string
+	startedAt   time.Time
+	completedAt time.Time
+}
+
+// keyRotationStatus reports a rotation's progress
+type keyRotationStatus struct {
+	Phase       string    `json:"phase"`
+	Old         []string  `json:"old"`
+	Next        []string  `json:"next"`
+	StartedAt   time.Time `json:"startedAt,omitzero"`
+	CompletedAt time.Time `json:"completedAt,omitzero"`
+	// Nonces of the old keys' tracked transactions, by destination and signer
+	Draining map[string]map[string][]uint64 `json:"draining,omitempty"`
+}
+
+// openNextSigners creates the NEXT_PRIVATE_KEYS accounts, behind the COSIGNER_* co-signers
+// like the current ones, or returns nil when none are configured
+func openNextSigners(config Config, current []Signer) ([]Signer, error) {
+	if len(config.NextPrivateKeys) == 0 {
+		return nil, nil
+	}
+	next, err := localSigners(config.NextPrivateKeys)
+	if err != nil {
+		return nil, fmt.Errorf("NEXT_PRIVATE_KEYS: %v", err)
+	}
+
+	seen := make(map[common.Address]bool, len(current)+len(next))
+	for _, account := range current {
+		seen[account.Address()] = true
+	}
+	for _, account := range next {
+		if seen[account.Address()] {
+			return nil, fmt.Errorf("NEXT_PRIVATE_KEYS: signer %s is already configured", account.Address().Hex())
+		}
+		seen[account.Address()] = true
+	}
+
+	cosigners, err := newCosigners(config)
+	if err != nil {
+		return nil, err
+	}
+	if cosigners != nil {
+		for i, account := range next {
+			next[i] = &cosignedSigner{Signer: account, cosigners: cosigners}
+		}
+	}
+	return next, nil
+}
+
+// KeyRotation reports the rotation's progress, or false when no next keys are configured
+func (r *Relayer) KeyRotation() (keyRotationStatus, bool) {
+	if r.rotation == nil {
+		return keyRotationStatus{}, false
+	}
+	r.rotation.mu.Lock()
+	defer r.rotation.mu.Unlock()
+	return r.keyRotationStatus(), true
+}
+
+// keyRotationStatus builds the status report; the caller holds rotation.mu
+func (r *Relayer) keyRotationStatus() keyRotationStatus {
+	rot := r.rotation
+	status := keyRotationStatus{
+		Phase:       rot.phase,
+		Old:         make([]string, len(rot.old)),
+		Next:        make([]string, len(rot.next)),
+		StartedAt:   rot.startedAt,
+		CompletedAt: rot.completedAt,
+	}
+	for i, address := range rot.old {
+		status.Old[i] = address.Hex()
+	}
+	for i, account := range rot.next {
+		status.Next[i] = account.Address().Hex()
+	}
+	if rot.phase == RotationDraining {
+		status.Draining = r.drainingNonces(rot.old)
+	}
+	return status
+}
+
+// drainingNonces returns the nonces of the old signers' tracked transactions, by destination
+// and signer, leaving out destinations with none
+func (r *Relayer) drainingNonces(old []common.Address) map[string]map[string][]uint64 {
+	retired := make(map[common.Address]bool, len(old))
+	for _, address := range old {
+		retired[address] = true
+	}
+	draining := make(map[string]map[string][]uint64)
+	for _, dest := range r.destinations {
+		for _, tx := range dest.client.pending.list() {
+			if !retired[tx.Signer] {
+				continue
+			}
+			if draining[dest.Name] == nil {
+				draining[dest.Name] = make(map[string][]uint64)
+			}
+			draining[dest.Name][tx.Signer.Hex()] = append(draining[dest.Name][tx.Signer.Hex()], tx.Nonce)
+		}
+	}
+	return draining
+}
+
+// StartKeyRotation moves new submissions on every destination to NEXT_PRIVATE_KEYS. The old
+// keys finish the submissions they were lent to and are then only watched until their
+// transactions are mined; see watchKeyRotation.
+func (r *Relayer) StartKeyRotation(principal string) (keyRotationStatus, error) {
+	if r.rotation == nil {
+		return keyRotationStatus{}, fmt.Errorf("no NEXT_PRIVATE_KEYS configured")
+	}
+	rot := r.rotation
+	rot.mu.Lock()
+	defer rot.mu.Unlock()
+	if rot.phase != RotationReady {
+		return r.keyRotationStatus(), fmt.Errorf("key rotation is already %s", rot.phase)
+	}
+
+	// Checked up front so a refusal leaves every destination on the old keys
+	for _, dest := range r.destinations {
+		if dest.client.txType != TxTypeZkSync {
+			continue
+		}
+		for _, account := range rot.next {
+			if _, ok := account.(typedDataSigner); !ok {
+				return r.keyRotationStatus(), fmt.Errorf("destination %q: signer %s cannot sign zkSync transactions",
+					dest.Name, account.Address().Hex())
+			}
+		}
+	}
+
+	rot.old = r.destinations[0].client.GetAddresses()
+	for _, dest := range r.destinations {
+		dest.client.signers.rotate(rot.next)
+	}
+	rot.phase = RotationDraining
+	rot.startedAt = time.Now()
+
+	status := r.keyRotationStatus()
+	r.logger.Warn("Signer key rotation started; new submissions use the next keys",
+		zap.Strings("old", status.Old),
+		zap.Strings("next", status.Next),
+		zap.String("principal", principal))
+	r.audit.Record(AuditKeyRotation, map[string]string{"phase": RotationDraining, "principal": principal})
+	return status, nil
+}
+
+// watchKeyRotation completes a draining rotation once no old key is lent to a submission or
+// has a tracked transaction, and the chain shows none of them with a pending nonce, which
+// also covers transactions sent before a restart
+func (r *Relayer) watchKeyRotation(ctx context.Context) {
+	if r.rotation == nil {
+		return
+	}
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if r.checkKeyRotation(ctx) {
+			return
+		}
+	}
+}
+
+// checkKeyRotation completes the rotation if the old keys are drained, reporting whether it
+// is complete
+func (r *Relayer) checkKeyRotation(ctx context.Context) bool {
+	rot := r.rotation
+	rot.mu.Lock()
+	defer rot.mu.Unlock()
+	switch rot.phase {
+	case RotationReady:
+		return false
+	case RotationComplete:
+		return true
+	}
+
+	if len(r.drainingNonces(rot.old)) > 0 {
+		return false
+	}
+	for _, dest := range r.destinations {
+		if dest.client.signers.retiredLent() {
+			return false
+		}
+	}
+	for _, dest := range r.destinations {
+		callCtx, cancel := context.WithTimeout(ctx, keyRotationCheckInterval)
+		err := dest.client.checkDrained(callCtx, rot.old)
+		cancel()
+		if err != nil {
+			r.logger.Debug("Old signer keys not drained yet",
+				zap.String("destination", dest.Name),
+				zap.Error(err))
+			return false
+		}
+	}
+
+	for _, dest := range r.destinations {
+		dest.client.signers.dropRetired()
+	}
+	rot.phase = RotationComplete
+	rot.completedAt = time.Now()
+	r.logger.Info("Signer key rotation complete; the old keys are out of the pool",
+		zap.Duration("drainedIn", rot.completedAt.Sub(rot.startedAt)))
+	r.audit.Record(AuditKeyRotation, map[string]string{"phase": RotationComplete})
+	return true
+}
+
+// checkDrained returns an error while any of the signers has a transaction pending on chain
+func (c *EVMClient) checkDrained(ctx context.Context, signers []common.Address) error {
+	for _, address := range signers {
+		confirmed, err := c.client.NonceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get confirmed nonce: %v", err)
+		}
+		pending, err := c.client.PendingNonceAt(ctx, address)
+		if err != nil {
+			return fmt.Errorf("failed to get pending nonce: %v", err)
+		}
+		if pending > confirmed {
+			return fmt.Errorf("signer %s has %d transaction(s) pending from nonce %d", address.Hex(), pending-confirmed, confirmed)
+		}
+	}
+	return nil
+}
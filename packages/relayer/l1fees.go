@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// How a destination charges for posting transaction data to L1
+const (
+	L1FeeAuto     = "auto"     // Detected from the chain's fee precompiles
+	L1FeeNone     = "none"     // An L1, or an L2 whose gas price covers its data costs
+	L1FeeOptimism = "optimism" // OP stack (Optimism, Base): an L1 fee on top of the gas paid
+	L1FeeArbitrum = "arbitrum" // Arbitrum: extra gas units charged within the gas limit
+)
+
+var (
+	// OP stack GasPriceOracle predeploy
+	opGasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+	// Arbitrum ArbSys precompile, present only on Arbitrum chains
+	arbSys = common.HexToAddress("0x0000000000000000000000000000000000000064")
+	// Arbitrum NodeInterface, a virtual contract answering eth_call only
+	arbNodeInterface = common.HexToAddress("0x00000000000000000000000000000000000000C8")
+)
+
+// l1FeeABI covers the OP stack oracle's and Arbitrum NodeInterface's L1 fee estimates
+const l1FeeABI = `[{
+    "inputs": [{"name": "data", "type": "bytes"}],
+    "name": "getL1Fee",
+    "outputs": [{"name": "fee", "type": "uint256"}],
+    "stateMutability": "view",
+    "type": "function"
+}, {
+    "inputs": [
+        {"name": "to", "type": "address"},
+        {"name": "contractCreation", "type": "bool"},
+        {"name": "data", "type": "bytes"}
+    ],
+    "name": "gasEstimateL1Component",
+    "outputs": [
+        {"name": "gasEstimateForL1", "type": "uint64"},
+        {"name": "baseFee", "type": "uint256"},
+        {"name": "l1BaseFeeEstimate", "type": "uint256"}
+    ],
+    "stateMutability": "payable",
+    "type": "function"
+}]`
+
+var parsedL1FeeABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(l1FeeABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// lookupL1FeeModel validates a configured L1 fee model
+func lookupL1FeeModel(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", L1FeeAuto:
+		return L1FeeAuto, nil
+	case L1FeeNone:
+		return L1FeeNone, nil
+	case L1FeeOptimism, "op", "base":
+		return L1FeeOptimism, nil
+	case L1FeeArbitrum:
+		return L1FeeArbitrum, nil
+	default:
+		return "", fmt.Errorf("unknown L1 fee model %q (want %s, %s, %s or %s)", name, L1FeeAuto, L1FeeNone, L1FeeOptimism, L1FeeArbitrum)
+	}
+}
+
+// detectL1FeeModel picks the L1 fee model from the fee precompiles the chain has
+func (c *EVMClient) detectL1FeeModel(ctx context.Context) (string, error) {
+	for _, candidate := range []struct {
+		address common.Address
+		model   string
+	}{{opGasPriceOracle, L1FeeOptimism}, {arbSys, L1FeeArbitrum}} {
+		code, err := c.client.CodeAt(ctx, candidate.address, nil)
+		if err != nil {
+			return "", err
+		}
+		if len(code) > 0 {
+			return candidate.model, nil
+		}
+	}
+	return L1FeeNone, nil
+}
+
+// l1Estimate is what posting a transaction's data to L1 is expected to cost
+type l1Estimate struct {
+	fee *big.Int // Charged on top of the gas paid (OP stack)
+	gas uint64   // Charged as gas within the gas limit (Arbitrum)
+}
+
+// estimateL1 estimates the L1 data cost of tx on the client's chain. Nothing is charged on
+// chains without an L1 fee.
+func (c *EVMClient) estimateL1(ctx context.Context, tx *types.Transaction) (l1Estimate, error) {
+	chain, err := c.chain(ctx)
+	if err != nil {
+		return l1Estimate{}, err
+	}
+	switch chain.l1Fee {
+	case L1FeeOptimism:
+		// The oracle takes the unsigned transaction and allows for the signature itself
+		encoded, err := tx.MarshalBinary()
+		if err != nil {
+			return l1Estimate{}, err
+		}
+		values, err := c.callL1Fee(ctx, opGasPriceOracle, "getL1Fee", encoded)
+		if err != nil {
+			return l1Estimate{}, err
+		}
+		return l1Estimate{fee: values[0].(*big.Int)}, nil
+	case L1FeeArbitrum:
+		values, err := c.callL1Fee(ctx, arbNodeInterface, "gasEstimateL1Component", *tx.To(), false, tx.Data())
+		if err != nil {
+			return l1Estimate{}, err
+		}
+		return l1Estimate{gas: values[0].(uint64)}, nil
+	}
+	return l1Estimate{}, nil
+}
+
+// callL1Fee calls one of the L1 fee precompiles
+func (c *EVMClient) callL1Fee(ctx context.Context, to common.Address, method string, args ...any) ([]any, error) {
+	input, err := parsedL1FeeABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ABI pack error: %v", err)
+	}
+	output, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: input}, nil)
+	if err != nil {
+		return nil, classifyError(fmt.Errorf("failed to estimate L1 data fee: %v", err), ErrorKindRPC)
+	}
+	values, err := parsedL1FeeABI.Unpack(method, output)
+	if err != nil || len(values) == 0 {
+		return nil, pipelineError(ErrorKindRPC, fmt.Errorf("unexpected %s result %x", method, output))
+	}
+	return values, nil
+}
+
+// withL1Costs accounts for the L1 data cost of a transaction about to be signed. On Arbitrum
+// its gas limit is raised by the L1 gas, so a jump in L1 prices can't run the call out of gas.
+// With MAX_TX_COST set, a transaction whose worst case cost exceeds it is refused; one over the
+// deferral threshold of its VAA is deferred.
+func (c *EVMClient) withL1Costs(ctx context.Context, params txParams, tx *types.Transaction, bumpPct int64) (*types.Transaction, error) {
+	maxTxCost := c.maxTxCost.Load()
+	if maxTxCost == nil && costThresholdFrom(ctx) == nil && params.chain.l1Fee != L1FeeArbitrum {
+		return tx, nil
+	}
+	l1, err := c.estimateL1(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if l1.gas > 0 {
+		tx = newTransaction(params, *tx.To(), tx.Value(), tx.Gas()+l1.gas, tx.Data(), bumpPct)
+	}
+	cost := worstCaseCost(tx, l1)
+	if maxTxCost != nil && cost.Cmp(maxTxCost) > 0 {
+		return nil, pipelineError(ErrorKindOverBudget, fmt.Errorf("transaction could cost %s ETH with its L1 data fee, over MAX_TX_COST of %s ETH",
+			formatWei(cost), formatWei(maxTxCost)))
+	}
+	if err := checkCostThreshold(ctx, cost); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// worstCaseCost is the most tx can cost with its L1 data fee: its whole gas limit at its fee
+// cap, plus the OP stack L1 fee
+func worstCaseCost(tx *types.Transaction, l1 l1Estimate) *big.Int {
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	if l1.fee != nil {
+		cost.Add(cost, l1.fee)
+	}
+	return cost
+}
+
+// receiptL1Fee returns the L1 fee a mined transaction paid on top of its gas, which OP stack
+// receipts report as l1Fee, or nil on other chains. Arbitrum's L1 cost is part of gasUsed.
+func (c *EVMClient) receiptL1Fee(ctx context.Context, receipt *types.Receipt) *big.Int {
+	chain, err := c.chain(ctx)
+	if err != nil || chain.l1Fee != L1FeeOptimism {
+		return nil
+	}
+	var raw struct {
+		L1Fee *hexutil.Big `json:"l1Fee"`
+	}
+	if err := c.client.Client().CallContext(ctx, &raw, "eth_getTransactionReceipt", receipt.TxHash); err != nil || raw.L1Fee == nil {
+		correlatedLogger(ctx, c.logger).Warn("Failed to read L1 fee from receipt; cost recorded without it",
+			zap.String("txHash", receipt.TxHash.Hex()),
+			zap.Error(err))
+		return nil
+	}
+	return raw.L1Fee.ToInt()
+}
+
+// receiptCost is what a mined transaction cost: its gas at the effective price plus l1Fee
+func receiptCost(receipt *types.Receipt, l1Fee *big.Int) *big.Int {
+	cost := new(big.Int).SetUint64(receipt.GasUsed)
+	if receipt.EffectiveGasPrice != nil {
+		cost.Mul(cost, receipt.EffectiveGasPrice)
+	} else {
+		cost.SetUint64(0)
+	}
+	if l1Fee != nil {
+		cost.Add(cost, l1Fee)
+	}
+	return cost
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// ledgerSigner signs with an account held on a USB Ledger. Every transaction has to be
+// approved on the device, so the key never touches the relayer host.
+type ledgerSigner struct {
+	wallet  accounts.Wallet
+	account accounts.Account
+	mu      sync.Mutex // The device handles one request at a time
+	logger  *zap.Logger
+}
+
+// openLedgerSigner connects to the first Ledger found and derives the account at derivationPath.
+// The device must be unlocked with the Ethereum app open.
+func openLedgerSigner(derivationPath string) (*ledgerSigner, error) {
+	path, err := accounts.ParseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ledger derivation path %q: %v", derivationPath, err)
+	}
+
+	hub, err := usbwallet.NewLedgerHub()
+	if err != nil {
+		return nil, fmt.Errorf("failed to access USB devices: %v", err)
+	}
+	wallets := hub.Wallets()
+	if len(wallets) == 0 {
+		return nil, fmt.Errorf("no Ledger found; connect and unlock it and open the Ethereum app")
+	}
+
+	wallet := wallets[0]
+	if err := wallet.Open(""); err != nil {
+		return nil, fmt.Errorf("failed to open Ledger: %v", err)
+	}
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("failed to derive Ledger account %s: %v", derivationPath, err)
+	}
+
+	signer := &ledgerSigner{
+		wallet:  wallet,
+		account: account,
+		logger: logger.With(zap.String("component", "Ledger"),
+			zap.String("address", account.Address.Hex())),
+	}
+	signer.logger.Info("Ledger signer ready", zap.String("derivationPath", derivationPath))
+	return signer, nil
+}
+
+// Address returns the Ledger account's address
+func (l *ledgerSigner) Address() common.Address {
+	return l.account.Address
+}
+
+// SignTx asks the operator to approve tx on the device. The log line carries the VAA and the
+// fields the Ledger displays so each approval can be matched to the recovery it relays.
+func (l *ledgerSigner) SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.logger.Warn("Approve transaction on Ledger",
+		zap.String("vaa", description),
+		zap.String("to", tx.To().Hex()),
+		zap.Uint64("nonce", tx.Nonce()),
+		zap.String("gasPrice", tx.GasPrice().String()),
+		zap.Uint64("gas", tx.Gas()),
+		zap.String("chainID", signer.ChainID().String()))
+
+	signed, err := l.wallet.SignTx(l.account, tx, signer.ChainID())
+	if err != nil {
+		return nil, fmt.Errorf("ledger signing failed (rejected on device?): %v", err)
+	}
+
+	l.logger.Info("Ledger approved transaction",
+		zap.String("vaa", description),
+		zap.String("txHash", signed.Hash().Hex()))
+	return signed, nil
+}
+
+// Close releases the device
+func (l *ledgerSigner) Close() error {
+	return l.wallet.Close()
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Longest maintenance window; also bounds how far back an active window's start is looked for
+const maxMaintenanceDuration = 7 * 24 * time.Hour
+
+// How often the windows are checked to log when they open and close
+const maintenanceCheckInterval = 15 * time.Second
+
+// MaintenanceWindowConfig describes a recurring window in which submission is deferred
+type MaintenanceWindowConfig struct {
+	Name     string        // Label used in logs and metrics
+	Schedule string        // Cron expression (minute hour day-of-month month day-of-week) of each start
+	Duration time.Duration // How long each window lasts
+	Timezone string        // Time zone the schedule is read in
+	Tenants  []string      // Tenants whose submissions are deferred (empty for all)
+}
+
+// loadMaintenanceWindowsFromEnv reads MAINTENANCE_WINDOWS and each window's MAINTENANCE_<NAME>_*
+// settings
+func loadMaintenanceWindowsFromEnv() []MaintenanceWindowConfig {
+	var windows []MaintenanceWindowConfig
+	for _, name := range getEnvListOrDefault("MAINTENANCE_WINDOWS", nil) {
+		prefix := "MAINTENANCE_" + strings.ToUpper(name) + "_"
+		windows = append(windows, MaintenanceWindowConfig{
+			Name:     name,
+			Schedule: getEnvOrDefault(prefix+"SCHEDULE", ""),
+			Duration: getEnvDurationOrDefault(prefix+"DURATION", time.Hour),
+			Timezone: getEnvOrDefault(prefix+"TIMEZONE", "UTC"),
+			Tenants:  getEnvListOrDefault(prefix+"TENANTS", nil),
+		})
+	}
+	return windows
+}
+
+// cronSchedule is a parsed five-field cron expression; each field is the set of values it
+// matches
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool // Whether day-of-month and day-of-week are *
+}
+
+// cronMonths and cronWeekdays are the names accepted in the month and day-of-week fields
+var (
+	cronMonths   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseCron parses a cron expression of minute, hour, day of month, month and day of week.
+// Fields take *, values, ranges (a-b), steps (*/n, a-b/n) and comma-separated lists of them;
+// months and weekdays may be given by their three-letter names, and Sunday is 0 or 7.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, has %d", expr, len(fields))
+	}
+	s := &cronSchedule{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if s.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if s.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if s.days, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if s.months, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if s.weekdays, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	return s, nil
+}
+
+// parseCronField returns the set of values a field matches. names, if any, are accepted for
+// the values from min on.
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	value := func(text string) (int, error) {
+		for i, name := range names {
+			if strings.EqualFold(text, name) {
+				return min + i, nil
+			}
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not a value from %d to %d", text, min, max)
+		}
+		return n, nil
+	}
+
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangeText == "*":
+		case strings.Contains(rangeText, "-"):
+			loText, hiText, _ := strings.Cut(rangeText, "-")
+			var err error
+			if lo, err = value(loText); err != nil {
+				return 0, err
+			}
+			if hi, err = value(hiText); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("range %q runs backwards", rangeText)
+			}
+		default:
+			n, err := value(rangeText)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if !hasStep {
+				hi = n
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+// matches reports whether a window starts at t's minute. As in cron, when both day fields are
+// restricted a day matching either is enough.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minutes&(1<<t.Minute()) == 0 || s.hours&(1<<t.Hour()) == 0 || s.months&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	return s.dayMatches(t)
+}
+
+// dayMatches reports whether t's day is one the schedule runs on
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// next returns the first start after t, or false if there is none within a few years, as
+// with February 30th
+func (s *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// maintenanceWindow is a configured window with its parsed schedule
+type maintenanceWindow struct {
+	MaintenanceWindowConfig
+	schedule *cronSchedule
+	location *time.Location
+	tenants  map[string]bool // nil for every tenant
+}
+
+// newMaintenanceWindows parses the configured windows, checking the tenants they name exist
+func newMaintenanceWindows(configs []MaintenanceWindowConfig, tenants []TenantConfig) ([]*maintenanceWindow, error) {
+	known := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		known[tenant.Name] = true
+	}
+	windows := make([]*maintenanceWindow, 0, len(configs))
+	for _, cfg := range configs {
+		schedule, err := parseCron(cfg.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %v", cfg.Name, err)
+		}
+		if cfg.Duration <= 0 || cfg.Duration > maxMaintenanceDuration {
+			return nil, fmt.Errorf("maintenance window %q: duration must be positive and at most %s", cfg.Name, maxMaintenanceDuration)
+		}
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance window %q: invalid timezone %q: %v", cfg.Name, cfg.Timezone, err)
+		}
+		window := &maintenanceWindow{MaintenanceWindowConfig: cfg, schedule: schedule, location: location}
+		if len(cfg.Tenants) > 0 {
+			window.tenants = make(map[string]bool, len(cfg.Tenants))
+			for _, name := range cfg.Tenants {
+				if !known[name] {
+					return nil, fmt.Errorf("maintenance window %q names unknown tenant %q", cfg.Name, name)
+				}
+				window.tenants[name] = true
+			}
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// appliesTo reports whether the window defers the tenant's submissions
+func (w *maintenanceWindow) appliesTo(tenant string) bool {
+	return w.tenants == nil || w.tenants[tenant]
+}
+
+// activeUntil returns when the window open at now closes, or false if it isn't open. Of
+// overlapping windows, the latest started decides.
+func (w *maintenanceWindow) activeUntil(now time.Time) (time.Time, bool) {
+	local := now.In(w.location).Truncate(time.Minute)
+	earliest := now.Add(-w.Duration)
+	for start := local; start.After(earliest); start = start.Add(-time.Minute) {
+		if w.schedule.matches(start) {
+			return start.Add(w.Duration), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// maintenanceUntil returns the window deferring the tenant's submissions at now and when it
+// closes, taking the one closing last if several are open
+func (r *Relayer) maintenanceUntil(tenant string, now time.Time) (*maintenanceWindow, time.Time) {
+	var open *maintenanceWindow
+	var until time.Time
+	for _, window := range r.maintenance {
+		if !window.appliesTo(tenant) {
+			continue
+		}
+		if end, ok := window.activeUntil(now); ok && end.After(until) {
+			open, until = window, end
+		}
+	}
+	return open, until
+}
+
+// waitOutMaintenance holds a VAA while a maintenance window of its tenant is open. The VAA
+// was observed and routed; it is submitted once the window closes.
+func (r *Relayer) waitOutMaintenance(ctx context.Context, vaaData *VAAData) error {
+	for {
+		window, until := r.maintenanceUntil(vaaData.Tenant, time.Now())
+		if window == nil {
+			return nil
+		}
+		withCorrelation(r.logger, vaaData.CorrelationID).Info("Maintenance window open, deferring VAA",
+			zap.String("window", window.Name),
+			zap.Time("until", until),
+			zap.Uint64("sequence", vaaData.Sequence))
+
+		r.deferredVAAs.Add(1)
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-timer.C:
+			r.deferredVAAs.Add(-1)
+		case <-ctx.Done():
+			timer.Stop()
+			r.deferredVAAs.Add(-1)
+			return ctx.Err()
+		}
+	}
+}
+
+// watchMaintenanceWindows logs each window opening and closing and keeps
+// relayer_maintenance_window_open current
+func (r *Relayer) watchMaintenanceWindows(ctx context.Context) {
+	if len(r.maintenance) == 0 {
+		return
+	}
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	open := make(map[string]bool, len(r.maintenance))
+	for {
+		now := time.Now()
+		for _, window := range r.maintenance {
+			until, active := window.activeUntil(now)
+			maintenanceWindowOpen.WithLabelValues(window.Name).Set(boolToFloat(active))
+			if active == open[window.Name] {
+				continue
+			}
+			open[window.Name] = active
+			if active {
+				r.logger.Warn("Maintenance window opened; submissions deferred",
+					zap.String("window", window.Name),
+					zap.Strings("tenants", window.Tenants),
+					zap.Time("until", until))
+				r.audit.Record(AuditMaintenance, map[string]string{"window": window.Name, "phase": "open"})
+			} else {
+				r.logger.Info("Maintenance window closed; submitting deferred VAAs",
+					zap.String("window", window.Name),
+					zap.Int64("deferred", r.deferredVAAs.Load()))
+				r.audit.Record(AuditMaintenance, map[string]string{"window": window.Name, "phase": "closed"})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MaintenanceState reports each window, whether it is open and when it next opens or closes
+func (r *Relayer) MaintenanceState() []map[string]any {
+	now := time.Now()
+	state := make([]map[string]any, 0, len(r.maintenance))
+	for _, window := range r.maintenance {
+		entry := map[string]any{
+			"name":     window.Name,
+			"schedule": window.Schedule,
+			"duration": window.Duration.String(),
+			"timezone": window.Timezone,
+			"tenants":  window.Tenants,
+		}
+		if until, ok := window.activeUntil(now); ok {
+			entry["open"] = true
+			entry["until"] = until.UTC().Format(time.RFC3339)
+		} else {
+			entry["open"] = false
+		}
+		if next, ok := window.schedule.next(now.In(window.location)); ok {
+			entry["nextStart"] = next.UTC().Format(time.RFC3339)
+		}
+		state = append(state, entry)
+	}
+	return state
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MemoryStore keeps relayer state in memory only; everything is lost on restart
+type MemoryStore struct {
+	mu          sync.Mutex
+	processed   map[string]time.Time
+	emitters    map[string]map[common.Address]string // tenant -> safe -> aztecContract
+	cursors     map[string]uint64
+	overrides   map[string]map[string]EmitterOverride // tenant -> emitter -> override
+	checkpoints map[string]Checkpoint
+	retries     map[string]RetryEntry
+	inflight    map[string]InflightEntry
+	safeCosts   map[string]SafeCost
+	relays      map[common.Hash]RelayRecord
+	lease       Lease
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		processed:   make(map[string]time.Time),
+		emitters:    make(map[string]map[common.Address]string),
+		cursors:     make(map[string]uint64),
+		overrides:   make(map[string]map[string]EmitterOverride),
+		checkpoints: make(map[string]Checkpoint),
+		retries:     make(map[string]RetryEntry),
+		inflight:    make(map[string]InflightEntry),
+		safeCosts:   make(map[string]SafeCost),
+		relays:      make(map[common.Hash]RelayRecord),
+	}
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// MarkProcessed records a handled VAA
+func (s *MemoryStore) MarkProcessed(key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processed[key] = at
+	return nil
+}
+
+// LoadProcessed returns dedupe entries recorded at or after since
+func (s *MemoryStore) LoadProcessed(since time.Time) (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]time.Time)
+	for key, at := range s.processed {
+		if !at.Before(since) {
+			result[key] = at
+		}
+	}
+	return result, nil
+}
+
+// PruneProcessed deletes dedupe entries recorded before the given time
+func (s *MemoryStore) PruneProcessed(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, at := range s.processed {
+		if at.Before(before) {
+			delete(s.processed, key)
+		}
+	}
+	return nil
+}
+
+// SaveEmitter records the Aztec contract registered by a Safe with a tenant's module
+func (s *MemoryStore) SaveEmitter(tenant string, safe common.Address, aztecContract string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.emitters[tenant] == nil {
+		s.emitters[tenant] = make(map[common.Address]string)
+	}
+	s.emitters[tenant][safe] = aztecContract
+	return nil
+}
+
+// RemoveEmitter deletes the Safe's registration with the tenant's module
+func (s *MemoryStore) RemoveEmitter(tenant string, safe common.Address) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.emitters[tenant], safe)
+	return nil
+}
+
+// LoadEmitters returns the Aztec contract registered by each Safe with the tenant's module
+func (s *MemoryStore) LoadEmitters(tenant string) (map[common.Address]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[common.Address]string, len(s.emitters[tenant]))
+	for safe, contract := range s.emitters[tenant] {
+		result[safe] = contract
+	}
+	return result, nil
+}
+
+// SaveEmitterOverride inserts or replaces an operator's override of a tenant's registry
+func (s *MemoryStore) SaveEmitterOverride(override EmitterOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overrides[override.Tenant] == nil {
+		s.overrides[override.Tenant] = make(map[string]EmitterOverride)
+	}
+	s.overrides[override.Tenant][override.Emitter] = override
+	return nil
+}
+
+// RemoveEmitterOverride deletes the override of the emitter in the tenant's registry
+func (s *MemoryStore) RemoveEmitterOverride(tenant, emitter string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides[tenant], emitter)
+	return nil
+}
+
+// LoadEmitterOverrides returns the overrides of the tenant's registry
+func (s *MemoryStore) LoadEmitterOverrides(tenant string) ([]EmitterOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]EmitterOverride, 0, len(s.overrides[tenant]))
+	for _, override := range s.overrides[tenant] {
+		result = append(result, override)
+	}
+	return result, nil
+}
+
+// SaveScanCursor records the last block whose registry events were applied for the tenant
+func (s *MemoryStore) SaveScanCursor(tenant string, block uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[tenant] = block
+	return nil
+}
+
+// LoadScanCursor returns the tenant's scan cursor, and false when none was saved
+func (s *MemoryStore) LoadScanCursor(tenant string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block, ok := s.cursors[tenant]
+	return block, ok, nil
+}
+
+// SaveCheckpoint stores cp unless a higher sequence is already recorded for the emitter
+func (s *MemoryStore) SaveCheckpoint(cp Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := checkpointKey(cp.EmitterChain, cp.EmitterAddress)
+	if current, ok := s.checkpoints[key]; ok && current.Sequence >= cp.Sequence {
+		return nil
+	}
+	s.checkpoints[key] = cp
+	return nil
+}
+
+// LoadCheckpoints returns all checkpoints keyed by checkpointKey
+func (s *MemoryStore) LoadCheckpoints() (map[string]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]Checkpoint, len(s.checkpoints))
+	for key, cp := range s.checkpoints {
+		result[key] = cp
+	}
+	return result, nil
+}
+
+// SaveRetry inserts or replaces a retry queue entry
+func (s *MemoryStore) SaveRetry(entry RetryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries[entry.Key] = entry
+	return nil
+}
+
+// RemoveRetry deletes the retry queue entry with the dedupe key
+func (s *MemoryStore) RemoveRetry(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.retries, key)
+	return nil
+}
+
+// LoadRetries returns every queued retry
+func (s *MemoryStore) LoadRetries() ([]RetryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]RetryEntry, 0, len(s.retries))
+	for _, entry := range s.retries {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// SaveInflight records a VAA that has entered processing
+func (s *MemoryStore) SaveInflight(entry InflightEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[entry.Key] = entry
+	return nil
+}
+
+// RemoveInflight deletes the inflight record with the dedupe key
+func (s *MemoryStore) RemoveInflight(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, key)
+	return nil
+}
+
+// LoadInflight returns every inflight record
+func (s *MemoryStore) LoadInflight() ([]InflightEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]InflightEntry, 0, len(s.inflight))
+	for _, entry := range s.inflight {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ClaimLease renews or takes over the lease for holder
+func (s *MemoryStore) ClaimLease(holder string, ttl time.Duration) (Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.lease.Holder == "" || s.lease.Holder == holder || now.Sub(s.lease.RenewedAt) > ttl {
+		s.lease = Lease{Holder: holder, RenewedAt: now}
+	}
+	return s.lease, nil
+}
+
+// AddSafeCost adds a confirmed relay to the Safe's daily gas totals on its chain
+func (s *MemoryStore) AddSafeCost(cost SafeCost) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cost.Day = safeCostDay(cost.Day)
+	key := safeCostKey(cost.Safe, cost.ChainID, cost.Day)
+	total, ok := s.safeCosts[key]
+	if !ok {
+		total = SafeCost{Safe: cost.Safe, ChainID: cost.ChainID, Day: cost.Day}
+	}
+	addSafeCost(&total, cost)
+	s.safeCosts[key] = total
+	return nil
+}
+
+// SafeCosts returns daily totals for one Safe, or all Safes when safe is nil
+func (s *MemoryStore) SafeCosts(safe *common.Address, from, to time.Time) ([]SafeCost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var costs []SafeCost
+	for _, cost := range s.safeCosts {
+		if safeCostMatches(cost, safe, from, to) {
+			costs = append(costs, cost)
+		}
+	}
+	sortSafeCosts(costs)
+	return costs, nil
+}
+
+// AddRelayRecord stores the gas and fee of a confirmed relay transaction
+func (s *MemoryStore) AddRelayRecord(record RelayRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.relays[record.TxHash] = record
+	return nil
+}
+
+// RelayRecords returns the transactions confirmed between from and to, oldest first
+func (s *MemoryStore) RelayRecords(safe *common.Address, from, to time.Time) ([]RelayRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []RelayRecord
+	for _, record := range s.relays {
+		if relayRecordMatches(record, safe, from, to) {
+			records = append(records, record)
+		}
+	}
+	sortRelayRecords(records)
+	return records, nil
+}
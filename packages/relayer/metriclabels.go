@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Ways emitter and Safe addresses are turned into metric label values
+const (
+	AddressLabelsExact  = "exact"  // The address itself
+	AddressLabelsHash   = "hash"   // First 8 hex digits of its SHA-256
+	AddressLabelsBucket = "bucket" // One of METRIC_ADDRESS_BUCKETS buckets picked by its SHA-256
+	AddressLabelsOff    = "off"    // Per-address metrics are not exported
+)
+
+// otherLabel stands for every address seen after the label limit was reached
+const otherLabel = "other"
+
+// addressLabels maps high-cardinality addresses to bounded label values. Exact values stay in
+// the logs, the audit log and the relay history. A nil *addressLabels exports nothing.
+type addressLabels struct {
+	mode      string
+	buckets   int
+	maxValues int // Distinct values per label before new ones become "other" (0 for no limit)
+
+	mu   sync.Mutex
+	seen map[string]map[string]bool // Label values handed out, by label name
+}
+
+// newAddressLabels creates the label mapping from METRIC_ADDRESS_LABELS, or returns nil when
+// per-address metrics are off
+func newAddressLabels(config Config) (*addressLabels, error) {
+	switch config.MetricAddressLabels {
+	case AddressLabelsOff:
+		return nil, nil
+	case AddressLabelsExact, AddressLabelsHash, AddressLabelsBucket:
+	default:
+		return nil, fmt.Errorf("METRIC_ADDRESS_LABELS must be %s, %s, %s or %s, got %q",
+			AddressLabelsExact, AddressLabelsHash, AddressLabelsBucket, AddressLabelsOff, config.MetricAddressLabels)
+	}
+	if config.MetricAddressBuckets < 1 {
+		return nil, fmt.Errorf("METRIC_ADDRESS_BUCKETS must be at least 1")
+	}
+	if config.MetricLabelMaxValues < 0 {
+		return nil, fmt.Errorf("METRIC_LABEL_MAX_VALUES must not be negative")
+	}
+	return &addressLabels{
+		mode:      config.MetricAddressLabels,
+		buckets:   config.MetricAddressBuckets,
+		maxValues: config.MetricLabelMaxValues,
+		seen:      make(map[string]map[string]bool),
+	}, nil
+}
+
+// value returns the label value of address under the label name
+func (l *addressLabels) value(name, address string) string {
+	address = strings.ToLower(address)
+	sum := sha256.Sum256([]byte(address))
+
+	var value string
+	switch l.mode {
+	case AddressLabelsHash:
+		value = hex.EncodeToString(sum[:4])
+	case AddressLabelsBucket:
+		// Buckets are bounded already
+		return fmt.Sprintf("bucket-%d", binary.BigEndian.Uint64(sum[:8])%uint64(l.buckets))
+	default:
+		value = address
+	}
+	if l.maxValues == 0 {
+		return value
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seen := l.seen[name]
+	if seen == nil {
+		seen = make(map[string]bool)
+		l.seen[name] = seen
+	}
+	if !seen[value] {
+		if len(seen) >= l.maxValues {
+			addressLabelOverflows.WithLabelValues(name).Inc()
+			return otherLabel
+		}
+		seen[value] = true
+	}
+	return value
+}
+
+// countEmitterVAA counts a VAA handled for its emitter
+func (r *Relayer) countEmitterVAA(vaaData *VAAData, result string) {
+	if r.addressLabels == nil {
+		return
+	}
+	emitter := r.addressLabels.value("emitter", vaaData.EmitterHex)
+	incWithExemplar(emitterVAAs.WithLabelValues(emitter, result), vaaData.CorrelationID)
+}
+
+// countSafeRelay counts a VAA relayed to a Safe
+func (r *Relayer) countSafeRelay(vaaData *VAAData, safe string) {
+	if r.addressLabels == nil {
+		return
+	}
+	incWithExemplar(safeRelays.WithLabelValues(r.addressLabels.value("safe", safe)), vaaData.CorrelationID)
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	evmRPCRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_evm_rpc_requests_total",
+			Help: "Total number of EVM JSON-RPC requests by endpoint, method and result",
+		}, []string{"endpoint", "method", "result"})
+
+	evmRPCDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "relayer_evm_rpc_duration_seconds",
+			Help:    "Latency of EVM JSON-RPC requests by endpoint and method",
+			Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"endpoint", "method"})
+
+	evmRPCCircuitOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_evm_rpc_circuit_open",
+			Help: "Whether the EVM RPC circuit breaker for an endpoint is open (1) or closed (0)",
+		}, []string{"endpoint"})
+
+	evmRPCStalled = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_evm_rpc_stalled",
+			Help: "Whether an EVM RPC endpoint's head block has stopped advancing (1) or not (0)",
+		}, []string{"endpoint"})
+
+	signerBalance = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_signer_balance_eth",
+			Help: "Balance of each relayer signer per destination, as last read by the treasury refiller",
+		}, []string{"destination", "signer"})
+
+	refills = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_treasury_refills_total",
+			Help: "Treasury top-ups per destination by result (sent, failed, limited)",
+		}, []string{"destination", "result"})
+
+	relayAcks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_relay_acks_total",
+			Help: "Relay acknowledgments published per destination by result (sent, failed)",
+		}, []string{"destination", "result"})
+
+	vaaClockSkew = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_vaa_clock_skew_seconds",
+			Help: "How far the last relayed VAA's timestamp was ahead of local time (reference=local) or a destination's latest block",
+		}, []string{"reference"})
+
+	pipelineErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_errors_total",
+			Help: "Pipeline failures by kind (spy, decode, simulation_revert, reverted, nonce_conflict, insufficient_funds, timeout, rpc, out_of_order, not_sponsored, over_budget, unknown)",
+		}, []string{"kind"})
+
+	spyLastMessageTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_spy_last_message_timestamp_seconds",
+			Help: "Unix time of the last message received from the spy stream",
+		})
+
+	tenantRegisteredEmitters = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_tenant_registered_emitters",
+			Help: "Number of Aztec emitters registered with each tenant's module",
+		}, []string{"tenant"})
+
+	tenantVAAs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_tenant_vaas_total",
+			Help: "VAAs handled per tenant by result (relayed, rejected, failed); tenant is none before routing",
+		}, []string{"tenant", "result"})
+
+	batchObservations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_batch_observations_total",
+			Help: "Observations unpacked from batch VAAs by result (relevant, relayed, unavailable, skipped, invalid)",
+		}, []string{"result"})
+
+	guardianSetIndex = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_guardian_set_index",
+			Help: "Current guardian set index read from the Wormhole core contract",
+		})
+
+	outdatedGuardianSetVAAs = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_outdated_guardian_set_vaas_total",
+			Help: "VAAs signed by a guardian set other than the current one",
+		})
+
+	failoverActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_failover_active",
+			Help: "1 while this instance holds the failover lease and submits, 0 while standing by",
+		})
+
+	failoverPromotions = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_failover_promotions_total",
+			Help: "Number of times this instance took the failover lease over from another instance",
+		})
+
+	standbyPendingVAAs = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_standby_pending_vaas",
+			Help: "VAAs held by a standby that the active instance has not yet handled",
+		})
+
+	safePreflightFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_safe_preflight_failures_total",
+			Help: "VAAs skipped because the Safe preflight found they would revert, by failed check",
+		}, []string{"check"})
+
+	reverts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_reverts_total",
+			Help: "Decoded reverts by class and stage (simulation or transaction)",
+		}, []string{"class", "stage"})
+
+	spyConnectionState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_spy_connection_state",
+			Help: "1 for the current gRPC connectivity state of the spy connection, 0 for the others",
+		}, []string{"state"})
+
+	spyConnectionTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_spy_connection_transitions_total",
+			Help: "Spy connection state changes by the state entered",
+		}, []string{"state"})
+
+	spyStreamStale = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_spy_stream_stale_total",
+			Help: "Number of times the spy stream went silent past the stale timeout and was resubscribed",
+		})
+
+	dedupeEntries = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_dedupe_entries",
+			Help: "Processed VAAs remembered in memory for dedupe",
+		})
+
+	dedupeEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_dedupe_evictions_total",
+			Help: "Dedupe entries dropped from memory, by reason (expired or capacity)",
+		}, []string{"reason"})
+
+	openConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_open_connections",
+			Help: "Open network connections by peer: spy for the gRPC stream, else the EVM RPC endpoint",
+		}, []string{"peer"})
+
+	queueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_queue_depth",
+			Help: "VAAs waiting in each internal queue (retry, inflight, paused, safe_order, intake, spilled, maintenance)",
+		}, []string{"queue"})
+
+	emitterWatchers = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_emitter_watchers",
+			Help: "Running emitter registry watchers per tenant; more than 1 means a leaked watcher",
+		}, []string{"tenant"})
+
+	emitterWatchRestarts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_emitter_watch_restarts_total",
+			Help: "Number of times a tenant's emitter subscription failed and was restarted",
+		}, []string{"tenant"})
+
+	reconcileDiscrepancies = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_reconcile_discrepancies_total",
+			Help: "Disagreements between relay records and on-chain state found by reconciliation, by kind",
+		}, []string{"kind"})
+
+	reconcileRedriven = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_reconcile_redriven_total",
+			Help: "VAAs recorded as relayed but not consumed that reconciliation queued for another attempt",
+		})
+
+	reconcileLastRun = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_reconcile_last_run_timestamp_seconds",
+			Help: "Unix time the last reconciliation finished",
+		})
+
+	catchupVAAs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_catchup_vaas_total",
+			Help: "VAAs missed during downtime requested from the guardian API on startup, by outcome (fetched or unavailable)",
+		}, []string{"outcome"})
+
+	emitterPollIntervalSeconds = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_emitter_poll_interval_seconds",
+			Help: "Current interval between emitter registry polls per tenant, before jitter",
+		}, []string{"tenant"})
+
+	canarySuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_canary_success",
+			Help: "Whether the last canary check passed (1) or failed (0), by source (injected or spy)",
+		}, []string{"source"})
+
+	canaryLastSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_canary_last_success_timestamp_seconds",
+			Help: "Unix time of the last passing canary check, by source",
+		}, []string{"source"})
+
+	canaryLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "relayer_canary_latency_seconds",
+			Help:    "Time from a spy canary VAA's timestamp to its passing simulation",
+			Buckets: []float64{5, 15, 30, 60, 120, 300, 600, 1800},
+		})
+
+	auditLogIntact = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_audit_log_intact",
+			Help: "Whether the audit log's hash chain verified on startup (1) or was found broken (0)",
+		})
+
+	auditLogWriteErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_audit_log_write_errors_total",
+			Help: "Audit log entries that could not be written",
+		})
+
+	adminAuthFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_admin_auth_failures_total",
+			Help: "Admin API requests refused, by status (401 without valid credentials, 403 without the permission needed)",
+		}, []string{"status"})
+
+	adminRequestsRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_admin_requests_rejected_total",
+			Help: "Admin HTTP requests refused before authentication, by reason (not_allowed or rate_limited)",
+		}, []string{"reason"})
+
+	metricsPushes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_metrics_pushes_total",
+			Help: "Pushes of the metrics to the Pushgateway, by result (ok or failed)",
+		}, []string{"result"})
+
+	emitterVAAs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_emitter_vaas_total",
+			Help: "VAAs handled per emitter, labelled as METRIC_ADDRESS_LABELS sets, by result (relayed, rejected or failed)",
+		}, []string{"emitter", "result"})
+
+	safeRelays = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_safe_relays_total",
+			Help: "VAAs relayed per Safe, labelled as METRIC_ADDRESS_LABELS sets",
+		}, []string{"safe"})
+
+	addressLabelOverflows = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_metric_label_overflows_total",
+			Help: "Per-address metric updates counted as \"other\" because METRIC_LABEL_MAX_VALUES was reached, by label",
+		}, []string{"label"})
+
+	cosignResponses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_cosign_responses_total",
+			Help: "Co-signer answers to approval requests, by co-signer host and result (approved, refused or duplicate)",
+		}, []string{"cosigner", "result"})
+
+	sponsorshipDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_sponsorship_decisions_total",
+			Help: "Sponsorship policy decisions on sponsored destinations (sponsored, signer_pool or rejected)",
+		}, []string{"destination", "result"})
+
+	gasStationReadings = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_gas_station_readings_total",
+			Help: "Gas station readings by whether they raised the RPC's fees (raised, unchanged or error)",
+		}, []string{"destination", "result"})
+
+	pendingTxsGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_pending_transactions",
+			Help: "Broadcast transactions not yet seen mined",
+		}, []string{"destination"})
+
+	pendingTxOldestAge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_pending_transaction_oldest_age_seconds",
+			Help: "Time since the oldest pending transaction was broadcast (0 when none are pending)",
+		}, []string{"destination"})
+
+	pendingTxsResolved = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_pending_transactions_resolved_total",
+			Help: "Tracked transactions that stopped being pending (mined, replaced, dropped or reset)",
+		}, []string{"destination", "result"})
+
+	ensTargetMismatch = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_ens_target_mismatch",
+			Help: "Whether a tenant's ENS target name resolves to another address than the one served (1) or not (0)",
+		}, []string{"tenant"})
+
+	intakeOverflows = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_intake_overflows_total",
+			Help: "VAAs that arrived from the spy while the intake queue was full, by the overflow policy applied",
+		}, []string{"policy"})
+
+	intakeOverflowing = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_intake_overflowing",
+			Help: "Whether the last VAA from the spy found the intake queue full (1) or not (0)",
+		})
+
+	maintenanceWindowOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "relayer_maintenance_window_open",
+			Help: "Whether a scheduled maintenance window is deferring submissions (1) or not (0)",
+		}, []string{"window"})
+
+	costDeferrals = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_cost_deferrals_total",
+			Help: "VAAs deferred because relaying them would cost more than their threshold",
+		})
+
+	costDeferralExpiries = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "relayer_cost_deferral_expiries_total",
+			Help: "Deferred VAAs relayed regardless of cost once their deadline passed",
+		})
+
+	payloadAuthorizationFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_payload_authorization_failures_total",
+			Help: "VAAs rejected because the authorization signature in their payload does not verify, by payload version",
+		}, []string{"version"})
+
+	attestations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_attestations_total",
+			Help: "Relay attestations written to the attestation log per destination by result (sent, failed)",
+		}, []string{"destination", "result"})
+
+	gossipMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_gossip_messages_total",
+			Help: "Messages received on the guardian gossip VAA topic by outcome (signed_vaa, invalid, unknown, loopback, rejected)",
+		}, []string{"outcome"})
+
+	gossipPeers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_gossip_peers",
+			Help: "Peers the gossip listener is connected to",
+		})
+
+	observations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_observations_total",
+			Help: "Guardian observations of source chain messages by source (gossip, api, vaa) and result (accepted, invalid, unknown_guardian, no_guardian_set, late, dropped)",
+		}, []string{"source", "result"})
+
+	assembledVAAs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "relayer_assembled_vaas_total",
+			Help: "VAAs signed by too few guardians by outcome (held, assembled, expired)",
+		}, []string{"outcome"})
+
+	assemblyPending = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "relayer_assembly_pending",
+			Help: "Message digests with observations or a held VAA awaiting a quorum",
+		})
+)
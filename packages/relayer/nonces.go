@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// Ways local nonce tracking can disagree with the chain
+const (
+	NonceAlreadyMined = "already_mined" // A tracked transaction's nonce is below the confirmed nonce
+	NonceNotPending   = "not_pending"   // A tracked transaction's nonce is at or above the RPC's pending nonce
+	NonceUntracked    = "untracked"     // The RPC has pending nonces the relayer didn't send, or lost track of
+)
+
+// nonceAudit compares one signer's tracked transactions on a destination with its nonces on chain
+type nonceAudit struct {
+	Destination   string   `json:"destination"`
+	Signer        string   `json:"signer"`
+	Confirmed     uint64   `json:"confirmed"`
+	Pending       uint64   `json:"pending"`
+	LocalNext     *uint64  `json:"localNext,omitempty"` // Lowest nonce the next transaction may use, while any are tracked
+	Tracked       []uint64 `json:"tracked"`             // Nonces of the tracked pending transactions
+	Discrepancies []string `json:"discrepancies"`
+	Reset         []string `json:"reset,omitempty"` // Transactions no longer tracked after a resync
+}
+
+// AuditNonces compares every signer's tracked pending transactions with its confirmed and
+// pending nonces on each destination, or only on the named one. With resync, tracking of
+// transactions the chain contradicts is dropped, so new transactions take their nonces from
+// the chain again.
+func (r *Relayer) AuditNonces(ctx context.Context, destination string, resync bool) ([]nonceAudit, error) {
+	var audits []nonceAudit
+	found := destination == ""
+	for _, dest := range r.destinations {
+		if destination != "" && dest.Name != destination {
+			continue
+		}
+		found = true
+		destAudits, err := dest.client.auditNonces(ctx, resync)
+		if err != nil {
+			return nil, fmt.Errorf("destination %q: %v", dest.Name, err)
+		}
+		for i := range destAudits {
+			destAudits[i].Destination = dest.Name
+		}
+		audits = append(audits, destAudits...)
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown destination %q", destination)
+	}
+	return audits, nil
+}
+
+// auditNonces audits each of the client's signers, reading their confirmed and pending nonces
+// in one batch request
+func (c *EVMClient) auditNonces(ctx context.Context, resync bool) ([]nonceAudit, error) {
+	addresses := c.signers.addresses()
+	confirmed := make([]hexutil.Uint64, len(addresses))
+	pending := make([]hexutil.Uint64, len(addresses))
+	calls := make([]rpc.BatchElem, 0, 2*len(addresses))
+	for i, address := range addresses {
+		calls = append(calls,
+			rpc.BatchElem{Method: "eth_getTransactionCount", Args: []any{address, "latest"}, Result: &confirmed[i]},
+			rpc.BatchElem{Method: "eth_getTransactionCount", Args: []any{address, "pending"}, Result: &pending[i]})
+	}
+	if err := c.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if call.Error != nil {
+			return nil, classifyError(fmt.Errorf("failed to get nonce: %v", call.Error), ErrorKindRPC)
+		}
+	}
+
+	tracked := c.pending.list()
+	audits := make([]nonceAudit, 0, len(addresses))
+	for i, address := range addresses {
+		audit := c.auditSigner(address, uint64(confirmed[i]), uint64(pending[i]), tracked, resync)
+		if len(audit.Discrepancies) > 0 {
+			c.logger.Warn("Nonce tracking disagrees with the chain",
+				zap.String("signer", audit.Signer),
+				zap.Uint64("confirmed", audit.Confirmed),
+				zap.Uint64("pending", audit.Pending),
+				zap.Uint64s("tracked", audit.Tracked),
+				zap.Strings("discrepancies", audit.Discrepancies),
+				zap.Strings("reset", audit.Reset))
+		}
+		audits = append(audits, audit)
+	}
+	return audits, nil
+}
+
+// auditSigner compares the tracked transactions of one signer with its nonces on chain
+func (c *EVMClient) auditSigner(address common.Address, confirmed, pending uint64, tracked []pendingTx, resync bool) nonceAudit {
+	audit := nonceAudit{
+		Signer:        address.Hex(),
+		Confirmed:     confirmed,
+		Pending:       pending,
+		Tracked:       []uint64{},
+		Discrepancies: []string{},
+	}
+	byNonce := make(map[uint64]bool)
+	for _, tx := range tracked {
+		if tx.Signer != address {
+			continue
+		}
+		audit.Tracked = append(audit.Tracked, tx.Nonce)
+		byNonce[tx.Nonce] = true
+
+		var discrepancy string
+		switch {
+		case tx.Nonce < confirmed:
+			discrepancy = fmt.Sprintf("%s: %s has nonce %d, below the confirmed nonce", NonceAlreadyMined, tx.Hash.Hex(), tx.Nonce)
+		case tx.Nonce >= pending:
+			discrepancy = fmt.Sprintf("%s: %s has nonce %d, but the RPC's pending nonce is %d", NonceNotPending, tx.Hash.Hex(), tx.Nonce, pending)
+		default:
+			continue
+		}
+		audit.Discrepancies = append(audit.Discrepancies, discrepancy)
+		if resync && c.resolvePendingTx(tx.Hash, PendingTxReset) {
+			audit.Reset = append(audit.Reset, tx.Hash.Hex())
+		}
+	}
+	sort.Slice(audit.Tracked, func(i, j int) bool { return audit.Tracked[i] < audit.Tracked[j] })
+
+	for nonce := confirmed; nonce < pending; nonce++ {
+		if !byNonce[nonce] {
+			audit.Discrepancies = append(audit.Discrepancies,
+				fmt.Sprintf("%s: nonce %d is pending on chain but not tracked; cancel it with relayer cancel --nonce %d if it is stuck", NonceUntracked, nonce, nonce))
+			break
+		}
+	}
+
+	if next, ok := c.pending.nextNonce(address); ok {
+		audit.LocalNext = &next
+	}
+	return audit
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+	"go.uber.org/zap"
+)
+
+// Observation sources selected with OBSERVATION_SOURCES
+const (
+	observationSourceGossip = "gossip" // The attestation topic of the gossip network
+	observationSourceAPI    = "api"    // The observations endpoint of GUARDIAN_API_URL
+)
+
+// observationPollInterval is how often the API is asked for observations of a held VAA
+const observationPollInterval = 10 * time.Second
+
+// pendingAssembly collects the signatures seen for one message digest
+type pendingAssembly struct {
+	body       *vaaLib.VAA  // The held under-signed VAA, nil until one arrives
+	set        *GuardianSet // The guardian set the held VAA names
+	signatures map[common.Address][]byte
+	created    time.Time
+}
+
+// observationAssembler completes VAAs that arrive signed by fewer guardians than a quorum
+// with the observations guardians publish individually, so a degraded data path delays a
+// VAA instead of losing it. Only VAAs of the current guardian set are assembled.
+type observationAssembler struct {
+	guardians  *guardianSetCache
+	sources    []string
+	ttl        time.Duration
+	maxPending int
+	logger     *zap.Logger
+
+	mu        sync.Mutex
+	pending   map[common.Hash]*pendingAssembly
+	assembled map[common.Hash]time.Time // Recently assembled digests, so late observations are ignored
+	emit      func([]byte)              // Receives each assembled VAA
+}
+
+// newObservationAssembler creates the assembler for the OBSERVATION_* settings, or returns nil
+// when OBSERVATION_ASSEMBLY is off
+func newObservationAssembler(config Config, guardians *guardianSetCache) (*observationAssembler, error) {
+	if !config.ObservationAssembly {
+		return nil, nil
+	}
+	if len(config.ObservationSources) == 0 {
+		return nil, fmt.Errorf("OBSERVATION_SOURCES must name at least one source")
+	}
+	for _, source := range config.ObservationSources {
+		switch source {
+		case observationSourceGossip:
+		case observationSourceAPI:
+			if config.GuardianAPIURL == "" {
+				return nil, fmt.Errorf("OBSERVATION_SOURCES=%s requires GUARDIAN_API_URL", observationSourceAPI)
+			}
+		default:
+			return nil, fmt.Errorf("unknown observation source %q (want %s or %s)", source, observationSourceGossip, observationSourceAPI)
+		}
+	}
+	if config.ObservationTTL <= 0 {
+		return nil, fmt.Errorf("OBSERVATION_TTL must be positive")
+	}
+	if config.ObservationMaxPending <= 0 {
+		return nil, fmt.Errorf("OBSERVATION_MAX_PENDING must be positive")
+	}
+	return &observationAssembler{
+		guardians:  guardians,
+		sources:    config.ObservationSources,
+		ttl:        config.ObservationTTL,
+		maxPending: config.ObservationMaxPending,
+		logger:     logger.With(zap.String("component", "ObservationAssembler")),
+		pending:    make(map[common.Hash]*pendingAssembly),
+		assembled:  make(map[common.Hash]time.Time),
+	}, nil
+}
+
+// uses reports whether observations are read from source
+func (a *observationAssembler) uses(source string) bool {
+	return a != nil && slices.Contains(a.sources, source)
+}
+
+// partial reports whether v is a VAA of the current guardian set whose signatures are all
+// valid but short of a quorum
+func (a *observationAssembler) partial(v *vaaLib.VAA) bool {
+	set := a.guardians.Current()
+	if set == nil || v.GuardianSetIndex != set.Index {
+		return false
+	}
+	if len(v.Signatures) >= vaaLib.CalculateQuorum(len(set.Keys)) {
+		return false
+	}
+	return v.VerifySignatures(set.Keys)
+}
+
+// hold keeps an under-signed VAA until observations complete it, returning false for VAAs it
+// doesn't hold. first is true when no VAA with the digest was held before.
+func (a *observationAssembler) hold(v *vaaLib.VAA) (held, first bool) {
+	if !a.partial(v) {
+		return false, false
+	}
+	set := a.guardians.Current()
+	digest := v.SigningDigest()
+
+	a.mu.Lock()
+	if _, ok := a.assembled[digest]; ok {
+		a.mu.Unlock()
+		return true, false
+	}
+	p := a.entry(digest)
+	if p == nil {
+		a.mu.Unlock()
+		observations.WithLabelValues("vaa", "dropped").Add(float64(len(v.Signatures)))
+		return false, false
+	}
+	first = p.body == nil
+	if first {
+		assembledVAAs.WithLabelValues("held").Inc()
+	}
+	// The same message re-signed by a newer set replaces the held VAA, since the signatures
+	// are indexed into the set it names
+	if first || p.set.Index != set.Index {
+		p.body, p.set = v, set
+	}
+	for _, sig := range v.Signatures {
+		p.signatures[set.Keys[sig.Index]] = sig.Signature[:]
+	}
+	observations.WithLabelValues("vaa", "accepted").Add(float64(len(v.Signatures)))
+	vaaBytes := a.tryAssemble(digest, p)
+	a.mu.Unlock()
+
+	if vaaBytes != nil {
+		a.emit(vaaBytes)
+	}
+	return true, first
+}
+
+// addObservation records a guardian's signature over digest, from source
+func (a *observationAssembler) addObservation(source string, digest common.Hash, guardian common.Address, signature []byte) {
+	set := a.guardians.Current()
+	if set == nil {
+		observations.WithLabelValues(source, "no_guardian_set").Inc()
+		return
+	}
+	if !slices.Contains(set.Keys, guardian) {
+		observations.WithLabelValues(source, "unknown_guardian").Inc()
+		return
+	}
+	if len(signature) != 65 {
+		observations.WithLabelValues(source, "invalid").Inc()
+		return
+	}
+	pub, err := crypto.SigToPub(digest.Bytes(), signature)
+	if err != nil || crypto.PubkeyToAddress(*pub) != guardian {
+		observations.WithLabelValues(source, "invalid").Inc()
+		return
+	}
+
+	a.mu.Lock()
+	if _, ok := a.assembled[digest]; ok {
+		a.mu.Unlock()
+		observations.WithLabelValues(source, "late").Inc()
+		return
+	}
+	p := a.entry(digest)
+	if p == nil {
+		a.mu.Unlock()
+		observations.WithLabelValues(source, "dropped").Inc()
+		return
+	}
+	p.signatures[guardian] = bytes.Clone(signature)
+	observations.WithLabelValues(source, "accepted").Inc()
+	vaaBytes := a.tryAssemble(digest, p)
+	a.mu.Unlock()
+
+	if vaaBytes != nil {
+		a.emit(vaaBytes)
+	}
+}
+
+// waiting reports whether a VAA with the digest is held and not yet assembled
+func (a *observationAssembler) waiting(digest common.Hash) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.pending[digest]
+	return ok && p.body != nil
+}
+
+// entry returns the pending assembly for digest, creating it unless OBSERVATION_MAX_PENDING
+// digests are already tracked. a.mu must be held.
+func (a *observationAssembler) entry(digest common.Hash) *pendingAssembly {
+	if p, ok := a.pending[digest]; ok {
+		return p
+	}
+	if len(a.pending) >= a.maxPending {
+		return nil
+	}
+	p := &pendingAssembly{signatures: make(map[common.Address][]byte), created: time.Now()}
+	a.pending[digest] = p
+	assemblyPending.Set(float64(len(a.pending)))
+	return p
+}
+
+// tryAssemble returns the held VAA with the signatures of every guardian of the set it names
+// seen for it once they reach a quorum, and forgets the digest. a.mu must be held.
+func (a *observationAssembler) tryAssemble(digest common.Hash, p *pendingAssembly) []byte {
+	if p.body == nil {
+		return nil
+	}
+	set := p.set
+	var signatures []*vaaLib.Signature
+	for index, key := range set.Keys {
+		if sig, ok := p.signatures[key]; ok {
+			signature := &vaaLib.Signature{Index: uint8(index)}
+			copy(signature.Signature[:], sig)
+			signatures = append(signatures, signature)
+		}
+	}
+	if len(signatures) < vaaLib.CalculateQuorum(len(set.Keys)) {
+		return nil
+	}
+	sort.Slice(signatures, func(i, j int) bool { return signatures[i].Index < signatures[j].Index })
+
+	assembled := *p.body
+	assembled.Signatures = signatures
+	vaaBytes, err := assembled.Marshal()
+	if err != nil {
+		a.logger.Error("Failed to encode assembled VAA", zap.String("digest", digest.Hex()), zap.Error(err))
+		return nil
+	}
+	delete(a.pending, digest)
+	a.assembled[digest] = time.Now()
+	assemblyPending.Set(float64(len(a.pending)))
+	assembledVAAs.WithLabelValues("assembled").Inc()
+	a.logger.Info("Assembled VAA from guardian observations",
+		zap.String("digest", digest.Hex()),
+		zap.Uint64("sequence", assembled.Sequence),
+		zap.Int("signatures", len(signatures)),
+		zap.Duration("waited", time.Since(p.created)))
+	return vaaBytes
+}
+
+// expire forgets digests tracked for longer than OBSERVATION_TTL
+func (a *observationAssembler) expire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cutoff := time.Now().Add(-a.ttl)
+	for digest, p := range a.pending {
+		if p.created.After(cutoff) {
+			continue
+		}
+		if p.body != nil {
+			assembledVAAs.WithLabelValues("expired").Inc()
+			a.logger.Warn("Held VAA expired without a quorum of observations",
+				zap.String("digest", digest.Hex()),
+				zap.Uint64("sequence", p.body.Sequence),
+				zap.Int("signatures", len(p.signatures)))
+		}
+		delete(a.pending, digest)
+	}
+	for digest, at := range a.assembled {
+		if at.Before(cutoff) {
+			delete(a.assembled, digest)
+		}
+	}
+	assemblyPending.Set(float64(len(a.pending)))
+}
+
+// startAssembly feeds assembled VAAs into intake and starts reading observations from the
+// configured sources until ctx is done
+func (r *Relayer) startAssembly(ctx context.Context) {
+	r.assembler.emit = func(vaaBytes []byte) {
+		key := r.dedupeKey(vaaBytes)
+		if !r.beginProcessingVAA(key) {
+			return
+		}
+		item := intakeItem{VAABytes: vaaBytes, Key: key, CorrelationID: newCorrelationID()}
+		if !r.intake.enqueue(ctx, item) {
+			r.finishProcessingVAA(key, false)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.assembler.ttl / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.assembler.expire()
+			}
+		}
+	}()
+
+	if r.assembler.uses(observationSourceGossip) {
+		go func() {
+			err := r.gossip.ReadObservations(ctx, r.config.SourceChainID, func(digest common.Hash, guardian common.Address, signature []byte) {
+				r.assembler.addObservation(observationSourceGossip, digest, guardian, signature)
+			})
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("Stopped reading gossiped observations", zap.Error(err))
+			}
+		}()
+	}
+}
+
+// holdPartialVAA hands a VAA signed by too few guardians to the assembler, asking the API
+// for observations of it when configured. It returns false for VAAs that go on to intake.
+func (r *Relayer) holdPartialVAA(ctx context.Context, vaaBytes []byte) bool {
+	v, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil {
+		return false
+	}
+	held, first := r.assembler.hold(v)
+	if !held {
+		return false
+	}
+	if first {
+		r.logger.Debug("Holding under-signed VAA for assembly",
+			zap.String("digest", v.SigningDigest().Hex()),
+			zap.Uint64("sequence", v.Sequence),
+			zap.Int("signatures", len(v.Signatures)))
+		if r.assembler.uses(observationSourceAPI) {
+			go r.pollObservations(ctx, v)
+		}
+	}
+	return true
+}
+
+// pollObservations asks the API for observations of the held VAA until it is assembled or
+// expires
+func (r *Relayer) pollObservations(ctx context.Context, v *vaaLib.VAA) {
+	digest := v.SigningDigest()
+	emitterHex := v.EmitterAddress.String()
+	log := r.logger.With(zap.String("digest", digest.Hex()), zap.Uint64("sequence", v.Sequence))
+	ticker := time.NewTicker(observationPollInterval)
+	defer ticker.Stop()
+
+	for r.assembler.waiting(digest) {
+		found, err := r.guardianAPI.Observations(ctx, uint16(v.EmitterChain), emitterHex, v.Sequence)
+		if err != nil {
+			log.Debug("Failed to fetch observations", zap.Error(err))
+		}
+		for _, o := range found {
+			if common.BytesToHash(o.Hash) != digest || !common.IsHexAddress(o.GuardianAddr) {
+				continue
+			}
+			r.assembler.addObservation(observationSourceAPI, digest, common.HexToAddress(o.GuardianAddr), o.Signature)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
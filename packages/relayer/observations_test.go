@@ -0,0 +1,248 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+)
+
+// newTestAssembler returns an assembler over guardians and the VAAs it emits
+func newTestAssembler(t *testing.T, guardians *guardianSetCache) (*observationAssembler, *[][]byte) {
+	t.Helper()
+	a, err := newObservationAssembler(Config{
+		ObservationAssembly:   true,
+		ObservationSources:    []string{observationSourceGossip},
+		ObservationTTL:        time.Minute,
+		ObservationMaxPending: 10,
+	}, guardians)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emitted [][]byte
+	a.emit = func(vaaBytes []byte) { emitted = append(emitted, vaaBytes) }
+	return a, &emitted
+}
+
+// observe adds key's signature over digest as a gossiped observation
+func observe(t *testing.T, a *observationAssembler, digest common.Hash, key *ecdsa.PrivateKey) {
+	t.Helper()
+	signature, err := crypto.Sign(digest.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.addObservation(observationSourceGossip, digest, crypto.PubkeyToAddress(key.PublicKey), signature)
+}
+
+// assembledVAA decodes the single emitted VAA and checks it against set
+func assembledVAA(t *testing.T, emitted [][]byte, set *GuardianSet) *vaaLib.VAA {
+	t.Helper()
+	if len(emitted) != 1 {
+		t.Fatalf("emitted %d VAAs, want 1", len(emitted))
+	}
+	v, err := vaaLib.Unmarshal(emitted[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.GuardianSetIndex != set.Index {
+		t.Fatalf("assembled VAA names guardian set %d, want %d", v.GuardianSetIndex, set.Index)
+	}
+	if err := v.Verify(set.Keys); err != nil {
+		t.Fatalf("assembled VAA does not verify against set %d: %v", set.Index, err)
+	}
+	return v
+}
+
+func TestObservationAssemblerAssembles(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5) // Quorum is 4
+	set := &GuardianSet{Index: 1, Keys: addresses}
+	var guardians guardianSetCache
+	guardians.put(set, true)
+	a, emitted := newTestAssembler(t, &guardians)
+
+	v := signTestVAA(newTestVAA(1, 7), keys, 1, 3)
+	digest := v.SigningDigest()
+	observe(t, a, digest, keys[4]) // Observations may arrive before the VAA
+	if held, first := a.hold(v); !held || !first {
+		t.Fatalf("hold = %v, %v, want the VAA held for the first time", held, first)
+	}
+	if !a.waiting(digest) {
+		t.Fatal("held VAA is not waiting")
+	}
+	observe(t, a, digest, keys[3]) // Already signed; doesn't count twice
+	if len(*emitted) != 0 {
+		t.Fatal("assembled with three signers")
+	}
+
+	observe(t, a, digest, keys[0])
+	assembled := assembledVAA(t, *emitted, set)
+	if len(assembled.Signatures) != 4 || assembled.Sequence != 7 {
+		t.Fatalf("assembled %d signatures for sequence %d", len(assembled.Signatures), assembled.Sequence)
+	}
+	if a.waiting(digest) {
+		t.Fatal("assembled VAA still waiting")
+	}
+
+	observe(t, a, digest, keys[2]) // Late
+	if held, _ := a.hold(v); !held || len(*emitted) != 1 {
+		t.Fatal("late copy of an assembled VAA was not absorbed")
+	}
+}
+
+func TestObservationAssemblerRejects(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5)
+	outsiders, _ := testGuardianKeys(t, 3)
+	set := &GuardianSet{Index: 1, Keys: addresses}
+
+	tests := []struct {
+		name    string
+		observe func(t *testing.T, a *observationAssembler, digest common.Hash)
+	}{
+		{
+			name: "signers outside the set",
+			observe: func(t *testing.T, a *observationAssembler, digest common.Hash) {
+				for _, key := range outsiders {
+					observe(t, a, digest, key)
+				}
+			},
+		},
+		{
+			name: "signatures over a conflicting digest",
+			observe: func(t *testing.T, a *observationAssembler, digest common.Hash) {
+				other := newTestVAA(1, 8).SigningDigest()
+				for _, key := range keys[2:4] {
+					signature, _ := crypto.Sign(other.Bytes(), key)
+					a.addObservation(observationSourceGossip, digest, crypto.PubkeyToAddress(key.PublicKey), signature)
+				}
+			},
+		},
+		{
+			name: "observations of another message",
+			observe: func(t *testing.T, a *observationAssembler, digest common.Hash) {
+				other := newTestVAA(1, 8).SigningDigest()
+				for _, key := range keys[2:] {
+					observe(t, a, other, key)
+				}
+			},
+		},
+		{
+			name: "truncated signature",
+			observe: func(t *testing.T, a *observationAssembler, digest common.Hash) {
+				for _, key := range keys[2:4] {
+					signature, _ := crypto.Sign(digest.Bytes(), key)
+					a.addObservation(observationSourceGossip, digest, crypto.PubkeyToAddress(key.PublicKey), signature[:64])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var guardians guardianSetCache
+			guardians.put(set, true)
+			a, emitted := newTestAssembler(t, &guardians)
+
+			v := signTestVAA(newTestVAA(1, 7), keys, 0, 1)
+			if held, _ := a.hold(v); !held {
+				t.Fatal("under-signed VAA not held")
+			}
+			tt.observe(t, a, v.SigningDigest())
+			if len(*emitted) != 0 {
+				t.Fatalf("assembled a VAA from rejected observations")
+			}
+			if !a.waiting(v.SigningDigest()) {
+				t.Fatal("held VAA no longer waiting")
+			}
+		})
+	}
+}
+
+func TestObservationAssemblerHoldsOnlyPartialVAAs(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5)
+	outsiders, _ := testGuardianKeys(t, 5)
+	var guardians guardianSetCache
+	guardians.put(&GuardianSet{Index: 1, Keys: addresses}, true)
+	a, _ := newTestAssembler(t, &guardians)
+
+	tests := []struct {
+		name string
+		vaa  *vaaLib.VAA
+	}{
+		{name: "quorum", vaa: signTestVAA(newTestVAA(1, 1), keys, 0, 1, 2, 3)},
+		{name: "older set", vaa: signTestVAA(newTestVAA(0, 2), keys, 0, 1)},
+		{name: "newer set", vaa: signTestVAA(newTestVAA(2, 3), keys, 0, 1)},
+		{name: "invalid signature", vaa: signTestVAA(signTestVAA(newTestVAA(1, 4), keys, 0), outsiders, 1)},
+	}
+	for _, tt := range tests {
+		if held, _ := a.hold(tt.vaa); held {
+			t.Errorf("%s: VAA held for assembly", tt.name)
+		}
+	}
+}
+
+func TestObservationAssemblerGuardianSetChange(t *testing.T) {
+	keys, addresses := testGuardianKeys(t, 5)
+	newKey, newAddresses := testGuardianKeys(t, 1)
+	oldSet := &GuardianSet{Index: 1, Keys: addresses}
+	// The next set reorders the guardians and replaces the first one
+	newSet := &GuardianSet{Index: 2, Keys: []common.Address{addresses[4], addresses[3], addresses[2], addresses[1], newAddresses[0]}}
+	newSetKeys := []*ecdsa.PrivateKey{keys[4], keys[3], keys[2], keys[1], newKey[0]}
+
+	t.Run("held VAA keeps its set's indexes", func(t *testing.T) {
+		var guardians guardianSetCache
+		guardians.put(oldSet, true)
+		a, emitted := newTestAssembler(t, &guardians)
+
+		v := signTestVAA(newTestVAA(1, 7), keys, 0, 1)
+		if held, _ := a.hold(v); !held {
+			t.Fatal("under-signed VAA not held")
+		}
+		guardians.put(newSet, true)
+		observe(t, a, v.SigningDigest(), keys[2])
+		observe(t, a, v.SigningDigest(), keys[3])
+		assembledVAA(t, *emitted, oldSet)
+	})
+
+	t.Run("observations of guardians only in the new set", func(t *testing.T) {
+		var guardians guardianSetCache
+		guardians.put(oldSet, true)
+		a, emitted := newTestAssembler(t, &guardians)
+
+		v := signTestVAA(newTestVAA(1, 7), keys, 0, 1, 2)
+		if held, _ := a.hold(v); !held {
+			t.Fatal("under-signed VAA not held")
+		}
+		guardians.put(newSet, true)
+		observe(t, a, v.SigningDigest(), newKey[0])
+		if len(*emitted) != 0 {
+			t.Fatal("assembled with a signer outside the set the VAA names")
+		}
+	})
+
+	t.Run("message re-signed by the new set", func(t *testing.T) {
+		var guardians guardianSetCache
+		guardians.put(oldSet, true)
+		a, emitted := newTestAssembler(t, &guardians)
+
+		v := signTestVAA(newTestVAA(1, 7), keys, 0, 1)
+		if held, _ := a.hold(v); !held {
+			t.Fatal("under-signed VAA not held")
+		}
+		guardians.put(newSet, true)
+		resigned := signTestVAA(newTestVAA(2, 7), newSetKeys, 0, 4)
+		if resigned.SigningDigest() != v.SigningDigest() {
+			t.Fatal("re-signed message has another digest")
+		}
+		if held, first := a.hold(resigned); !held || first {
+			t.Fatalf("hold = %v, %v, want the re-signed VAA merged into the held one", held, first)
+		}
+		observe(t, a, v.SigningDigest(), keys[2])
+		assembled := assembledVAA(t, *emitted, newSet)
+		// keys[1] signed the old VAA and is index 3 of the new set
+		if len(assembled.Signatures) != 4 {
+			t.Fatalf("assembled %d signatures, want 4", len(assembled.Signatures))
+		}
+	})
+}
@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+)
+
+// RecoveryPayload is the decoded form of a recovery VAA payload. The payload format lives in
+// pkg/sdk, which services outside the relayer decode payloads with.
+type RecoveryPayload = sdk.RecoveryPayload
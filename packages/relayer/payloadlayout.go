@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+// loadPayloadLayouts registers the layouts in PAYLOAD_LAYOUTS_FILE, a JSON object of payload
+// version to layout, alongside the built-in ones. A configured version replaces the built-in
+// layout of the same version.
+func loadPayloadLayouts(path string) error {
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read payload layouts: %v", err)
+	}
+	layouts, err := sdk.ParseLayouts(content)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]uint8, 0, len(layouts))
+	for version := range layouts {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, version := range versions {
+		layout := layouts[version]
+		if _, ok := sdk.LayoutFor(version); ok {
+			logger.Warn("Payload layout overrides the built-in one", zap.Uint8("version", version))
+		}
+		if err := sdk.RegisterLayout(version, layout); err != nil {
+			return err
+		}
+		logger.Info("Registered payload layout",
+			zap.Uint8("version", version),
+			zap.Int("size", layout.Size),
+			zap.Int("fields", len(layout.Fields)))
+	}
+	return nil
+}
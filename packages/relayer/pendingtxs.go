@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// How a tracked transaction stopped being pending
+const (
+	PendingTxMined    = "mined"    // Included in a block
+	PendingTxReplaced = "replaced" // Gone, with its nonce consumed by another transaction
+	PendingTxDropped  = "dropped"  // Gone from the mempool for PENDING_TX_DROP_TIMEOUT, nonce unused
+	PendingTxReset    = "reset"    // Contradicted by the chain and dropped by a nonce resync
+)
+
+// pendingTx is a broadcast transaction that has not been seen mined
+type pendingTx struct {
+	Hash          common.Hash
+	Signer        common.Address
+	Nonce         uint64
+	Tip           *big.Int
+	FeeCap        *big.Int
+	Gas           uint64
+	Description   string
+	CorrelationID string
+	SentAt        time.Time
+	LastSeen      time.Time // Last time the RPC returned it as pending (zero until then)
+}
+
+// pendingTxs tracks a destination's broadcast transactions until they are mined, replaced or
+// dropped, and keeps new transactions from reusing their nonces. Tracking is in memory;
+// transactions sent before a restart are not followed.
+type pendingTxs struct {
+	mu  sync.Mutex
+	txs map[common.Hash]*pendingTx
+}
+
+func newPendingTxs() *pendingTxs {
+	return &pendingTxs{txs: make(map[common.Hash]*pendingTx)}
+}
+
+// add starts tracking a transaction that was just broadcast
+func (p *pendingTxs) add(tx pendingTx) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.txs[tx.Hash] = &tx
+}
+
+// remove stops tracking a transaction, reporting whether it was tracked
+func (p *pendingTxs) remove(hash common.Hash) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.txs[hash]
+	delete(p.txs, hash)
+	return ok
+}
+
+// list returns the tracked transactions, oldest first
+func (p *pendingTxs) list() []pendingTx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]pendingTx, 0, len(p.txs))
+	for _, tx := range p.txs {
+		result = append(result, *tx)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SentAt.Before(result[j].SentAt) })
+	return result
+}
+
+// byNonce returns the newest tracked transaction from signer at nonce, the one a replacement
+// has to outbid
+func (p *pendingTxs) byNonce(signer common.Address, nonce uint64) (pendingTx, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var found *pendingTx
+	for _, tx := range p.txs {
+		if tx.Signer == signer && tx.Nonce == nonce && (found == nil || tx.SentAt.After(found.SentAt)) {
+			found = tx
+		}
+	}
+	if found == nil {
+		return pendingTx{}, false
+	}
+	return *found, true
+}
+
+// forDescription returns the newest tracked transaction sent with description, the VAA's
+// earlier submission when it is still pending
+func (p *pendingTxs) forDescription(description string) (pendingTx, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var found *pendingTx
+	for _, tx := range p.txs {
+		if tx.Description == description && (found == nil || tx.SentAt.After(found.SentAt)) {
+			found = tx
+		}
+	}
+	if found == nil {
+		return pendingTx{}, false
+	}
+	return *found, true
+}
+
+// markSeen records that the RPC returned the transaction as pending
+func (p *pendingTxs) markSeen(hash common.Hash, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if tx, ok := p.txs[hash]; ok {
+		tx.LastSeen = at
+	}
+}
+
+// PendingTxs returns every destination's tracked transactions, by destination name
+func (r *Relayer) PendingTxs() map[string][]pendingTx {
+	result := make(map[string][]pendingTx, len(r.destinations))
+	for _, dest := range r.destinations {
+		result[dest.Name] = dest.client.pending.list()
+	}
+	return result
+}
+
+// trackPendingTxs polls the destination's tracked transactions every PENDING_TX_POLL_INTERVAL,
+// looking each up with eth_getTransactionByHash and its signer's confirmed nonce in one batch
+// request. Mined transactions, and those gone with their nonce used, stop being tracked; a
+// transaction the RPC hasn't returned for PENDING_TX_DROP_TIMEOUT, while its nonce is still
+// unused, is reported as dropped.
+func (r *Relayer) trackPendingTxs(ctx context.Context, dest *Destination) {
+	if r.config.PendingTxPollInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.config.PendingTxPollInterval)
+	defer ticker.Stop()
+
+	logger := r.logger.With(zap.String("destination", dest.Name))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		callCtx, cancel := context.WithTimeout(ctx, r.config.PendingTxPollInterval)
+		err := dest.client.pollPendingTxs(callCtx, r.config.PendingTxDropTimeout)
+		cancel()
+		if err != nil {
+			logger.Debug("Pending transaction poll failed", zap.Error(err))
+		}
+		r.samplePendingTxs(dest)
+	}
+}
+
+// pollPendingTxs looks up every tracked transaction once, as trackPendingTxs describes
+func (c *EVMClient) pollPendingTxs(ctx context.Context, dropTimeout time.Duration) error {
+	tracked := c.pending.list()
+	if len(tracked) == 0 {
+		return nil
+	}
+
+	type txLookup struct {
+		BlockNumber *hexutil.Big `json:"blockNumber"`
+	}
+	lookups := make([]*txLookup, len(tracked))
+	calls := make([]rpc.BatchElem, 0, 2*len(tracked))
+	for i, tx := range tracked {
+		calls = append(calls, rpc.BatchElem{Method: "eth_getTransactionByHash", Args: []any{tx.Hash}, Result: &lookups[i]})
+	}
+	signerNonces := make(map[common.Address]*hexutil.Uint64)
+	for _, tx := range tracked {
+		if _, ok := signerNonces[tx.Signer]; !ok {
+			nonce := new(hexutil.Uint64)
+			signerNonces[tx.Signer] = nonce
+			calls = append(calls, rpc.BatchElem{Method: "eth_getTransactionCount", Args: []any{tx.Signer, "latest"}, Result: nonce})
+		}
+	}
+	if err := c.batchCall(ctx, calls); err != nil {
+		return err
+	}
+	nonceKnown := make(map[common.Address]bool, len(signerNonces))
+	for _, call := range calls[len(tracked):] {
+		nonceKnown[call.Args[0].(common.Address)] = call.Error == nil
+	}
+
+	now := time.Now()
+	for i, tx := range tracked {
+		log := withCorrelation(c.logger, tx.CorrelationID).With(
+			zap.String("txHash", tx.Hash.Hex()),
+			zap.String("signer", tx.Signer.Hex()),
+			zap.Uint64("nonce", tx.Nonce),
+			zap.Duration("age", now.Sub(tx.SentAt)))
+		if calls[i].Error != nil {
+			continue
+		}
+		lookup := lookups[i]
+		if lookup != nil && lookup.BlockNumber != nil {
+			c.resolvePendingTx(tx.Hash, PendingTxMined)
+			continue
+		}
+		if lookup != nil {
+			c.pending.markSeen(tx.Hash, now)
+			continue
+		}
+
+		// Not known to the RPC: replaced if its nonce was used, dropped once it stayed gone
+		if nonceKnown[tx.Signer] && uint64(*signerNonces[tx.Signer]) > tx.Nonce {
+			if c.resolvePendingTx(tx.Hash, PendingTxReplaced) {
+				log.Warn("Pending transaction replaced; its nonce was used by another transaction")
+			}
+			continue
+		}
+		lastSeen := tx.LastSeen
+		if lastSeen.IsZero() {
+			lastSeen = tx.SentAt
+		}
+		if dropTimeout > 0 && now.Sub(lastSeen) >= dropTimeout {
+			if c.resolvePendingTx(tx.Hash, PendingTxDropped) {
+				log.Warn("Pending transaction dropped from the mempool; its nonce is still unused",
+					zap.Duration("missingFor", now.Sub(lastSeen)))
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePendingTx stops tracking a transaction and counts how it was resolved, reporting
+// whether it was still tracked
+func (c *EVMClient) resolvePendingTx(hash common.Hash, result string) bool {
+	if !c.pending.remove(hash) {
+		return false
+	}
+	pendingTxsResolved.WithLabelValues(c.breaker.name, result).Inc()
+	return true
+}
+
+// samplePendingTxs updates the destination's pending transaction gauges
+func (r *Relayer) samplePendingTxs(dest *Destination) {
+	tracked := dest.client.pending.list()
+	pendingTxsGauge.WithLabelValues(dest.Name).Set(float64(len(tracked)))
+	var oldest float64
+	if len(tracked) > 0 {
+		oldest = time.Since(tracked[0].SentAt).Seconds()
+	}
+	pendingTxOldestAge.WithLabelValues(dest.Name).Set(oldest)
+}
+
+// nextNonce returns the nonce after the highest one tracked for signer, which a new
+// transaction must not go below even when the RPC's pending nonce lags behind its mempool
+func (p *pendingTxs) nextNonce(signer common.Address) (uint64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var next uint64
+	found := false
+	for _, tx := range p.txs {
+		if tx.Signer == signer && (!found || tx.Nonce+1 > next) {
+			next, found = tx.Nonce+1, true
+		}
+	}
+	return next, found
+}
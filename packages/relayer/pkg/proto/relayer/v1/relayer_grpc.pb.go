@@ -0,0 +1,413 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: relayer/v1/relayer.proto
+
+package relayerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RelayerService_GetHealth_FullMethodName     = "/relayer.v1.RelayerService/GetHealth"
+	RelayerService_ListRelays_FullMethodName    = "/relayer.v1.RelayerService/ListRelays"
+	RelayerService_ListReceipts_FullMethodName  = "/relayer.v1.RelayerService/ListReceipts"
+	RelayerService_ListRetries_FullMethodName   = "/relayer.v1.RelayerService/ListRetries"
+	RelayerService_GetPauseState_FullMethodName = "/relayer.v1.RelayerService/GetPauseState"
+	RelayerService_Pause_FullMethodName         = "/relayer.v1.RelayerService/Pause"
+	RelayerService_Resume_FullMethodName        = "/relayer.v1.RelayerService/Resume"
+	RelayerService_Drain_FullMethodName         = "/relayer.v1.RelayerService/Drain"
+)
+
+// RelayerServiceClient is the client API for RelayerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RelayerService is the relayer's status and control API. It serves the same state as the
+// admin HTTP API, with the same credentials and permissions.
+type RelayerServiceClient interface {
+	// GetHealth reports whether the relayer can receive and submit VAAs. Public.
+	GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*GetHealthResponse, error)
+	// ListRelays lists the relays confirmed in a range with the gas they used and what they cost.
+	// Needs read permission.
+	ListRelays(ctx context.Context, in *ListRelaysRequest, opts ...grpc.CallOption) (*ListRelaysResponse, error)
+	// ListReceipts lists the signed receipts of the relays confirmed in a range. Public.
+	ListReceipts(ctx context.Context, in *ListReceiptsRequest, opts ...grpc.CallOption) (*ListReceiptsResponse, error)
+	// ListRetries lists the VAAs waiting for another processing attempt. Needs read permission.
+	ListRetries(ctx context.Context, in *ListRetriesRequest, opts ...grpc.CallOption) (*ListRetriesResponse, error)
+	// GetPauseState reports whether submission is paused. Needs read permission.
+	GetPauseState(ctx context.Context, in *GetPauseStateRequest, opts ...grpc.CallOption) (*GetPauseStateResponse, error)
+	// Pause halts submission while VAAs keep being observed and queued. Needs control permission.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error)
+	// Resume restarts submission, releasing queued VAAs. Needs control permission.
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error)
+	// Drain stops VAA intake; the relayer exits once inflight VAAs are confirmed. Needs control
+	// permission.
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error)
+}
+
+type relayerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRelayerServiceClient(cc grpc.ClientConnInterface) RelayerServiceClient {
+	return &relayerServiceClient{cc}
+}
+
+func (c *relayerServiceClient) GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*GetHealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHealthResponse)
+	err := c.cc.Invoke(ctx, RelayerService_GetHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) ListRelays(ctx context.Context, in *ListRelaysRequest, opts ...grpc.CallOption) (*ListRelaysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRelaysResponse)
+	err := c.cc.Invoke(ctx, RelayerService_ListRelays_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) ListReceipts(ctx context.Context, in *ListReceiptsRequest, opts ...grpc.CallOption) (*ListReceiptsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReceiptsResponse)
+	err := c.cc.Invoke(ctx, RelayerService_ListReceipts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) ListRetries(ctx context.Context, in *ListRetriesRequest, opts ...grpc.CallOption) (*ListRetriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRetriesResponse)
+	err := c.cc.Invoke(ctx, RelayerService_ListRetries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) GetPauseState(ctx context.Context, in *GetPauseStateRequest, opts ...grpc.CallOption) (*GetPauseStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPauseStateResponse)
+	err := c.cc.Invoke(ctx, RelayerService_GetPauseState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PauseResponse)
+	err := c.cc.Invoke(ctx, RelayerService_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeResponse)
+	err := c.cc.Invoke(ctx, RelayerService_Resume_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relayerServiceClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainResponse)
+	err := c.cc.Invoke(ctx, RelayerService_Drain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RelayerServiceServer is the server API for RelayerService service.
+// All implementations must embed UnimplementedRelayerServiceServer
+// for forward compatibility.
+//
+// RelayerService is the relayer's status and control API. It serves the same state as the
+// admin HTTP API, with the same credentials and permissions.
+type RelayerServiceServer interface {
+	// GetHealth reports whether the relayer can receive and submit VAAs. Public.
+	GetHealth(context.Context, *GetHealthRequest) (*GetHealthResponse, error)
+	// ListRelays lists the relays confirmed in a range with the gas they used and what they cost.
+	// Needs read permission.
+	ListRelays(context.Context, *ListRelaysRequest) (*ListRelaysResponse, error)
+	// ListReceipts lists the signed receipts of the relays confirmed in a range. Public.
+	ListReceipts(context.Context, *ListReceiptsRequest) (*ListReceiptsResponse, error)
+	// ListRetries lists the VAAs waiting for another processing attempt. Needs read permission.
+	ListRetries(context.Context, *ListRetriesRequest) (*ListRetriesResponse, error)
+	// GetPauseState reports whether submission is paused. Needs read permission.
+	GetPauseState(context.Context, *GetPauseStateRequest) (*GetPauseStateResponse, error)
+	// Pause halts submission while VAAs keep being observed and queued. Needs control permission.
+	Pause(context.Context, *PauseRequest) (*PauseResponse, error)
+	// Resume restarts submission, releasing queued VAAs. Needs control permission.
+	Resume(context.Context, *ResumeRequest) (*ResumeResponse, error)
+	// Drain stops VAA intake; the relayer exits once inflight VAAs are confirmed. Needs control
+	// permission.
+	Drain(context.Context, *DrainRequest) (*DrainResponse, error)
+	mustEmbedUnimplementedRelayerServiceServer()
+}
+
+// UnimplementedRelayerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRelayerServiceServer struct{}
+
+func (UnimplementedRelayerServiceServer) GetHealth(context.Context, *GetHealthRequest) (*GetHealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedRelayerServiceServer) ListRelays(context.Context, *ListRelaysRequest) (*ListRelaysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRelays not implemented")
+}
+func (UnimplementedRelayerServiceServer) ListReceipts(context.Context, *ListReceiptsRequest) (*ListReceiptsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReceipts not implemented")
+}
+func (UnimplementedRelayerServiceServer) ListRetries(context.Context, *ListRetriesRequest) (*ListRetriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRetries not implemented")
+}
+func (UnimplementedRelayerServiceServer) GetPauseState(context.Context, *GetPauseStateRequest) (*GetPauseStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPauseState not implemented")
+}
+func (UnimplementedRelayerServiceServer) Pause(context.Context, *PauseRequest) (*PauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedRelayerServiceServer) Resume(context.Context, *ResumeRequest) (*ResumeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedRelayerServiceServer) Drain(context.Context, *DrainRequest) (*DrainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
+func (UnimplementedRelayerServiceServer) mustEmbedUnimplementedRelayerServiceServer() {}
+func (UnimplementedRelayerServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRelayerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RelayerServiceServer will
+// result in compilation errors.
+type UnsafeRelayerServiceServer interface {
+	mustEmbedUnimplementedRelayerServiceServer()
+}
+
+func RegisterRelayerServiceServer(s grpc.ServiceRegistrar, srv RelayerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRelayerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RelayerService_ServiceDesc, srv)
+}
+
+func _RelayerService_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).GetHealth(ctx, req.(*GetHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_ListRelays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRelaysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).ListRelays(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_ListRelays_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).ListRelays(ctx, req.(*ListRelaysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_ListReceipts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReceiptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).ListReceipts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_ListReceipts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).ListReceipts(ctx, req.(*ListReceiptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_ListRetries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRetriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).ListRetries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_ListRetries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).ListRetries(ctx, req.(*ListRetriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_GetPauseState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPauseStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).GetPauseState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_GetPauseState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).GetPauseState(ctx, req.(*GetPauseStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelayerService_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelayerServiceServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelayerService_Drain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelayerServiceServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RelayerService_ServiceDesc is the grpc.ServiceDesc for RelayerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RelayerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "relayer.v1.RelayerService",
+	HandlerType: (*RelayerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHealth",
+			Handler:    _RelayerService_GetHealth_Handler,
+		},
+		{
+			MethodName: "ListRelays",
+			Handler:    _RelayerService_ListRelays_Handler,
+		},
+		{
+			MethodName: "ListReceipts",
+			Handler:    _RelayerService_ListReceipts_Handler,
+		},
+		{
+			MethodName: "ListRetries",
+			Handler:    _RelayerService_ListRetries_Handler,
+		},
+		{
+			MethodName: "GetPauseState",
+			Handler:    _RelayerService_GetPauseState_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _RelayerService_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _RelayerService_Resume_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _RelayerService_Drain_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "relayer/v1/relayer.proto",
+}
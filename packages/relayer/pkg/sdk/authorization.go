@@ -0,0 +1,134 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// How a payload's authorization signature was made over its digest
+const (
+	AuthSchemeEIP191 = "eip191" // personal_sign of the 32-byte digest
+	AuthSchemeRaw    = "raw"    // The digest signed as is
+)
+
+// authSignatureSize is an r || s || v secp256k1 signature
+const authSignatureSize = 65
+
+// PayloadAuthorization describes a user or guardian signature the Aztec contract embeds in a
+// payload, which the module checks before acting on the message. The relayer checks it too,
+// so a payload the module would reject for its signature is never relayed.
+type PayloadAuthorization struct {
+	Signature string   `json:"signature"`        // bytes field holding the signature, 65 wide
+	Signer    string   `json:"signer"`           // address field naming the expected signer, or a fixed address
+	Covers    []string `json:"covers,omitempty"` // Fields whose bytes, in order, are hashed; the rest of the payload when empty
+	Scheme    string   `json:"scheme,omitempty"` // eip191 (default) or raw
+}
+
+// Validate checks the authorization against the fields of its layout
+func (a *PayloadAuthorization) Validate(layout *PayloadLayout) error {
+	signature, ok := layout.Field(a.Signature)
+	if !ok {
+		return fmt.Errorf("authorization signature field %q is not in the layout", a.Signature)
+	}
+	if signature.Type != FieldTypeBytes || signature.Width != authSignatureSize {
+		return fmt.Errorf("authorization signature field %q must be %d bytes of type %s", a.Signature, authSignatureSize, FieldTypeBytes)
+	}
+	if !common.IsHexAddress(a.Signer) {
+		signer, ok := layout.Field(a.Signer)
+		if !ok {
+			return fmt.Errorf("authorization signer %q is neither an address nor a field of the layout", a.Signer)
+		}
+		if signer.Type != FieldTypeAddress && signer.Type != FieldTypeAddressLE {
+			return fmt.Errorf("authorization signer field %q must be an address", a.Signer)
+		}
+	}
+	for _, name := range a.Covers {
+		if _, ok := layout.Field(name); !ok {
+			return fmt.Errorf("authorization covers field %q, which is not in the layout", name)
+		}
+		if name == a.Signature {
+			return fmt.Errorf("authorization can't cover its own signature field %q", name)
+		}
+	}
+	switch a.Scheme {
+	case "", AuthSchemeEIP191, AuthSchemeRaw:
+	default:
+		return fmt.Errorf("unknown authorization scheme %q (want %s or %s)", a.Scheme, AuthSchemeEIP191, AuthSchemeRaw)
+	}
+	return nil
+}
+
+// Digest returns the hash the signature is expected over
+func (a *PayloadAuthorization) Digest(layout *PayloadLayout, payload []byte) []byte {
+	var message []byte
+	if len(a.Covers) == 0 {
+		signature, _ := layout.Field(a.Signature)
+		message = slices.Concat(payload[:signature.Offset], payload[signature.Offset+signature.Width:])
+	} else {
+		for _, name := range a.Covers {
+			field, _ := layout.Field(name)
+			message = append(message, payload[field.Offset:field.Offset+field.Width]...)
+		}
+	}
+	hash := crypto.Keccak256(message)
+	if a.Scheme == AuthSchemeRaw {
+		return hash
+	}
+	return accounts.TextHash(hash)
+}
+
+// ExpectedSigner returns the address the signature must recover to
+func (a *PayloadAuthorization) ExpectedSigner(layout *PayloadLayout, payload []byte) common.Address {
+	if common.IsHexAddress(a.Signer) {
+		return common.HexToAddress(a.Signer)
+	}
+	field, _ := layout.Field(a.Signer)
+	return field.Address(payload[field.Offset : field.Offset+field.Width])
+}
+
+// Verify checks the payload's signature recovers to its expected signer, returning the signer
+func (a *PayloadAuthorization) Verify(layout *PayloadLayout, payload []byte) (common.Address, error) {
+	if len(payload) < layout.End() {
+		return common.Address{}, fmt.Errorf("payload too short: %d bytes", len(payload))
+	}
+	expected := a.ExpectedSigner(layout, payload)
+	if expected == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("payload names no authorization signer")
+	}
+
+	field, _ := layout.Field(a.Signature)
+	signature := slices.Clone(payload[field.Offset : field.Offset+field.Width])
+	// Accept the 27/28 recovery IDs Ethereum signers produce as well as 0/1
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:64])
+	if !crypto.ValidateSignatureValues(signature[64], r, s, true) {
+		return common.Address{}, fmt.Errorf("malformed authorization signature")
+	}
+	pub, err := crypto.SigToPub(a.Digest(layout, payload), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid authorization signature: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != expected {
+		return common.Address{}, fmt.Errorf("authorization signed by %s, not the expected signer %s", signer.Hex(), expected.Hex())
+	}
+	return expected, nil
+}
+
+// VerifyAuthorization checks the signature embedded in a payload whose layout declares one,
+// returning its signer and whether there was one to check. Payloads of layouts without an
+// authorization pass unchecked.
+func VerifyAuthorization(payload []byte) (common.Address, bool, error) {
+	layout, ok := LayoutFor(PayloadVersion(payload))
+	if !ok || layout.Authorization == nil {
+		return common.Address{}, false, nil
+	}
+	signer, err := layout.Authorization.Verify(layout, payload)
+	return signer, true, err
+}
@@ -0,0 +1,206 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Client queries a relayer's status API: its health, the relays it confirmed, their signed
+// receipts and the VAAs waiting to be retried
+type Client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the admin API at baseURL. apiKey is sent as a bearer
+// credential and may be empty for the public endpoints, Health and Receipts.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithHTTPClient makes the client send its requests through httpClient
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// Health is the relayer's answer to GET /healthz
+type Health struct {
+	Healthy      bool                `json:"healthy"`
+	Paused       bool                `json:"paused"`
+	Queued       int64               `json:"queued"` // VAAs held while paused
+	Spy          SpyHealth           `json:"spy"`
+	Destinations []DestinationHealth `json:"destinations"`
+	Canary       json.RawMessage     `json:"canary,omitempty"` // Last canary run, when canaries are enabled
+}
+
+// SpyHealth is the state of the relayer's VAA stream
+type SpyHealth struct {
+	Stale             bool  `json:"stale"`
+	LastMessageAgeSec int64 `json:"lastMessageAgeSec"`
+}
+
+// DestinationHealth is the state of the RPC circuit of one destination
+type DestinationHealth struct {
+	Name                string `json:"name"`
+	ChainID             uint64 `json:"chainId"`
+	Circuit             string `json:"circuit"` // closed or open
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Stalled             bool   `json:"stalled"`
+}
+
+// RelayQuery selects the relays of a report. Days are UTC; to covers the whole day. The
+// relayer defaults to the last 30 days.
+type RelayQuery struct {
+	Safe *common.Address // Only relays recovering this Safe
+	From time.Time
+	To   time.Time
+}
+
+// RelayReport is the answer to GET /admin/reports/relays
+type RelayReport struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	GasUsed uint64        `json:"gasUsed"`
+	CostWei string        `json:"costWei"` // Total paid, in wei, as a decimal string
+	Relays  []RelayRecord `json:"relays"`
+}
+
+// ReceiptQuery selects receipts: those of the relays a RelayQuery selects, optionally
+// narrowed to one transaction or one VAA
+type ReceiptQuery struct {
+	RelayQuery
+	TxHash    common.Hash
+	VAADigest common.Hash
+}
+
+// ReceiptList is the answer to GET /receipts
+type ReceiptList struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Domain struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"domain"`
+	Signer   *common.Address `json:"signer,omitempty"` // Address receipts are currently signed with
+	Receipts []*RelayReceipt `json:"receipts"`
+}
+
+// Retry is a VAA waiting for another processing attempt, from GET /admin/retries
+type Retry struct {
+	VAAHash       string    `json:"vaaHash"`
+	CorrelationID string    `json:"correlationId"`
+	Attempts      int       `json:"attempts"`
+	NextAttempt   time.Time `json:"nextAttempt"`
+	LastError     string    `json:"lastError"`
+	ErrorKind     string    `json:"errorKind"`
+	Parked        bool      `json:"parked"`              // Held until fees come down rather than retried with backoff
+	DeferredAt    time.Time `json:"deferredAt,omitzero"` // When it was first deferred for its cost
+}
+
+// Health reports whether the relayer can receive and submit VAAs. An unhealthy relayer is not
+// an error: its Health says why.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.get(ctx, "/healthz", nil, &health, http.StatusServiceUnavailable); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Relays lists the relays confirmed in a range with the gas they used and what they cost
+func (c *Client) Relays(ctx context.Context, query RelayQuery) (*RelayReport, error) {
+	var report RelayReport
+	if err := c.get(ctx, "/admin/reports/relays", query.values(), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Receipts lists the signed receipts of the relays a query selects. Check each with
+// RelayReceipt.Verify and compare its Signer to the relayer's known address.
+func (c *Client) Receipts(ctx context.Context, query ReceiptQuery) (*ReceiptList, error) {
+	values := query.values()
+	if query.TxHash != (common.Hash{}) {
+		values.Set("txHash", query.TxHash.Hex())
+	}
+	if query.VAADigest != (common.Hash{}) {
+		values.Set("vaaDigest", query.VAADigest.Hex())
+	}
+	var list ReceiptList
+	if err := c.get(ctx, "/receipts", values, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Retries lists the VAAs waiting for another processing attempt
+func (c *Client) Retries(ctx context.Context) ([]Retry, error) {
+	var retries []Retry
+	if err := c.get(ctx, "/admin/retries", nil, &retries); err != nil {
+		return nil, err
+	}
+	return retries, nil
+}
+
+// values encodes the query's parameters
+func (q RelayQuery) values() url.Values {
+	values := url.Values{}
+	if q.Safe != nil {
+		values.Set("safe", q.Safe.Hex())
+	}
+	if !q.From.IsZero() {
+		values.Set("from", q.From.UTC().Format(time.DateOnly))
+	}
+	if !q.To.IsZero() {
+		values.Set("to", q.To.UTC().Format(time.DateOnly))
+	}
+	return values
+}
+
+// get fetches path and decodes the JSON answer into out. Statuses other than 200 and those
+// in accept are errors.
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any, accept ...int) error {
+	target := c.url + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("relayer API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read relayer API response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && !slices.Contains(accept, resp.StatusCode) {
+		return fmt.Errorf("relayer API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid relayer API response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Field types a payload layout can use
+const (
+	FieldTypeBytes     = "bytes"      // Raw bytes
+	FieldTypeAddress   = "address"    // 20-byte address, big-endian
+	FieldTypeAddressLE = "address_le" // 20-byte address stored little-endian (Aztec Field)
+	FieldTypeUint      = "uint"       // Unsigned integer of up to 8 bytes, big-endian
+	FieldTypeUintLE    = "uint_le"    // Unsigned integer of up to 8 bytes, little-endian
+)
+
+// Field names a layout maps onto RecoveryPayload; any other name is decoded for logs only
+const (
+	FieldTxID      = "txID"      // bytes, 32 wide
+	FieldModule    = "module"    // address
+	FieldChainID   = "chainId"   // uint
+	FieldSafe      = "safe"      // address, required
+	FieldCandidate = "candidate" // address, required
+	FieldType      = "type"      // uint, 1 wide: message type, picks the tenant's call flow
+	// uint, at most 2 wide: Wormhole chain ID of the destination, checked against its configuration
+	FieldWormholeChainID = "wormholeChainId"
+)
+
+// PayloadField locates one field of a payload
+type PayloadField struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Width  int    `json:"width"`
+	Type   string `json:"type"`
+}
+
+// PayloadLayout describes the fields of one payload version and its exact size
+type PayloadLayout struct {
+	Size   int            `json:"size"`
+	Fields []PayloadField `json:"fields"`
+
+	// Signature embedded in the payload that is checked before relaying, if any
+	Authorization *PayloadAuthorization `json:"authorization,omitempty"`
+}
+
+// LegacyPayloadLayout is the built-in layout of version 0, see payload.go
+var LegacyPayloadLayout = &PayloadLayout{
+	Size: PayloadLegacySize,
+	Fields: []PayloadField{
+		{Name: FieldTxID, Offset: PayloadTxIDOffset, Width: 32, Type: FieldTypeBytes},
+		{Name: FieldModule, Offset: PayloadModuleOffset, Width: PayloadAddressSize, Type: FieldTypeAddressLE},
+		{Name: FieldChainID, Offset: PayloadChainIDOffset, Width: PayloadChainIDSize, Type: FieldTypeUintLE},
+		{Name: FieldSafe, Offset: PayloadSafeOffset, Width: PayloadAddressSize, Type: FieldTypeAddressLE},
+		{Name: FieldCandidate, Offset: PayloadCandidateOffset, Width: PayloadAddressSize, Type: FieldTypeAddressLE},
+	},
+}
+
+// Validate checks the layout can be decoded: fields fit the payload without overlapping,
+// widths suit their types and the fields the relayer routes on are present
+func (l *PayloadLayout) Validate() error {
+	if l.Size <= PayloadVersionOffset {
+		return fmt.Errorf("size %d leaves no room for the version byte at offset %d", l.Size, PayloadVersionOffset)
+	}
+
+	seen := make(map[string]bool, len(l.Fields))
+	for _, f := range l.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("field at offset %d has no name", f.Offset)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("field %q is described more than once", f.Name)
+		}
+		seen[f.Name] = true
+
+		if f.Offset < 0 || f.Width <= 0 || f.Offset+f.Width > l.Size {
+			return fmt.Errorf("field %q (offset %d, width %d) does not fit in %d bytes", f.Name, f.Offset, f.Width, l.Size)
+		}
+		switch f.Type {
+		case FieldTypeBytes:
+		case FieldTypeAddress, FieldTypeAddressLE:
+			if f.Width != common.AddressLength {
+				return fmt.Errorf("address field %q must be %d bytes wide", f.Name, common.AddressLength)
+			}
+		case FieldTypeUint, FieldTypeUintLE:
+			if f.Width > 8 {
+				return fmt.Errorf("uint field %q is wider than 8 bytes", f.Name)
+			}
+		default:
+			return fmt.Errorf("field %q has unknown type %q (want %s, %s, %s, %s or %s)", f.Name, f.Type,
+				FieldTypeBytes, FieldTypeAddress, FieldTypeAddressLE, FieldTypeUint, FieldTypeUintLE)
+		}
+
+		switch f.Name {
+		case FieldTxID:
+			if f.Type != FieldTypeBytes || f.Width != common.HashLength {
+				return fmt.Errorf("field %q must be %d bytes of type %s", f.Name, common.HashLength, FieldTypeBytes)
+			}
+		case FieldModule, FieldSafe, FieldCandidate:
+			if f.Type != FieldTypeAddress && f.Type != FieldTypeAddressLE {
+				return fmt.Errorf("field %q must be an address", f.Name)
+			}
+		case FieldChainID:
+			if f.Type != FieldTypeUint && f.Type != FieldTypeUintLE {
+				return fmt.Errorf("field %q must be a uint", f.Name)
+			}
+		case FieldType:
+			if (f.Type != FieldTypeUint && f.Type != FieldTypeUintLE) || f.Width != 1 {
+				return fmt.Errorf("field %q must be a 1-byte uint", f.Name)
+			}
+		case FieldWormholeChainID:
+			if (f.Type != FieldTypeUint && f.Type != FieldTypeUintLE) || f.Width > 2 {
+				return fmt.Errorf("field %q must be a uint of at most 2 bytes", f.Name)
+			}
+		}
+	}
+
+	byOffset := append([]PayloadField(nil), l.Fields...)
+	sort.Slice(byOffset, func(i, j int) bool { return byOffset[i].Offset < byOffset[j].Offset })
+	for i := 1; i < len(byOffset); i++ {
+		prev, f := byOffset[i-1], byOffset[i]
+		if f.Offset < prev.Offset+prev.Width {
+			return fmt.Errorf("fields %q and %q overlap", prev.Name, f.Name)
+		}
+	}
+
+	for _, required := range []string{FieldChainID, FieldSafe, FieldCandidate} {
+		if !seen[required] {
+			return fmt.Errorf("required field %q is missing", required)
+		}
+	}
+	if l.Authorization != nil {
+		return l.Authorization.Validate(l)
+	}
+	return nil
+}
+
+// Field looks up a field by name
+func (l *PayloadLayout) Field(name string) (PayloadField, bool) {
+	for _, f := range l.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return PayloadField{}, false
+}
+
+// End returns the offset just past the last field
+func (l *PayloadLayout) End() int {
+	end := 0
+	for _, f := range l.Fields {
+		end = max(end, f.Offset+f.Width)
+	}
+	return end
+}
+
+// Decode reads the recovery fields of payload as the layout describes them
+func (l *PayloadLayout) Decode(payload []byte) (*RecoveryPayload, error) {
+	if len(payload) < l.End() {
+		return nil, fmt.Errorf("payload too short: %d bytes", len(payload))
+	}
+
+	decoded := &RecoveryPayload{}
+	for _, f := range l.Fields {
+		b := payload[f.Offset : f.Offset+f.Width]
+		switch f.Name {
+		case FieldTxID:
+			decoded.TxID = common.BytesToHash(b)
+		case FieldModule:
+			decoded.Module = f.Address(b)
+		case FieldChainID:
+			decoded.ChainID = f.Uint(b)
+		case FieldSafe:
+			decoded.Safe = f.Address(b)
+		case FieldCandidate:
+			decoded.Candidate = f.Address(b)
+		case FieldType:
+			decoded.Type = uint8(f.Uint(b))
+		case FieldWormholeChainID:
+			decoded.WormholeChainID = uint16(f.Uint(b))
+		}
+	}
+	return decoded, nil
+}
+
+// Address reads an address field
+func (f PayloadField) Address(b []byte) common.Address {
+	if f.Type == FieldTypeAddressLE {
+		return readAddressLE(b)
+	}
+	return common.BytesToAddress(b)
+}
+
+// Uint reads an unsigned integer field
+func (f PayloadField) Uint(b []byte) uint64 {
+	if f.Type == FieldTypeUintLE {
+		return readUintLE(b)
+	}
+	return new(big.Int).SetBytes(b).Uint64()
+}
+
+// Format renders a field's bytes for logs
+func (f PayloadField) Format(b []byte) string {
+	switch f.Type {
+	case FieldTypeAddress, FieldTypeAddressLE:
+		return f.Address(b).Hex()
+	case FieldTypeUint, FieldTypeUintLE:
+		return strconv.FormatUint(f.Uint(b), 10)
+	default:
+		return fmt.Sprintf("0x%x", b)
+	}
+}
+
+// layouts maps each payload version to its layout. RegisterLayout adds to it.
+var (
+	layoutsMu sync.RWMutex
+	layouts   = map[uint8]*PayloadLayout{
+		PayloadVersionLegacy: LegacyPayloadLayout,
+	}
+)
+
+// LayoutFor returns the layout registered for a payload version
+func LayoutFor(version uint8) (*PayloadLayout, bool) {
+	layoutsMu.RLock()
+	defer layoutsMu.RUnlock()
+	layout, ok := layouts[version]
+	return layout, ok
+}
+
+// RegisterLayout validates a layout and registers it for a payload version, replacing any
+// layout registered for it before, the built-in ones included
+func RegisterLayout(version uint8, layout *PayloadLayout) error {
+	if err := layout.Validate(); err != nil {
+		return fmt.Errorf("payload v%d: %v", version, err)
+	}
+	layoutsMu.Lock()
+	defer layoutsMu.Unlock()
+	layouts[version] = layout
+	return nil
+}
+
+// ParseLayouts parses and validates a JSON object of payload version to layout, the format
+// of the relayer's PAYLOAD_LAYOUTS_FILE. The layouts are not registered.
+func ParseLayouts(content []byte) (map[uint8]*PayloadLayout, error) {
+	var byVersion map[string]*PayloadLayout
+	if err := json.Unmarshal(content, &byVersion); err != nil {
+		return nil, fmt.Errorf("failed to parse payload layouts: %v", err)
+	}
+
+	versions := make([]string, 0, len(byVersion))
+	for version := range byVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	parsed := make(map[uint8]*PayloadLayout, len(byVersion))
+	for _, key := range versions {
+		version, err := strconv.ParseUint(key, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload version %q", key)
+		}
+		layout := byVersion[key]
+		if layout == nil {
+			return nil, fmt.Errorf("payload v%d: no layout", version)
+		}
+		if err := layout.Validate(); err != nil {
+			return nil, fmt.Errorf("payload v%d: %v", version, err)
+		}
+		parsed[uint8(version)] = layout
+	}
+	return parsed, nil
+}
@@ -0,0 +1,218 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testLayout returns a valid layout with the required fields, with extra fields appended
+func testLayout(extra ...PayloadField) *PayloadLayout {
+	return &PayloadLayout{
+		Size: 150,
+		Fields: append([]PayloadField{
+			{Name: FieldChainID, Offset: PayloadChainIDOffset, Width: PayloadChainIDSize, Type: FieldTypeUintLE},
+			{Name: FieldSafe, Offset: PayloadSafeOffset, Width: PayloadAddressSize, Type: FieldTypeAddressLE},
+			{Name: FieldCandidate, Offset: PayloadCandidateOffset, Width: PayloadAddressSize, Type: FieldTypeAddressLE},
+		}, extra...),
+	}
+}
+
+func TestPayloadLayoutValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  *PayloadLayout
+		wantErr string // Empty when the layout is valid
+	}{
+		{name: "legacy", layout: LegacyPayloadLayout},
+		{name: "extra field", layout: testLayout(PayloadField{Name: "delay", Offset: 117, Width: 8, Type: FieldTypeUint})},
+		{name: "adjacent fields", layout: testLayout(PayloadField{Name: "flag", Offset: 75, Width: 1, Type: FieldTypeBytes})},
+		{name: "field ends at size", layout: testLayout(PayloadField{Name: "tail", Offset: 140, Width: 10, Type: FieldTypeBytes})},
+		{
+			name:    "overlapping fields",
+			layout:  testLayout(PayloadField{Name: "delay", Offset: 110, Width: 8, Type: FieldTypeUint}),
+			wantErr: `fields "candidate" and "delay" overlap`,
+		},
+		{
+			name:    "field inside another",
+			layout:  testLayout(PayloadField{Name: "blob", Offset: 50, Width: 60, Type: FieldTypeBytes}),
+			wantErr: "overlap",
+		},
+		{
+			name:    "same offset",
+			layout:  testLayout(PayloadField{Name: "flag", Offset: PayloadSafeOffset, Width: 1, Type: FieldTypeBytes}),
+			wantErr: "overlap",
+		},
+		{
+			name:    "negative offset",
+			layout:  testLayout(PayloadField{Name: "flag", Offset: -1, Width: 1, Type: FieldTypeBytes}),
+			wantErr: "does not fit in 150 bytes",
+		},
+		{
+			name:    "offset past size",
+			layout:  testLayout(PayloadField{Name: "flag", Offset: 150, Width: 1, Type: FieldTypeBytes}),
+			wantErr: "does not fit in 150 bytes",
+		},
+		{
+			name:    "width past size",
+			layout:  testLayout(PayloadField{Name: "tail", Offset: 140, Width: 11, Type: FieldTypeBytes}),
+			wantErr: "does not fit in 150 bytes",
+		},
+		{
+			name:    "zero width",
+			layout:  testLayout(PayloadField{Name: "flag", Offset: 120, Width: 0, Type: FieldTypeBytes}),
+			wantErr: "does not fit",
+		},
+		{
+			name:    "size leaves no room for the version",
+			layout:  &PayloadLayout{Size: PayloadVersionOffset, Fields: testLayout().Fields},
+			wantErr: "no room for the version byte",
+		},
+		{
+			name:    "uint wider than 8 bytes",
+			layout:  testLayout(PayloadField{Name: "amount", Offset: 117, Width: 9, Type: FieldTypeUint}),
+			wantErr: "wider than 8 bytes",
+		},
+		{
+			name:    "address not 20 bytes",
+			layout:  testLayout(PayloadField{Name: "owner", Offset: 117, Width: 21, Type: FieldTypeAddress}),
+			wantErr: "must be 20 bytes wide",
+		},
+		{
+			name:    "unknown type",
+			layout:  testLayout(PayloadField{Name: "flag", Offset: 117, Width: 1, Type: "bool"}),
+			wantErr: "unknown type",
+		},
+		{
+			name:    "duplicate field",
+			layout:  testLayout(PayloadField{Name: FieldSafe, Offset: 117, Width: 20, Type: FieldTypeAddress}),
+			wantErr: "described more than once",
+		},
+		{
+			name:    "type wider than 1 byte",
+			layout:  testLayout(PayloadField{Name: FieldType, Offset: 117, Width: 2, Type: FieldTypeUint}),
+			wantErr: "must be a 1-byte uint",
+		},
+		{
+			name:    "missing candidate",
+			layout:  &PayloadLayout{Size: 150, Fields: testLayout().Fields[:2]},
+			wantErr: `required field "candidate" is missing`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.layout.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayloadLayoutDecode(t *testing.T) {
+	layout := testLayout(
+		PayloadField{Name: FieldType, Offset: 117, Width: 1, Type: FieldTypeUint},
+		PayloadField{Name: FieldWormholeChainID, Offset: 118, Width: 2, Type: FieldTypeUint},
+		PayloadField{Name: "delay", Offset: 140, Width: 8, Type: FieldTypeUint},
+	)
+	payload := legacyPayload(layout.Size, testModule, 11155111, testSafe, testCandidate)
+	payload[PayloadVersionOffset] = 1
+	payload[117] = 2
+	payload[118], payload[119] = 0x27, 0x12 // 10002, big-endian
+
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr bool
+	}{
+		{name: "full payload", payload: payload},
+		{name: "ends with the last field", payload: payload[:layout.End()]},
+		{name: "shorter than the last field", payload: payload[:layout.End()-1], wantErr: true},
+		{name: "empty", payload: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := layout.Decode(tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decoded %+v, want an error", decoded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.ChainID != 11155111 || decoded.Safe != testSafe || decoded.Candidate != testCandidate {
+				t.Errorf("got chain %d, safe %s, candidate %s", decoded.ChainID, decoded.Safe.Hex(), decoded.Candidate.Hex())
+			}
+			if decoded.Type != 2 || decoded.WormholeChainID != 10002 {
+				t.Errorf("got type %d, Wormhole chain %d, want 2 and 10002", decoded.Type, decoded.WormholeChainID)
+			}
+			if decoded.Module != (common.Address{}) {
+				t.Errorf("module %s decoded from a layout without one", decoded.Module.Hex())
+			}
+		})
+	}
+}
+
+func TestParseLayouts(t *testing.T) {
+	const v1 = `{"size": 150, "fields": [
+		{"name": "chainId", "offset": 52, "width": 3, "type": "uint_le"},
+		{"name": "safe", "offset": 55, "width": 20, "type": "address_le"},
+		{"name": "candidate", "offset": 96, "width": 20, "type": "address_le"}
+	]}`
+
+	tests := []struct {
+		name         string
+		content      string
+		wantVersions []uint8
+		wantErr      string // Empty when the content is valid
+	}{
+		{name: "one version", content: `{"1": ` + v1 + `}`, wantVersions: []uint8{1}},
+		{name: "several versions", content: `{"0": ` + v1 + `, "255": ` + v1 + `}`, wantVersions: []uint8{0, 255}},
+		{name: "empty", content: `{}`},
+		{name: "malformed JSON", content: `{"1": `, wantErr: "failed to parse payload layouts"},
+		{name: "version out of range", content: `{"256": ` + v1 + `}`, wantErr: `invalid payload version "256"`},
+		{name: "version not a number", content: `{"v1": ` + v1 + `}`, wantErr: `invalid payload version "v1"`},
+		{name: "null layout", content: `{"1": null}`, wantErr: "payload v1: no layout"},
+		{
+			name:    "overlapping fields",
+			content: `{"1": {"size": 150, "fields": [{"name": "chainId", "offset": 52, "width": 3, "type": "uint_le"}, {"name": "safe", "offset": 54, "width": 20, "type": "address_le"}, {"name": "candidate", "offset": 96, "width": 20, "type": "address_le"}]}}`,
+			wantErr: `payload v1: fields "chainId" and "safe" overlap`,
+		},
+		{
+			name:    "field past the size",
+			content: `{"1": {"size": 117, "fields": [{"name": "chainId", "offset": 52, "width": 3, "type": "uint_le"}, {"name": "safe", "offset": 55, "width": 20, "type": "address_le"}, {"name": "candidate", "offset": 98, "width": 20, "type": "address_le"}]}}`,
+			wantErr: "does not fit in 117 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layouts, err := ParseLayouts([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(layouts) != len(tt.wantVersions) {
+				t.Fatalf("got %d layouts, want %d", len(layouts), len(tt.wantVersions))
+			}
+			for _, version := range tt.wantVersions {
+				if layouts[version] == nil || layouts[version].Size != 150 {
+					t.Errorf("v%d: got %+v", version, layouts[version])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package sdk decodes the Aztec recovery payloads the relayer forwards, verifies the receipts
+// it signs and queries its status API. It is what Go services outside the relayer, such as a
+// wallet backend, build on instead of copying byte offsets.
+package sdk
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Recovery payload layout (after Wormhole prepends the 32-byte source txID).
+// Aztec fields are little-endian, see SafeRecoveryModule.verify. The deployed Recovery contract
+// leaves the byte after the candidate zero, which reads as the legacy version:
+//
+//	[txID(32), module(20), chainId(3), safe(20), zeros(21), candidate(20), version(1), padding(16)]
+const (
+	PayloadTxIDOffset      = 0
+	PayloadModuleOffset    = 32
+	PayloadChainIDOffset   = 52
+	PayloadSafeOffset      = 55
+	PayloadCandidateOffset = 96
+	PayloadVersionOffset   = 116
+
+	PayloadAddressSize = 20
+	PayloadChainIDSize = 3
+
+	// Total size of a version 0 payload
+	PayloadLegacySize = 133
+)
+
+// Payload versions with a built-in layout
+const (
+	// PayloadVersionLegacy is the original recovery message, which carries no explicit
+	// version and therefore reads as zero at PayloadVersionOffset
+	PayloadVersionLegacy uint8 = 0
+)
+
+// RecoveryPayload is the decoded form of a recovery VAA payload
+type RecoveryPayload struct {
+	Version         uint8          // Payload format version
+	TxID            common.Hash    // Source transaction ID prepended by Wormhole
+	Module          common.Address // Destination module the message is addressed to
+	ChainID         uint64         // Destination EVM chain ID
+	Safe            common.Address // Safe being recovered
+	Candidate       common.Address // New owner to add to the Safe
+	Type            uint8          // Message type, 0 when the layout has no type field
+	WormholeChainID uint16         // Destination Wormhole chain ID, 0 when the layout has no such field
+}
+
+// PayloadVersion reads the version byte; payloads too short to carry one are legacy
+func PayloadVersion(payload []byte) uint8 {
+	if len(payload) <= PayloadVersionOffset {
+		return PayloadVersionLegacy
+	}
+	return payload[PayloadVersionOffset]
+}
+
+// CheckPayloadSize checks that payload has exactly the size of its version's layout
+func CheckPayloadSize(payload []byte) error {
+	version := PayloadVersion(payload)
+	layout, ok := LayoutFor(version)
+	if !ok {
+		return fmt.Errorf("unsupported payload version %d", version)
+	}
+	if len(payload) != layout.Size {
+		return fmt.Errorf("payload length %d does not match v%d schema size %d", len(payload), version, layout.Size)
+	}
+	return nil
+}
+
+// DecodeRecoveryPayload decodes payload with the layout registered for its version
+func DecodeRecoveryPayload(payload []byte) (*RecoveryPayload, error) {
+	version := PayloadVersion(payload)
+
+	layout, ok := LayoutFor(version)
+	if !ok {
+		return nil, fmt.Errorf("unsupported payload version %d", version)
+	}
+
+	decoded, err := layout.Decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode payload v%d: %v", version, err)
+	}
+	decoded.Version = version
+	return decoded, nil
+}
+
+// Validate checks the decoded fields against the layout of the payload's version and the
+// destination it is relayed to. module may be the zero address to skip the module check.
+func (p *RecoveryPayload) Validate(payloadLen int, chainID uint64, module common.Address) error {
+	layout, ok := LayoutFor(p.Version)
+	if !ok {
+		return fmt.Errorf("unsupported payload version %d", p.Version)
+	}
+	if payloadLen != layout.Size {
+		return fmt.Errorf("payload length %d does not match v%d schema size %d", payloadLen, p.Version, layout.Size)
+	}
+	if p.Safe == (common.Address{}) {
+		return fmt.Errorf("safe address is zero")
+	}
+	if p.Candidate == (common.Address{}) {
+		return fmt.Errorf("candidate address is zero")
+	}
+	if p.Candidate == p.Safe {
+		return fmt.Errorf("candidate %s is the Safe itself", p.Candidate.Hex())
+	}
+	if p.ChainID != chainID {
+		return fmt.Errorf("payload chain ID %d does not match destination chain ID %d", p.ChainID, chainID)
+	}
+	// Older Aztec deployments leave the destination module unset
+	if module != (common.Address{}) && p.Module != (common.Address{}) && p.Module != module {
+		return fmt.Errorf("payload module %s does not match target contract %s", p.Module.Hex(), module.Hex())
+	}
+	return nil
+}
+
+// readAddressLE reads an address stored as little-endian bytes (Aztec Field format)
+func readAddressLE(b []byte) common.Address {
+	var addr common.Address
+	for i := 0; i < len(b) && i < len(addr); i++ {
+		addr[len(addr)-1-i] = b[i]
+	}
+	return addr
+}
+
+// readUintLE reads an unsigned integer of up to 8 little-endian bytes
+func readUintLE(b []byte) uint64 {
+	var value uint64
+	for i := 0; i < len(b) && i < 8; i++ {
+		value |= uint64(b[i]) << (8 * i)
+	}
+	return value
+}
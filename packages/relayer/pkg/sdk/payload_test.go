@@ -0,0 +1,179 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	testModule    = common.HexToAddress("0x1111111111111111111111111111111111111122")
+	testSafe      = common.HexToAddress("0x00a329c0648769A73afAc7F9381E08FB43dBEA72")
+	testCandidate = common.HexToAddress("0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbe01")
+)
+
+// writeAddressLE stores an address as little-endian bytes, the way the Aztec contract does
+func writeAddressLE(b []byte, addr common.Address) {
+	for i := range addr {
+		b[i] = addr[len(addr)-1-i]
+	}
+}
+
+// legacyPayload builds a version 0 payload, truncated or zero-extended to size bytes
+func legacyPayload(size int, module common.Address, chainID uint64, safe, candidate common.Address) []byte {
+	payload := make([]byte, max(size, PayloadLegacySize))
+	for i := 0; i < 32; i++ {
+		payload[i] = byte(i + 1)
+	}
+	writeAddressLE(payload[PayloadModuleOffset:], module)
+	for i := 0; i < PayloadChainIDSize; i++ {
+		payload[PayloadChainIDOffset+i] = byte(chainID >> (8 * i))
+	}
+	writeAddressLE(payload[PayloadSafeOffset:], safe)
+	writeAddressLE(payload[PayloadCandidateOffset:], candidate)
+	return payload[:size]
+}
+
+func TestDecodeRecoveryPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		chainID uint64
+	}{
+		{name: "Sepolia", chainID: 11155111},
+		{name: "one byte chain ID", chainID: 1},
+		{name: "largest 3-byte chain ID", chainID: 0xffffff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := legacyPayload(PayloadLegacySize, testModule, tt.chainID, testSafe, testCandidate)
+			decoded, err := DecodeRecoveryPayload(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Version != PayloadVersionLegacy {
+				t.Errorf("version %d, want %d", decoded.Version, PayloadVersionLegacy)
+			}
+			if decoded.TxID != common.BytesToHash(payload[:32]) {
+				t.Errorf("txID %s, want %x", decoded.TxID.Hex(), payload[:32])
+			}
+			if decoded.Module != testModule {
+				t.Errorf("module %s, want %s", decoded.Module.Hex(), testModule.Hex())
+			}
+			if decoded.ChainID != tt.chainID {
+				t.Errorf("chain ID %d, want %d", decoded.ChainID, tt.chainID)
+			}
+			if decoded.Safe != testSafe {
+				t.Errorf("safe %s, want %s", decoded.Safe.Hex(), testSafe.Hex())
+			}
+			if decoded.Candidate != testCandidate {
+				t.Errorf("candidate %s, want %s", decoded.Candidate.Hex(), testCandidate.Hex())
+			}
+		})
+	}
+}
+
+func TestDecodeRecoveryPayloadErrors(t *testing.T) {
+	unknownVersion := legacyPayload(PayloadLegacySize, testModule, 1, testSafe, testCandidate)
+	unknownVersion[PayloadVersionOffset] = 0xfe
+
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr string
+	}{
+		{name: "unregistered version", payload: unknownVersion, wantErr: "unsupported payload version 254"},
+		{name: "shorter than the candidate", payload: legacyPayload(PayloadCandidateOffset+10, testModule, 1, testSafe, testCandidate), wantErr: "decode payload v0"},
+		{name: "empty", payload: nil, wantErr: "decode payload v0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeRecoveryPayload(tt.payload)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPayloadSize(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantErr bool
+	}{
+		{size: PayloadLegacySize},
+		{size: PayloadLegacySize - 1, wantErr: true},
+		{size: PayloadLegacySize + 1, wantErr: true},
+		{size: PayloadVersionOffset, wantErr: true},
+	}
+	for _, tt := range tests {
+		payload := legacyPayload(tt.size, testModule, 1, testSafe, testCandidate)
+		if err := CheckPayloadSize(payload); (err != nil) != tt.wantErr {
+			t.Errorf("size %d: error = %v, want error %v", tt.size, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRecoveryPayloadValidate(t *testing.T) {
+	const chainID = 11155111
+	valid := func() *RecoveryPayload {
+		return &RecoveryPayload{Module: testModule, ChainID: chainID, Safe: testSafe, Candidate: testCandidate}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(p *RecoveryPayload)
+		size    int // PayloadLegacySize when 0
+		chainID uint64
+		module  common.Address
+		wantErr string // Empty when the payload is valid
+	}{
+		{name: "valid", chainID: chainID, module: testModule},
+		{name: "module check skipped", chainID: chainID},
+		{name: "module unset by older deployments", modify: func(p *RecoveryPayload) { p.Module = common.Address{} }, chainID: chainID, module: testModule},
+		{name: "132 bytes", size: PayloadLegacySize - 1, chainID: chainID, wantErr: "payload length 132 does not match v0 schema size 133"},
+		{name: "134 bytes", size: PayloadLegacySize + 1, chainID: chainID, wantErr: "payload length 134 does not match v0 schema size 133"},
+		{name: "zero safe", modify: func(p *RecoveryPayload) { p.Safe = common.Address{} }, chainID: chainID, wantErr: "safe address is zero"},
+		{name: "zero candidate", modify: func(p *RecoveryPayload) { p.Candidate = common.Address{} }, chainID: chainID, wantErr: "candidate address is zero"},
+		{name: "candidate is the Safe", modify: func(p *RecoveryPayload) { p.Candidate = testSafe }, chainID: chainID, wantErr: "is the Safe itself"},
+		{name: "wrong chain", chainID: 1, wantErr: "does not match destination chain ID 1"},
+		{name: "wrong module", chainID: chainID, module: testSafe, wantErr: "does not match target contract"},
+		{name: "unregistered version", modify: func(p *RecoveryPayload) { p.Version = 0xfe }, chainID: chainID, wantErr: "unsupported payload version 254"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid()
+			if tt.modify != nil {
+				tt.modify(payload)
+			}
+			size := tt.size
+			if size == 0 {
+				size = PayloadLegacySize
+			}
+			err := payload.Validate(size, tt.chainID, tt.module)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadLE(t *testing.T) {
+	if got := readUintLE([]byte{0xa7, 0x36, 0xaa}); got != 0xaa36a7 {
+		t.Errorf("readUintLE = %#x, want 0xaa36a7", got)
+	}
+	b := make([]byte, PayloadAddressSize)
+	writeAddressLE(b, testSafe)
+	if b[0] != 0x72 || b[19] != 0x00 {
+		t.Errorf("address not stored little-endian: %x", b)
+	}
+	if got := readAddressLE(b); got != testSafe {
+		t.Errorf("readAddressLE = %s, want %s", got.Hex(), testSafe.Hex())
+	}
+}
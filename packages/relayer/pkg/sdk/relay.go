@@ -0,0 +1,91 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 domain of relay receipts. It has no chain ID or verifying contract: receipts are
+// checked off-chain, and the destination chain is part of the signed receipt.
+const (
+	ReceiptDomainName    = "AztecSafeRecoveryRelayer"
+	ReceiptDomainVersion = "1"
+)
+
+var (
+	receiptDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version)"))
+	receiptTypeHash       = crypto.Keccak256Hash([]byte("RelayReceipt(bytes32 vaaDigest,uint256 chainId,bytes32 txHash,address safe,uint256 timestamp)"))
+	receiptDomainSep      = crypto.Keccak256Hash(
+		receiptDomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(ReceiptDomainName)),
+		crypto.Keccak256([]byte(ReceiptDomainVersion)),
+	)
+)
+
+// RelayReceipt is the relayer's signed statement that it delivered a VAA to a destination:
+// an EIP-712 signature over the VAA digest, the transaction and when it was confirmed
+type RelayReceipt struct {
+	VAADigest common.Hash    `json:"vaaDigest"` // Wormhole signing digest of the VAA (double keccak256 of its body)
+	ChainID   uint64         `json:"chainId"`   // Destination EVM chain ID
+	TxHash    common.Hash    `json:"txHash"`
+	Safe      common.Address `json:"safe"`
+	Timestamp uint64         `json:"timestamp"` // Unix time the transaction was confirmed
+	Signer    common.Address `json:"signer"`    // Address whose key signed the receipt
+	Signature hexutil.Bytes  `json:"signature"` // 65-byte [R || S || V] signature, V being 27 or 28
+}
+
+// Digest returns the EIP-712 hash of the receipt that is signed
+func (rr *RelayReceipt) Digest() common.Hash {
+	structHash := crypto.Keccak256(
+		receiptTypeHash.Bytes(),
+		rr.VAADigest.Bytes(),
+		common.LeftPadBytes(new(big.Int).SetUint64(rr.ChainID).Bytes(), 32),
+		rr.TxHash.Bytes(),
+		common.LeftPadBytes(rr.Safe.Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(rr.Timestamp).Bytes(), 32),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, receiptDomainSep.Bytes(), structHash)
+}
+
+// Verify checks that the receipt was signed by its Signer
+func (rr *RelayReceipt) Verify() error {
+	if len(rr.Signature) != crypto.SignatureLength {
+		return fmt.Errorf("signature is %d bytes, want %d", len(rr.Signature), crypto.SignatureLength)
+	}
+	sig := append([]byte{}, rr.Signature...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(rr.Digest().Bytes(), sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub); recovered != rr.Signer {
+		return fmt.Errorf("signed by %s, not %s", recovered.Hex(), rr.Signer.Hex())
+	}
+	return nil
+}
+
+// RelayRecord is the gas a confirmed relay transaction used and what it cost, read from its
+// receipt
+type RelayRecord struct {
+	TxHash            common.Hash    `json:"txHash"`
+	VAAHash           string         `json:"vaaHash"` // SHA-256 of the relayed VAA
+	CorrelationID     string         `json:"correlationId"`
+	Tenant            string         `json:"tenant"`
+	Function          string         `json:"function"` // Call flow step the transaction sent
+	Safe              common.Address `json:"safe"`
+	ChainID           uint64         `json:"chainId"`
+	Block             uint64         `json:"block"`
+	GasUsed           uint64         `json:"gasUsed"`
+	EffectiveGasPrice *big.Int       `json:"effectiveGasPrice"`
+	CostWei           *big.Int       `json:"costWei"` // GasUsed times EffectiveGasPrice, plus any OP stack L1 fee
+	ConfirmedAt       time.Time      `json:"confirmedAt"`
+	VAA               []byte         `json:"vaa,omitempty"`     // The relayed VAA, for reconciliation; empty on older records
+	Receipt           *RelayReceipt  `json:"receipt,omitempty"` // Signed receipt, when receipts are enabled
+}
@@ -0,0 +1,50 @@
+package sdk
+
+import (
+	"fmt"
+
+	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
+)
+
+// VAAData is a parsed VAA with the fields the relayer routes and logs by
+type VAAData struct {
+	VAA        *vaaLib.VAA      // The parsed VAA
+	RawBytes   []byte           // Raw VAA bytes
+	ChainID    uint16           // Source chain ID
+	EmitterHex string           // Hex-encoded emitter address
+	Sequence   uint64           // VAA sequence number
+	TxID       string           // Source transaction ID
+	Payload    *RecoveryPayload // Decoded recovery payload (set once the VAA is accepted for relay)
+}
+
+// ParseVAA parses a VAA without decoding its payload, which may not be a recovery message
+func ParseVAA(vaaBytes []byte) (*VAAData, error) {
+	wormholeVAA, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ""
+	if len(wormholeVAA.Payload) >= 32 {
+		txID = fmt.Sprintf("0x%x", wormholeVAA.Payload[:32])
+	}
+
+	return &VAAData{
+		VAA:        wormholeVAA,
+		RawBytes:   vaaBytes,
+		ChainID:    uint16(wormholeVAA.EmitterChain),
+		EmitterHex: fmt.Sprintf("%064x", wormholeVAA.EmitterAddress),
+		Sequence:   wormholeVAA.Sequence,
+		TxID:       txID,
+	}, nil
+}
+
+// DecodePayload decodes the VAA's payload as a recovery message and sets Payload
+func (v *VAAData) DecodePayload() (*RecoveryPayload, error) {
+	payload, err := DecodeRecoveryPayload(v.VAA.Payload)
+	if err != nil {
+		return nil, err
+	}
+	v.Payload = payload
+	return payload, nil
+}
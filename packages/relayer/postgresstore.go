@@ -0,0 +1,509 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/lib/pq"
+)
+
+// postgresSchema creates the state tables if they don't exist
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS relayer_processed (
+	key          TEXT PRIMARY KEY,
+	processed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS relayer_processed_at_idx ON relayer_processed (processed_at);
+CREATE TABLE IF NOT EXISTS relayer_emitters (
+	tenant         TEXT NOT NULL,
+	safe           TEXT NOT NULL,
+	aztec_contract TEXT NOT NULL,
+	PRIMARY KEY (tenant, safe)
+);
+CREATE TABLE IF NOT EXISTS relayer_emitter_overrides (
+	tenant     TEXT NOT NULL,
+	emitter    TEXT NOT NULL,
+	safe       TEXT NOT NULL,
+	disabled   BOOLEAN NOT NULL,
+	reason     TEXT NOT NULL,
+	principal  TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant, emitter)
+);
+CREATE TABLE IF NOT EXISTS relayer_scan_cursors (
+	tenant TEXT PRIMARY KEY,
+	block  BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS relayer_checkpoints (
+	emitter_chain   INTEGER NOT NULL,
+	emitter_address TEXT NOT NULL,
+	sequence        BIGINT NOT NULL,
+	updated_at      TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (emitter_chain, emitter_address)
+);
+CREATE TABLE IF NOT EXISTS relayer_safe_costs (
+	safe     TEXT NOT NULL,
+	chain_id BIGINT NOT NULL,
+	day      DATE NOT NULL,
+	relays   BIGINT NOT NULL,
+	gas_used BIGINT NOT NULL,
+	cost_wei NUMERIC(78, 0) NOT NULL,
+	PRIMARY KEY (safe, chain_id, day)
+);
+CREATE TABLE IF NOT EXISTS relayer_relays (
+	tx_hash             TEXT PRIMARY KEY,
+	vaa_hash            TEXT NOT NULL,
+	tenant              TEXT NOT NULL,
+	function            TEXT NOT NULL,
+	safe                TEXT NOT NULL,
+	chain_id            BIGINT NOT NULL,
+	block               BIGINT NOT NULL,
+	gas_used            BIGINT NOT NULL,
+	effective_gas_price NUMERIC(78, 0) NOT NULL,
+	cost_wei            NUMERIC(78, 0) NOT NULL,
+	confirmed_at        TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS relayer_relays_confirmed_at_idx ON relayer_relays (confirmed_at);
+ALTER TABLE relayer_relays ADD COLUMN IF NOT EXISTS correlation_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE relayer_relays ADD COLUMN IF NOT EXISTS vaa_bytes BYTEA;
+ALTER TABLE relayer_relays ADD COLUMN IF NOT EXISTS receipt TEXT;
+CREATE TABLE IF NOT EXISTS relayer_retries (
+	key          TEXT PRIMARY KEY,
+	vaa_bytes    BYTEA NOT NULL,
+	attempts     INTEGER NOT NULL,
+	next_attempt TIMESTAMPTZ NOT NULL,
+	last_error   TEXT NOT NULL
+);
+ALTER TABLE relayer_retries ADD COLUMN IF NOT EXISTS error_kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE relayer_retries ADD COLUMN IF NOT EXISTS correlation_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE relayer_retries ADD COLUMN IF NOT EXISTS deferred_at TIMESTAMPTZ;
+ALTER TABLE relayer_retries ADD COLUMN IF NOT EXISTS flow_step INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE relayer_retries ADD COLUMN IF NOT EXISTS flow_captured TEXT;
+CREATE TABLE IF NOT EXISTS relayer_inflight (
+	key        TEXT PRIMARY KEY,
+	vaa_bytes  BYTEA NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE relayer_inflight ADD COLUMN IF NOT EXISTS correlation_id TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS relayer_lease (
+	id         INTEGER PRIMARY KEY CHECK (id = 1),
+	holder     TEXT NOT NULL,
+	renewed_at TIMESTAMPTZ NOT NULL
+);
+`
+
+// PostgresStore persists relayer state in PostgreSQL, letting several hosts share one database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// OpenPostgresStore connects to the database at url and creates the schema
+func OpenPostgresStore(url string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres state store: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres state store: %v", err)
+	}
+	if _, err := db.Exec(postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize postgres state store: %v", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close closes the connection pool
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// MarkProcessed records a handled VAA
+func (s *PostgresStore) MarkProcessed(key string, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_processed (key, processed_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET processed_at = EXCLUDED.processed_at`, key, at)
+	return err
+}
+
+// LoadProcessed returns dedupe entries recorded at or after since
+func (s *PostgresStore) LoadProcessed(since time.Time) (map[string]time.Time, error) {
+	rows, err := s.db.Query(`SELECT key, processed_at FROM relayer_processed WHERE processed_at >= $1`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	processed := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var at time.Time
+		if err := rows.Scan(&key, &at); err != nil {
+			return nil, err
+		}
+		processed[key] = at
+	}
+	return processed, rows.Err()
+}
+
+// PruneProcessed deletes dedupe entries recorded before the given time
+func (s *PostgresStore) PruneProcessed(before time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM relayer_processed WHERE processed_at < $1`, before)
+	return err
+}
+
+// SaveEmitter records the Aztec contract registered by a Safe with a tenant's module
+func (s *PostgresStore) SaveEmitter(tenant string, safe common.Address, aztecContract string) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_emitters (tenant, safe, aztec_contract) VALUES ($1, $2, $3)
+		ON CONFLICT (tenant, safe) DO UPDATE SET aztec_contract = EXCLUDED.aztec_contract`,
+		tenant, safe.Hex(), aztecContract)
+	return err
+}
+
+// RemoveEmitter deletes the Safe's registration with the tenant's module
+func (s *PostgresStore) RemoveEmitter(tenant string, safe common.Address) error {
+	_, err := s.db.Exec(`DELETE FROM relayer_emitters WHERE tenant = $1 AND safe = $2`, tenant, safe.Hex())
+	return err
+}
+
+// LoadEmitters returns the Aztec contract registered by each Safe with the tenant's module
+func (s *PostgresStore) LoadEmitters(tenant string) (map[common.Address]string, error) {
+	rows, err := s.db.Query(`SELECT safe, aztec_contract FROM relayer_emitters WHERE tenant = $1`, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emitters := make(map[common.Address]string)
+	for rows.Next() {
+		var safe, contract string
+		if err := rows.Scan(&safe, &contract); err != nil {
+			return nil, err
+		}
+		emitters[common.HexToAddress(safe)] = contract
+	}
+	return emitters, rows.Err()
+}
+
+// SaveEmitterOverride inserts or replaces an operator's override of a tenant's registry
+func (s *PostgresStore) SaveEmitterOverride(override EmitterOverride) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_emitter_overrides (tenant, emitter, safe, disabled, reason, principal, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant, emitter) DO UPDATE SET safe = EXCLUDED.safe, disabled = EXCLUDED.disabled,
+			reason = EXCLUDED.reason, principal = EXCLUDED.principal, updated_at = EXCLUDED.updated_at`,
+		override.Tenant, override.Emitter, override.Safe.Hex(), override.Disabled, override.Reason, override.Principal, override.UpdatedAt)
+	return err
+}
+
+// RemoveEmitterOverride deletes the override of the emitter in the tenant's registry
+func (s *PostgresStore) RemoveEmitterOverride(tenant, emitter string) error {
+	_, err := s.db.Exec(`DELETE FROM relayer_emitter_overrides WHERE tenant = $1 AND emitter = $2`, tenant, emitter)
+	return err
+}
+
+// LoadEmitterOverrides returns the overrides of the tenant's registry
+func (s *PostgresStore) LoadEmitterOverrides(tenant string) ([]EmitterOverride, error) {
+	rows, err := s.db.Query(`SELECT emitter, safe, disabled, reason, principal, updated_at
+		FROM relayer_emitter_overrides WHERE tenant = $1`, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []EmitterOverride
+	for rows.Next() {
+		override := EmitterOverride{Tenant: tenant}
+		var safe string
+		if err := rows.Scan(&override.Emitter, &safe, &override.Disabled, &override.Reason, &override.Principal, &override.UpdatedAt); err != nil {
+			return nil, err
+		}
+		override.Safe = common.HexToAddress(safe)
+		overrides = append(overrides, override)
+	}
+	return overrides, rows.Err()
+}
+
+// SaveScanCursor records the last block whose registry events were applied for the tenant
+func (s *PostgresStore) SaveScanCursor(tenant string, block uint64) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_scan_cursors (tenant, block) VALUES ($1, $2)
+		ON CONFLICT (tenant) DO UPDATE SET block = EXCLUDED.block`, tenant, int64(block))
+	return err
+}
+
+// LoadScanCursor returns the tenant's scan cursor, and false when none was saved
+func (s *PostgresStore) LoadScanCursor(tenant string) (uint64, bool, error) {
+	var block int64
+	err := s.db.QueryRow(`SELECT block FROM relayer_scan_cursors WHERE tenant = $1`, tenant).Scan(&block)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(block), true, nil
+}
+
+// SaveCheckpoint stores cp unless a higher sequence is already recorded for the emitter
+func (s *PostgresStore) SaveCheckpoint(cp Checkpoint) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_checkpoints (emitter_chain, emitter_address, sequence, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (emitter_chain, emitter_address) DO UPDATE
+		SET sequence = EXCLUDED.sequence, updated_at = EXCLUDED.updated_at
+		WHERE relayer_checkpoints.sequence < EXCLUDED.sequence`,
+		int(cp.EmitterChain), cp.EmitterAddress, int64(cp.Sequence), cp.UpdatedAt)
+	return err
+}
+
+// LoadCheckpoints returns all checkpoints keyed by checkpointKey
+func (s *PostgresStore) LoadCheckpoints() (map[string]Checkpoint, error) {
+	rows, err := s.db.Query(`SELECT emitter_chain, emitter_address, sequence, updated_at FROM relayer_checkpoints`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make(map[string]Checkpoint)
+	for rows.Next() {
+		var chain int
+		var sequence int64
+		var cp Checkpoint
+		if err := rows.Scan(&chain, &cp.EmitterAddress, &sequence, &cp.UpdatedAt); err != nil {
+			return nil, err
+		}
+		cp.EmitterChain = uint16(chain)
+		cp.Sequence = uint64(sequence)
+		checkpoints[checkpointKey(cp.EmitterChain, cp.EmitterAddress)] = cp
+	}
+	return checkpoints, rows.Err()
+}
+
+// SaveRetry inserts or replaces a retry queue entry
+func (s *PostgresStore) SaveRetry(entry RetryEntry) error {
+	deferredAt := sql.NullTime{Time: entry.DeferredAt, Valid: !entry.DeferredAt.IsZero()}
+	var captured sql.NullString
+	if len(entry.FlowCaptured) > 0 {
+		encoded, err := json.Marshal(entry.FlowCaptured)
+		if err != nil {
+			return err
+		}
+		captured = sql.NullString{String: string(encoded), Valid: true}
+	}
+	_, err := s.db.Exec(`INSERT INTO relayer_retries (key, vaa_bytes, attempts, next_attempt, last_error, error_kind, correlation_id, deferred_at,
+			flow_step, flow_captured)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (key) DO UPDATE SET vaa_bytes = EXCLUDED.vaa_bytes, attempts = EXCLUDED.attempts,
+			next_attempt = EXCLUDED.next_attempt, last_error = EXCLUDED.last_error, error_kind = EXCLUDED.error_kind,
+			correlation_id = EXCLUDED.correlation_id, deferred_at = EXCLUDED.deferred_at,
+			flow_step = EXCLUDED.flow_step, flow_captured = EXCLUDED.flow_captured`,
+		entry.Key, entry.VAABytes, entry.Attempts, entry.NextAttempt, entry.LastError, string(entry.ErrorKind), entry.CorrelationID, deferredAt,
+		entry.FlowStep, captured)
+	return err
+}
+
+// RemoveRetry deletes the retry queue entry with the dedupe key
+func (s *PostgresStore) RemoveRetry(key string) error {
+	_, err := s.db.Exec(`DELETE FROM relayer_retries WHERE key = $1`, key)
+	return err
+}
+
+// LoadRetries returns every queued retry
+func (s *PostgresStore) LoadRetries() ([]RetryEntry, error) {
+	rows, err := s.db.Query(`SELECT key, vaa_bytes, attempts, next_attempt, last_error, error_kind, correlation_id, deferred_at,
+		flow_step, flow_captured FROM relayer_retries`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []RetryEntry
+	for rows.Next() {
+		var entry RetryEntry
+		var deferredAt sql.NullTime
+		var captured sql.NullString
+		if err := rows.Scan(&entry.Key, &entry.VAABytes, &entry.Attempts, &entry.NextAttempt, &entry.LastError, &entry.ErrorKind, &entry.CorrelationID, &deferredAt,
+			&entry.FlowStep, &captured); err != nil {
+			return nil, err
+		}
+		entry.DeferredAt = deferredAt.Time
+		if captured.Valid {
+			if err := json.Unmarshal([]byte(captured.String), &entry.FlowCaptured); err != nil {
+				return nil, fmt.Errorf("retry %s: invalid flow captures: %v", entry.Key, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// SaveInflight records a VAA that has entered processing
+func (s *PostgresStore) SaveInflight(entry InflightEntry) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_inflight (key, vaa_bytes, started_at, correlation_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET vaa_bytes = EXCLUDED.vaa_bytes, started_at = EXCLUDED.started_at,
+			correlation_id = EXCLUDED.correlation_id`,
+		entry.Key, entry.VAABytes, entry.StartedAt, entry.CorrelationID)
+	return err
+}
+
+// RemoveInflight deletes the inflight record with the dedupe key
+func (s *PostgresStore) RemoveInflight(key string) error {
+	_, err := s.db.Exec(`DELETE FROM relayer_inflight WHERE key = $1`, key)
+	return err
+}
+
+// LoadInflight returns every inflight record
+func (s *PostgresStore) LoadInflight() ([]InflightEntry, error) {
+	rows, err := s.db.Query(`SELECT key, vaa_bytes, started_at, correlation_id FROM relayer_inflight`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []InflightEntry
+	for rows.Next() {
+		var entry InflightEntry
+		if err := rows.Scan(&entry.Key, &entry.VAABytes, &entry.StartedAt, &entry.CorrelationID); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ClaimLease renews or takes over the lease for holder. Expiry is judged by the database
+// clock, so instances don't depend on their own clocks agreeing.
+func (s *PostgresStore) ClaimLease(holder string, ttl time.Duration) (Lease, error) {
+	var lease Lease
+	err := s.db.QueryRow(`INSERT INTO relayer_lease (id, holder, renewed_at) VALUES (1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET holder = EXCLUDED.holder, renewed_at = EXCLUDED.renewed_at
+		WHERE relayer_lease.holder = EXCLUDED.holder
+			OR relayer_lease.renewed_at < NOW() - $2 * INTERVAL '1 millisecond'
+		RETURNING holder, renewed_at`, holder, ttl.Milliseconds()).Scan(&lease.Holder, &lease.RenewedAt)
+	if err == sql.ErrNoRows {
+		// Held by a live instance; the conditional update returned nothing
+		err = s.db.QueryRow(`SELECT holder, renewed_at FROM relayer_lease WHERE id = 1`).Scan(&lease.Holder, &lease.RenewedAt)
+	}
+	return lease, err
+}
+
+// AddSafeCost adds a confirmed relay to the Safe's daily gas totals on its chain
+func (s *PostgresStore) AddSafeCost(cost SafeCost) error {
+	_, err := s.db.Exec(`INSERT INTO relayer_safe_costs (safe, chain_id, day, relays, gas_used, cost_wei)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (safe, chain_id, day) DO UPDATE
+		SET relays = relayer_safe_costs.relays + EXCLUDED.relays,
+			gas_used = relayer_safe_costs.gas_used + EXCLUDED.gas_used,
+			cost_wei = relayer_safe_costs.cost_wei + EXCLUDED.cost_wei`,
+		cost.Safe.Hex(), int64(cost.ChainID), safeCostDay(cost.Day).Format(time.DateOnly),
+		int64(cost.Relays), int64(cost.GasUsed), cost.CostWei.String())
+	return err
+}
+
+// SafeCosts returns daily totals for one Safe, or all Safes when safe is nil
+func (s *PostgresStore) SafeCosts(safe *common.Address, from, to time.Time) ([]SafeCost, error) {
+	var safeFilter sql.NullString
+	if safe != nil {
+		safeFilter = sql.NullString{String: safe.Hex(), Valid: true}
+	}
+	rows, err := s.db.Query(`SELECT safe, chain_id, day, relays, gas_used, cost_wei::TEXT FROM relayer_safe_costs
+		WHERE ($1::TEXT IS NULL OR safe = $1) AND day BETWEEN $2 AND $3
+		ORDER BY day, safe, chain_id`,
+		safeFilter, safeCostDay(from).Format(time.DateOnly), safeCostDay(to).Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var costs []SafeCost
+	for rows.Next() {
+		var safeHex, costWei string
+		var chainID, relays, gasUsed int64
+		var cost SafeCost
+		if err := rows.Scan(&safeHex, &chainID, &cost.Day, &relays, &gasUsed, &costWei); err != nil {
+			return nil, err
+		}
+		cost.Safe = common.HexToAddress(safeHex)
+		cost.ChainID = uint64(chainID)
+		cost.Day = cost.Day.UTC()
+		cost.Relays = uint64(relays)
+		cost.GasUsed = uint64(gasUsed)
+		var ok bool
+		if cost.CostWei, ok = new(big.Int).SetString(costWei, 10); !ok {
+			return nil, fmt.Errorf("invalid cost %q for Safe %s", costWei, safeHex)
+		}
+		costs = append(costs, cost)
+	}
+	return costs, rows.Err()
+}
+
+// AddRelayRecord stores the gas and fee of a confirmed relay transaction
+func (s *PostgresStore) AddRelayRecord(record RelayRecord) error {
+	var receipt sql.NullString
+	if record.Receipt != nil {
+		encoded, err := json.Marshal(record.Receipt)
+		if err != nil {
+			return err
+		}
+		receipt = sql.NullString{String: string(encoded), Valid: true}
+	}
+	_, err := s.db.Exec(`INSERT INTO relayer_relays (tx_hash, vaa_hash, correlation_id, tenant, function, safe, chain_id,
+			block, gas_used, effective_gas_price, cost_wei, confirmed_at, vaa_bytes, receipt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (tx_hash) DO NOTHING`,
+		record.TxHash.Hex(), record.VAAHash, record.CorrelationID, record.Tenant, record.Function, record.Safe.Hex(),
+		int64(record.ChainID), int64(record.Block), int64(record.GasUsed),
+		record.EffectiveGasPrice.String(), record.CostWei.String(), record.ConfirmedAt, record.VAA, receipt)
+	return err
+}
+
+// RelayRecords returns the transactions confirmed between from and to, oldest first
+func (s *PostgresStore) RelayRecords(safe *common.Address, from, to time.Time) ([]RelayRecord, error) {
+	var safeFilter sql.NullString
+	if safe != nil {
+		safeFilter = sql.NullString{String: safe.Hex(), Valid: true}
+	}
+	rows, err := s.db.Query(`SELECT tx_hash, vaa_hash, correlation_id, tenant, function, safe, chain_id, block, gas_used,
+			effective_gas_price::TEXT, cost_wei::TEXT, confirmed_at, vaa_bytes, receipt
+		FROM relayer_relays
+		WHERE ($1::TEXT IS NULL OR safe = $1) AND confirmed_at BETWEEN $2 AND $3
+		ORDER BY confirmed_at`, safeFilter, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []RelayRecord
+	for rows.Next() {
+		var txHash, safeHex, gasPrice, costWei string
+		var chainID, block, gasUsed int64
+		var receipt sql.NullString
+		var record RelayRecord
+		if err := rows.Scan(&txHash, &record.VAAHash, &record.CorrelationID, &record.Tenant, &record.Function, &safeHex,
+			&chainID, &block, &gasUsed, &gasPrice, &costWei, &record.ConfirmedAt, &record.VAA, &receipt); err != nil {
+			return nil, err
+		}
+		if receipt.Valid {
+			record.Receipt = new(RelayReceipt)
+			if err := json.Unmarshal([]byte(receipt.String), record.Receipt); err != nil {
+				return nil, fmt.Errorf("corrupt receipt for transaction %s: %v", txHash, err)
+			}
+		}
+		record.TxHash = common.HexToHash(txHash)
+		record.Safe = common.HexToAddress(safeHex)
+		record.ChainID = uint64(chainID)
+		record.Block = uint64(block)
+		record.GasUsed = uint64(gasUsed)
+		var ok bool
+		if record.EffectiveGasPrice, ok = new(big.Int).SetString(gasPrice, 10); !ok {
+			return nil, fmt.Errorf("invalid gas price %q for transaction %s", gasPrice, txHash)
+		}
+		if record.CostWei, ok = new(big.Int).SetString(costWei, 10); !ok {
+			return nil, fmt.Errorf("invalid cost %q for transaction %s", costWei, txHash)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// priorityKey marks a context whose submission is on the priority lane
+type priorityKey struct{}
+
+// withPriority marks ctx so the submission made under it preempts routine ones for a signer
+func withPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// isPriority reports whether ctx was marked with withPriority
+func isPriority(ctx context.Context) bool {
+	priority, _ := ctx.Value(priorityKey{}).(bool)
+	return priority
+}
+
+// parsePayloadVersions parses a list of payload versions, e.g. "0,1"
+func parsePayloadVersions(entries []string) (map[uint8]bool, error) {
+	result := make(map[uint8]bool, len(entries))
+	for _, entry := range entries {
+		version, err := strconv.ParseUint(strings.TrimSpace(entry), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload version %q: %v", entry, err)
+		}
+		result[uint8(version)] = true
+	}
+	return result, nil
+}
+
+// isPriorityVAA reports whether the VAA belongs on the priority lane: its payload version is
+// listed in PRIORITY_PAYLOAD_VERSIONS or its emitter in PRIORITY_EMITTERS
+func (r *Relayer) isPriorityVAA(vaaData *VAAData) bool {
+	if vaaData.Payload != nil && r.config.PriorityPayloadVersions[vaaData.Payload.Version] {
+		return true
+	}
+	if len(r.config.PriorityEmitters) == 0 {
+		return false
+	}
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(vaaData.EmitterHex)
+	for _, emitter := range r.config.PriorityEmitters {
+		if normalizedEmitter == strings.ToLower(strings.TrimLeft(strings.TrimPrefix(emitter, "0x"), "0")) ||
+			decodedEmitter == emitter {
+			return true
+		}
+	}
+	return false
+}
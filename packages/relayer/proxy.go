@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// dialFunc dials a TCP connection
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// proxyFunc picks the proxy for a request, or nil to connect directly
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+// outboundProxy returns the proxy used for every outbound connection: OUTBOUND_PROXY when set,
+// otherwise HTTP_PROXY/HTTPS_PROXY from the environment. NO_PROXY is honored in both cases.
+func outboundProxy(proxyURL string) (proxyFunc, error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid OUTBOUND_PROXY %q", proxyURL)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported OUTBOUND_PROXY scheme %q (use http, https, socks5 or socks5h)", parsed.Scheme)
+	}
+
+	lookup := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    httpproxy.FromEnvironment().NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return lookup(req.URL)
+	}, nil
+}
+
+// proxyDialer returns a dialer for raw connections to host:port addresses reached with the
+// given scheme, tunnelled through the proxy picked for them (HTTP CONNECT or SOCKS5) and
+// dialed directly otherwise. It serves the gRPC and WebSocket connections, which do not go
+// through an http.Transport.
+func proxyDialer(pick proxyFunc, scheme string, dial dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		proxyURL, err := pick(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick proxy for %s: %v", addr, err)
+		}
+		if proxyURL == nil {
+			return dial(ctx, network, addr)
+		}
+
+		switch proxyURL.Scheme {
+		case "socks5", "socks5h":
+			socks, err := proxy.FromURL(proxyURL, dial)
+			if err != nil {
+				return nil, err
+			}
+			return socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+		default:
+			return dialConnect(ctx, dial, proxyURL, addr)
+		}
+	}
+}
+
+// Dial and DialContext let a dialFunc forward SOCKS5 connections
+func (d dialFunc) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+func (d dialFunc) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
+
+// dialConnect opens a tunnel to addr through an HTTP(S) proxy with a CONNECT request
+func dialConnect(ctx context.Context, dial dialFunc, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := dial(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial proxy %s: %v", proxyAddr, err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with proxy %s failed: %v", proxyAddr, err)
+		}
+		conn = tlsConn
+	}
+
+	// The deadline bounds the CONNECT exchange only
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %v", proxyAddr, err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from proxy %s: %v", proxyAddr, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyAddr, addr, resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the bytes the proxy sent right after its CONNECT response first
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+// newMetricsPusher creates a pusher of the metrics served on /metrics to PUSHGATEWAY_URL, or
+// returns nil when it is not set. The group is the job plus an instance label, the host name
+// unless PUSHGATEWAY_INSTANCE is set, so instances don't overwrite each other.
+func newMetricsPusher(config Config) (*push.Pusher, error) {
+	if config.PushgatewayURL == "" {
+		return nil, nil
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	instance := config.PushgatewayInstance
+	if instance == "" {
+		if instance, err = os.Hostname(); err != nil {
+			instance = "relayer"
+		}
+	}
+	pusher := push.New(config.PushgatewayURL, config.PushgatewayJob).
+		Gatherer(prometheus.DefaultGatherer).
+		Grouping("instance", instance).
+		Client(&http.Client{Timeout: 10 * time.Second, Transport: transport})
+	if config.PushgatewayUsername != "" {
+		pusher = pusher.BasicAuth(config.PushgatewayUsername, config.PushgatewayPassword)
+	}
+	return pusher, nil
+}
+
+// runMetricsPush pushes the metrics every PUSHGATEWAY_INTERVAL until ctx is done, then once
+// more so the final values are kept, or deletes the group with PUSHGATEWAY_DELETE_ON_SHUTDOWN
+func (r *Relayer) runMetricsPush(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PushgatewayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if r.config.PushgatewayDeleteOnShutdown {
+				if err := r.metricsPusher.Delete(); err != nil {
+					r.logger.Warn("Failed to delete metrics from Pushgateway", zap.Error(err))
+				}
+				return
+			}
+			r.pushMetrics(context.Background())
+			return
+		case <-ticker.C:
+			r.pushMetrics(ctx)
+		}
+	}
+}
+
+// pushMetrics replaces the instance's metric group on the Pushgateway
+func (r *Relayer) pushMetrics(ctx context.Context) {
+	pushCtx, cancel := context.WithTimeout(ctx, r.config.PushgatewayInterval)
+	defer cancel()
+
+	if err := r.metricsPusher.PushContext(pushCtx); err != nil {
+		r.logger.Warn("Failed to push metrics to Pushgateway", zap.Error(err))
+		metricsPushes.WithLabelValues("failed").Inc()
+		return
+	}
+	metricsPushes.WithLabelValues("ok").Inc()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// safeStateABI covers the Safe getters proven with a query
+const safeStateABI = `[{
+    "inputs": [],
+    "name": "getOwners",
+    "outputs": [{"internalType": "address[]", "name": "", "type": "address[]"}],
+    "stateMutability": "view",
+    "type": "function"
+}, {
+    "inputs": [],
+    "name": "getThreshold",
+    "outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+    "stateMutability": "view",
+    "type": "function"
+}]`
+
+// SafeState is a Safe's owners and threshold as attested by the guardians
+type SafeState struct {
+	Safe        common.Address   `json:"safe"`
+	ChainID     uint16           `json:"chainId"` // Wormhole chain ID
+	BlockNumber uint64           `json:"blockNumber"`
+	BlockHash   common.Hash      `json:"blockHash"`
+	BlockTime   time.Time        `json:"blockTime"`
+	Owners      []common.Address `json:"owners"`
+	Threshold   *big.Int         `json:"threshold"`
+}
+
+// QuerySafeState asks the guardians for the Safe's owners and threshold on the destination and
+// verifies their signatures against the tracked guardian set. The returned response can be
+// handed to the Aztec side as proof of the EVM-side state.
+func (r *Relayer) QuerySafeState(ctx context.Context, dest *Destination, safe common.Address) (*SafeState, *QueryResponse, error) {
+	if r.queryClient == nil {
+		return nil, nil, fmt.Errorf("QUERY_SERVER_URL is not set")
+	}
+	set := r.guardians.Current()
+	if set == nil {
+		return nil, nil, fmt.Errorf("no guardian set to verify the response with; configure a Wormhole core")
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(safeStateABI))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ABI parse error: %v", err)
+	}
+	getOwners, _ := parsedABI.Pack("getOwners")
+	getThreshold, _ := parsedABI.Pack("getThreshold")
+
+	request, err := encodeEthCallQueries(uint32(time.Now().Unix()), []EthCallQuery{{
+		ChainID: dest.WormholeChainID,
+		BlockID: "latest",
+		Calls:   []EthCall{{To: safe, Data: getOwners}, {To: safe, Data: getThreshold}},
+	}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := r.queryClient.Query(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := response.Verify(set); err != nil {
+		return nil, nil, fmt.Errorf("query response failed verification: %v", err)
+	}
+	if len(response.Results) != 1 || len(response.Results[0].Results) != 2 {
+		return nil, nil, fmt.Errorf("query response does not match the request")
+	}
+	result := response.Results[0]
+
+	state := &SafeState{
+		Safe:        safe,
+		ChainID:     result.ChainID,
+		BlockNumber: result.BlockNumber,
+		BlockHash:   result.BlockHash,
+		BlockTime:   result.BlockTime,
+	}
+	owners, err := parsedABI.Unpack("getOwners", result.Results[0])
+	if err != nil || len(owners) != 1 {
+		return nil, nil, fmt.Errorf("failed to decode getOwners result: %v", err)
+	}
+	threshold, err := parsedABI.Unpack("getThreshold", result.Results[1])
+	if err != nil || len(threshold) != 1 {
+		return nil, nil, fmt.Errorf("failed to decode getThreshold result: %v", err)
+	}
+	var ok bool
+	if state.Owners, ok = owners[0].([]common.Address); !ok {
+		return nil, nil, fmt.Errorf("unexpected getOwners result %T", owners[0])
+	}
+	if state.Threshold, ok = threshold[0].(*big.Int); !ok {
+		return nil, nil, fmt.Errorf("unexpected getThreshold result %T", threshold[0])
+	}
+
+	r.logger.Info("Verified Safe state query",
+		zap.String("safe", safe.Hex()),
+		zap.String("destination", dest.Name),
+		zap.Uint64("block", state.BlockNumber),
+		zap.Int("owners", len(state.Owners)),
+		zap.String("threshold", state.Threshold.String()))
+	return state, response, nil
+}
+
+// runQuerySafe proves a Safe's owners and threshold through Wormhole Queries and prints the
+// state with the signed response
+func runQuerySafe(ctx context.Context, config Config, args []string) int {
+	if len(args) < 1 || len(args) > 2 || !common.IsHexAddress(args[0]) {
+		fmt.Fprintln(os.Stderr, "Usage: relayer query-safe <safe-address> [destination]")
+		return 2
+	}
+	safe := common.HexToAddress(args[0])
+
+	config.StateStore = StateStoreMemory
+	r, err := NewRelayer(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer r.Close()
+	if err := r.resolveDestinationChainIDs(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	dest := r.destinations[0]
+	if len(args) == 2 {
+		dest = nil
+		for _, candidate := range r.destinations {
+			if candidate.Name == args[1] {
+				dest = candidate
+			}
+		}
+		if dest == nil {
+			fmt.Fprintf(os.Stderr, "unknown destination %q\n", args[1])
+			return 2
+		}
+	}
+
+	core := r.guardianCoreDestination()
+	if core == nil {
+		fmt.Fprintln(os.Stderr, "no destination has a Wormhole core configured to read the guardian set from")
+		return 1
+	}
+	if err := r.refreshGuardianSet(ctx, core, r.logger); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read guardian set: %v\n", err)
+		return 1
+	}
+
+	state, response, err := r.QuerySafeState(ctx, dest, safe)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	signatures := make([]string, len(response.Signatures))
+	for i, sig := range response.Signatures {
+		signatures[i] = hex.EncodeToString(append(sig.Signature[:], sig.Index))
+	}
+	out, _ := json.MarshalIndent(map[string]any{
+		"state":      state,
+		"response":   hex.EncodeToString(response.Bytes),
+		"signatures": signatures,
+	}, "", "  ")
+	fmt.Println(string(out))
+	return 0
+}
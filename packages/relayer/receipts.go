@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+// RelayReceipt is the relayer's signed statement that it delivered a VAA to a destination. It
+// lives in pkg/sdk, which users and auditors verify receipts with.
+type RelayReceipt = sdk.RelayReceipt
+
+// receiptSigner signs relay receipts with RECEIPT_SIGNING_KEY or the first local signing key
+type receiptSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// newReceiptSigner creates the receipt signer, or returns nil when receipts are disabled or
+// no in-process key is configured to sign them with
+func newReceiptSigner(config Config) (*receiptSigner, error) {
+	if !config.RelayReceipts {
+		return nil, nil
+	}
+	keyHex := config.ReceiptSigningKey
+	if keyHex == "" {
+		keys := config.signingKeys()
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		keyHex = keys[0]
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(keyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt signing key: %v", err)
+	}
+	return &receiptSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+// sign fills in the receipt's signer and signature
+func (s *receiptSigner) sign(rr *RelayReceipt) error {
+	rr.Signer = s.address
+	sig, err := crypto.Sign(rr.Digest().Bytes(), s.key)
+	if err != nil {
+		return err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	rr.Signature = sig
+	return nil
+}
+
+// signRelayReceipt signs the receipt of a relay record, or returns nil when receipts are not
+// signed
+func (r *Relayer) signRelayReceipt(vaaData *VAAData, record RelayRecord) *RelayReceipt {
+	if r.receiptSigner == nil {
+		return nil
+	}
+	receipt := &RelayReceipt{
+		VAADigest: vaaData.VAA.SigningDigest(),
+		ChainID:   record.ChainID,
+		TxHash:    record.TxHash,
+		Safe:      record.Safe,
+		Timestamp: uint64(record.ConfirmedAt.Unix()),
+	}
+	if err := r.receiptSigner.sign(receipt); err != nil {
+		withCorrelation(r.logger, vaaData.CorrelationID).Error("Failed to sign relay receipt", zap.Error(err))
+		return nil
+	}
+	return receipt
+}
+
+// runVerifyReceipt checks the signature of a relay receipt read as JSON from a file or stdin
+func runVerifyReceipt(ctx context.Context, config Config, args []string) int {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "Usage: relayer verify-receipt <receipt-file|-> [expected-signer]")
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer f.Close()
+		in = f
+	}
+	var receipt RelayReceipt
+	if err := json.NewDecoder(io.LimitReader(in, 1<<20)).Decode(&receipt); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid receipt: %v\n", err)
+		return 2
+	}
+
+	if err := receipt.Verify(); err != nil {
+		fmt.Fprintf(os.Stderr, "receipt is not valid: %v\n", err)
+		return 1
+	}
+	if len(args) == 2 {
+		if !common.IsHexAddress(args[1]) {
+			fmt.Fprintf(os.Stderr, "invalid signer address %q\n", args[1])
+			return 2
+		}
+		if expected := common.HexToAddress(args[1]); receipt.Signer != expected {
+			fmt.Fprintf(os.Stderr, "receipt is signed by %s, not %s\n", receipt.Signer.Hex(), expected.Hex())
+			return 1
+		}
+	}
+	fmt.Printf("Valid receipt signed by %s: VAA %s relayed to chain %d in %s\n",
+		receipt.Signer.Hex(), receipt.VAADigest.Hex(), receipt.ChainID, receipt.TxHash.Hex())
+	return 0
+}
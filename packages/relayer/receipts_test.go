@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestReceiptSigner(t *testing.T) {
+	receiptKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relayKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiptKeyHex := "0x" + common.Bytes2Hex(crypto.FromECDSA(receiptKey))
+	relayKeyHex := common.Bytes2Hex(crypto.FromECDSA(relayKey))
+
+	tests := []struct {
+		name       string
+		config     Config
+		wantSigner common.Address // Zero when no receipts are signed
+	}{
+		{name: "disabled by default", config: Config{ReceiptSigningKey: receiptKeyHex, PrivateKey: relayKeyHex}},
+		{name: "receipt signing key", config: Config{RelayReceipts: true, ReceiptSigningKey: receiptKeyHex, PrivateKey: relayKeyHex}, wantSigner: crypto.PubkeyToAddress(receiptKey.PublicKey)},
+		{name: "first relay key", config: Config{RelayReceipts: true, PrivateKeys: []string{relayKeyHex, receiptKeyHex}}, wantSigner: crypto.PubkeyToAddress(relayKey.PublicKey)},
+		{name: "no in-process key", config: Config{RelayReceipts: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newReceiptSigner(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantSigner == (common.Address{}) {
+				if signer != nil {
+					t.Fatalf("got signer %s, want none", signer.address.Hex())
+				}
+				return
+			}
+			if signer == nil {
+				t.Fatal("got no signer")
+			}
+
+			receipt := &RelayReceipt{
+				VAADigest: common.HexToHash("0x01"),
+				ChainID:   11155111,
+				TxHash:    common.HexToHash("0x02"),
+				Safe:      common.HexToAddress("0x00a329c0648769A73afAc7F9381E08FB43dBEA72"),
+				Timestamp: 1_700_000_000,
+			}
+			if err := signer.sign(receipt); err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Signer != tt.wantSigner {
+				t.Fatalf("receipt signer %s, want %s", receipt.Signer.Hex(), tt.wantSigner.Hex())
+			}
+
+			// Recover the signer the way a wallet library would, from the 27/28 V form
+			sig := append([]byte{}, receipt.Signature...)
+			if v := sig[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+				t.Fatalf("signature V is %d, want 27 or 28", v)
+			}
+			sig[crypto.RecoveryIDOffset] -= 27
+			pub, err := crypto.SigToPub(receipt.Digest().Bytes(), sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recovered := crypto.PubkeyToAddress(*pub); recovered != tt.wantSigner {
+				t.Fatalf("recovered %s, want %s", recovered.Hex(), tt.wantSigner.Hex())
+			}
+			if err := receipt.Verify(); err != nil {
+				t.Fatal(err)
+			}
+
+			receipt.ChainID++
+			if err := receipt.Verify(); err == nil {
+				t.Fatal("receipt for another chain verified")
+			}
+		})
+	}
+}
+
+func TestReceiptSignerInvalidKey(t *testing.T) {
+	if _, err := newReceiptSigner(Config{RelayReceipts: true, ReceiptSigningKey: "0xnotakey"}); err == nil {
+		t.Fatal("invalid receipt signing key accepted")
+	}
+}
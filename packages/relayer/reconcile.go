@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// Discrepancies reconciliation flags between the relay records and the chain
+const (
+	DiscrepancyTxMissing   = "tx_missing"   // The recorded transaction has no receipt (reorged out or dropped)
+	DiscrepancyTxFailed    = "tx_failed"    // The recorded transaction's receipt now shows a revert
+	DiscrepancyNotConsumed = "not_consumed" // Recorded as relayed, but the module hasn't consumed the VAA
+	DiscrepancyNotRecorded = "not_recorded" // The module applied a VAA no relay record covers
+)
+
+// reconcileSettle keeps the newest records and events out of a run, so a relay confirmed while
+// it runs isn't flagged before its record is written
+const reconcileSettle = time.Minute
+
+var (
+	consumedVaasSelector = crypto.Keccak256([]byte("consumedVaas(bytes32)"))[:4]
+	recoveryAppliedTopic = crypto.Keccak256Hash([]byte("RecoveryApplied(address,uint256,bytes32)"))
+)
+
+// Discrepancy is a relay record the chain disagrees with, or a consumed VAA without a record
+type Discrepancy struct {
+	Kind          string         `json:"kind"`
+	Tenant        string         `json:"tenant"`
+	ChainID       uint64         `json:"chainId"`
+	TxHash        common.Hash    `json:"txHash"`
+	ConsumedKey   common.Hash    `json:"consumedKey"` // keccak256 of the VAA, the module's consumedVaas key
+	Safe          common.Address `json:"safe"`
+	CorrelationID string         `json:"correlationId,omitempty"`
+	Redriven      bool           `json:"redriven"` // Queued for another relay attempt
+}
+
+// ReconcileReport is the outcome of one reconciliation run
+type ReconcileReport struct {
+	StartedAt     time.Time     `json:"startedAt"`
+	FinishedAt    time.Time     `json:"finishedAt"`
+	From          time.Time     `json:"from"` // Oldest record and event considered
+	Records       int           `json:"recordsChecked"`
+	Events        int           `json:"eventsChecked"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Errors        []string      `json:"errors,omitempty"` // Checks that could not be made
+}
+
+// consumedRef identifies a VAA consumed by one tenant's module
+type consumedRef struct {
+	tenant string
+	key    common.Hash
+}
+
+// runReconciliation reconciles every RECONCILE_INTERVAL until ctx is done. A standby leaves it
+// to the active instance.
+func (r *Relayer) runReconciliation(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.isActive() {
+				continue
+			}
+			r.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile cross-checks the relay records of the last RECONCILE_WINDOW against the chain: each
+// recorded transaction must still be mined successfully and its VAA consumed by the module,
+// and each RecoveryApplied event of a tenant's module must match a record. Discrepancies are
+// logged and counted, and VAAs recorded but not consumed are re-driven when RECONCILE_REDRIVE
+// is set.
+func (r *Relayer) reconcile(ctx context.Context) *ReconcileReport {
+	r.reconcileRun.Lock()
+	defer r.reconcileRun.Unlock()
+
+	now := time.Now().UTC()
+	report := &ReconcileReport{StartedAt: now, From: now.Add(-r.config.ReconcileWindow)}
+	to := now.Add(-reconcileSettle)
+
+	// Heads are read first, so every event scanned below was mined before the records are read
+	heads := make(map[uint64]uint64, len(r.destinations))
+	for _, dest := range r.destinations {
+		head, err := dest.client.client.BlockNumber(ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("destination %s: block number: %v", dest.Name, err))
+			continue
+		}
+		heads[dest.ChainID] = head
+	}
+
+	records, err := r.store.RelayRecords(nil, report.From, to)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("relay records: %v", err))
+		return r.finishReconcile(report)
+	}
+	report.Records = len(records)
+
+	recorded := make(map[consumedRef]bool)
+	checked := make(map[consumedRef]bool)
+	noConsumedView := make(map[string]bool) // Tenants whose module can't answer consumedVaas
+	for _, record := range records {
+		dest, ok := r.destinationForChain(record.ChainID)
+		tenant := r.tenantNamed(record.Tenant)
+		if !ok || tenant == nil || tenant.dest != dest {
+			continue
+		}
+		flag := func(kind string, key common.Hash) *Discrepancy {
+			return &Discrepancy{Kind: kind, Tenant: tenant.Name, ChainID: record.ChainID, TxHash: record.TxHash,
+				ConsumedKey: key, Safe: record.Safe, CorrelationID: record.CorrelationID}
+		}
+
+		var key common.Hash
+		if record.VAA != nil {
+			key = crypto.Keccak256Hash(record.VAA)
+			recorded[consumedRef{tenant.Name, key}] = true
+		}
+
+		receipt, err := dest.client.client.TransactionReceipt(ctx, record.TxHash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			r.addDiscrepancy(report, flag(DiscrepancyTxMissing, key))
+		case err != nil:
+			report.Errors = append(report.Errors, fmt.Sprintf("receipt of %s: %v", record.TxHash.Hex(), err))
+		case receipt.Status != types.ReceiptStatusSuccessful:
+			r.addDiscrepancy(report, flag(DiscrepancyTxFailed, key))
+		}
+
+		// Records written before VAAs were kept can only be checked by receipt
+		ref := consumedRef{tenant.Name, key}
+		if record.VAA == nil || checked[ref] || noConsumedView[tenant.Name] {
+			continue
+		}
+		checked[ref] = true
+		consumed, err := r.isVAAConsumed(ctx, tenant, key)
+		if err != nil {
+			noConsumedView[tenant.Name] = true
+			report.Errors = append(report.Errors, fmt.Sprintf("tenant %s: consumedVaas: %v", tenant.Name, err))
+			continue
+		}
+		if !consumed {
+			discrepancy := flag(DiscrepancyNotConsumed, key)
+			if r.config.ReconcileRedrive {
+				discrepancy.Redriven = r.redriveVAA(record)
+			}
+			r.addDiscrepancy(report, discrepancy)
+		}
+	}
+
+	for _, tenant := range r.tenants {
+		head, ok := heads[tenant.dest.ChainID]
+		if !ok {
+			continue
+		}
+		if err := r.reconcileEvents(ctx, report, tenant, head, to, recorded); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("tenant %s: RecoveryApplied events: %v", tenant.Name, err))
+		}
+	}
+
+	return r.finishReconcile(report)
+}
+
+// reconcileEvents flags the tenant module's RecoveryApplied events between the report's start
+// and to whose VAA no relay record covers
+func (r *Relayer) reconcileEvents(ctx context.Context, report *ReconcileReport, tenant *Tenant, head uint64, to time.Time, recorded map[consumedRef]bool) error {
+	client := tenant.dest.client
+	from := uint64(0)
+	if lookback := uint64(r.config.ReconcileLookbackBlocks); head > lookback {
+		from = head - lookback
+	}
+	logs, err := client.filterLogsPaged(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{tenant.target},
+		Topics:    [][]common.Hash{{recoveryAppliedTopic}},
+	}, from, head)
+	if err != nil {
+		return err
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	for _, log := range logs {
+		// RecoveryApplied(address indexed safe, uint256 threshold, bytes32 vaaHash)
+		if len(log.Topics) < 2 || len(log.Data) < 64 {
+			continue
+		}
+		blockTime, ok := blockTimes[log.BlockNumber]
+		if !ok {
+			header, err := client.client.HeaderByNumber(ctx, new(big.Int).SetUint64(log.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("header of block %d: %v", log.BlockNumber, err)
+			}
+			blockTime = time.Unix(int64(header.Time), 0).UTC()
+			blockTimes[log.BlockNumber] = blockTime
+		}
+		if blockTime.Before(report.From) || blockTime.After(to) {
+			continue
+		}
+
+		report.Events++
+		key := common.BytesToHash(log.Data[32:64])
+		if !recorded[consumedRef{tenant.Name, key}] {
+			r.addDiscrepancy(report, &Discrepancy{Kind: DiscrepancyNotRecorded, Tenant: tenant.Name, ChainID: tenant.dest.ChainID,
+				TxHash: log.TxHash, ConsumedKey: key, Safe: common.BytesToAddress(log.Topics[1].Bytes())})
+		}
+	}
+	return nil
+}
+
+// isVAAConsumed reads the module's consumedVaas entry for the VAA with the given keccak256
+func (r *Relayer) isVAAConsumed(ctx context.Context, tenant *Tenant, key common.Hash) (bool, error) {
+	data := append(append([]byte{}, consumedVaasSelector...), key.Bytes()...)
+	result, err := tenant.dest.client.client.CallContract(ctx, ethereum.CallMsg{To: &tenant.target, Data: data}, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(result) != 32 {
+		return false, fmt.Errorf("returned %d bytes, want 32", len(result))
+	}
+	return result[31] == 1, nil
+}
+
+// redriveVAA queues a VAA recorded as relayed but not consumed for another attempt, forgetting
+// that it was processed. It returns false if the VAA is being processed right now or a later
+// message for its Safe has executed.
+func (r *Relayer) redriveVAA(record RelayRecord) bool {
+	vaaData, err := parseVAAData(record.VAA)
+	if err != nil {
+		return false
+	}
+	key := r.dedupeKey(record.VAA)
+
+	r.dedupeMu.Lock()
+	defer r.dedupeMu.Unlock()
+	if _, inflight := r.inflightVAAs[key]; inflight {
+		return false
+	}
+	// The Safe's queue counts the sequence as executed; it wasn't. Once a later message for
+	// the Safe executed, relaying this one again would be refused as superseded.
+	if !r.safeOrder.reopen(safeOrderKey(record.ChainID, record.Safe, vaaData.EmitterHex), vaaData.Sequence) {
+		return false
+	}
+	r.processedVAAs.forget(key)
+	// Backdating the stored entry keeps a restart from loading it again; the next prune drops it
+	if err := r.store.MarkProcessed(key, time.Time{}); err != nil {
+		r.logger.Warn("Failed to forget processed VAA in state store", zap.String("key", key), zap.Error(err))
+	}
+
+	correlationID := record.CorrelationID
+	if correlationID == "" {
+		correlationID = newCorrelationID()
+	}
+	r.scheduleRetry(key, correlationID, record.VAA,
+		fmt.Errorf("reconciliation: recorded as relayed in %s but not consumed", record.TxHash.Hex()))
+	reconcileRedriven.Inc()
+	return true
+}
+
+// tenantNamed returns the tenant with the given name, or nil
+func (r *Relayer) tenantNamed(name string) *Tenant {
+	for _, tenant := range r.tenants {
+		if tenant.Name == name {
+			return tenant
+		}
+	}
+	return nil
+}
+
+// addDiscrepancy logs, counts and reports a discrepancy
+func (r *Relayer) addDiscrepancy(report *ReconcileReport, d *Discrepancy) {
+	withCorrelation(r.logger, d.CorrelationID).Warn("Reconciliation discrepancy",
+		zap.String("kind", d.Kind),
+		zap.String("tenant", d.Tenant),
+		zap.Uint64("chainId", d.ChainID),
+		zap.String("txHash", d.TxHash.Hex()),
+		zap.String("consumedKey", d.ConsumedKey.Hex()),
+		zap.String("safe", d.Safe.Hex()),
+		zap.Bool("redriven", d.Redriven))
+	reconcileDiscrepancies.WithLabelValues(d.Kind).Inc()
+	report.Discrepancies = append(report.Discrepancies, *d)
+}
+
+// finishReconcile records the report as the latest run
+func (r *Relayer) finishReconcile(report *ReconcileReport) *ReconcileReport {
+	report.FinishedAt = time.Now().UTC()
+	if report.Discrepancies == nil {
+		report.Discrepancies = []Discrepancy{}
+	}
+	reconcileLastRun.SetToCurrentTime()
+
+	r.logger.Info("Reconciliation finished",
+		zap.Int("records", report.Records),
+		zap.Int("events", report.Events),
+		zap.Int("discrepancies", len(report.Discrepancies)),
+		zap.Int("errors", len(report.Errors)),
+		zap.Duration("took", report.FinishedAt.Sub(report.StartedAt)))
+
+	r.reconcileMu.Lock()
+	r.lastReconcile = report
+	r.reconcileMu.Unlock()
+	return report
+}
+
+// LastReconcile returns the latest reconciliation report, or nil before the first run
+func (r *Relayer) LastReconcile() *ReconcileReport {
+	r.reconcileMu.Lock()
+	defer r.reconcileMu.Unlock()
+	return r.lastReconcile
+}
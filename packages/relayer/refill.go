@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// Gas limit of a plain ETH transfer
+const transferGasLimit = 21000
+
+// errRefillLimited is returned when a top-up would exceed the daily limit
+var errRefillLimited = errors.New("daily refill limit reached")
+
+// Refiller tops relayer signers up from a treasury account whenever their balance on a
+// destination falls below a threshold, within a daily limit per destination
+type Refiller struct {
+	treasury   Signer
+	threshold  *big.Int // Balance below which a signer is topped up
+	amount     *big.Int // Amount sent per top-up
+	dailyLimit *big.Int // Most sent per destination per UTC day (nil for no limit)
+	interval   time.Duration
+	logger     *zap.Logger
+
+	mu    sync.Mutex
+	day   time.Time
+	spent map[string]*big.Int // destination -> amount sent today
+
+	audit *AuditLog // Records each signed top-up, if auditing is enabled
+}
+
+// newRefiller creates a refiller from the TREASURY_* and REFILL_* settings, or returns nil
+// when no treasury is configured
+func newRefiller(config Config, signers []Signer) (*Refiller, error) {
+	if config.TreasuryPrivateKey == "" {
+		return nil, nil
+	}
+
+	accounts, err := localSigners([]string{config.TreasuryPrivateKey})
+	if err != nil {
+		return nil, fmt.Errorf("treasury key: %v", err)
+	}
+	treasury := accounts[0]
+	for _, signer := range signers {
+		if signer.Address() == treasury.Address() {
+			return nil, fmt.Errorf("treasury %s is also a relayer signer", treasury.Address().Hex())
+		}
+	}
+
+	threshold, err := parseEther(config.RefillThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("REFILL_THRESHOLD: %v", err)
+	}
+	amount, err := parseEther(config.RefillAmount)
+	if err != nil {
+		return nil, fmt.Errorf("REFILL_AMOUNT: %v", err)
+	}
+	if amount.Sign() == 0 {
+		return nil, fmt.Errorf("REFILL_AMOUNT must be positive")
+	}
+	dailyLimit, err := parseEther(config.RefillDailyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("REFILL_DAILY_LIMIT: %v", err)
+	}
+	if dailyLimit.Sign() == 0 {
+		dailyLimit = nil
+	}
+
+	return &Refiller{
+		treasury:   treasury,
+		threshold:  threshold,
+		amount:     amount,
+		dailyLimit: dailyLimit,
+		interval:   config.RefillInterval,
+		logger:     logger.With(zap.String("component", "Refiller"), zap.String("treasury", treasury.Address().Hex())),
+		spent:      make(map[string]*big.Int),
+	}, nil
+}
+
+// run checks every destination's signer balances each interval until ctx is done
+func (f *Refiller) run(ctx context.Context, destinations []*Destination) {
+	f.logger.Info("Treasury refill enabled",
+		zap.String("threshold", formatWei(f.threshold)),
+		zap.String("amount", formatWei(f.amount)))
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, dest := range destinations {
+			f.checkDestination(ctx, dest)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDestination tops up each of the destination's signers that is below the threshold
+func (f *Refiller) checkDestination(ctx context.Context, dest *Destination) {
+	for _, address := range dest.client.GetAddresses() {
+		balance, err := dest.client.client.BalanceAt(ctx, address, nil)
+		if err != nil {
+			f.logger.Warn("Failed to read signer balance",
+				zap.String("destination", dest.Name),
+				zap.String("signer", address.Hex()),
+				zap.Error(err))
+			continue
+		}
+		signerBalance.WithLabelValues(dest.Name, address.Hex()).Set(weiToFloat(balance))
+		if balance.Cmp(f.threshold) >= 0 {
+			continue
+		}
+
+		if err := f.refill(ctx, dest, address, balance); err != nil {
+			result := "failed"
+			if errors.Is(err, errRefillLimited) {
+				result = "limited"
+			}
+			refills.WithLabelValues(dest.Name, result).Inc()
+			f.logger.Error("Signer balance low and treasury refill failed",
+				zap.String("destination", dest.Name),
+				zap.String("signer", address.Hex()),
+				zap.String("balance", formatWei(balance)),
+				zap.Error(err))
+		}
+	}
+}
+
+// refill sends one top-up from the treasury to address and waits for it to be mined
+func (f *Refiller) refill(ctx context.Context, dest *Destination, address common.Address, balance *big.Int) error {
+	if !f.reserve(dest.Name) {
+		// The limit may have been removed through the admin API since
+		limit := f.limit()
+		if limit == nil {
+			return errRefillLimited
+		}
+		return fmt.Errorf("%w (%s ETH)", errRefillLimited, formatWei(limit))
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			f.unreserve(dest.Name)
+		}
+	}()
+
+	client := dest.client.client
+	treasuryBalance, err := client.BalanceAt(ctx, f.treasury.Address(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read treasury balance: %v", err)
+	}
+	if treasuryBalance.Cmp(f.amount) <= 0 {
+		return fmt.Errorf("treasury balance %s ETH cannot cover a top-up", formatWei(treasuryBalance))
+	}
+
+	strategy, err := lookupFeeStrategy(dest.feeStrategyName())
+	if err != nil {
+		return err
+	}
+	params, err := dest.client.fetchTxParams(ctx, f.treasury.Address(), strategy)
+	if err != nil {
+		return fmt.Errorf("failed to get treasury nonce and fees: %v", err)
+	}
+
+	tx := newTransaction(params, address, f.amount, transferGasLimit, nil, 0)
+	signedTx, err := f.treasury.SignTx(ctx, tx, params.chain.signer, "treasury refill")
+	if err != nil {
+		return fmt.Errorf("failed to sign refill: %v", err)
+	}
+	f.audit.recordTxSigned(ctx, params.chain.chainID.Uint64(), f.treasury.Address().Hex(), address.Hex(), signedTx.Hash().Hex(), params.nonce, "treasury refill")
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		return fmt.Errorf("failed to send refill: %v", err)
+	}
+	sent = true
+
+	f.logger.Info("Refilling signer from treasury",
+		zap.String("destination", dest.Name),
+		zap.String("signer", address.Hex()),
+		zap.String("balance", formatWei(balance)),
+		zap.String("amount", formatWei(f.amount)),
+		zap.String("txHash", signedTx.Hash().Hex()))
+
+	receiptCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	receipt, err := dest.client.WaitForReceipt(receiptCtx, signedTx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("refill %s reverted", signedTx.Hash().Hex())
+	}
+	refills.WithLabelValues(dest.Name, "sent").Inc()
+	return nil
+}
+
+// reserve counts a top-up against the destination's daily limit, reporting false when it
+// would exceed it
+func (f *Refiller) reserve(destination string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if today := safeCostDay(time.Now()); !today.Equal(f.day) {
+		f.day = today
+		f.spent = make(map[string]*big.Int)
+	}
+	spent, ok := f.spent[destination]
+	if !ok {
+		spent = new(big.Int)
+	}
+	next := new(big.Int).Add(spent, f.amount)
+	if f.dailyLimit != nil && next.Cmp(f.dailyLimit) > 0 {
+		return false
+	}
+	f.spent[destination] = next
+	return true
+}
+
+// limit returns the daily limit per destination (nil for no limit)
+func (f *Refiller) limit() *big.Int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dailyLimit
+}
+
+// setLimit changes the daily limit per destination; top-ups already sent today count toward it
+func (f *Refiller) setLimit(limit *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dailyLimit = limit
+}
+
+// unreserve returns a reserved top-up that was never sent
+func (f *Refiller) unreserve(destination string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if spent, ok := f.spent[destination]; ok {
+		f.spent[destination] = new(big.Int).Sub(spent, f.amount)
+	}
+}
+
+// parseEther parses a decimal ETH amount such as "0.05" into wei
+func parseEther(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 18 {
+		return nil, fmt.Errorf("invalid ETH amount %q: more than 18 decimals", s)
+	}
+	wei, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", 18-len(frac)), 10)
+	if !ok || wei.Sign() < 0 {
+		return nil, fmt.Errorf("invalid ETH amount %q", s)
+	}
+	return wei, nil
+}
+
+// weiToFloat converts wei to ETH for gauges
+func weiToFloat(wei *big.Int) float64 {
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return ether
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// registryViewBatch is the number of per-Safe view calls sent per batch request
+const registryViewBatch = 100
+
+// verifyRegistry checks every Safe in the registry against the module's
+// getAztecRecoveryContract(address) view at block and corrects registrations that differ, such
+// as ones whose events were missed
+func (t *Tenant) verifyRegistry(ctx context.Context, block uint64) error {
+	t.emittersMu.RLock()
+	safes := make([]common.Address, 0, len(t.safeEmitters))
+	for safe := range t.safeEmitters {
+		safes = append(safes, safe)
+	}
+	t.emittersMu.RUnlock()
+	if len(safes) == 0 {
+		return nil
+	}
+
+	corrected := 0
+	for start := 0; start < len(safes); start += registryViewBatch {
+		batch := safes[start:min(start+registryViewBatch, len(safes))]
+		contracts, err := t.readRecoveryContracts(ctx, batch, block)
+		if err != nil {
+			return err
+		}
+		for i, safe := range batch {
+			t.emittersMu.RLock()
+			known := t.safeEmitters[safe]
+			t.emittersMu.RUnlock()
+			switch {
+			case contracts[i] == known:
+			case contracts[i] == "":
+				t.removeEmitter(safe, block, "not in registry view")
+				corrected++
+			default:
+				t.setEmitter(safe, contracts[i], block)
+				corrected++
+			}
+		}
+	}
+	if corrected > 0 {
+		t.logger.Warn("Corrected registry entries that differed from the module",
+			zap.Int("corrected", corrected),
+			zap.Uint64("block", block))
+	}
+	return nil
+}
+
+// readRecoveryContracts reads the Aztec contract each Safe registered through the module's
+// getAztecRecoveryContract(address) view, as hex ("" for none)
+func (t *Tenant) readRecoveryContracts(ctx context.Context, safes []common.Address, block uint64) ([]string, error) {
+	blockArg := hexutil.EncodeUint64(block)
+	results := make([]hexutil.Bytes, len(safes))
+	calls := make([]rpc.BatchElem, len(safes))
+	for i, safe := range safes {
+		calls[i] = rpc.BatchElem{Method: "eth_call", Args: []any{map[string]any{
+			"to":   t.target,
+			"data": hexutil.Bytes(append(append([]byte{}, getAztecRecoveryContractSelector...), common.LeftPadBytes(safe.Bytes(), 32)...)),
+		}, blockArg}, Result: &results[i]}
+	}
+	if err := t.dest.client.batchCall(ctx, calls); err != nil {
+		return nil, err
+	}
+
+	contracts := make([]string, len(safes))
+	for i, call := range calls {
+		if call.Error != nil {
+			return nil, fmt.Errorf("registry view for Safe %s failed: %v", safes[i].Hex(), call.Error)
+		}
+		if len(results[i]) != 32 {
+			return nil, fmt.Errorf("registry view for Safe %s returned %d bytes, want 32", safes[i].Hex(), len(results[i]))
+		}
+		if common.BytesToHash(results[i]) != (common.Hash{}) {
+			contracts[i] = hex.EncodeToString(results[i])
+		}
+	}
+	return contracts, nil
+}
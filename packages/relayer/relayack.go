@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// Relay acknowledgment payload, published through the destination's Wormhole core once a
+// recovery is applied. Fields are big-endian:
+//
+//	[payloadID(1), sourceChain(2), sourceEmitter(32), sourceSequence(8), sourceTxID(32),
+//	 safe(20), candidate(20), verifyTxHash(32)]
+const (
+	relayAckPayloadID   uint8 = 1
+	relayAckPayloadSize       = 1 + 2 + 32 + 8 + 32 + 20 + 20 + 32
+)
+
+// Gas limit of a publishMessage call carrying a relay acknowledgment
+const relayAckGasLimit = 200000
+
+// wormholeCoreABI covers the core contract calls used to publish acknowledgments and track
+// the guardian set
+const wormholeCoreABI = `[{
+    "inputs": [
+        {"internalType": "uint32", "name": "nonce", "type": "uint32"},
+        {"internalType": "bytes", "name": "payload", "type": "bytes"},
+        {"internalType": "uint8", "name": "consistencyLevel", "type": "uint8"}
+    ],
+    "name": "publishMessage",
+    "outputs": [{"internalType": "uint64", "name": "sequence", "type": "uint64"}],
+    "stateMutability": "payable",
+    "type": "function"
+}, {
+    "inputs": [],
+    "name": "messageFee",
+    "outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
+    "stateMutability": "view",
+    "type": "function"
+}, {
+    "inputs": [],
+    "name": "getCurrentGuardianSetIndex",
+    "outputs": [{"internalType": "uint32", "name": "", "type": "uint32"}],
+    "stateMutability": "view",
+    "type": "function"
+}, {
+    "inputs": [{"internalType": "uint32", "name": "index", "type": "uint32"}],
+    "name": "getGuardianSet",
+    "outputs": [{
+        "components": [
+            {"internalType": "address[]", "name": "keys", "type": "address[]"},
+            {"internalType": "uint32", "name": "expirationTime", "type": "uint32"}
+        ],
+        "internalType": "struct Structs.GuardianSet",
+        "name": "",
+        "type": "tuple"
+    }],
+    "stateMutability": "view",
+    "type": "function"
+}]`
+
+// encodeRelayAck builds the acknowledgment payload for a relayed VAA
+func encodeRelayAck(vaaData *VAAData, verifyTxHash common.Hash) []byte {
+	payload := make([]byte, 0, relayAckPayloadSize)
+	payload = append(payload, relayAckPayloadID)
+	payload = binary.BigEndian.AppendUint16(payload, vaaData.ChainID)
+	payload = append(payload, vaaData.VAA.EmitterAddress[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, vaaData.Sequence)
+	payload = append(payload, vaaData.Payload.TxID.Bytes()...)
+	payload = append(payload, vaaData.Payload.Safe.Bytes()...)
+	payload = append(payload, vaaData.Payload.Candidate.Bytes()...)
+	payload = append(payload, verifyTxHash.Bytes()...)
+	return payload
+}
+
+// publishRelayAck publishes a Wormhole message from the destination confirming that the VAA
+// was delivered, so the Aztec side can learn the recovery went through. It does not wait for
+// the message to be mined; a failure is logged and never fails the relay itself.
+func (r *Relayer) publishRelayAck(ctx context.Context, dest *Destination, vaaData *VAAData, verifyTxHash common.Hash) {
+	logger := withCorrelation(r.logger, vaaData.CorrelationID).With(
+		zap.String("destination", dest.Name),
+		zap.Uint64("sequence", vaaData.Sequence),
+		zap.String("verifyTxHash", verifyTxHash.Hex()))
+
+	txHash, err := r.sendRelayAck(ctx, dest, vaaData, verifyTxHash)
+	if err != nil {
+		relayAcks.WithLabelValues(dest.Name, "failed").Inc()
+		logger.Error("Failed to publish relay acknowledgment", zap.Error(err))
+		return
+	}
+	relayAcks.WithLabelValues(dest.Name, "sent").Inc()
+	logger.Info("Published relay acknowledgment", zap.String("txHash", txHash))
+}
+
+// sendRelayAck pays the core's message fee and broadcasts the publishMessage call
+func (r *Relayer) sendRelayAck(ctx context.Context, dest *Destination, vaaData *VAAData, verifyTxHash common.Hash) (string, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(wormholeCoreABI))
+	if err != nil {
+		return "", fmt.Errorf("ABI parse error: %v", err)
+	}
+	core := common.HexToAddress(dest.WormholeCore)
+
+	feeCall, err := parsedABI.Pack("messageFee")
+	if err != nil {
+		return "", fmt.Errorf("ABI pack error: %v", err)
+	}
+	result, err := dest.client.client.CallContract(ctx, ethereum.CallMsg{To: &core, Data: feeCall}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message fee: %v", err)
+	}
+	if len(result) != 32 {
+		return "", fmt.Errorf("unexpected messageFee result of %d bytes", len(result))
+	}
+	fee := new(big.Int).SetBytes(result)
+
+	// The source sequence doubles as the nonce so acknowledgments are easy to correlate
+	data, err := parsedABI.Pack("publishMessage", uint32(vaaData.Sequence),
+		encodeRelayAck(vaaData, verifyTxHash), r.config.RelayAckConsistencyLevel)
+	if err != nil {
+		return "", fmt.Errorf("ABI pack error: %v", err)
+	}
+
+	strategy := r.feeStrategyFor(dest, vaaData.Payload)
+	return dest.client.sendTransaction(ctx, core, fee, data, relayAckGasLimit, strategy,
+		"relay acknowledgment for "+describeVAA(vaaData.RawBytes))
+}
@@ -2,31 +2,34 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
 	"github.com/joho/godotenv"
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
 	vaaLib "github.com/wormhole-foundation/wormhole/sdk/vaa"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Global logger for initial setup
@@ -53,6 +56,9 @@ func initLogger() {
 			config.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
 		}
 	}
+	// Every line passes through logSecrets, so keys and RPC credentials never reach the output
+	config.OutputPaths = redactingPaths(config.OutputPaths)
+	config.ErrorOutputPaths = redactingPaths(config.ErrorOutputPaths)
 
 	logger, err = config.Build()
 	if err != nil {
@@ -70,308 +76,828 @@ type Config struct {
 	SourceChainID    uint16 // Source chain ID (Aztec)
 	DestChainID      uint16 // Destination chain ID (EVM chain)
 	WormholeContract string // Wormhole core contract address on Aztec
-	EmitterAddress   string // Emitter address to monitor
 	AcceptAnyEmitter bool   // Accept any emitter from source chain (for testing)
 
 	// EVM chain configuration (Sepolia)
-	EVMRPCURL         string // RPC URL for EVM chain
-	PrivateKey        string // Private key for signing transactions
-	EVMTargetContract string // SafeRecoveryModule contract on EVM
+	EVMRPCURL         string        // RPC URL for EVM chain
+	PrivateKey        string        // Private key for signing transactions
+	PrivateKeys       []string      // Additional signer keys; submissions rotate across all keys
+	PrivateKeyFile    string        // File holding the hex private key, read at startup instead of PrivateKey
+	KeyFile           string        // Encrypted JSON key file used instead of PrivateKey
+	KeyPassphraseFile string        // File holding the key file passphrase (prompted for when empty)
+	LedgerEnabled     bool          // Sign with an account on a USB Ledger
+	LedgerPath        string        // HD derivation path of the Ledger account
+	RemoteSignerURL   string        // Web3Signer / eth_signTransaction endpoint
+	RemoteSignerAddrs []string      // Remote signer accounts to use (all when empty)
+	EVMTargetContract string        // SafeRecoveryModule contract on EVM
+	ReceiptTimeout    time.Duration // How long to wait for a verify transaction to be mined
+	SendTimeout       time.Duration // Budget for signing and broadcasting a verify transaction
+	MaxTxCost         string        // Most ETH a transaction may cost, L1 data fee included (0 for no limit)
+
+	// Most gas a zkSync transaction (TX_TYPE=zksync) pays per byte of data published to L1
+	ZkSyncGasPerPubdata int
+
+	// Key, passphrase and token files must be 0600 (or stricter) and owned by the relayer's user
+	AllowInsecureKeyFiles bool // Read files failing those checks with a warning instead of refusing to start
+
+	// Signing with a Fireblocks vault account (disabled without an API key)
+	FireblocksAPIKey         string // API user's key
+	FireblocksSecretKeyFile  string // API user's RSA secret key (PEM)
+	FireblocksVaultAccountID string // Vault account holding the signing key
+	FireblocksAssetID        string // Asset whose address in the vault account signs
+	FireblocksAPIURL         string // API base URL (the sandbox's for testing)
+
+	// Sponsored submission through Biconomy, for destinations with SUBMITTER=biconomy
+	BiconomyOwnerKey         string // Owner key of the smart accounts (the first PRIVATE_KEY/PRIVATE_KEYS when empty)
+	BiconomyValidationModule string // ECDSA ownership module the smart accounts validate the owner's signature with
+
+	// Co-signers that must approve every transaction before it is signed (disabled when empty)
+	CosignerURLs      []string      // Co-signer endpoints
+	CosignerAddresses []string      // Keys co-signers approve with
+	CosignerThreshold int           // Distinct approvals needed
+	CosignerTimeout   time.Duration // How long to wait for the approvals
+
+	// Which VAAs get sponsored gas on destinations with SUBMITTER=biconomy; with no Safes or
+	// emitters listed every VAA qualifies
+	SponsoredSafes        []string // Safes whose relays are sponsored, as address or address:daily cap
+	SponsoredEmitters     []string // Emitters whose relays are sponsored, as address or address:daily cap
+	SponsorshipDailyLimit int      // Sponsored operations per destination per UTC day (0 for no limit)
+	SponsorshipFallback   string   // What happens to VAAs that don't qualify: eoa or reject
+
+	// Destination chains; the first is the primary built from the EVM_* settings above
+	Destinations []DestinationConfig
+
+	// Relay acknowledgments published through each destination's Wormhole core
+	RelayAckEnabled          bool  // Publish an acknowledgment after each confirmed relay
+	RelayAckConsistencyLevel uint8 // Consistency level requested for acknowledgment messages
+
+	// Signed receipts stored with each relay record
+	RelayReceipts     bool   // Sign a receipt for every confirmed relay transaction
+	ReceiptSigningKey string // Key receipts are signed with (the first PRIVATE_KEY/PRIVATE_KEYS when empty)
+
+	// Module deployments served; each destination's target contract plus any TENANTS
+	Tenants []TenantConfig
+
+	// Emitters accepted for every tenant; EMITTER_ADDRESS plus any EMITTERS
+	Emitters []EmitterConfig
+
+	// Treasury top-ups of low signer balances (disabled without a treasury key)
+	TreasuryPrivateKey string        // Treasury account funding the relayer signers
+	RefillThreshold    string        // Signer balance in ETH below which it is topped up
+	RefillAmount       string        // ETH sent per top-up
+	RefillDailyLimit   string        // Most ETH sent per destination per UTC day (0 for no limit)
+	RefillInterval     time.Duration // How often signer balances are checked
+
+	// Persistent state
+	StateStore       string // Backend: memory, bolt or postgres
+	StateDBPath      string // BoltDB file for the bolt backend
+	StatePostgresURL string // Connection URL for the postgres backend
+
+	// Retry queue for VAAs whose processing failed
+	RetryMaxAttempts int           // Attempts before a VAA is given up on (0 retries forever)
+	RetryBackoff     time.Duration // Delay before the first retry, doubled on each further attempt
+
+	// Dedupe of VAAs already handled
+	DedupePolicy     string        // bytes, digest or sequence
+	DedupeTTL        time.Duration // How long a handled VAA is remembered
+	DedupeMaxEntries int           // Most processed VAAs remembered in memory for dedupe (0 for no bound)
+
+	// Hot-standby failover between instances sharing a postgres state store
+	Role              string        // standalone, primary or standby
+	InstanceID        string        // Name the instance holds the failover lease under
+	HeartbeatInterval time.Duration // How often the lease is renewed or checked
+	FailoverTimeout   time.Duration // Lease age after which a standby takes over
+
+	// Emitter registry log scans
+	LogScanChunkSize int // Blocks queried per eth_getLogs call
+	LogScanBatchSize int // eth_getLogs calls sent per JSON-RPC batch request
+
+	// Emitter registry polling, where events aren't subscribed to
+	EmitterPollInterval    time.Duration // Interval after a poll that found events
+	EmitterPollMaxInterval time.Duration // Longest interval the idle back-off reaches
+	EmitterPollMaxBlocks   int           // Most blocks scanned per poll (0 for no limit)
+
+	// Largest payload accepted before decoding (0 disables)
+	MaxPayloadSize int
+	// Check the Safe, its enabled modules and the emitter registration before relaying
+	SafePreflight bool
+	// JSON file describing each tenant's call flow, instead of a single verify call
+	CallFlowsFile string
+
+	// JSON file setting each destination's finality parameters in one place
+	ChainFinalityFile string
+
+	// Function called once per VAA on tenants without a configured call flow, and its arguments
+	TargetFunction     string
+	TargetFunctionArgs []string
+	// JSON file describing payload layouts by version, added to the built-in ones
+	PayloadLayoutsFile string
+
+	// Fee strategy overrides by payload version (take precedence over the destination's strategy)
+	FeeStrategyByPayloadVersion map[uint8]string
+
+	// Priority lane: VAAs that take the next free signer and are sent with the urgent strategy
+	PriorityPayloadVersions map[uint8]bool // Payload versions relayed with priority
+	PriorityEmitters        []string       // Emitters whose VAAs are relayed with priority
+
+	// Admin API
+	AdminListenAddr string // Admin HTTP listen address (disabled when empty)
+	EnablePprof     bool   // Expose pprof profiles on the admin listener
+
+	// Admin gRPC API: RelayerService of proto/relayer/v1, on the admin API's credentials
+	AdminGRPCListenAddr string // Admin gRPC listen address (disabled when empty)
+
+	// Admin API authentication (open when no keys or JWT verification key are configured)
+	AdminAPIKeys          []string // name:permission:key entries, permission being read or control
+	AdminAPIKeysFile      string   // File of further name:permission:key entries, one per line
+	AdminJWTSecret        string   // HS256 secret JWTs are verified with
+	AdminJWTPublicKeyFile string   // PEM RSA (RS256) or P-256 (ES256) public key JWTs are verified with
+	AdminJWTIssuer        string   // Required iss claim (not checked when empty)
+	AdminJWTAudience      string   // Required aud claim (not checked when empty)
+	AdminJWTRoleClaim     string   // Claim granting read or control
+
+	// Admin API client filtering
+	AdminAllowedCIDRs   []string // Client CIDRs or addresses accepted (any when empty)
+	AdminTrustedProxies []string // Proxies whose X-Forwarded-For header names the client
+	AdminRateLimit      int      // Requests per second per client (0 for no limit)
+	AdminRateBurst      int      // Requests a client may send at once (ADMIN_RATE_LIMIT when 0)
+
+	// Pushing metrics to a Prometheus Pushgateway, for hosts that can't be scraped
+	PushgatewayURL              string        // Pushgateway base URL (disabled when empty)
+	PushgatewayJob              string        // Job the metrics are grouped under
+	PushgatewayInstance         string        // Instance grouping label (the host name when empty)
+	PushgatewayInterval         time.Duration // How often metrics are pushed
+	PushgatewayUsername         string        // Basic auth username (none when empty)
+	PushgatewayPassword         string        // Basic auth password
+	PushgatewayDeleteOnShutdown bool          // Delete the instance's metrics on shutdown instead of pushing them a last time
+
+	// Per-emitter and per-Safe metric labels, which grow with every new address
+	MetricAddressLabels  string // exact, hash, bucket or off
+	MetricAddressBuckets int    // Buckets addresses are spread over in bucket mode
+	MetricLabelMaxValues int    // Distinct values per label before new addresses are counted as "other" (0 for no limit)
+
+	// EVM RPC circuit breaker
+	CircuitBreakerThreshold int           // Consecutive RPC failures before submissions pause (0 disables)
+	CircuitBreakerCooldown  time.Duration // Interval between recovery probes while open
+	RPCStallTimeout         time.Duration // Head block age after which an RPC is treated as down (0 disables)
+
+	// Mempool tracking of broadcast transactions
+	PendingTxPollInterval time.Duration // Interval between lookups of pending transactions (0 disables)
+	PendingTxDropTimeout  time.Duration // Time out of the mempool after which one is reported dropped
+
+	// Signer keys a key rotation started through the admin API moves submissions to
+	NextPrivateKeys []string
+
+	// ENS resolution of target contracts configured as names
+	ENSRPCURL          string        // Mainnet RPC names are resolved through (empty uses EVM_RPC_URL)
+	ENSRegistry        string        // ENS registry contract
+	ENSRefreshInterval time.Duration // How often names are re-resolved to detect changes (0 disables)
+
+	// Bounded intake queue between the spy reader and the processing workers
+	IntakeQueueSize int    // VAAs waiting for a worker before the overflow policy applies
+	IntakeWorkers   int    // VAAs from the spy processed concurrently
+	IntakeOverflow  string // What happens to a VAA arriving while the queue is full: block, spill or shed
+	IntakeSpillDir  string // Directory VAAs are spilled to with the spill policy
+
+	// Scheduled windows in which VAAs are observed and queued but not submitted
+	MaintenanceWindows []MaintenanceWindowConfig
+
+	// Deferral of VAAs while relaying them would cost more than their threshold
+	DeferCostThreshold                 string        // ETH over which VAAs are deferred (0 for never)
+	DeferCostThresholdByPayloadVersion []string      // version:eth thresholds overriding it by payload version
+	DeferDeadline                      time.Duration // How long a VAA may be deferred before it is relayed regardless
+
+	// systemd integration
+	WatchdogStreamTimeout time.Duration // Max spy stream silence before watchdog pings stop
+
+	// Spy stream silence after which the subscription is considered stale and recreated (0 disables)
+	SpyStaleTimeout time.Duration
+
+	// Spy gRPC connection
+	SpyKeepaliveTime     time.Duration // Idle time before a keepalive ping (0 disables pings)
+	SpyKeepaliveTimeout  time.Duration // Wait for a ping ack before the connection is dropped
+	SpyReconnectMaxDelay time.Duration // Longest backoff between reconnect attempts
+	SpyMaxMessageSize    int           // Largest message accepted from the spy, in bytes
+
+	// Spy authentication, for spies behind an authenticating gateway
+	SpyAuthToken     string // Token sent with every spy call (empty sends none)
+	SpyAuthTokenFile string // File holding the token, read at startup instead of SpyAuthToken
+	SpyAuthHeader    string // Metadata key the token is sent under
+
+	// Spy TLS
+	SpyTLS           bool   // Dial the spy over TLS
+	SpyTLSCAFile     string // PEM CA bundle the spy's certificate is checked against (empty uses the system roots)
+	SpyTLSServerName string // Name expected in the spy's certificate (empty uses the SPY_RPC_HOST host)
+
+	// VAA source: the spy, or the guardian gossip network joined directly
+	VAASource         string // "spy" or "gossip"
+	GossipNetwork     string // Wormhole network whose ID and bootstrap peers are used ("mainnet" or "testnet")
+	GossipNetworkID   string // Overrides the network's gossip network ID
+	GossipBootstrap   string // Overrides the network's comma-separated bootstrap multiaddrs
+	GossipPort        int    // UDP port the QUIC listener binds
+	GossipNodeKeyFile string // File holding the libp2p node key, created when missing (ephemeral key when empty)
+
+	// Assembly of quorum VAAs from under-signed VAAs and individual guardian observations
+	ObservationAssembly   bool          // Hold VAAs signed by too few guardians and complete them from observations
+	ObservationSources    []string      // Where observations are read from: gossip (attestation topic), api (GUARDIAN_API_URL)
+	ObservationTTL        time.Duration // How long held VAAs and unmatched observations are kept
+	ObservationMaxPending int           // Most message digests tracked at once
+
+	// Proxy for every outbound connection (empty uses HTTP_PROXY/HTTPS_PROXY)
+	OutboundProxy string
+
+	// VAA timestamp sanity checks
+	ClockSkewWarn     time.Duration // Skew between VAA, local and chain time that is logged (0 disables)
+	DelayFutureVAAs   bool          // Hold future-dated VAAs until local time reaches their timestamp
+	FutureVAAMaxDelay time.Duration // Longest hold; VAAs dated further ahead fail and are retried
+
+	// How often the guardian set is read from the Wormhole core (0 disables local signature checks)
+	GuardianSetRefresh time.Duration
+
+	// Wormhole Queries (CCQ) for proving EVM-side Safe state
+	QueryServerURL string // Query server (CCQ proxy) endpoint (disabled when empty)
+	QueryAPIKey    string // API key sent to the query server
+
+	// Reconciliation of relay records against on-chain state
+	ReconcileInterval       time.Duration // How often records are reconciled (0 disables)
+	ReconcileWindow         time.Duration // Age of the oldest record and event reconciled
+	ReconcileLookbackBlocks int           // Blocks scanned back from the head for RecoveryApplied events
+	ReconcileRedrive        bool          // Queue VAAs recorded as relayed but not consumed for another attempt
+
+	// Startup catch-up of VAAs missed during downtime
+	GuardianAPIURL string // Wormhole API the missed VAAs are fetched from (disabled when empty)
+	CatchupMaxVAAs int    // Most VAAs caught up per emitter on startup (0 for no limit)
+
+	// Canary self-test of the pipeline up to destination simulation
+	CanaryVAA      string        // Hex test VAA checked every CanaryInterval (disabled when empty)
+	CanaryEmitter  string        // Aztec emitter whose VAAs are checked instead of relayed (disabled when empty)
+	CanaryInterval time.Duration // How often CanaryVAA is checked
+	CanaryMaxAge   time.Duration // Longest time without a passing spy canary before health fails (0 disables)
+
+	// Hash-chained audit log of security-relevant actions (disabled when empty)
+	AuditLogFile string
 
 	// Custom VAA processor (optional)
-	vaaProcessor func(*Relayer, *VAAData) error
+	vaaProcessor func(context.Context, *Relayer, *VAAData) error
+
+	// Signers added to the configured ones, e.g. a KMS backend (optional)
+	signers []Signer
+}
+
+// signingKeys returns PRIVATE_KEY followed by any PRIVATE_KEYS
+func (c Config) signingKeys() []string {
+	var keys []string
+	if c.PrivateKey != "" {
+		keys = append(keys, c.PrivateKey)
+	}
+	return append(keys, c.PrivateKeys...)
 }
 
 // NewConfigFromEnv creates a Config from environment variables
 func NewConfigFromEnv() Config {
-	return Config{
+	// The dedupe TTL and capacity default to the selected policy's; an unknown policy is
+	// reported by NewRelayer
+	dedupeDefaults, err := lookupDedupePolicy(getEnvOrDefault("DEDUPE_POLICY", DedupePolicyBytes))
+	if err != nil {
+		dedupeDefaults = dedupePolicies[DedupePolicyBytes]
+	}
+
+	config := Config{
 		// Wormhole
 		SpyRPCHost:       getEnvOrDefault("SPY_RPC_HOST", "localhost:7073"),
 		SourceChainID:    uint16(getEnvIntOrDefault("SOURCE_CHAIN_ID", 56)),    // Aztec
 		DestChainID:      uint16(getEnvIntOrDefault("DEST_CHAIN_ID", 10002)),   // Sepolia
 		WormholeContract: getEnvOrDefault("WORMHOLE_CONTRACT", ""),
-		EmitterAddress:   getEnvOrDefault("EMITTER_ADDRESS", ""),
 		AcceptAnyEmitter: getEnvBoolOrDefault("ACCEPT_ANY_EMITTER", false),
 
 		// EVM chain
 		EVMRPCURL:         getEnvOrDefault("EVM_RPC_URL", ""),
 		PrivateKey:        getEnvOrDefault("PRIVATE_KEY", ""),
+		PrivateKeys:       getEnvListOrDefault("PRIVATE_KEYS", nil),
+		PrivateKeyFile:    getEnvOrDefault("PRIVATE_KEY_FILE", ""),
+		KeyFile:           getEnvOrDefault("KEY_FILE", ""),
+		KeyPassphraseFile: getEnvOrDefault("KEY_FILE_PASSPHRASE_FILE", ""),
+		LedgerEnabled:     getEnvBoolOrDefault("LEDGER_ENABLED", false),
+		LedgerPath:        getEnvOrDefault("LEDGER_DERIVATION_PATH", "m/44'/60'/0'/0/0"),
+		RemoteSignerURL:   getEnvOrDefault("REMOTE_SIGNER_URL", ""),
+		RemoteSignerAddrs: getEnvListOrDefault("REMOTE_SIGNER_ADDRESSES", nil),
 		EVMTargetContract: getEnvOrDefault("EVM_TARGET_CONTRACT", ""),
+		ReceiptTimeout:    getEnvDurationOrDefault("RECEIPT_TIMEOUT", 2*time.Minute),
+		SendTimeout:       getEnvDurationOrDefault("SEND_TIMEOUT", 60*time.Second),
+		MaxTxCost:         getEnvOrDefault("MAX_TX_COST", "0"),
+
+		ZkSyncGasPerPubdata: getEnvIntOrDefault("ZKSYNC_GAS_PER_PUBDATA", 50000),
+
+		AllowInsecureKeyFiles: getEnvBoolOrDefault("ALLOW_INSECURE_KEY_FILES", false),
+
+		FireblocksAPIKey:         getEnvOrDefault("FIREBLOCKS_API_KEY", ""),
+		FireblocksSecretKeyFile:  getEnvOrDefault("FIREBLOCKS_SECRET_KEY_FILE", ""),
+		FireblocksVaultAccountID: getEnvOrDefault("FIREBLOCKS_VAULT_ACCOUNT_ID", ""),
+		FireblocksAssetID:        getEnvOrDefault("FIREBLOCKS_ASSET_ID", "ETH"),
+		FireblocksAPIURL:         getEnvOrDefault("FIREBLOCKS_API_URL", "https://api.fireblocks.io"),
+
+		BiconomyOwnerKey:         getEnvOrDefault("BICONOMY_OWNER_KEY", ""),
+		BiconomyValidationModule: getEnvOrDefault("BICONOMY_VALIDATION_MODULE", "0x0000001c5b32F37F5beA87BDD5374eB2aC54eA8e"),
+
+		CosignerURLs:      getEnvListOrDefault("COSIGNER_URLS", nil),
+		CosignerAddresses: getEnvListOrDefault("COSIGNER_ADDRESSES", nil),
+		CosignerThreshold: getEnvIntOrDefault("COSIGNER_THRESHOLD", 0),
+		CosignerTimeout:   getEnvDurationOrDefault("COSIGNER_TIMEOUT", 30*time.Second),
+
+		SponsoredSafes:        getEnvListOrDefault("SPONSORED_SAFES", nil),
+		SponsoredEmitters:     getEnvListOrDefault("SPONSORED_EMITTERS", nil),
+		SponsorshipDailyLimit: getEnvIntOrDefault("SPONSORSHIP_DAILY_LIMIT", 0),
+		SponsorshipFallback:   getEnvOrDefault("SPONSORSHIP_FALLBACK", SponsorshipFallbackEOA),
+
+		// Relay acknowledgments
+		RelayAckEnabled:          getEnvBoolOrDefault("RELAY_ACK_ENABLED", false),
+		RelayAckConsistencyLevel: uint8(getEnvIntOrDefault("RELAY_ACK_CONSISTENCY_LEVEL", 1)),
+
+		// Relay receipts
+		RelayReceipts:     getEnvBoolOrDefault("RELAY_RECEIPTS", false),
+		ReceiptSigningKey: getEnvOrDefault("RECEIPT_SIGNING_KEY", ""),
+
+		// Treasury refill
+		TreasuryPrivateKey: getEnvOrDefault("TREASURY_PRIVATE_KEY", ""),
+		RefillThreshold:    getEnvOrDefault("REFILL_THRESHOLD", "0.05"),
+		RefillAmount:       getEnvOrDefault("REFILL_AMOUNT", "0.1"),
+		RefillDailyLimit:   getEnvOrDefault("REFILL_DAILY_LIMIT", "0.5"),
+		RefillInterval:     getEnvDurationOrDefault("REFILL_INTERVAL", 5*time.Minute),
+
+		// Persistent state
+		StateStore:       strings.ToLower(getEnvOrDefault("STATE_STORE", StateStoreMemory)),
+		StateDBPath:      getEnvOrDefault("STATE_DB_PATH", "relayer.db"),
+		StatePostgresURL: getEnvOrDefault("STATE_POSTGRES_URL", ""),
+		RetryMaxAttempts: getEnvIntOrDefault("RETRY_MAX_ATTEMPTS", 5),
+		RetryBackoff:     getEnvDurationOrDefault("RETRY_BACKOFF", 30*time.Second),
+		DedupePolicy:     strings.ToLower(getEnvOrDefault("DEDUPE_POLICY", DedupePolicyBytes)),
+		DedupeTTL:        getEnvDurationOrDefault("DEDUPE_TTL", dedupeDefaults.TTL),
+		DedupeMaxEntries: getEnvIntOrDefault("DEDUPE_MAX_ENTRIES", dedupeDefaults.MaxEntries),
+
+		// Hot-standby failover
+		Role:              strings.ToLower(getEnvOrDefault("RELAYER_ROLE", RoleStandalone)),
+		InstanceID:        getEnvOrDefault("INSTANCE_ID", defaultInstanceID()),
+		HeartbeatInterval: getEnvDurationOrDefault("HEARTBEAT_INTERVAL", 5*time.Second),
+		FailoverTimeout:   getEnvDurationOrDefault("FAILOVER_TIMEOUT", 30*time.Second),
+
+		LogScanChunkSize: getEnvIntOrDefault("LOG_SCAN_CHUNK_SIZE", 10000),
+		LogScanBatchSize: getEnvIntOrDefault("LOG_SCAN_BATCH_SIZE", 10),
+
+		EmitterPollInterval:    getEnvDurationOrDefault("EMITTER_POLL_INTERVAL", 15*time.Second),
+		EmitterPollMaxInterval: getEnvDurationOrDefault("EMITTER_POLL_MAX_INTERVAL", 2*time.Minute),
+		EmitterPollMaxBlocks:   getEnvIntOrDefault("EMITTER_POLL_MAX_BLOCKS", 10000),
+
+		MaxPayloadSize:     getEnvIntOrDefault("MAX_PAYLOAD_SIZE", 512),
+		PayloadLayoutsFile: getEnvOrDefault("PAYLOAD_LAYOUTS_FILE", ""),
+		SafePreflight:      getEnvBoolOrDefault("SAFE_PREFLIGHT", false),
+		CallFlowsFile:      getEnvOrDefault("CALL_FLOWS_FILE", ""),
+		ChainFinalityFile:  getEnvOrDefault("CHAIN_FINALITY_FILE", ""),
+		TargetFunction:     getEnvOrDefault("TARGET_FUNCTION", "verify(bytes)"),
+		TargetFunctionArgs: getEnvListOrDefault("TARGET_FUNCTION_ARGS", []string{FlowArgVAA}),
+
+		PriorityEmitters: getEnvListOrDefault("PRIORITY_EMITTERS", nil),
+
+		// Admin API
+		AdminListenAddr: getEnvOrDefault("ADMIN_LISTEN_ADDR", ""),
+		EnablePprof:     getEnvBoolOrDefault("ENABLE_PPROF", false),
+
+		AdminGRPCListenAddr: getEnvOrDefault("ADMIN_GRPC_LISTEN_ADDR", ""),
+
+		AdminAPIKeys:          getEnvListOrDefault("ADMIN_API_KEYS", nil),
+		AdminAPIKeysFile:      getEnvOrDefault("ADMIN_API_KEYS_FILE", ""),
+		AdminJWTSecret:        getEnvOrDefault("ADMIN_JWT_SECRET", ""),
+		AdminJWTPublicKeyFile: getEnvOrDefault("ADMIN_JWT_PUBLIC_KEY_FILE", ""),
+		AdminJWTIssuer:        getEnvOrDefault("ADMIN_JWT_ISSUER", ""),
+		AdminJWTAudience:      getEnvOrDefault("ADMIN_JWT_AUDIENCE", ""),
+		AdminJWTRoleClaim:     getEnvOrDefault("ADMIN_JWT_ROLE_CLAIM", "role"),
+
+		AdminAllowedCIDRs:   getEnvListOrDefault("ADMIN_ALLOWED_CIDRS", nil),
+		AdminTrustedProxies: getEnvListOrDefault("ADMIN_TRUSTED_PROXIES", nil),
+		AdminRateLimit:      getEnvIntOrDefault("ADMIN_RATE_LIMIT", 20),
+		AdminRateBurst:      getEnvIntOrDefault("ADMIN_RATE_BURST", 40),
+
+		PushgatewayURL:              getEnvOrDefault("PUSHGATEWAY_URL", ""),
+		PushgatewayJob:              getEnvOrDefault("PUSHGATEWAY_JOB", "aztec-relayer"),
+		PushgatewayInstance:         getEnvOrDefault("PUSHGATEWAY_INSTANCE", ""),
+		PushgatewayInterval:         getEnvDurationOrDefault("PUSHGATEWAY_INTERVAL", 15*time.Second),
+		PushgatewayUsername:         getEnvOrDefault("PUSHGATEWAY_USERNAME", ""),
+		PushgatewayPassword:         getEnvOrDefault("PUSHGATEWAY_PASSWORD", ""),
+		PushgatewayDeleteOnShutdown: getEnvBoolOrDefault("PUSHGATEWAY_DELETE_ON_SHUTDOWN", false),
+
+		MetricAddressLabels:  getEnvOrDefault("METRIC_ADDRESS_LABELS", AddressLabelsHash),
+		MetricAddressBuckets: getEnvIntOrDefault("METRIC_ADDRESS_BUCKETS", 32),
+		MetricLabelMaxValues: getEnvIntOrDefault("METRIC_LABEL_MAX_VALUES", 500),
+
+		// EVM RPC circuit breaker
+		CircuitBreakerThreshold: getEnvIntOrDefault("CIRCUIT_BREAKER_THRESHOLD", 5),
+		CircuitBreakerCooldown:  getEnvDurationOrDefault("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second),
+		RPCStallTimeout:         getEnvDurationOrDefault("RPC_STALL_TIMEOUT", 3*time.Minute),
+
+		// Mempool tracking of broadcast transactions
+		PendingTxPollInterval: getEnvDurationOrDefault("PENDING_TX_POLL_INTERVAL", 15*time.Second),
+		PendingTxDropTimeout:  getEnvDurationOrDefault("PENDING_TX_DROP_TIMEOUT", 10*time.Minute),
+
+		// Signer key rotation
+		NextPrivateKeys: getEnvListOrDefault("NEXT_PRIVATE_KEYS", nil),
+
+		// ENS resolution
+		ENSRPCURL:          getEnvOrDefault("ENS_RPC_URL", ""),
+		ENSRegistry:        getEnvOrDefault("ENS_REGISTRY", defaultENSRegistry),
+		ENSRefreshInterval: getEnvDurationOrDefault("ENS_REFRESH_INTERVAL", time.Hour),
+
+		// Intake queue
+		IntakeQueueSize: getEnvIntOrDefault("INTAKE_QUEUE_SIZE", 1000),
+		IntakeWorkers:   getEnvIntOrDefault("INTAKE_WORKERS", 32),
+		IntakeOverflow:  strings.ToLower(getEnvOrDefault("INTAKE_OVERFLOW", IntakeOverflowBlock)),
+		IntakeSpillDir:  getEnvOrDefault("INTAKE_SPILL_DIR", "intake-spill"),
+
+		// Cost deferral
+		DeferCostThreshold:                 getEnvOrDefault("DEFER_COST_THRESHOLD", "0"),
+		DeferCostThresholdByPayloadVersion: getEnvListOrDefault("DEFER_COST_THRESHOLD_BY_PAYLOAD_VERSION", nil),
+		DeferDeadline:                      getEnvDurationOrDefault("DEFER_DEADLINE", 6*time.Hour),
+
+		// systemd integration
+		WatchdogStreamTimeout: getEnvDurationOrDefault("WATCHDOG_STREAM_TIMEOUT", 5*time.Minute),
+
+		SpyStaleTimeout: getEnvDurationOrDefault("SPY_STALE_TIMEOUT", 2*time.Minute),
+
+		SpyKeepaliveTime:     getEnvDurationOrDefault("SPY_KEEPALIVE_TIME", 5*time.Minute),
+		SpyKeepaliveTimeout:  getEnvDurationOrDefault("SPY_KEEPALIVE_TIMEOUT", 20*time.Second),
+		SpyReconnectMaxDelay: getEnvDurationOrDefault("SPY_RECONNECT_MAX_DELAY", 30*time.Second),
+		SpyMaxMessageSize:    getEnvIntOrDefault("SPY_MAX_MESSAGE_SIZE", 4<<20),
+
+		SpyAuthToken:     getEnvOrDefault("SPY_AUTH_TOKEN", ""),
+		SpyAuthTokenFile: getEnvOrDefault("SPY_AUTH_TOKEN_FILE", ""),
+		SpyAuthHeader:    getEnvOrDefault("SPY_AUTH_HEADER", "authorization"),
+
+		SpyTLS:           getEnvBoolOrDefault("SPY_TLS", false),
+		SpyTLSCAFile:     getEnvOrDefault("SPY_TLS_CA_FILE", ""),
+		SpyTLSServerName: getEnvOrDefault("SPY_TLS_SERVER_NAME", ""),
+
+		VAASource:         getEnvOrDefault("VAA_SOURCE", "spy"),
+		GossipNetwork:     getEnvOrDefault("GOSSIP_NETWORK", "mainnet"),
+		GossipNetworkID:   getEnvOrDefault("GOSSIP_NETWORK_ID", ""),
+		GossipBootstrap:   getEnvOrDefault("GOSSIP_BOOTSTRAP", ""),
+		GossipPort:        getEnvIntOrDefault("GOSSIP_PORT", 8999),
+		GossipNodeKeyFile: getEnvOrDefault("GOSSIP_NODE_KEY_FILE", ""),
+
+		ObservationAssembly:   getEnvBoolOrDefault("OBSERVATION_ASSEMBLY", false),
+		ObservationSources:    getEnvListOrDefault("OBSERVATION_SOURCES", []string{"gossip", "api"}),
+		ObservationTTL:        getEnvDurationOrDefault("OBSERVATION_TTL", 5*time.Minute),
+		ObservationMaxPending: getEnvIntOrDefault("OBSERVATION_MAX_PENDING", 10000),
+
+		OutboundProxy: getEnvOrDefault("OUTBOUND_PROXY", ""),
+
+		ClockSkewWarn:     getEnvDurationOrDefault("CLOCK_SKEW_WARN", 5*time.Minute),
+		DelayFutureVAAs:   getEnvBoolOrDefault("DELAY_FUTURE_VAAS", false),
+		FutureVAAMaxDelay: getEnvDurationOrDefault("FUTURE_VAA_MAX_DELAY", time.Hour),
+
+		GuardianSetRefresh: getEnvDurationOrDefault("GUARDIAN_SET_REFRESH", 10*time.Minute),
+
+		QueryServerURL: getEnvOrDefault("QUERY_SERVER_URL", ""),
+		QueryAPIKey:    getEnvOrDefault("QUERY_API_KEY", ""),
+
+		ReconcileInterval:       getEnvDurationOrDefault("RECONCILE_INTERVAL", time.Hour),
+		ReconcileWindow:         getEnvDurationOrDefault("RECONCILE_WINDOW", 24*time.Hour),
+		ReconcileLookbackBlocks: getEnvIntOrDefault("RECONCILE_LOOKBACK_BLOCKS", 50000),
+		ReconcileRedrive:        getEnvBoolOrDefault("RECONCILE_REDRIVE", false),
+
+		GuardianAPIURL: getEnvOrDefault("GUARDIAN_API_URL", ""),
+		CatchupMaxVAAs: getEnvIntOrDefault("CATCHUP_MAX_VAAS", 1000),
+
+		CanaryVAA:      getEnvOrDefault("CANARY_VAA", ""),
+		CanaryEmitter:  getEnvOrDefault("CANARY_EMITTER", ""),
+		CanaryInterval: getEnvDurationOrDefault("CANARY_INTERVAL", 5*time.Minute),
+		CanaryMaxAge:   getEnvDurationOrDefault("CANARY_MAX_AGE", 0),
+
+		AuditLogFile: getEnvOrDefault("AUDIT_LOG_FILE", ""),
 	}
-}
 
-// VAAData encapsulates a VAA and its metadata
-type VAAData struct {
-	VAA        *vaaLib.VAA // The parsed VAA
-	RawBytes   []byte      // Raw VAA bytes
-	ChainID    uint16      // Source chain ID
-	EmitterHex string      // Hex-encoded emitter address
-	Sequence   uint64      // VAA sequence number
-	TxID       string      // Source transaction ID
-}
-
-// SpyClient handles connections to the Wormhole spy service
-type SpyClient struct {
-	conn   *grpc.ClientConn
-	client spyv1.SpyRPCServiceClient
-	logger *zap.Logger
-}
-
-// NewSpyClient creates a new client for the Wormhole spy service
-func NewSpyClient(endpoint string) (*SpyClient, error) {
-	client := &SpyClient{
-		logger: logger.With(zap.String("component", "SpyClient")),
+	config.Destinations = loadDestinationsFromEnv(DestinationConfig{
+		Name:            getEnvOrDefault("EVM_CHAIN_NAME", "primary"),
+		WormholeChainID: config.DestChainID,
+		RPCURL:          config.EVMRPCURL,
+		TargetContract:  config.EVMTargetContract,
+		FeeStrategy:     getEnvOrDefault("FEE_STRATEGY", FeeStrategyStandard),
+		TxType:          getEnvOrDefault("EVM_TX_TYPE", TxTypeAuto),
+		WormholeCore:    getEnvOrDefault("EVM_WORMHOLE_CORE", ""),
+		AttestationLog:  getEnvOrDefault("EVM_ATTESTATION_LOG", ""),
+		ScanStartBlock:  int64(getEnvIntOrDefault("EMITTER_SCAN_START_BLOCK", emitterScanStartBlock)),
+		Finality:        getEnvOrDefault("EVM_FINALITY", FinalityLatest),
+		Confirmations:   getEnvIntOrDefault("EVM_CONFIRMATIONS", 0),
+		Submitter:       getEnvOrDefault("SUBMITTER", SubmitterEOA),
+		BundlerURL:      getEnvOrDefault("BICONOMY_BUNDLER_URL", ""),
+		PaymasterURL:    getEnvOrDefault("BICONOMY_PAYMASTER_URL", ""),
+		SmartAccount:    getEnvOrDefault("BICONOMY_SMART_ACCOUNT", ""),
+		L1Fee:           getEnvOrDefault("EVM_L1_FEE", L1FeeAuto),
+		GasStationURL:   getEnvOrDefault("EVM_GAS_STATION_URL", ""),
+		MaxFeePerGas:    getEnvOrDefault("MAX_FEE_PER_GAS", "0"),
+		ReorgLookback:   getEnvIntOrDefault("EVM_REORG_LOOKBACK", 0),
+		ReceiptTimeout:  config.ReceiptTimeout,
+	})
+	config.Tenants = loadTenantsFromEnv(config.Destinations)
+	config.MaintenanceWindows = loadMaintenanceWindowsFromEnv()
+	config.Emitters = loadEmittersFromEnv()
+
+	byVersion, err := parseFeeStrategyByVersion(getEnvListOrDefault("FEE_STRATEGY_BY_PAYLOAD_VERSION", nil))
+	if err != nil {
+		logger.Warn("Invalid environment variable value, using default",
+			zap.String("key", "FEE_STRATEGY_BY_PAYLOAD_VERSION"),
+			zap.Error(err))
 	}
+	config.FeeStrategyByPayloadVersion = byVersion
 
-	client.logger.Info("Connecting to spy service", zap.String("endpoint", endpoint))
-	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	priorityVersions, err := parsePayloadVersions(getEnvListOrDefault("PRIORITY_PAYLOAD_VERSIONS", nil))
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to spy: %v", err)
+		logger.Warn("Invalid environment variable value, using default",
+			zap.String("key", "PRIORITY_PAYLOAD_VERSIONS"),
+			zap.Error(err))
 	}
+	config.PriorityPayloadVersions = priorityVersions
 
-	client.conn = conn
-	client.client = spyv1.NewSpyRPCServiceClient(conn)
-	return client, nil
+	return config
 }
 
-// Close closes the connection to the spy service
-func (c *SpyClient) Close() {
-	if c.conn != nil {
-		c.conn.Close()
-	}
-}
-
-// SubscribeSignedVAA subscribes to all signed VAAs with retry logic
-func (c *SpyClient) SubscribeSignedVAA(ctx context.Context) (spyv1.SpyRPCService_SubscribeSignedVAAClient, error) {
-	const maxRetries = 5
-	const retryDelay = 2 * time.Second
-
-	c.logger.Debug("Subscribing to signed VAAs")
-
-	var stream spyv1.SpyRPCService_SubscribeSignedVAAClient
-	var err error
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		// Create a fresh connection for each attempt
-		endpoint := c.conn.Target()
-		conn, err := grpc.DialContext(ctx, endpoint,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithBlock())
-		if err != nil {
-			if attempt < maxRetries {
-				c.logger.Warn("Connection attempt failed",
-					zap.Int("attempt", attempt),
-					zap.Error(err),
-					zap.Duration("retryIn", retryDelay))
-				time.Sleep(retryDelay)
-				continue
-			}
-			return nil, fmt.Errorf("failed to create connection after %d attempts: %v", maxRetries, err)
-		}
-
-		client := spyv1.NewSpyRPCServiceClient(conn)
-		stream, err = client.SubscribeSignedVAA(ctx, &spyv1.SubscribeSignedVAARequest{})
-		if err == nil {
-			return stream, nil
-		}
-
-		conn.Close() // Close the failed connection
-
-		if attempt < maxRetries {
-			c.logger.Warn("Subscribe attempt failed",
-				zap.Int("attempt", attempt),
-				zap.Error(err),
-				zap.Duration("retryIn", retryDelay))
-
-			select {
-			case <-time.After(retryDelay):
-				// Continue to next retry
-			case <-ctx.Done():
-				return nil, fmt.Errorf("context cancelled during retry: %v", ctx.Err())
-			}
-		}
-	}
+// VAAData encapsulates a VAA and its metadata
+type VAAData struct {
+	sdk.VAAData                // The parsed VAA and its decoded recovery payload
+	Tenant      string         // Tenant the payload routes to (set once routed)
+	TxHash      string         // Verify transaction hash (set once broadcast)
+	Receipt     *types.Receipt // Verify transaction receipt (set once mined)
+	Priority    bool           // Relayed on the priority lane (set once routed)
 
-	return nil, fmt.Errorf("failed to subscribe after %d attempts: %v", maxRetries, err)
+	CorrelationID string // Ties the VAA's log lines, exemplars and records together
 }
 
 // EVMClient handles interactions with EVM-compatible blockchains
 type EVMClient struct {
-	client     *ethclient.Client
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	logger     *zap.Logger
-	nonceMu    sync.Mutex
+	client  *ethclient.Client
+	signers *signerPool
+	breaker *CircuitBreaker
+	logger  *zap.Logger
+	// Transaction type and L1 fee model configured; auto is resolved into chainInfo
+	txType     string
+	l1FeeModel string
+	// Most gas a zkSync transaction pays per byte of published data
+	gasPerPubdata uint64
+	// Most a transaction may cost, L1 data fee included (nil for no limit)
+	maxTxCost atomic.Pointer[big.Int]
+	// Highest fee cap per gas a transaction may be sent with, bumps included (nil for no limit)
+	maxFeePerGas atomic.Pointer[big.Int]
+	// Fee endpoint the RPC's fees are raised to, if configured
+	gasStation *gasStation
+	// Immutable chain facts, read on first use and re-validated after the RPC recovers
+	chainMu    sync.Mutex
+	chainInfo  *chainInfo
+	chainStale atomic.Bool
+	// Log scans: blocks per eth_getLogs call and calls per batch request
+	logScanChunk uint64
+	logScanBatch atomic.Int64
+	logScanLimit atomic.Uint64 // Smaller page size the RPC accepts, once one was rejected
+	// Registry polling: base and longest interval, and most blocks scanned per poll
+	pollInterval    time.Duration
+	pollMaxInterval time.Duration
+	pollMaxBlocks   uint64
+	// Newest block registry scans read: the finality tag, or the depth behind the head where
+	// the tag is unsupported
+	finality            string
+	confirmations       uint64
+	finalityUnsupported atomic.Bool
+	// Blocks behind the last one scanned that registry scans read again, for reorgs deeper
+	// than the finality allows for
+	reorgLookback uint64
+	// Records every transaction signed, if auditing is enabled
+	audit *AuditLog
+	// Broadcast transactions not yet seen mined
+	pending *pendingTxs
 }
 
-// NewEVMClient creates a new client for EVM-compatible blockchains
-func NewEVMClient(rpcURL, privateKeyHex string) (*EVMClient, error) {
+// NewEVMClient creates a new client for EVM-compatible blockchains that signs with
+// the given keys, rotating across them so submissions can run in parallel. HTTP(S)
+// RPC calls are recorded in metrics and reported to breaker.
+func NewEVMClient(rpcURL string, accounts []Signer, breaker *CircuitBreaker, proxy proxyFunc) (*EVMClient, error) {
 	client := &EVMClient{
-		logger: logger.With(zap.String("component", "EVMClient")),
+		breaker: breaker,
+		logger:  logger.With(zap.String("component", "EVMClient")),
+		pending: newPendingTxs(),
 	}
 
 	client.logger.Info("Connecting to EVM chain", zap.String("rpcURL", rpcURL))
-	ethClient, err := ethclient.Dial(rpcURL)
+	var options []rpc.ClientOption
+	dial := countingDialer(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}, breaker.name)
+	switch {
+	case strings.HasPrefix(rpcURL, "http://") || strings.HasPrefix(rpcURL, "https://"):
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.DialContext = dial
+		base.Proxy = proxy
+		httpClient := &http.Client{
+			Transport: &rpcTransport{base: base, endpoint: breaker.name, breaker: breaker},
+		}
+		options = append(options, rpc.WithHTTPClient(httpClient))
+	case strings.HasPrefix(rpcURL, "ws://") || strings.HasPrefix(rpcURL, "wss://"):
+		// The upgrade request goes through the proxy as a CONNECT tunnel
+		scheme := "http"
+		if strings.HasPrefix(rpcURL, "wss://") {
+			scheme = "https"
+		}
+		options = append(options, rpc.WithWebsocketDialer(websocket.Dialer{
+			NetDialContext:  proxyDialer(proxy, scheme, dial),
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		}))
+	}
+	rpcClient, err := rpc.DialOptions(context.Background(), rpcURL, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to EVM node: %v", err)
 	}
+	ethClient := ethclient.NewClient(rpcClient)
 
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
+	breaker.setProbe(func(ctx context.Context) error {
+		_, err := ethClient.BlockNumber(ctx)
+		return err
+	})
+	breaker.setOnClose(client.invalidateChainInfo)
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("error casting public key to ECDSA")
+	signers, err := newSignerPool(accounts)
+	if err != nil {
+		return nil, err
 	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
 	client.client = ethClient
-	client.privateKey = privateKey
-	client.address = address
+	client.signers = signers
 
 	return client, nil
 }
 
-// GetAddress returns the public address for this client
+// GetAddress returns the public address of the client's first signer
 func (c *EVMClient) GetAddress() common.Address {
-	return c.address
+	return c.signers.addresses()[0]
 }
 
-// getFreshNonce gets a fresh nonce by taking the max of confirmed and pending nonce
-func (c *EVMClient) getFreshNonce(ctx context.Context) (uint64, error) {
-	// Get confirmed nonce (transactions that are mined)
-	confirmedNonce, err := c.client.NonceAt(ctx, c.address, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get confirmed nonce: %v", err)
-	}
-
-	// Get pending nonce (includes pending transactions in mempool)
-	pendingNonce, err := c.client.PendingNonceAt(ctx, c.address)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get pending nonce: %v", err)
-	}
-
-	// Use the higher of the two to avoid conflicts
-	nonce := confirmedNonce
-	if pendingNonce > confirmedNonce {
-		nonce = pendingNonce
-	}
-
-	c.logger.Debug("Fresh nonce fetched",
-		zap.Uint64("confirmed", confirmedNonce),
-		zap.Uint64("pending", pendingNonce),
-		zap.Uint64("using", nonce))
-
-	return nonce, nil
+// GetAddresses returns the public addresses of all the client's signers
+func (c *EVMClient) GetAddresses() []common.Address {
+	return c.signers.addresses()
 }
 
-// SendVerifyTransaction sends a transaction to the verify function
-func (c *EVMClient) SendVerifyTransaction(ctx context.Context, targetContract string, vaaBytes []byte) (string, error) {
-	// Lock to prevent concurrent nonce conflicts
-	c.nonceMu.Lock()
-	defer c.nonceMu.Unlock()
-
-	c.logger.Debug("Sending verify transaction to EVM", zap.Int("vaaLength", len(vaaBytes)))
-
-	const abiJSON = `[{
-        "inputs": [
-            {"internalType": "bytes", "name": "encodedVm", "type": "bytes"}
-        ],
-        "name": "verify",
-        "outputs": [],
-        "stateMutability": "nonpayable",
-        "type": "function"
-    }]`
-
-	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
-	if err != nil {
-		return "", fmt.Errorf("ABI parse error: %v", err)
-	}
-
-	data, err := parsedABI.Pack("verify", vaaBytes)
-	if err != nil {
-		return "", fmt.Errorf("ABI pack error: %v", err)
-	}
+// sendTransaction signs a call to targetAddr with a pooled signer and broadcasts it, retrying
+// with a fresh nonce and a bumped gas price on nonce conflicts. description identifies the
+// transaction for signers that ask an operator to approve it.
+func (c *EVMClient) sendTransaction(ctx context.Context, targetAddr common.Address, value *big.Int, data []byte, gasLimit uint64, strategy FeeStrategy, description string) (string, error) {
+	log := correlatedLogger(ctx, c.logger)
 
-	chainID, err := c.client.NetworkID(ctx)
+	// Borrow a signer exclusively to prevent concurrent nonce conflicts on its account
+	signer, err := c.signers.acquire(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get chain ID: %v", err)
+		return "", err
 	}
-
-	targetAddr := common.HexToAddress(targetContract)
+	defer c.signers.release(signer)
 
 	// Retry loop for nonce conflicts
 	maxRetries := 3
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Always fetch fresh nonce for each attempt
-		nonce, err := c.getFreshNonce(ctx)
+		// Always fetch a fresh nonce and fees for each attempt, in one round trip
+		params, err := c.fetchTxParams(ctx, signer.Address(), strategy)
 		if err != nil {
 			return "", err
 		}
-
-		// Get fresh gas price
-		gasPrice, err := c.client.SuggestGasPrice(ctx)
-		if err != nil {
-			return "", fmt.Errorf("failed to get gas price: %v", err)
+		// Stay ahead of our own pending transactions when the RPC's pending nonce lags them
+		if next, ok := c.pending.nextNonce(signer.Address()); ok && next > params.nonce {
+			log.Debug("Nonce raised past tracked pending transactions",
+				zap.Uint64("rpcNonce", params.nonce),
+				zap.Uint64("using", next))
+			params.nonce = next
 		}
+		nonce := params.nonce
 
-		// Add 20% to gas price to help with replacement
+		// Add 20% to the fees after a conflict to help with replacement
+		var bumpPct int64
 		if attempt > 0 {
-			bump := new(big.Int).Div(gasPrice, big.NewInt(5))
-			gasPrice = new(big.Int).Add(gasPrice, bump)
-			c.logger.Debug("Bumped gas price for retry",
+			bumpPct = 20
+		}
+		tx := newTransaction(params, targetAddr, value, gasLimit, data, bumpPct)
+		if tx, err = c.withL1Costs(ctx, params, tx, bumpPct); err != nil {
+			return "", err
+		}
+		if err := c.checkFeeCeiling(tx); err != nil {
+			return "", err
+		}
+		gasPrice := tx.GasFeeCap()
+		if attempt > 0 {
+			log.Debug("Bumped gas price for retry",
 				zap.Int("attempt", attempt+1),
 				zap.String("gasPrice", gasPrice.String()))
 		}
 
-		tx := types.NewTransaction(
-			nonce,
-			targetAddr,
-			big.NewInt(0),
-			3000000,
-			gasPrice,
-			data,
-		)
-
-		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), c.privateKey)
+		rawTx, txHash, err := c.signTx(ctx, signer, params, tx, description)
 		if err != nil {
 			return "", fmt.Errorf("failed to sign transaction: %v", err)
 		}
+		c.audit.recordTxSigned(ctx, params.chain.chainID.Uint64(), signer.Address().Hex(), targetAddr.Hex(), txHash.Hex(), nonce, description)
 
-		c.logger.Debug("Attempting to send transaction",
+		log.Debug("Attempting to send transaction",
 			zap.Int("attempt", attempt+1),
+			zap.String("signer", signer.Address().Hex()),
 			zap.Uint64("nonce", nonce),
+			zap.Uint8("txType", tx.Type()),
 			zap.String("gasPrice", gasPrice.String()),
-			zap.String("txHash", signedTx.Hash().Hex()))
+			zap.String("txHash", txHash.Hex()))
 
-		err = c.client.SendTransaction(ctx, signedTx)
+		err = c.client.Client().CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(rawTx))
 		if err != nil {
 			errStr := err.Error()
 			// Check for nonce-related errors that warrant a retry
 			if strings.Contains(errStr, "replacement transaction underpriced") ||
 				strings.Contains(errStr, "nonce too low") ||
 				strings.Contains(errStr, "already known") {
-				c.logger.Warn("Nonce conflict, retrying with fresh nonce",
-					zap.Int("attempt", attempt+1),
-					zap.Error(err))
+				fields := []zap.Field{zap.Int("attempt", attempt+1), zap.Error(err)}
+				if held, ok := c.pending.byNonce(signer.Address(), nonce); ok {
+					fields = append(fields,
+						zap.String("pendingTxHash", held.Hash.Hex()),
+						zap.Duration("pendingAge", time.Since(held.SentAt)),
+						zap.String("pendingFeeCap", held.FeeCap.String()))
+				}
+				log.Warn("Nonce conflict, retrying with fresh nonce", fields...)
 				// Small delay before retry
 				time.Sleep(2 * time.Second)
 				continue
 			}
-			return "", fmt.Errorf("failed to send transaction: %v", err)
+			return "", classifyError(fmt.Errorf("failed to send transaction: %v", err), ErrorKindRPC)
 		}
 
-		c.logger.Info("Transaction sent successfully",
+		log.Info("Transaction sent successfully",
+			zap.String("signer", signer.Address().Hex()),
 			zap.Uint64("nonce", nonce),
-			zap.String("txHash", signedTx.Hash().Hex()))
+			zap.String("txHash", txHash.Hex()))
+		c.pending.add(pendingTx{
+			Hash:          txHash,
+			Signer:        signer.Address(),
+			Nonce:         nonce,
+			Tip:           tx.GasTipCap(),
+			FeeCap:        gasPrice,
+			Gas:           tx.Gas(),
+			Description:   description,
+			CorrelationID: correlationIDFrom(ctx),
+			SentAt:        time.Now(),
+		})
+
+		return txHash.Hex(), nil
+	}
+
+	return "", pipelineError(ErrorKindNonceConflict,
+		fmt.Errorf("failed to send transaction after %d attempts due to nonce conflicts", maxRetries))
+}
+
+// SimulateCall runs a call to target from the first signer without broadcasting it,
+// returning the gas it would use or why it would revert
+func (c *EVMClient) SimulateCall(ctx context.Context, target common.Address, data []byte) (uint64, error) {
+	msg := ethereum.CallMsg{From: c.GetAddress(), To: &target, Data: data}
+	if _, err := c.client.CallContract(ctx, msg, nil); err != nil {
+		if revert := revertFromCallError(err); revert != nil {
+			return 0, pipelineError(ErrorKindSimulationRevert, revertError(revert, "simulation", correlationIDFrom(ctx), "call"))
+		}
+		return 0, classifyError(fmt.Errorf("call failed: %v", err), ErrorKindRPC)
+	}
+	gas, err := c.client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, classifyError(fmt.Errorf("gas estimation failed: %v", err), ErrorKindRPC)
+	}
+	return gas, nil
+}
 
-		return signedTx.Hash().Hex(), nil
+// describeVAA identifies a VAA by chain, emitter and sequence for signer prompts
+func describeVAA(vaaBytes []byte) string {
+	parsed, err := vaaLib.Unmarshal(vaaBytes)
+	if err != nil {
+		return "unparseable VAA"
 	}
+	return fmt.Sprintf("chain %d emitter %s sequence %d", parsed.EmitterChain, parsed.EmitterAddress, parsed.Sequence)
+}
+
+// WaitForReceipt polls for the receipt of a sent transaction until it is mined or ctx expires
+func (c *EVMClient) WaitForReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	log := correlatedLogger(ctx, c.logger)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
 
-	return "", fmt.Errorf("failed to send transaction after %d attempts due to nonce conflicts", maxRetries)
+	for {
+		receipt, err := c.client.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			c.resolvePendingTx(txHash, PendingTxMined)
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			log.Debug("Receipt lookup failed, retrying",
+				zap.String("txHash", txHash.Hex()),
+				zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for receipt of %s: %v", txHash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
 }
 
 // Relayer coordinates processing VAAs from the spy service
@@ -379,49 +905,363 @@ type Relayer struct {
 	spyClient *SpyClient
 	evmClient *EVMClient
 	config      Config
-	vaaProcessor       func(*Relayer, *VAAData) error
+	vaaProcessor       func(context.Context, *Relayer, *VAAData) error
 	logger             *zap.Logger
 	dedupeMu           sync.Mutex
 	inflightVAAs       map[string]struct{}
-	processedVAAs      map[string]time.Time
+	processedVAAs      *dedupeCache
+	dedupePolicy       DedupePolicy
 	dedupeTTL          time.Duration
-	// Dynamic emitter tracking
-	emittersMu         sync.RWMutex
-	registeredEmitters map[string]common.Address // aztecContract -> safeAddress
+	// Module deployments served, each with its own emitter registry
+	tenants          []*Tenant
+	emitters         []*configuredEmitter // Emitters accepted without registration
+	tenantsByChainID map[uint64]map[common.Address]*Tenant
+	// Destination chains; destinations[0] is the primary chain hosting the emitter registry
+	destinations          []*Destination
+	destinationsByChainID map[uint64]*Destination
+	// Unix nanos of the last message received from the spy stream
+	lastVAAReceived atomic.Int64
+	// Spy subscription staleness; cancelSubscription tears down the current stream
+	streamStale        atomic.Bool
+	subscriptionMu     sync.Mutex
+	cancelSubscription context.CancelFunc
+	// Guardian gossip network listener, in place of the spy with VAA_SOURCE=gossip and for
+	// observations to assemble under-signed VAAs from
+	gossip    *GossipListener
+	assembler *observationAssembler
+	// Closed to stop intake and exit once inflight VAAs are done
+	drainCh   chan struct{}
+	drainOnce sync.Once
+	// Submission pause state; resumeCh is closed when submission resumes
+	pauseMu     sync.Mutex
+	paused      bool
+	resumeCh    chan struct{}
+	pausedSince time.Time
+	queuedVAAs  atomic.Int64
+	// Scheduled maintenance windows, and the VAAs waiting one out
+	maintenance  []*maintenanceWindow
+	deferredVAAs atomic.Int64
+	// Deferral of VAAs over their cost threshold, nil when no threshold is set
+	costDeferral *costDeferral
+	// Hardware and remote signers behind the signer accounts
+	signerBackends *signerBackends
+	// Tops up low signer balances from the treasury, if configured
+	refiller *Refiller
+	// Guardian sets read from a destination's Wormhole core
+	guardians guardianSetCache
+	// Wormhole Queries client, if a query server is configured
+	queryClient *QueryClient
+	// Wormhole API client for the startup catch-up, if configured
+	guardianAPI *GuardianAPIClient
+	// Signs relay receipts, if enabled
+	receiptSigner *receiptSigner
+	// Append-only audit log of security-relevant actions, if enabled
+	audit *AuditLog
+	// Admin API credentials checker; nil leaves the admin API open
+	adminAuth *adminAuth
+	// Admin API client allowlist and rate limit; nil lets every request through
+	adminGuard *adminGuard
+	// Pushes metrics to a Pushgateway, if configured
+	metricsPusher *push.Pusher
+	// Label values of per-emitter and per-Safe metrics; nil when they are off
+	addressLabels *addressLabels
+	// Decides which VAAs get sponsored gas; nil when no destination is sponsored
+	sponsorship *sponsorshipPolicy
+	// Hot-standby failover: only the lease holder submits; see ha.go
+	active       atomic.Bool
+	haMu         sync.Mutex
+	lease        Lease     // Last lease read from the store
+	leaseRenewed time.Time // When this instance last renewed the lease
+	standbyVAAs  map[string]standbyVAA
+	activeFuncs  []func(context.Context)
+	activeCtx    context.Context
+	cancelActive context.CancelFunc
+	// Persistent state and the per-emitter sequence checkpoints loaded from it
+	store         StateStore
+	checkpointsMu sync.Mutex
+	checkpoints   map[string]Checkpoint
+	// VAAs waiting for another processing attempt, by dedupe key
+	retriesMu sync.Mutex
+	retries   map[string]RetryEntry
+	// RETRY_MAX_ATTEMPTS and RETRY_BACKOFF, adjustable at runtime
+	retryMaxAttempts atomic.Int64
+	retryBackoff     atomic.Int64
+	// Per-Safe ordering of recovery messages
+	safeOrder safeOrdering
+	// Call flows interrupted after a mined step, resumed on retry
+	flowProgress flowProgressStore
+	// Recently rejected VAAs, newest last
+	rejectionsMu sync.Mutex
+	rejections   []VAARejection
+	// Latest VAA outcomes, for the dashboard
+	recentVAAs vaaOutcomes
+	// Reconciliation against on-chain state; runs hold reconcileRun
+	reconcileRun  sync.Mutex
+	reconcileMu   sync.Mutex
+	lastReconcile *ReconcileReport
+	// Canary self-test: the injected VAA, the emitters whose VAAs are canaries, and the last
+	// outcome per source
+	canaryVAA      []byte
+	canaryEmitters map[string]bool
+	canarySince    time.Time
+	canaryMu       sync.Mutex
+	canary         map[string]*CanaryStatus
+	// Cutover to NEXT_PRIVATE_KEYS; nil when none are configured
+	rotation *keyRotation
+	// Target contracts configured as ENS names; nil when there are none
+	ens *ensTargets
+	// VAAs read from the spy, waiting for a processing worker
+	intake *intakeQueue
 }
 
+// VAARejection records why a VAA was refused instead of being relayed
+type VAARejection struct {
+	Sequence   uint64
+	EmitterHex string
+	TxID       string
+	Reason     string
+	Time       time.Time
+
+	CorrelationID string
+}
+
+// Number of rejections kept in memory
+const maxRecordedRejections = 100
+
 // AztecRecoveryContractSet event signature
 const aztecRecoveryContractSetEventSig = "AztecRecoveryContractSet(address,bytes32)"
 
-// Block number to start scanning for events (deployment block)
+// AztecRecoveryContractRemoved event signature
+const aztecRecoveryContractRemovedEventSig = "AztecRecoveryContractRemoved(address,bytes32)"
+
+// Event signature hashes for the emitter registry events
+var (
+	aztecRecoveryContractSetTopic     = crypto.Keccak256Hash([]byte(aztecRecoveryContractSetEventSig))
+	aztecRecoveryContractRemovedTopic = crypto.Keccak256Hash([]byte(aztecRecoveryContractRemovedEventSig))
+)
+
+// emitterEventTopics matches any event that changes the emitter registry
+var emitterEventTopics = [][]common.Hash{{aztecRecoveryContractSetTopic, aztecRecoveryContractRemovedTopic}}
+
+// Block number to start scanning for events on the primary module (deployment block)
 const emitterScanStartBlock = 9856363
 
 // NewRelayer creates a new relayer instance
 func NewRelayer(config Config) (*Relayer, error) {
+	configured, err := loadChainFinality(config)
+	if err != nil {
+		return nil, err
+	}
+	config.Destinations = configured
+
 	relayer := &Relayer{
 		config:             config,
 		logger:             logger.With(zap.String("component", "Relayer")),
 		inflightVAAs:       make(map[string]struct{}),
-		processedVAAs:      make(map[string]time.Time),
-		dedupeTTL:          15 * time.Minute,
-		registeredEmitters: make(map[string]common.Address),
+		dedupeTTL:          config.DedupeTTL,
+		drainCh:            make(chan struct{}),
+		checkpoints:        make(map[string]Checkpoint),
+		retries:            make(map[string]RetryEntry),
+		standbyVAAs:        make(map[string]standbyVAA),
+	}
+	relayer.active.Store(true)
+	dedupePolicy, err := lookupDedupePolicy(config.DedupePolicy)
+	if err != nil {
+		return nil, err
+	}
+	relayer.dedupePolicy = dedupePolicy
+	relayer.processedVAAs = newDedupeCache(relayer.dedupeTTL, config.DedupeMaxEntries)
+
+	// Connect to the spy service, unless the gossip network is joined in its place at start
+	var spyClient *SpyClient
+	switch config.VAASource {
+	case vaaSourceSpy:
+		if spyClient, err = NewSpyClient(config); err != nil {
+			return nil, fmt.Errorf("failed to create spy client: %v", err)
+		}
+	case vaaSourceGossip:
+	default:
+		return nil, fmt.Errorf("unknown VAA_SOURCE %q (expected %s or %s)", config.VAASource, vaaSourceSpy, vaaSourceGossip)
+	}
+
+	for _, dest := range config.Destinations {
+		if _, err := lookupFeeStrategy(dest.FeeStrategy); err != nil {
+			return nil, fmt.Errorf("destination %q: %v", dest.Name, err)
+		}
+		if _, err := lookupTxType(dest.TxType); err != nil {
+			return nil, fmt.Errorf("destination %q: %v", dest.Name, err)
+		}
+		if _, err := lookupFinality(dest.Finality); err != nil {
+			return nil, fmt.Errorf("destination %q: %v", dest.Name, err)
+		}
+		if _, err := lookupL1FeeModel(dest.L1Fee); err != nil {
+			return nil, fmt.Errorf("destination %q: %v", dest.Name, err)
+		}
+		if _, err := parseGwei(dest.MaxFeePerGas); err != nil {
+			return nil, fmt.Errorf("destination %q: MAX_FEE_PER_GAS: %v", dest.Name, err)
+		}
+		if config.RelayAckEnabled && !common.IsHexAddress(dest.WormholeCore) {
+			return nil, fmt.Errorf("destination %q: relay acknowledgments need a Wormhole core address", dest.Name)
+		}
+		if dest.AttestationLog != "" && !common.IsHexAddress(dest.AttestationLog) {
+			return nil, fmt.Errorf("destination %q: invalid attestation log address %q", dest.Name, dest.AttestationLog)
+		}
+	}
+
+	accounts, backends, err := openSigners(config)
+	if err != nil {
+		spyClient.Close()
+		return nil, err
+	}
+	relayer.signerBackends = backends
+
+	next, err := openNextSigners(config, accounts)
+	if err != nil {
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+	if next != nil {
+		relayer.rotation = &keyRotation{next: next, phase: RotationReady}
 	}
 
-	// Connect to the spy service
-	spyClient, err := NewSpyClient(config.SpyRPCHost)
+	refiller, err := newRefiller(config, accounts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create spy client: %v", err)
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
 	}
+	relayer.refiller = refiller
 
-	// Connect to EVM chain
-	evmClient, err := NewEVMClient(config.EVMRPCURL, config.PrivateKey)
+	intake, err := newIntakeQueue(config)
 	if err != nil {
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+	relayer.intake = intake
+
+	maintenance, err := newMaintenanceWindows(config.MaintenanceWindows, config.Tenants)
+	if err != nil {
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+	relayer.maintenance = maintenance
+
+	costDeferral, err := newCostDeferral(config)
+	if err != nil {
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+	relayer.costDeferral = costDeferral
+	relayer.retryMaxAttempts.Store(int64(config.RetryMaxAttempts))
+	relayer.retryBackoff.Store(int64(config.RetryBackoff))
+
+	// Connect to every destination EVM chain
+	destinations, err := connectDestinations(config, accounts)
+	if err != nil {
+		relayer.Close()
 		spyClient.Close()
 		return nil, fmt.Errorf("failed to create EVM client: %v", err)
 	}
 
+	store, err := OpenStateStore(config)
+	if err != nil {
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+
+	// Target contracts may be ENS names, fixed to the addresses they resolve to now
+	resolveCtx, cancelResolve := context.WithTimeout(context.Background(), time.Minute)
+	resolver, bindings, err := resolveENSTargets(resolveCtx, &config)
+	cancelResolve()
+	if err != nil {
+		store.Close()
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+	if resolver != nil {
+		relayer.ens = &ensTargets{resolver: resolver, bindings: bindings}
+	}
+
+	tenants, err := newTenants(config.Tenants, destinations, store)
+	if err == nil {
+		err = loadCallFlows(config, tenants)
+	}
+	var emitters []*configuredEmitter
+	if err == nil {
+		emitters, err = newConfiguredEmitters(config.Emitters, destinations)
+	}
+	if err != nil {
+		store.Close()
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+
+	relayer.store = store
 	relayer.spyClient = spyClient
-	relayer.evmClient = evmClient
+	relayer.destinations = destinations
+	relayer.evmClient = destinations[0].client
+	relayer.tenants = tenants
+	relayer.emitters = emitters
+	relayer.queryClient, err = newQueryClient(config)
+	if err == nil {
+		relayer.guardianAPI, err = newGuardianAPIClient(config)
+	}
+	if err == nil {
+		relayer.receiptSigner, err = newReceiptSigner(config)
+	}
+	if err == nil {
+		relayer.audit, err = openAuditLog(config.AuditLogFile)
+	}
+	if err == nil {
+		relayer.adminAuth, err = newAdminAuth(config)
+	}
+	if err == nil {
+		relayer.adminGuard, err = newAdminGuard(config)
+	}
+	if err == nil {
+		relayer.metricsPusher, err = newMetricsPusher(config)
+	}
+	if err == nil {
+		relayer.addressLabels, err = newAddressLabels(config)
+	}
+	if err == nil {
+		relayer.sponsorship, err = newSponsorshipPolicy(config)
+	}
+	if err == nil {
+		relayer.canaryVAA, err = decodeCanaryVAA(config)
+	}
+	if err == nil {
+		relayer.canaryEmitters, err = canaryEmitters(config, relayer.canaryVAA)
+	}
+	if err == nil {
+		relayer.assembler, err = newObservationAssembler(config, &relayer.guardians)
+	}
+	relayer.canarySince = time.Now()
+	relayer.canary = make(map[string]*CanaryStatus)
+	if err != nil {
+		store.Close()
+		relayer.Close()
+		spyClient.Close()
+		return nil, err
+	}
+
+	for _, dest := range destinations {
+		dest.client.audit = relayer.audit
+		if dest.sponsor != nil {
+			dest.sponsor.audit = relayer.audit
+		}
+	}
+	if relayer.refiller != nil {
+		relayer.refiller.audit = relayer.audit
+	}
+	relayer.audit.RecordConfig(config)
 
 	if config.vaaProcessor == nil {
 		relayer.vaaProcessor = defaultVAAProcessor
@@ -437,180 +1277,420 @@ func (r *Relayer) Close() {
 	if r.spyClient != nil {
 		r.spyClient.Close()
 	}
+	if r.store != nil {
+		r.store.Close()
+	}
+	if r.signerBackends != nil {
+		r.signerBackends.Close()
+	}
+	if r.ens != nil {
+		r.ens.resolver.Close()
+	}
+	r.audit.Close()
+}
+
+// Drain stops accepting new VAAs from the spy; Start returns once inflight VAAs are confirmed
+func (r *Relayer) Drain() {
+	r.drainOnce.Do(func() {
+		r.logger.Info("Drain requested, no longer accepting new VAAs")
+		r.audit.Record(AuditDrain, nil)
+		close(r.drainCh)
+	})
+}
+
+// Pause halts transaction submission; VAAs keep being observed and wait until Resume
+func (r *Relayer) Pause() {
+	r.pauseMu.Lock()
+	defer r.pauseMu.Unlock()
+
+	if r.paused {
+		return
+	}
+	r.paused = true
+	r.pausedSince = time.Now()
+	r.resumeCh = make(chan struct{})
+	r.logger.Warn("Submission paused")
+	r.audit.Record(AuditPause, nil)
+}
+
+// Resume releases all VAAs queued while paused and restarts submission
+func (r *Relayer) Resume() {
+	r.pauseMu.Lock()
+	defer r.pauseMu.Unlock()
+
+	if !r.paused {
+		return
+	}
+	r.paused = false
+	close(r.resumeCh)
+	r.logger.Info("Submission resumed",
+		zap.Duration("pausedFor", time.Since(r.pausedSince)),
+		zap.Int64("queued", r.queuedVAAs.Load()))
+	r.audit.Record(AuditResume, map[string]string{"pausedFor": time.Since(r.pausedSince).String()})
+}
+
+// PauseState reports whether submission is paused, since when, and how many VAAs are waiting
+func (r *Relayer) PauseState() (bool, time.Time, int64) {
+	r.pauseMu.Lock()
+	defer r.pauseMu.Unlock()
+	return r.paused, r.pausedSince, r.queuedVAAs.Load()
 }
 
-// loadRegisteredEmitters queries the SafeRecoveryModule for AztecRecoveryContractSet events
-func (r *Relayer) loadRegisteredEmitters(ctx context.Context) error {
-	if r.config.EVMTargetContract == "" {
-		r.logger.Warn("No EVM target contract configured, skipping emitter loading")
+// waitUntilResumed blocks a VAA while submission is paused
+func (r *Relayer) waitUntilResumed(ctx context.Context, vaaData *VAAData) error {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	r.pauseMu.Lock()
+	if !r.paused {
+		r.pauseMu.Unlock()
 		return nil
 	}
+	resumeCh := r.resumeCh
+	r.pauseMu.Unlock()
 
-	r.logger.Info("Loading registered Aztec emitters from SafeRecoveryModule",
-		zap.String("contract", r.config.EVMTargetContract),
-		zap.Int64("fromBlock", emitterScanStartBlock))
+	r.queuedVAAs.Add(1)
+	defer r.queuedVAAs.Add(-1)
 
-	// Event signature hash: keccak256("AztecRecoveryContractSet(address,bytes32)")
-	eventSigHash := crypto.Keccak256Hash([]byte(aztecRecoveryContractSetEventSig))
+	log.Info("Submission paused, queueing VAA",
+		zap.Uint64("sequence", vaaData.Sequence),
+		zap.String("sourceTxID", vaaData.TxID))
 
-	// Query logs
-	query := ethereum.FilterQuery{
-		FromBlock: big.NewInt(emitterScanStartBlock),
-		Addresses: []common.Address{common.HexToAddress(r.config.EVMTargetContract)},
-		Topics:    [][]common.Hash{{eventSigHash}},
+	select {
+	case <-resumeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	logs, err := r.evmClient.client.FilterLogs(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to query logs: %v", err)
+// isDraining reports whether Drain has been called
+func (r *Relayer) isDraining() bool {
+	select {
+	case <-r.drainCh:
+		return true
+	default:
+		return false
 	}
+}
+
+// inflightCount returns the number of VAAs currently being processed
+func (r *Relayer) inflightCount() int {
+	r.dedupeMu.Lock()
+	defer r.dedupeMu.Unlock()
+	return len(r.inflightVAAs)
+}
 
-	r.emittersMu.Lock()
-	defer r.emittersMu.Unlock()
+// loadRegisteredEmitters loads the tenant module's emitter registry at the final head by
+// replaying its registry events, resuming after the saved scan cursor when the registry was
+// restored from the state store. The restored Safes are then checked against the module's
+// getAztecRecoveryContract(address) view.
+func (t *Tenant) loadRegisteredEmitters(ctx context.Context) error {
+	// Events are only read up to the final head, so a reorg can't register a phantom emitter
+	head, err := t.dest.client.finalHead(ctx)
+	if err != nil {
+		return err
+	}
 
-	for _, log := range logs {
-		if len(log.Topics) < 2 || len(log.Data) < 32 {
-			continue
+	from := uint64(t.ScanStartBlock)
+	if t.restored {
+		cursor, ok, err := t.store.LoadScanCursor(t.Name)
+		if err != nil {
+			t.logger.Warn("Failed to load registry scan cursor, scanning from the start block", zap.Error(err))
+		} else if ok && cursor >= from {
+			from = t.rescanFrom(cursor)
 		}
+	}
 
-		// Topics[0] = event signature
-		// Topics[1] = indexed safe address (padded to 32 bytes)
-		safeAddress := common.HexToAddress(log.Topics[1].Hex())
+	t.logger.Info("Loading registered Aztec emitters from SafeRecoveryModule",
+		zap.String("contract", t.TargetContract),
+		zap.Uint64("fromBlock", from))
 
-		// Data = aztecContract (bytes32)
-		aztecContract := hex.EncodeToString(log.Data[:32])
+	// Query logs (set and removed events, returned in chain order)
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{t.target},
+		Topics:    emitterEventTopics,
+	}
+	logs, err := t.dest.client.filterLogsPaged(ctx, query, from, head)
+	if err != nil {
+		return fmt.Errorf("failed to query logs: %v", err)
+	}
 
-		r.registeredEmitters[aztecContract] = safeAddress
-		r.logger.Info("Registered emitter",
-			zap.String("aztecContract", aztecContract),
-			zap.String("safeAddress", safeAddress.Hex()))
+	for _, log := range logs {
+		t.handleEmitterEvent(log)
 	}
+	scanned := head
+	if head >= from {
+		t.saveScanCursor(head)
+	} else if from > 0 {
+		scanned = from - 1 // The saved cursor is past the final head
+	}
+	t.scannedTo = int64(scanned)
 
-	r.logger.Info("Loaded registered emitters",
-		zap.Int("count", len(r.registeredEmitters)))
+	// Only a resumed scan can have missed events, e.g. while the relayer was down and reorgs
+	// rewrote blocks it had already scanned
+	if from > uint64(t.ScanStartBlock) {
+		if err := t.verifyRegistry(ctx, head); err != nil {
+			t.logger.Warn("Could not check restored registry against the module", zap.Error(err))
+		}
+	}
 
+	t.logRegistrySize("Loaded registered emitters")
 	return nil
 }
 
-// watchNewEmitters subscribes to new AztecRecoveryContractSet events and adds them dynamically
-func (r *Relayer) watchNewEmitters(ctx context.Context) {
-	if r.config.EVMTargetContract == "" {
-		r.logger.Warn("No EVM target contract configured, skipping emitter watcher")
-		return
-	}
+// logRegistrySize logs msg with the number of registered emitters
+func (t *Tenant) logRegistrySize(msg string) {
+	t.emittersMu.RLock()
+	count := len(t.registeredEmitters)
+	t.emittersMu.RUnlock()
+
+	t.logger.Info(msg, zap.Int("count", count))
+}
 
-	r.logger.Info("Starting emitter watcher for new registrations",
-		zap.String("contract", r.config.EVMTargetContract))
+// watchNewEmitters subscribes to emitter registry events and applies them dynamically,
+// resubscribing in place when the subscription fails
+func (t *Tenant) watchNewEmitters(ctx context.Context) {
+	emitterWatchers.WithLabelValues(t.Name).Inc()
+	defer emitterWatchers.WithLabelValues(t.Name).Dec()
 
-	// Event signature hash: keccak256("AztecRecoveryContractSet(address,bytes32)")
-	eventSigHash := crypto.Keccak256Hash([]byte(aztecRecoveryContractSetEventSig))
+	t.logger.Info("Starting emitter watcher for new registrations",
+		zap.String("contract", t.TargetContract))
 
 	query := ethereum.FilterQuery{
-		Addresses: []common.Address{common.HexToAddress(r.config.EVMTargetContract)},
-		Topics:    [][]common.Hash{{eventSigHash}},
+		Addresses: []common.Address{t.target},
+		Topics:    emitterEventTopics,
 	}
 
-	// Subscribe to new events
-	logs := make(chan types.Log)
-	sub, err := r.evmClient.client.SubscribeFilterLogs(ctx, query, logs)
-	if err != nil {
-		// Fallback to polling if subscription not supported
-		r.logger.Warn("Log subscription not supported, falling back to polling",
-			zap.Error(err))
-		r.pollNewEmitters(ctx)
+	// Subscriptions deliver events at the head; final ones can only be polled for
+	if t.dest.client.finality != FinalityLatest || t.dest.client.confirmations > 0 {
+		t.pollNewEmitters(ctx)
 		return
 	}
-	defer sub.Unsubscribe()
-
-	r.logger.Info("Subscribed to new emitter registrations")
 
 	for {
+		// Subscribe to new events
+		logs := make(chan types.Log)
+		sub, err := t.dest.client.client.SubscribeFilterLogs(ctx, query, logs)
+		if err != nil {
+			// Fallback to polling if subscription not supported
+			t.logger.Warn("Log subscription not supported, falling back to polling",
+				zap.Error(err))
+			t.pollNewEmitters(ctx)
+			return
+		}
+
+		t.logger.Info("Subscribed to new emitter registrations")
+
+		if !t.consumeEmitterEvents(ctx, sub, logs) {
+			return
+		}
+		emitterWatchRestarts.WithLabelValues(t.Name).Inc()
 		select {
 		case <-ctx.Done():
 			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// consumeEmitterEvents applies events from sub until ctx is done, returning false, or the
+// subscription fails, returning true so the caller resubscribes
+func (t *Tenant) consumeEmitterEvents(ctx context.Context, sub ethereum.Subscription, logs <-chan types.Log) bool {
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
 		case err := <-sub.Err():
-			r.logger.Warn("Emitter subscription error, restarting",
+			t.logger.Warn("Emitter subscription error, restarting",
 				zap.Error(err))
-			time.Sleep(5 * time.Second)
-			go r.watchNewEmitters(ctx)
-			return
+			return true
 		case log := <-logs:
-			r.handleNewEmitterEvent(log)
+			t.handleEmitterEvent(log)
 		}
 	}
 }
 
-// pollNewEmitters periodically checks for new emitter registrations (fallback when subscriptions not supported)
-func (r *Relayer) pollNewEmitters(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// handleEmitterEvent applies an AztecRecoveryContractSet or AztecRecoveryContractRemoved event
+func (t *Tenant) handleEmitterEvent(log types.Log) {
+	if len(log.Topics) < 2 {
+		return
+	}
 
-	lastBlock := int64(emitterScanStartBlock)
+	// Topics[0] = event signature
+	// Topics[1] = indexed safe address (padded to 32 bytes)
+	safeAddress := common.HexToAddress(log.Topics[1].Hex())
 
-	for {
-		select {
-		case <-ctx.Done():
+	// Data = aztecContract (bytes32)
+	var aztecContract common.Hash
+	if len(log.Data) >= 32 {
+		copy(aztecContract[:], log.Data[:32])
+	}
+
+	switch log.Topics[0] {
+	case aztecRecoveryContractSetTopic:
+		// A set event dropped by a reorg never happened
+		if log.Removed {
+			t.revertEmitter(safeAddress, hex.EncodeToString(aztecContract[:]), log.BlockNumber)
 			return
-		case <-ticker.C:
-			currentBlock, err := r.evmClient.client.BlockNumber(ctx)
-			if err != nil {
-				r.logger.Warn("Failed to get current block", zap.Error(err))
-				continue
+		}
+		// Setting a zero contract is equivalent to removing it
+		if aztecContract == (common.Hash{}) {
+			t.removeEmitter(safeAddress, log.BlockNumber, "cleared")
+			return
+		}
+		t.setEmitter(safeAddress, hex.EncodeToString(aztecContract[:]), log.BlockNumber)
+	case aztecRecoveryContractRemovedTopic:
+		// A removal dropped by a reorg restores the contract it removed
+		if log.Removed {
+			if aztecContract != (common.Hash{}) {
+				t.setEmitter(safeAddress, hex.EncodeToString(aztecContract[:]), log.BlockNumber)
 			}
+			return
+		}
+		t.removeEmitter(safeAddress, log.BlockNumber, "removed")
+	}
+}
 
-			if int64(currentBlock) <= lastBlock {
-				continue
-			}
+// setEmitter maps aztecContract to safeAddress, replacing any contract the Safe registered before
+func (t *Tenant) setEmitter(safeAddress common.Address, aztecContract string, block uint64) {
+	t.emittersMu.Lock()
+	defer t.emittersMu.Unlock()
 
-			eventSigHash := crypto.Keccak256Hash([]byte(aztecRecoveryContractSetEventSig))
-			query := ethereum.FilterQuery{
-				FromBlock: big.NewInt(lastBlock + 1),
-				ToBlock:   big.NewInt(int64(currentBlock)),
-				Addresses: []common.Address{common.HexToAddress(r.config.EVMTargetContract)},
-				Topics:    [][]common.Hash{{eventSigHash}},
-			}
+	previous, hadPrevious := t.safeEmitters[safeAddress]
+	if hadPrevious && previous == aztecContract {
+		return
+	}
+	// The previous contract may have been registered by another Safe since
+	if hadPrevious && t.registeredEmitters[previous] == safeAddress {
+		delete(t.registeredEmitters, previous)
+	}
+	if hadPrevious {
+		t.replacedEmitters[safeAddress] = previous
+	} else {
+		delete(t.replacedEmitters, safeAddress)
+	}
 
-			logs, err := r.evmClient.client.FilterLogs(ctx, query)
-			if err != nil {
-				r.logger.Warn("Failed to poll for new emitters", zap.Error(err))
-				continue
-			}
+	t.registeredEmitters[aztecContract] = safeAddress
+	t.safeEmitters[safeAddress] = aztecContract
+	t.updateEmitterGauge()
 
-			for _, log := range logs {
-				r.handleNewEmitterEvent(log)
-			}
+	if err := t.store.SaveEmitter(t.Name, safeAddress, aztecContract); err != nil {
+		t.logger.Error("Failed to persist emitter",
+			zap.String("safeAddress", safeAddress.Hex()),
+			zap.Error(err))
+	}
 
-			lastBlock = int64(currentBlock)
-		}
+	if hadPrevious {
+		t.logger.Info("Emitter updated",
+			zap.String("aztecContract", aztecContract),
+			zap.String("previousAztecContract", previous),
+			zap.String("safeAddress", safeAddress.Hex()),
+			zap.Uint64("block", block))
+		return
 	}
+	t.logger.Info("Registered emitter",
+		zap.String("aztecContract", aztecContract),
+		zap.String("safeAddress", safeAddress.Hex()),
+		zap.Uint64("block", block))
 }
 
-// handleNewEmitterEvent processes a new AztecRecoveryContractSet event
-func (r *Relayer) handleNewEmitterEvent(log types.Log) {
-	if len(log.Topics) < 2 || len(log.Data) < 32 {
+// revertEmitter undoes a set event dropped by a reorg. The Safe's mapping is only touched
+// while it still points at the event's contract; the contract the event replaced is restored
+// unless another Safe has registered it since, otherwise the Safe is deregistered.
+func (t *Tenant) revertEmitter(safeAddress common.Address, aztecContract string, block uint64) {
+	t.emittersMu.RLock()
+	current, exists := t.safeEmitters[safeAddress]
+	previous, replaced := t.replacedEmitters[safeAddress]
+	owner, taken := t.registeredEmitters[previous]
+	t.emittersMu.RUnlock()
+
+	if !exists || current != aztecContract {
 		return
 	}
+	if replaced && (!taken || owner == safeAddress) {
+		t.setEmitter(safeAddress, previous, block)
+		t.emittersMu.Lock()
+		delete(t.replacedEmitters, safeAddress)
+		t.emittersMu.Unlock()
+		return
+	}
+	t.removeEmitter(safeAddress, block, "reorg")
+}
 
-	safeAddress := common.HexToAddress(log.Topics[1].Hex())
-	aztecContract := hex.EncodeToString(log.Data[:32])
-
-	r.emittersMu.Lock()
-	defer r.emittersMu.Unlock()
+// removeEmitter drops the contract registered by safeAddress so its VAAs are no longer relayed
+func (t *Tenant) removeEmitter(safeAddress common.Address, block uint64, reason string) {
+	t.emittersMu.Lock()
+	defer t.emittersMu.Unlock()
 
-	// Check if already registered
-	if _, exists := r.registeredEmitters[aztecContract]; exists {
+	aztecContract, exists := t.safeEmitters[safeAddress]
+	if !exists {
 		return
 	}
 
-	r.registeredEmitters[aztecContract] = safeAddress
-	r.logger.Info("New emitter registered dynamically",
+	delete(t.safeEmitters, safeAddress)
+	delete(t.replacedEmitters, safeAddress)
+	if t.registeredEmitters[aztecContract] == safeAddress {
+		delete(t.registeredEmitters, aztecContract)
+	}
+	t.updateEmitterGauge()
+
+	if err := t.store.RemoveEmitter(t.Name, safeAddress); err != nil {
+		t.logger.Error("Failed to remove persisted emitter",
+			zap.String("safeAddress", safeAddress.Hex()),
+			zap.Error(err))
+	}
+
+	t.logger.Info("Emitter deregistered",
 		zap.String("aztecContract", aztecContract),
 		zap.String("safeAddress", safeAddress.Hex()),
-		zap.Uint64("block", log.BlockNumber))
+		zap.String("reason", reason),
+		zap.Uint64("block", block))
+}
+
+// loadStoredEmitters seeds the registry from the state store so known emitters are accepted
+// even if the on-chain scan fails; the scan then brings it up to date
+func (t *Tenant) loadStoredEmitters() error {
+	emitters, err := t.store.LoadEmitters(t.Name)
+	if err != nil {
+		return err
+	}
+
+	t.emittersMu.Lock()
+	for safeAddress, aztecContract := range emitters {
+		t.registeredEmitters[aztecContract] = safeAddress
+		t.safeEmitters[safeAddress] = aztecContract
+	}
+	t.updateEmitterGauge()
+	t.emittersMu.Unlock()
+	t.restored = true
+
+	t.logger.Info("Restored emitter registry from state store", zap.Int("count", len(emitters)))
+	return nil
 }
 
-// isRegisteredEmitter checks if the given emitter address is registered
-func (r *Relayer) isRegisteredEmitter(emitterHex string) (bool, common.Address) {
+// lookupEmitter returns the Safe that registered the emitter with the tenant's module, or the
+// one an operator override maps it to; a disabled emitter is not found
+func (t *Tenant) lookupEmitter(normalizedEmitter, decodedEmitter string) (common.Address, bool) {
+	t.emittersMu.RLock()
+	defer t.emittersMu.RUnlock()
+
+	if override, ok := t.overrides[normalizedEmitter]; ok {
+		return override.Safe, !override.Disabled
+	}
+	for aztecContract, safeAddr := range t.registeredEmitters {
+		normalizedRegistered := strings.ToLower(strings.TrimLeft(aztecContract, "0"))
+		if normalizedEmitter == normalizedRegistered || decodedEmitter == aztecContract {
+			return safeAddr, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// decodeEmitter returns the emitter normalized for comparison (no leading zeros, lowercase)
+// and decoded from hex-encoded ASCII where it is one
+func (r *Relayer) decodeEmitter(emitterHex string) (string, string) {
 	// The VAA emitter might be hex-encoded ASCII, try to decode it
 	decodedEmitter := emitterHex
 	if decoded, err := hex.DecodeString(emitterHex); err == nil {
@@ -630,142 +1710,330 @@ func (r *Relayer) isRegisteredEmitter(emitterHex string) (bool, common.Address)
 		}
 	}
 
-	// Normalize the emitter address (remove leading zeros, lowercase)
-	normalizedEmitter := strings.ToLower(strings.TrimLeft(decodedEmitter, "0"))
+	return strings.ToLower(strings.TrimLeft(decodedEmitter, "0")), decodedEmitter
+}
 
-	// First check if it matches the configured Wormhole emitter (accepts all messages from Wormhole)
-	if r.config.EmitterAddress != "" {
-		normalizedConfigEmitter := strings.ToLower(strings.TrimLeft(r.config.EmitterAddress, "0"))
-		if normalizedEmitter == normalizedConfigEmitter || decodedEmitter == r.config.EmitterAddress {
-			r.logger.Debug("Emitter matches configured Wormhole emitter",
-				zap.String("emitter", decodedEmitter))
-			// Return true with zero address - we'll parse the Safe address from payload
-			return true, common.Address{}
+// isRegisteredEmitter checks if the emitter is configured or registered with any tenant
+func (r *Relayer) isRegisteredEmitter(emitterHex string) bool {
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(emitterHex)
+	if _, ok := r.configuredEmitter(normalizedEmitter, decodedEmitter); ok {
+		return true
+	}
+	for _, tenant := range r.tenants {
+		if _, ok := tenant.lookupEmitter(normalizedEmitter, decodedEmitter); ok {
+			return true
 		}
 	}
+	return false
+}
 
-	r.emittersMu.RLock()
-	defer r.emittersMu.RUnlock()
-
-	for aztecContract, safeAddr := range r.registeredEmitters {
-		normalizedRegistered := strings.ToLower(strings.TrimLeft(aztecContract, "0"))
-		if normalizedEmitter == normalizedRegistered || decodedEmitter == aztecContract {
-			return true, safeAddr
-		}
+// registeredSafe returns the Safe that registered the emitter with the tenant. A configured
+// Wormhole emitter routed to the tenant's destination matches with a zero address; the Safe
+// then comes from the payload alone. An operator override of the emitter comes first.
+func (r *Relayer) registeredSafe(tenant *Tenant, emitterHex string) (common.Address, bool) {
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(emitterHex)
+	if override, ok := tenant.emitterOverride(normalizedEmitter); ok {
+		return override.Safe, !override.Disabled
+	}
+	if emitter, ok := r.configuredEmitter(normalizedEmitter, decodedEmitter); ok {
+		return common.Address{}, emitter.routesTo(tenant.Destination)
 	}
-	return false, common.Address{}
+	return tenant.lookupEmitter(normalizedEmitter, decodedEmitter)
 }
 
 // Start begins listening for VAAs and processing them
 func (r *Relayer) Start(ctx context.Context) error {
 	r.logger.Info("Starting Aztec->EVM relayer",
 		zap.String("evmAddress", r.evmClient.GetAddress().Hex()),
+		zap.Int("signers", len(r.evmClient.GetAddresses())),
 		zap.Uint16("sourceChain", r.config.SourceChainID),
 		zap.String("evmTarget", r.config.EVMTargetContract))
 
-	// Load registered emitters from SafeRecoveryModule
-	if err := r.loadRegisteredEmitters(ctx); err != nil {
-		r.logger.Warn("Failed to load registered emitters", zap.Error(err))
+	// Payloads are routed by the EVM chain ID and module they carry
+	if err := r.resolveDestinationChainIDs(ctx); err != nil {
+		return err
+	}
+	if err := r.indexTenants(); err != nil {
+		return err
+	}
+	if err := r.validateFailover(); err != nil {
+		return err
+	}
+	if len(r.tenants) == 0 {
+		r.logger.Warn("No target contract configured, no emitters will be loaded")
+	}
+
+	// Restore dedupe entries, the emitter registry, checkpoints, retries and interrupted VAAs
+	// from the state store
+	if err := r.loadProcessedVAAs(); err != nil {
+		return fmt.Errorf("failed to load dedupe entries: %v", err)
+	}
+	for _, tenant := range r.tenants {
+		if err := tenant.loadStoredEmitters(); err != nil {
+			return fmt.Errorf("failed to load stored emitters for tenant %q: %v", tenant.Name, err)
+		}
+		if err := tenant.loadEmitterOverrides(); err != nil {
+			return fmt.Errorf("failed to load emitter overrides for tenant %q: %v", tenant.Name, err)
+		}
+	}
+	if err := r.loadCheckpoints(); err != nil {
+		return fmt.Errorf("failed to load checkpoints: %v", err)
+	}
+	if err := r.loadRetries(); err != nil {
+		return fmt.Errorf("failed to load retry queue: %v", err)
+	}
+
+	// With failover, only the lease holder re-drives interrupted VAAs; a standby takes them
+	// over when it is promoted
+	if err := r.claimInitialLease(); err != nil {
+		return err
+	}
+	if r.isActive() {
+		if err := r.recoverInflight(); err != nil {
+			return fmt.Errorf("failed to recover inflight VAAs: %v", err)
+		}
+	}
+	if r.failoverEnabled() {
+		go r.runFailover(ctx)
+	}
+
+	// Load each tenant's registered emitters from its SafeRecoveryModule and keep watching
+	// for new registrations in the background
+	for _, tenant := range r.tenants {
+		if err := tenant.loadRegisteredEmitters(ctx); err != nil {
+			tenant.logger.Warn("Failed to load registered emitters", zap.Error(err))
+		}
+		go tenant.watchNewEmitters(ctx)
+	}
+
+	// Join the guardian gossip network before anything can report on it
+	if r.config.VAASource == vaaSourceGossip || r.assembler.uses(observationSourceGossip) {
+		gossip, err := NewGossipListener(r.config)
+		if err != nil {
+			pipelineErrors.WithLabelValues(string(ErrorKindSpy)).Inc()
+			return pipelineError(ErrorKindSpy, fmt.Errorf("join gossip network: %v", err))
+		}
+		defer gossip.Close()
+		r.gossip = gossip
+	}
+	if r.guardianCoreDestination() == nil || r.config.GuardianSetRefresh <= 0 {
+		if r.config.VAASource == vaaSourceGossip {
+			r.logger.Warn("No guardian set tracked, gossiped VAAs are not signature-checked before intake")
+		}
+		if r.assembler != nil {
+			r.logger.Warn("No guardian set tracked, OBSERVATION_ASSEMBLY has no effect")
+		}
 	}
 
-	// Start watching for new emitter registrations in the background
-	go r.watchNewEmitters(ctx)
+	// Serve the admin API if enabled
+	if r.config.AdminListenAddr != "" {
+		NewAdminServer(r.config.AdminListenAddr, r).Start(ctx)
+	} else if r.config.EnablePprof {
+		r.logger.Warn("ENABLE_PPROF is set but ADMIN_LISTEN_ADDR is empty, pprof not exposed")
+	}
+	if r.config.AdminGRPCListenAddr != "" {
+		NewAdminGRPCServer(r.config.AdminGRPCListenAddr, r).Start(ctx)
+	}
 
 	var wg sync.WaitGroup
 
-	stream, err := r.spyClient.SubscribeSignedVAA(ctx)
+	// The stream has its own context so a drain can interrupt a blocked Recv
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	defer cancelStream()
+	go func() {
+		select {
+		case <-r.drainCh:
+			cancelStream()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	stream, err := r.subscribeVAAs(streamCtx)
 	if err != nil {
-		return fmt.Errorf("subscribe to VAA stream: %v", err)
+		pipelineErrors.WithLabelValues(string(ErrorKindSpy)).Inc()
+		return pipelineError(ErrorKindSpy, fmt.Errorf("subscribe to VAA stream: %v", err))
 	}
 
 	r.logger.Info("Listening for VAAs")
+	go r.watchStreamStaleness(streamCtx)
+
+	// Hold submissions while a destination RPC's head block stops advancing
+	for _, dest := range r.destinations {
+		go r.watchRPCStall(ctx, dest)
+		go r.trackPendingTxs(ctx, dest)
+	}
+	go r.watchKeyRotation(ctx)
+	go r.watchENSTargets(ctx)
+	go r.watchMaintenanceWindows(ctx)
+	if r.refiller != nil {
+		r.whileActive(ctx, func(ctx context.Context) { r.refiller.run(ctx, r.destinations) })
+	}
+	if dest := r.guardianCoreDestination(); dest != nil && r.config.GuardianSetRefresh > 0 {
+		go r.watchGuardianSet(ctx, dest)
+	}
+
+	// Tell systemd we're up and start pinging its watchdog while the pipeline is alive
+	r.notifySystemd("READY=1")
+	go r.runWatchdog(ctx)
+	go r.sampleQueueDepths(ctx)
+	go r.expireDedupe(ctx)
+	if r.config.ReconcileInterval > 0 {
+		go r.runReconciliation(ctx)
+	}
+	if r.canaryVAA != nil && r.config.CanaryInterval > 0 {
+		go r.runCanary(ctx)
+	}
+	if r.metricsPusher != nil && r.config.PushgatewayInterval > 0 {
+		// Its last push, after everything else has stopped, is waited for
+		pushCtx, stopPush := context.WithCancel(context.Background())
+		pushDone := make(chan struct{})
+		go func() {
+			defer close(pushDone)
+			r.runMetricsPush(pushCtx)
+		}()
+		defer func() {
+			stopPush()
+			<-pushDone
+		}()
+	}
 
 	processingCtx, cancelProcessing := context.WithCancel(context.Background())
 	defer cancelProcessing()
 
+	// Failed VAAs are retried until intake stops
+	wg.Add(1)
+	go r.runRetryQueue(streamCtx, processingCtx, &wg)
+
+	// VAAs from the spy wait in the bounded intake queue for a worker
+	if err := r.startIntake(processingCtx, &wg); err != nil {
+		cancelStream()
+		cancelProcessing()
+		wg.Wait()
+		return err
+	}
+	if r.assembler != nil {
+		r.startAssembly(streamCtx)
+	}
+
+	// VAAs published while the relayer was down come first; the live stream is already
+	// subscribed, so none published meanwhile are missed
+	r.catchUp(streamCtx, processingCtx, &wg)
+
 	for {
 		select {
 		case <-ctx.Done():
 			r.logger.Info("Shutting down relayer")
+			r.notifySystemd("STOPPING=1")
 			cancelProcessing()
 			r.logger.Info("Waiting for all VAA processing to complete")
 			wg.Wait()
+			r.flushIntake()
 			r.logger.Info("Shutdown complete")
 			return nil
+		case <-r.drainCh:
+			// Unlike shutdown, processing is not cancelled: inflight VAAs run to confirmation
+			r.notifySystemd("STOPPING=1")
+			r.logger.Info("Draining, waiting for inflight VAAs to confirm",
+				zap.Int("inflight", r.inflightCount()))
+			r.intake.close()
+			wg.Wait()
+			r.logger.Info("Drain complete")
+			return nil
 		default:
 			resp, err := stream.Recv()
 			if err != nil {
+				if r.isDraining() {
+					continue
+				}
 				r.logger.Warn("Stream error, retrying in 5s", zap.Error(err))
+				pipelineErrors.WithLabelValues(string(ErrorKindSpy)).Inc()
 				time.Sleep(5 * time.Second)
-				stream, err = r.spyClient.SubscribeSignedVAA(ctx)
+				stream, err = r.subscribeVAAs(streamCtx)
 				if err != nil {
+					cancelStream()
 					cancelProcessing()
 					wg.Wait()
-					return fmt.Errorf("subscribe to VAA stream after retry: %v", err)
+					r.flushIntake()
+					return pipelineError(ErrorKindSpy, fmt.Errorf("subscribe to VAA stream after retry: %v", err))
 				}
 				continue
 			}
+			r.markVAAReceived()
+
+			// VAAs signed by too few guardians wait for observations to complete them
+			if r.assembler != nil && r.holdPartialVAA(streamCtx, resp.VaaBytes) {
+				continue
+			}
 
-			key := computeVAAKey(resp.VaaBytes)
+			key := r.dedupeKey(resp.VaaBytes)
 			if !r.beginProcessingVAA(key) {
 				r.logger.Debug("Skipping duplicate VAA", zap.String("vaaHash", key))
 				continue
 			}
 
-			wg.Add(1)
-			go func(vaaBytes []byte, dedupeKey string) {
-				defer wg.Done()
-				if err := r.processVAA(processingCtx, vaaBytes); err != nil {
-					r.finishProcessingVAA(dedupeKey, false)
-				} else {
-					r.finishProcessingVAA(dedupeKey, true)
-				}
-			}(resp.VaaBytes, key)
+			correlationID := newCorrelationID()
+			r.logger.Debug("Received VAA",
+				zap.String("vaaHash", key),
+				zap.String("correlationId", correlationID))
+
+			item := intakeItem{VAABytes: resp.VaaBytes, Key: key, CorrelationID: correlationID}
+			if !r.intake.enqueue(streamCtx, item) {
+				r.finishProcessingVAA(key, false)
+			}
 		}
 	}
 }
 
 func (r *Relayer) processVAA(ctx context.Context, vaaBytes []byte) error {
+	correlationID := correlationIDFrom(ctx)
+	log := withCorrelation(r.logger, correlationID)
+
 	select {
 	case <-ctx.Done():
-		r.logger.Debug("Processing cancelled for VAA")
+		log.Debug("Processing cancelled for VAA")
 		return ctx.Err()
 	default:
 	}
 
-	wormholeVAA, err := vaaLib.Unmarshal(vaaBytes)
-	if err != nil {
-		r.logger.Error("Failed to parse VAA", zap.Error(err))
-		return err
-	}
-
-	txID := ""
-	if len(wormholeVAA.Payload) >= 32 {
-		txIDBytes := wormholeVAA.Payload[:32]
-		txID = fmt.Sprintf("0x%x", txIDBytes)
-		r.logger.Debug("Extracted txID from payload", zap.String("txID", txID))
+	if isBatchVAA(vaaBytes) {
+		return r.processBatchVAA(ctx, vaaBytes)
 	}
 
-	vaaData := &VAAData{
-		VAA:        wormholeVAA,
-		RawBytes:   vaaBytes,
-		ChainID:    uint16(wormholeVAA.EmitterChain),
-		EmitterHex: fmt.Sprintf("%064x", wormholeVAA.EmitterAddress),
-		Sequence:   wormholeVAA.Sequence,
-		TxID:       txID,
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		log.Error("Failed to parse VAA", zap.Error(err))
+		incWithExemplar(pipelineErrors.WithLabelValues(string(ErrorKindDecode)), correlationID)
+		return pipelineError(ErrorKindDecode, err)
 	}
+	vaaData.CorrelationID = correlationID
 
-	r.logger.Debug("Processing VAA",
+	log.Debug("Processing VAA",
 		zap.Uint16("chain", vaaData.ChainID),
 		zap.Uint64("sequence", vaaData.Sequence),
 		zap.String("emitter", vaaData.EmitterHex),
 		zap.String("sourceTxID", vaaData.TxID))
 
-	if err := r.vaaProcessor(r, vaaData); err != nil {
-		r.logger.Error("Error processing VAA", zap.Error(err))
+	if err := r.vaaProcessor(ctx, r, vaaData); err != nil {
+		// A call flow wait isn't a failure; the retry queue picks the VAA up again
+		var wait *flowWaitError
+		if errors.As(err, &wait) {
+			return err
+		}
+		kind := errorKindOf(err)
+		log.Error("Error processing VAA", zap.String("errorKind", string(kind)), zap.Error(err))
+		incWithExemplar(tenantVAAs.WithLabelValues(tenantLabel(vaaData), "failed"), correlationID)
+		r.countEmitterVAA(vaaData, "failed")
+		r.recentVAAs.record(vaaData, "failed", string(kind))
+		incWithExemplar(pipelineErrors.WithLabelValues(string(kind)), correlationID)
 		return err
 	}
 
+	// The VAA was finally handled (relayed, rejected or skipped), so the emitter's
+	// stream has advanced past it
+	if vaaData.ChainID == r.config.SourceChainID {
+		r.advanceCheckpoint(vaaData)
+	}
+
 	return nil
 }
 
@@ -773,11 +2041,8 @@ func (r *Relayer) beginProcessingVAA(key string) bool {
 	r.dedupeMu.Lock()
 	defer r.dedupeMu.Unlock()
 
-	if ts, ok := r.processedVAAs[key]; ok {
-		if time.Since(ts) < r.dedupeTTL {
-			return false
-		}
-		delete(r.processedVAAs, key)
+	if r.processedVAAs.seen(key, time.Now()) {
+		return false
 	}
 
 	if _, ok := r.inflightVAAs[key]; ok {
@@ -795,28 +2060,44 @@ func (r *Relayer) finishProcessingVAA(key string, success bool) {
 	delete(r.inflightVAAs, key)
 
 	if success {
-		r.processedVAAs[key] = time.Now()
+		now := time.Now()
+		r.processedVAAs.add(key, now)
+		if err := r.store.MarkProcessed(key, now); err != nil {
+			r.logger.Error("Failed to persist dedupe entry", zap.String("vaaHash", key), zap.Error(err))
+		}
 	}
+}
 
-	cutoff := time.Now().Add(-r.dedupeTTL)
-	for k, ts := range r.processedVAAs {
-		if ts.Before(cutoff) {
-			delete(r.processedVAAs, k)
-		}
+// loadProcessedVAAs restores dedupe entries still within the TTL, so VAAs handled just before
+// a restart are not relayed again
+func (r *Relayer) loadProcessedVAAs() error {
+	processed, err := r.store.LoadProcessed(time.Now().Add(-r.dedupeTTL))
+	if err != nil {
+		return err
 	}
+
+	r.dedupeMu.Lock()
+	r.processedVAAs.addAll(processed)
+	r.dedupeMu.Unlock()
+
+	r.logger.Debug("Restored dedupe entries", zap.Int("count", len(processed)))
+	return nil
 }
 
-func computeVAAKey(vaaBytes []byte) string {
-	hash := sha256.Sum256(vaaBytes)
-	return hex.EncodeToString(hash[:])
+// parseVAAData parses a signed VAA and extracts the fields used for routing and logging
+func parseVAAData(vaaBytes []byte) (*VAAData, error) {
+	parsed, err := sdk.ParseVAA(vaaBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &VAAData{VAAData: *parsed}, nil
 }
 
 // defaultVAAProcessor routes VAAs between Aztec and EVM chains
-func defaultVAAProcessor(r *Relayer, vaaData *VAAData) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+func defaultVAAProcessor(ctx context.Context, r *Relayer, vaaData *VAAData) error {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
 
-	r.logger.Debug("VAA Details",
+	log.Debug("VAA Details",
 		zap.Uint16("emitterChain", vaaData.ChainID),
 		zap.String("emitterAddress", vaaData.EmitterHex),
 		zap.Uint64("sequence", vaaData.Sequence),
@@ -824,10 +2105,10 @@ func defaultVAAProcessor(r *Relayer, vaaData *VAAData) error {
 		zap.Int("payloadLength", len(vaaData.VAA.Payload)),
 		zap.String("sourceTxID", vaaData.TxID))
 
-	r.logger.Debug("VAA Payload", zap.String("payloadHex", fmt.Sprintf("%x", vaaData.VAA.Payload)))
+	log.Debug("VAA Payload", zap.String("payloadHex", fmt.Sprintf("%x", vaaData.VAA.Payload)))
 
 	if len(vaaData.VAA.Payload) >= 32 {
-		r.parseAndLogPayload(vaaData.VAA.Payload)
+		parseAndLogPayload(log, vaaData.VAA.Payload)
 	}
 
 	var txHash string
@@ -836,100 +2117,310 @@ func defaultVAAProcessor(r *Relayer, vaaData *VAAData) error {
 
 	// Only process VAAs from Aztec (source chain) -> send to EVM
 	if vaaData.ChainID != r.config.SourceChainID {
-		r.logger.Debug("Skipping VAA (not from Aztec)",
+		log.Debug("Skipping VAA (not from Aztec)",
 			zap.Uint64("sequence", vaaData.Sequence),
 			zap.Uint16("chain", vaaData.ChainID))
 		return nil
 	}
 
-	// Check if emitter is registered in SafeRecoveryModule (unless AcceptAnyEmitter is set)
-	var safeAddr common.Address
+	// Canary VAAs exercise the pipeline up to simulation and are never relayed
+	if r.isCanary(vaaData) {
+		r.handleCanary(ctx, vaaData)
+		return nil
+	}
+
+	// Check if emitter is registered with any SafeRecoveryModule (unless AcceptAnyEmitter is set)
 	if r.config.AcceptAnyEmitter {
-		r.logger.Info("Accepting VAA from any emitter (AcceptAnyEmitter=true)",
+		log.Info("Accepting VAA from any emitter (AcceptAnyEmitter=true)",
 			zap.Uint64("sequence", vaaData.Sequence),
 			zap.String("emitter", vaaData.EmitterHex))
-	} else {
-		isRegistered, registeredSafeAddr := r.isRegisteredEmitter(vaaData.EmitterHex)
-		if !isRegistered {
-			r.logger.Debug("Skipping VAA (emitter not registered)",
-				zap.Uint64("sequence", vaaData.Sequence),
-				zap.String("emitter", vaaData.EmitterHex))
+	} else if !r.isRegisteredEmitter(vaaData.EmitterHex) {
+		log.Debug("Skipping VAA (emitter not registered)",
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("emitter", vaaData.EmitterHex))
+		return nil
+	}
+
+	// Check the signatures locally, so a forged or stale VAA doesn't cost a reverted transaction
+	if err := r.verifyGuardianSignatures(ctx, vaaData); err != nil {
+		r.recordRejection(vaaData, err.Error())
+		return nil
+	}
+
+	dest, tenant, err := r.routeVAA(vaaData)
+	if err != nil {
+		r.recordRejection(vaaData, err.Error())
+		return nil
+	}
+	payload := vaaData.Payload
+	vaaData.Priority = r.isPriorityVAA(vaaData)
+	if vaaData.Priority {
+		ctx = withPriority(ctx)
+	}
+
+	direction = "Aztec->EVM"
+	r.audit.Record(AuditVAAAccepted, map[string]string{
+		"vaaHash":       computeVAAKey(vaaData.RawBytes),
+		"emitter":       vaaData.EmitterHex,
+		"sequence":      fmt.Sprint(vaaData.Sequence),
+		"tenant":        tenant.Name,
+		"safe":          payload.Safe.Hex(),
+		"correlationId": vaaData.CorrelationID,
+	})
+
+	// Flag (and optionally hold) VAAs whose timestamp doesn't line up with the clocks
+	if err := r.checkVAATimestamp(ctx, dest, vaaData); err != nil {
+		return err
+	}
+
+	// Send one transaction per Safe at a time, in sequence order
+	releaseSafe, err := r.acquireSafe(ctx, dest, vaaData)
+	if errors.Is(err, errSafeSuperseded) {
+		r.recordRejection(vaaData, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	executed := false
+	defer func() { releaseSafe(executed) }()
+
+	// Hold the VAA while an operator has submission paused
+	if err := r.waitUntilResumed(ctx, vaaData); err != nil {
+		return fmt.Errorf("interrupted while paused: %v", err)
+	}
+	// ...or a maintenance window of the tenant's module is open
+	if err := r.waitOutMaintenance(ctx, vaaData); err != nil {
+		return fmt.Errorf("interrupted during maintenance window: %v", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, r.config.SendTimeout)
+	defer cancel()
+
+	// Don't pile onto an RPC that is known to be down
+	if state, _ := dest.client.breaker.State(); state == circuitOpen {
+		log.Info("EVM RPC circuit open, queueing VAA",
+			zap.Uint64("sequence", vaaData.Sequence),
+			zap.String("destination", dest.Name))
+	}
+	if err := dest.client.breaker.Wait(ctx); err != nil {
+		return err
+	}
+
+	// Skip a VAA the Safe's current state would make revert, instead of paying for it
+	if r.config.SafePreflight {
+		if err := r.preflightSafe(sendCtx, dest, tenant, vaaData); errors.Is(err, errPreflightFailed) {
+			r.forgetSafeMessage(r.dedupeKey(vaaData.RawBytes))
+			r.recordRejection(vaaData, err.Error())
 			return nil
+		} else if err != nil {
+			return pipelineError(ErrorKindRPC, fmt.Errorf("Safe preflight: %v", err))
 		}
-		safeAddr = registeredSafeAddr
 	}
 
-	direction = "Aztec->EVM"
+	strategy := r.feeStrategyFor(dest, payload)
+	if vaaData.Priority {
+		strategy = feeStrategies[FeeStrategyUrgent]
+	}
 
-	r.logger.Info("Processing VAA from Aztec to EVM",
+	log.Info("Processing VAA from Aztec to EVM",
 		zap.Uint64("sequence", vaaData.Sequence),
+		zap.Bool("priority", vaaData.Priority),
 		zap.String("sourceTxID", vaaData.TxID),
-		zap.String("safeAddress", safeAddr.Hex()),
+		zap.String("safeAddress", payload.Safe.Hex()),
+		zap.String("destination", dest.Name),
+		zap.String("feeStrategy", strategy.Name),
 		zap.String("emitter", vaaData.EmitterHex))
 
-	txHash, err = r.evmClient.SendVerifyTransaction(ctx, r.config.EVMTargetContract, vaaData.RawBytes)
-
-	if err != nil {
-		if ctx.Err() != nil {
-			r.logger.Warn("Transaction sending cancelled or timed out", zap.Error(ctx.Err()))
-			return fmt.Errorf("transaction interrupted: %v", ctx.Err())
-		}
-
-		r.logger.Error("Failed to send verify transaction",
-			zap.String("direction", direction),
+	receipt, err := r.runCallFlow(r.withCostThreshold(ctx, vaaData), dest, tenant, vaaData, strategy)
+	if errors.Is(err, errVAAConsumed) {
+		// The interrupted attempt got through; there is nothing left to send
+		executed = true
+		incWithExemplar(tenantVAAs.WithLabelValues(tenant.Name, "relayed"), vaaData.CorrelationID)
+		r.countEmitterVAA(vaaData, "relayed")
+		r.recentVAAs.record(vaaData, "relayed", "already consumed")
+		log.Info("VAA was consumed by an earlier submission, counting it as relayed",
+			zap.String("destination", dest.Name),
 			zap.Uint64("sequence", vaaData.Sequence),
-			zap.String("sourceTxID", vaaData.TxID),
-			zap.Error(err))
-		return fmt.Errorf("transaction failed: %v", err)
+			zap.String("sourceTxID", vaaData.TxID))
+		return nil
+	}
+	if err != nil {
+		return err
 	}
+	txHash = receipt.TxHash.Hex()
+
+	executed = true
+	incWithExemplar(tenantVAAs.WithLabelValues(tenant.Name, "relayed"), vaaData.CorrelationID)
+	r.countEmitterVAA(vaaData, "relayed")
+	r.countSafeRelay(vaaData, payload.Safe.Hex())
+	r.recentVAAs.record(vaaData, "relayed", txHash)
 
-	r.logger.Info("VAA verification completed",
+	log.Info("VAA verification completed",
 		zap.String("direction", direction),
+		zap.String("destination", dest.Name),
 		zap.Uint64("sequence", vaaData.Sequence),
 		zap.String("txHash", txHash),
+		zap.Uint64("block", receipt.BlockNumber.Uint64()),
+		zap.Uint64("gasUsed", receipt.GasUsed),
 		zap.String("sourceTxID", vaaData.TxID))
 
+	if r.config.RelayAckEnabled {
+		ackCtx, cancelAck := context.WithTimeout(ctx, r.config.SendTimeout)
+		defer cancelAck()
+		r.publishRelayAck(ackCtx, dest, vaaData, receipt.TxHash)
+	}
+	if dest.AttestationLog != "" {
+		attestCtx, cancelAttest := context.WithTimeout(ctx, r.config.SendTimeout)
+		defer cancelAttest()
+		r.writeAttestation(attestCtx, dest, vaaData)
+	}
+
 	return nil
 }
 
-// parseAndLogPayload parses and logs payload structure
-func (r *Relayer) parseAndLogPayload(payload []byte) {
-	const txIDOffset = 32
-	const arraySize = 31
+// routeVAA decodes the recovery payload and resolves the destination and tenant it is
+// addressed to, checking it against the tenant's emitter registry. The error explains why the
+// VAA must be rejected.
+func (r *Relayer) routeVAA(vaaData *VAAData) (*Destination, *Tenant, error) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	// Cheap bound before any decoding; the exact size per version is checked by Validate
+	if r.config.MaxPayloadSize > 0 && len(vaaData.VAA.Payload) > r.config.MaxPayloadSize {
+		return nil, nil, fmt.Errorf("payload of %d bytes exceeds MAX_PAYLOAD_SIZE %d",
+			len(vaaData.VAA.Payload), r.config.MaxPayloadSize)
+	}
+
+	// Decode the payload with the decoder for its version; skip formats this relayer doesn't know
+	payload, err := sdk.DecodeRecoveryPayload(vaaData.VAA.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	vaaData.Payload = payload
+
+	log.Debug("Decoded recovery payload",
+		zap.Uint8("version", payload.Version),
+		zap.String("module", payload.Module.Hex()),
+		zap.Uint64("chainID", payload.ChainID),
+		zap.String("safe", payload.Safe.Hex()),
+		zap.String("candidate", payload.Candidate.Hex()),
+		zap.Uint8("type", payload.Type))
+
+	// Route to the chain named in the payload
+	dest, ok := r.destinationForChain(payload.ChainID)
+	if !ok {
+		return nil, nil, fmt.Errorf("payload names unconfigured destination chain %d", payload.ChainID)
+	}
+	if err := dest.checkPayloadChain(payload); err != nil {
+		log.Error("Refusing VAA addressed to another chain", zap.Error(err))
+		return nil, nil, err
+	}
+
+	// A configured emitter may be limited to some destinations
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(vaaData.EmitterHex)
+	if emitter, ok := r.configuredEmitter(normalizedEmitter, decodedEmitter); ok && !emitter.routesTo(dest.Name) {
+		return nil, nil, fmt.Errorf("emitter %s is not routed to destination %s", emitter.Name, dest.Name)
+	}
+
+	// ...and to the module deployment it names there
+	tenant, ok := r.tenantFor(dest.ChainID, payload.Module)
+	if !ok {
+		return nil, nil, fmt.Errorf("payload names module %s, not a configured tenant on %s",
+			payload.Module.Hex(), dest.Name)
+	}
+	vaaData.Tenant = tenant.Name
+
+	// The payload type picks the functions called on the module
+	if _, ok := tenant.flowFor(payload.Type); !ok {
+		return nil, nil, fmt.Errorf("tenant %s has no call flow for payload type %d", tenant.Name, payload.Type)
+	}
 
-	if len(payload) >= 32 {
-		txIDBytes := payload[:32]
-		r.logger.Debug("Source Transaction ID", zap.String("txID", fmt.Sprintf("0x%x", txIDBytes)))
+	// Never forward a payload the contract would reject (or misapply)
+	if err := payload.Validate(len(vaaData.VAA.Payload), dest.ChainID, tenant.target); err != nil {
+		return nil, nil, err
+	}
+	// ...for its embedded signature either
+	if signer, checked, err := sdk.VerifyAuthorization(vaaData.VAA.Payload); err != nil {
+		payloadAuthorizationFailures.WithLabelValues(strconv.Itoa(int(payload.Version))).Inc()
+		log.Warn("Payload authorization signature does not verify", zap.Uint8("version", payload.Version), zap.Error(err))
+		return nil, nil, err
+	} else if checked {
+		log.Debug("Verified payload authorization signature", zap.String("signer", signer.Hex()))
 	}
 
-	for i := txIDOffset; i < len(payload); i += arraySize {
-		end := i + arraySize
-		if end > len(payload) {
-			end = len(payload)
+	// The emitter must be registered with the module being called
+	var safeAddr common.Address
+	if !r.config.AcceptAnyEmitter {
+		registeredSafeAddr, ok := r.registeredSafe(tenant, vaaData.EmitterHex)
+		if !ok {
+			return nil, nil, fmt.Errorf("emitter is not registered with tenant %s", tenant.Name)
 		}
+		safeAddr = registeredSafeAddr
+	}
+	if safeAddr != (common.Address{}) && safeAddr != payload.Safe {
+		return nil, nil, fmt.Errorf("payload safe %s does not match emitter's registered safe %s",
+			payload.Safe.Hex(), safeAddr.Hex())
+	}
 
-		arrayIndex := (i - txIDOffset) / arraySize
-		r.logger.Debug(fmt.Sprintf("Payload array %d", arrayIndex),
-			zap.String("hex", fmt.Sprintf("0x%x", payload[i:end])))
+	return dest, tenant, nil
+}
 
-		switch arrayIndex {
-		case 0:
-			if i+20 <= end {
-				r.logger.Debug("Address", zap.String("address", fmt.Sprintf("0x%x", payload[i:i+20])))
-			}
-		case 1:
-			if i+2 <= end {
-				chainIDLower := uint16(payload[i])
-				chainIDUpper := uint16(payload[i+1])
-				chainID := (chainIDUpper << 8) | chainIDLower
-				r.logger.Debug("Chain ID", zap.Uint16("chainID", chainID))
-			}
-		case 2:
-			if i < end {
-				amount := uint64(payload[i])
-				r.logger.Debug("Amount", zap.Uint64("amount", amount))
-			}
+// recordRejection logs and remembers why a VAA was refused
+func (r *Relayer) recordRejection(vaaData *VAAData, reason string) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	log.Warn("Rejecting VAA",
+		zap.Uint64("sequence", vaaData.Sequence),
+		zap.String("emitter", vaaData.EmitterHex),
+		zap.String("sourceTxID", vaaData.TxID),
+		zap.String("reason", reason))
+	incWithExemplar(tenantVAAs.WithLabelValues(tenantLabel(vaaData), "rejected"), vaaData.CorrelationID)
+	r.countEmitterVAA(vaaData, "rejected")
+	r.recentVAAs.record(vaaData, "rejected", reason)
+	r.audit.Record(AuditVAARejected, map[string]string{
+		"vaaHash":       computeVAAKey(vaaData.RawBytes),
+		"emitter":       vaaData.EmitterHex,
+		"sequence":      fmt.Sprint(vaaData.Sequence),
+		"reason":        reason,
+		"correlationId": vaaData.CorrelationID,
+	})
+
+	r.rejectionsMu.Lock()
+	defer r.rejectionsMu.Unlock()
+
+	r.rejections = append(r.rejections, VAARejection{
+		Sequence:   vaaData.Sequence,
+		EmitterHex: vaaData.EmitterHex,
+		TxID:       vaaData.TxID,
+		Reason:     reason,
+		Time:       time.Now(),
+
+		CorrelationID: vaaData.CorrelationID,
+	})
+	if len(r.rejections) > maxRecordedRejections {
+		r.rejections = r.rejections[len(r.rejections)-maxRecordedRejections:]
+	}
+}
+
+// parseAndLogPayload logs each field of the payload as its version's layout describes it
+func parseAndLogPayload(log *zap.Logger, payload []byte) {
+	version := sdk.PayloadVersion(payload)
+	layout, ok := sdk.LayoutFor(version)
+	if !ok {
+		log.Debug("Payload of unknown layout",
+			zap.Uint8("version", version),
+			zap.String("hex", fmt.Sprintf("0x%x", payload)))
+		return
+	}
+
+	for _, field := range layout.Fields {
+		if field.Offset+field.Width > len(payload) {
+			continue
 		}
+		log.Debug("Payload field",
+			zap.Uint8("version", version),
+			zap.String("name", field.Name),
+			zap.String("value", field.Format(payload[field.Offset:field.Offset+field.Width])))
 	}
 }
 
@@ -966,6 +2457,37 @@ func getEnvBoolOrDefault(key string, defaultValue bool) bool {
 	return strings.ToLower(val) == "true" || val == "1"
 }
 
+func getEnvListOrDefault(key string, defaultValue []string) []string {
+	val, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	val, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	result, err := time.ParseDuration(val)
+	if err != nil {
+		logger.Warn("Invalid environment variable value, using default",
+			zap.String("key", key),
+			zap.Duration("default", defaultValue))
+		return defaultValue
+	}
+	return result
+}
+
 func main() {
 	// Load .env file if present (ignore error if not found)
 	_ = godotenv.Load()
@@ -973,9 +2495,35 @@ func main() {
 	initLogger()
 	defer logger.Sync()
 
-	logger.Info("Starting Aztec-EVM Wormhole relayer")
-
 	config := NewConfigFromEnv()
+	logSecrets.add(configSecrets(config)...)
+
+	// Read or decrypt the signing key file so the raw key never has to live in the environment
+	if err := loadPrivateKeyFile(&config); err != nil {
+		logger.Fatal("Failed to load private key file", zap.Error(err))
+	}
+	if err := unlockKeyFile(&config); err != nil {
+		logger.Fatal("Failed to unlock key file", zap.Error(err))
+	}
+	if err := loadSpyAuthToken(&config); err != nil {
+		logger.Fatal("Failed to load spy auth token", zap.Error(err))
+	}
+	// The unlocked key and the token read from its file
+	logSecrets.add(configSecrets(config)...)
+	if err := loadPayloadLayouts(config.PayloadLayoutsFile); err != nil {
+		logger.Fatal("Failed to load payload layouts", zap.Error(err))
+	}
+
+	// A subcommand runs once instead of the daemon
+	if len(os.Args) > 1 {
+		code := runCommand(config, os.Args[1:])
+		logger.Sync()
+		os.Exit(code)
+	}
+
+	logger.Info("Starting Aztec-EVM Wormhole relayer",
+		zap.String("version", version),
+		zap.String("commit", buildInfo()["gitCommit"]))
 
 	logger.Info("Config loaded",
 		zap.Uint16("sourceChainID", config.SourceChainID),
@@ -999,6 +2547,15 @@ func main() {
 		cancel()
 	}()
 
+	// SIGUSR1 drains: finish inflight VAAs, then exit
+	drain := make(chan os.Signal, 1)
+	signal.Notify(drain, syscall.SIGUSR1)
+	go func() {
+		<-drain
+		logger.Info("Received drain signal")
+		relayer.Drain()
+	}()
+
 	if err := relayer.Start(ctx); err != nil {
 		logger.Fatal("Relayer stopped with error", zap.Error(err))
 	}
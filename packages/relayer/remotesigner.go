@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.uber.org/zap"
+)
+
+// remoteSigner delegates signing to a Web3Signer (or any eth_signTransaction JSON-RPC)
+// endpoint, so keys stay in a central signing service shared by the relayer fleet
+type remoteSigner struct {
+	client *rpc.Client
+	url    string
+	logger *zap.Logger
+}
+
+// openRemoteSigner connects to the signing endpoint at url and returns an account for each of
+// addresses, or for every account the endpoint holds when addresses is empty. It is reached
+// through proxyURL, or the environment's proxy when empty.
+func openRemoteSigner(url string, addresses []string, proxyURL string) (*remoteSigner, []Signer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	proxy, err := outboundProxy(proxyURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	client, err := rpc.DialOptions(ctx, url, rpc.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to remote signer: %v", err)
+	}
+	signer := &remoteSigner{
+		client: client,
+		url:    url,
+		logger: logger.With(zap.String("component", "RemoteSigner")),
+	}
+
+	var available []common.Address
+	if err := client.CallContext(ctx, &available, "eth_accounts"); err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("failed to list remote signer accounts: %v", err)
+	}
+	held := make(map[common.Address]bool, len(available))
+	for _, address := range available {
+		held[address] = true
+	}
+
+	selected := available
+	if len(addresses) > 0 {
+		selected = nil
+		for _, hexAddress := range addresses {
+			if !common.IsHexAddress(hexAddress) {
+				client.Close()
+				return nil, nil, fmt.Errorf("invalid remote signer address %q", hexAddress)
+			}
+			address := common.HexToAddress(hexAddress)
+			if !held[address] {
+				client.Close()
+				return nil, nil, fmt.Errorf("remote signer does not hold a key for %s", address.Hex())
+			}
+			selected = append(selected, address)
+		}
+	}
+	if len(selected) == 0 {
+		client.Close()
+		return nil, nil, fmt.Errorf("remote signer holds no keys")
+	}
+
+	accounts := make([]Signer, 0, len(selected))
+	for _, address := range selected {
+		accounts = append(accounts, &remoteAccount{signer: signer, address: address})
+		signer.logger.Info("Remote signer account ready", zap.String("address", address.Hex()))
+	}
+
+	return signer, accounts, nil
+}
+
+// remoteAccount is one account held by the remote signer
+type remoteAccount struct {
+	signer  *remoteSigner
+	address common.Address
+}
+
+func (a *remoteAccount) Address() common.Address {
+	return a.address
+}
+
+func (a *remoteAccount) SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error) {
+	return a.signer.signTx(ctx, a.address, tx, signer)
+}
+
+// SignTypedData has the endpoint sign data with eth_signTypedData, as Web3Signer names it
+func (a *remoteAccount) SignTypedData(ctx context.Context, data apitypes.TypedData, description string) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := a.signer.client.CallContext(ctx, &signature, "eth_signTypedData", a.address, data); err != nil {
+		return nil, fmt.Errorf("remote signing failed: %v", err)
+	}
+	return signature, nil
+}
+
+// signTx has the endpoint sign tx as from and checks the result is the transaction asked for
+func (s *remoteSigner) signTx(ctx context.Context, from common.Address, tx *types.Transaction, signer types.Signer) (*types.Transaction, error) {
+	args := map[string]interface{}{
+		"from":    from,
+		"to":      tx.To(),
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"data":    hexutil.Bytes(tx.Data()),
+		"chainId": (*hexutil.Big)(signer.ChainID()),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	var raw hexutil.Bytes
+	if err := s.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signing failed: %v", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %v", err)
+	}
+
+	// Never broadcast something other than what was requested
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an unverifiable signature: %v", err)
+	}
+	if sender != from || signed.Type() != tx.Type() || signed.Nonce() != tx.Nonce() || *signed.To() != *tx.To() ||
+		signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 || signed.GasTipCap().Cmp(tx.GasTipCap()) != 0 || signed.Gas() != tx.Gas() ||
+		signed.Value().Cmp(tx.Value()) != 0 || string(signed.Data()) != string(tx.Data()) {
+		return nil, fmt.Errorf("remote signer returned a transaction that differs from the request")
+	}
+
+	return signed, nil
+}
+
+// Close closes the connection to the endpoint
+func (s *remoteSigner) Close() {
+	s.client.Close()
+}
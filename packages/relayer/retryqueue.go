@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+const (
+	retryPollInterval = 5 * time.Second // How often the retry queue is checked for due entries
+	maxRetryBackoff   = time.Hour       // Upper bound on the delay between attempts
+)
+
+// loadRetries restores the retry queue left by the previous run
+func (r *Relayer) loadRetries() error {
+	entries, err := r.store.LoadRetries()
+	if err != nil {
+		return err
+	}
+
+	r.retriesMu.Lock()
+	for _, entry := range entries {
+		r.retries[entry.Key] = entry
+	}
+	r.retriesMu.Unlock()
+
+	// Flows interrupted after a mined step resume with the next one
+	for _, entry := range entries {
+		if entry.FlowStep == 0 {
+			continue
+		}
+		progress := &flowProgress{next: entry.FlowStep, captured: entry.FlowCaptured}
+		if progress.captured == nil {
+			progress.captured = make(map[string]common.Hash)
+		}
+		if entry.ErrorKind == ErrorKindFlowWait {
+			progress.readyAt = entry.NextAttempt
+		}
+		r.flowProgress.save(entry.Key, progress, false)
+	}
+
+	if len(entries) > 0 {
+		r.logger.Info("Restored retry queue", zap.Int("count", len(entries)))
+	}
+	return nil
+}
+
+// recoverInflight queues the VAAs a crashed run left in processing, so they are re-driven
+// instead of lost. The interrupted run counts as an attempt.
+func (r *Relayer) recoverInflight() error {
+	entries, err := r.store.LoadInflight()
+	if err != nil {
+		return err
+	}
+
+	for _, inflight := range entries {
+		r.dedupeMu.Lock()
+		processed := r.processedVAAs.contains(inflight.Key)
+		r.dedupeMu.Unlock()
+
+		entry := RetryEntry{
+			Key:         inflight.Key,
+			VAABytes:    inflight.VAABytes,
+			Attempts:    1,
+			NextAttempt: time.Now(),
+			LastError:   "interrupted before confirmation",
+
+			CorrelationID: inflight.CorrelationID,
+		}
+		r.retriesMu.Lock()
+		_, queued := r.retries[inflight.Key]
+		requeue := !processed && !queued
+		if requeue {
+			r.retries[inflight.Key] = entry
+		}
+		r.retriesMu.Unlock()
+
+		if requeue {
+			r.logger.Warn("Re-driving VAA interrupted before confirmation",
+				zap.String("vaaHash", inflight.Key),
+				zap.String("correlationId", inflight.CorrelationID),
+				zap.Time("startedAt", inflight.StartedAt))
+			if err := r.store.SaveRetry(entry); err != nil {
+				return err
+			}
+		}
+		if err := r.store.RemoveInflight(inflight.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleRetry queues a VAA whose processing failed for another attempt with exponential
+// backoff, giving up after RetryMaxAttempts. A VAA refused by the fee ceiling or MAX_TX_COST, or
+// deferred for its cost, is parked instead: checked again every RetryBackoff, without using up
+// attempts, until fees allow it to be sent. A deferred VAA is also tried at its deadline.
+func (r *Relayer) scheduleRetry(key, correlationID string, vaaBytes []byte, procErr error) {
+	log := withCorrelation(r.logger, correlationID)
+
+	r.retriesMu.Lock()
+	entry, exists := r.retries[key]
+	if !exists {
+		entry = RetryEntry{Key: key, VAABytes: vaaBytes}
+	}
+	wasParked := exists && entry.parked()
+	entry.CorrelationID = correlationID
+	entry.LastError = procErr.Error()
+	entry.ErrorKind = errorKindOf(procErr)
+	entry.FlowStep, entry.FlowCaptured = 0, nil
+	if progress, ok := r.flowProgress.peek(key); ok {
+		entry.FlowStep, entry.FlowCaptured = progress.next, maps.Clone(progress.captured)
+	}
+	// A call flow wait is no failure: the VAA is due when the wait is over, at no attempt's cost
+	var wait *flowWaitError
+	if errors.As(procErr, &wait) {
+		entry.NextAttempt = wait.until
+		r.retries[key] = entry
+		r.retriesMu.Unlock()
+
+		log.Info("VAA scheduled after call flow wait",
+			zap.String("vaaHash", key),
+			zap.String("reason", wait.reason),
+			zap.Time("at", wait.until))
+		if err := r.store.SaveRetry(entry); err != nil {
+			log.Error("Failed to persist retry entry", zap.String("vaaHash", key), zap.Error(err))
+		}
+		return
+	}
+	if entry.deferred() {
+		firstDeferral := entry.DeferredAt.IsZero()
+		if firstDeferral {
+			entry.DeferredAt = time.Now()
+		}
+		entry.NextAttempt = r.costDeferral.deferUntil(entry, time.Now(), time.Duration(r.retryBackoff.Load()))
+		r.retries[key] = entry
+		r.retriesMu.Unlock()
+
+		if firstDeferral {
+			costDeferrals.Inc()
+			log.Info("VAA deferred until fees drop",
+				zap.String("vaaHash", key),
+				zap.String("lastError", entry.LastError),
+				zap.Time("deadline", entry.DeferredAt.Add(r.costDeferral.deadline)))
+		}
+		if err := r.store.SaveRetry(entry); err != nil {
+			log.Error("Failed to persist retry entry", zap.String("vaaHash", key), zap.Error(err))
+		}
+		return
+	}
+	if entry.parked() {
+		entry.NextAttempt = time.Now().Add(time.Duration(r.retryBackoff.Load()))
+		r.retries[key] = entry
+		r.retriesMu.Unlock()
+
+		if !wasParked {
+			log.Error("VAA parked until fees come down",
+				zap.String("vaaHash", key),
+				zap.String("errorKind", string(entry.ErrorKind)),
+				zap.String("lastError", entry.LastError),
+				zap.Duration("recheck", time.Duration(r.retryBackoff.Load())))
+		}
+		if err := r.store.SaveRetry(entry); err != nil {
+			log.Error("Failed to persist retry entry", zap.String("vaaHash", key), zap.Error(err))
+		}
+		return
+	}
+	entry.Attempts++
+
+	// A revert the module will repeat on every attempt isn't worth retrying
+	var revert *RevertError
+	permanent := errors.As(procErr, &revert) && revert.Permanent()
+
+	maxAttempts := int(r.retryMaxAttempts.Load())
+	if permanent || (maxAttempts > 0 && entry.Attempts >= maxAttempts) {
+		delete(r.retries, key)
+		r.retriesMu.Unlock()
+
+		r.forgetSafeMessage(key)
+		r.flowProgress.save(key, nil, true)
+		message := "Giving up on VAA after repeated failures"
+		if permanent {
+			message = "Giving up on VAA, the revert won't change on retry"
+		}
+		log.Error(message,
+			zap.String("vaaHash", key),
+			zap.Int("attempts", entry.Attempts),
+			zap.String("errorKind", string(entry.ErrorKind)),
+			zap.String("lastError", entry.LastError))
+		if err := r.store.RemoveRetry(key); err != nil {
+			log.Error("Failed to remove retry entry", zap.String("vaaHash", key), zap.Error(err))
+		}
+		return
+	}
+
+	backoff := time.Duration(r.retryBackoff.Load())
+	for i := 1; i < entry.Attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	entry.NextAttempt = time.Now().Add(backoff)
+	r.retries[key] = entry
+	r.retriesMu.Unlock()
+
+	log.Info("Scheduled VAA retry",
+		zap.String("vaaHash", key),
+		zap.Int("attempt", entry.Attempts),
+		zap.Duration("backoff", backoff))
+	if err := r.store.SaveRetry(entry); err != nil {
+		log.Error("Failed to persist retry entry", zap.String("vaaHash", key), zap.Error(err))
+	}
+}
+
+// clearRetry drops the VAA from the retry queue once it has been handled
+func (r *Relayer) clearRetry(key string) {
+	r.retriesMu.Lock()
+	_, exists := r.retries[key]
+	delete(r.retries, key)
+	r.retriesMu.Unlock()
+
+	if !exists {
+		return
+	}
+	if err := r.store.RemoveRetry(key); err != nil {
+		r.logger.Error("Failed to remove retry entry", zap.String("vaaHash", key), zap.Error(err))
+	}
+}
+
+// isQueuedForRetry reports whether the VAA is in the retry queue, so processing it re-drives
+// an earlier attempt
+func (r *Relayer) isQueuedForRetry(key string) bool {
+	r.retriesMu.Lock()
+	defer r.retriesMu.Unlock()
+	_, queued := r.retries[key]
+	return queued
+}
+
+// flowWaitUntil returns when the queued VAA's call flow wait is over, if it is waiting one out
+func (r *Relayer) flowWaitUntil(key string) (time.Time, bool) {
+	r.retriesMu.Lock()
+	defer r.retriesMu.Unlock()
+	entry, ok := r.retries[key]
+	if !ok || entry.ErrorKind != ErrorKindFlowWait {
+		return time.Time{}, false
+	}
+	return entry.NextAttempt, true
+}
+
+// dueRetries returns the queued entries whose next attempt has come
+func (r *Relayer) dueRetries(now time.Time) []RetryEntry {
+	r.retriesMu.Lock()
+	defer r.retriesMu.Unlock()
+
+	var due []RetryEntry
+	for _, entry := range r.retries {
+		if !entry.NextAttempt.After(now) {
+			due = append(due, entry)
+		}
+	}
+	return due
+}
+
+// Retries returns a snapshot of the retry queue
+func (r *Relayer) Retries() []RetryEntry {
+	r.retriesMu.Lock()
+	defer r.retriesMu.Unlock()
+
+	result := make([]RetryEntry, 0, len(r.retries))
+	for _, entry := range r.retries {
+		result = append(result, entry)
+	}
+	return result
+}
+
+// runRetryQueue re-drives due retries until ctx is done. Retried VAAs are processed under
+// processingCtx and tracked by wg like VAAs from the stream.
+func (r *Relayer) runRetryQueue(ctx, processingCtx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// A standby leaves the queue to the active instance
+			if !r.isActive() {
+				continue
+			}
+			for _, entry := range r.dueRetries(now) {
+				if !r.beginProcessingVAA(entry.Key) {
+					continue
+				}
+				// Entries queued before correlation IDs existed get one now
+				if entry.CorrelationID == "" {
+					entry.CorrelationID = newCorrelationID()
+				}
+				r.logger.Info("Retrying VAA",
+					zap.String("vaaHash", entry.Key),
+					zap.String("correlationId", entry.CorrelationID),
+					zap.Int("attempt", entry.Attempts+1))
+
+				wg.Add(1)
+				go func(entry RetryEntry) {
+					defer wg.Done()
+					r.handleVAA(processingCtx, entry.VAABytes, entry.Key, entry.CorrelationID)
+				}(entry)
+			}
+		}
+	}
+}
+
+// handleVAA processes a VAA and updates dedupe and retry state with the outcome. The VAA is
+// recorded as inflight until it is confirmed or queued for retry, so a crash in between
+// leaves it to recoverInflight. The correlation ID is kept in the inflight and retry records,
+// so every attempt logs under the same ID.
+func (r *Relayer) handleVAA(ctx context.Context, vaaBytes []byte, key, correlationID string) {
+	if r.holdWhileStandby(key, vaaBytes, correlationID) {
+		r.finishProcessingVAA(key, false)
+		return
+	}
+	ctx = withCorrelationID(ctx, correlationID)
+	log := withCorrelation(r.logger, correlationID)
+
+	inflight := InflightEntry{Key: key, VAABytes: vaaBytes, StartedAt: time.Now(), CorrelationID: correlationID}
+	if err := r.store.SaveInflight(inflight); err != nil {
+		log.Error("Failed to persist inflight VAA", zap.String("vaaHash", key), zap.Error(err))
+	}
+	defer func() {
+		if err := r.store.RemoveInflight(key); err != nil {
+			log.Error("Failed to remove inflight VAA", zap.String("vaaHash", key), zap.Error(err))
+		}
+	}()
+
+	if err := r.processVAA(ctx, vaaBytes); err != nil {
+		r.finishProcessingVAA(key, false)
+		r.scheduleRetry(key, correlationID, vaaBytes, err)
+		return
+	}
+	r.finishProcessingVAA(key, true)
+	r.clearRetry(key)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+// fakeRPC answers consumedVaas calls and receipt lookups, and records every method called
+type fakeRPC struct {
+	mu       sync.Mutex
+	consumed bool
+	receipt  *types.Receipt // Returned for any transaction; nil while none is mined
+	methods  []string
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var call struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&call); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.methods = append(f.methods, call.Method)
+	consumed, receipt := f.consumed, f.receipt
+	f.mu.Unlock()
+
+	response := map[string]any{"jsonrpc": "2.0", "id": call.ID}
+	switch call.Method {
+	case "eth_call":
+		result := common.Hash{}
+		if consumed {
+			result[31] = 1
+		}
+		response["result"] = result.Hex()
+	case "eth_getTransactionReceipt":
+		response["result"] = receipt
+	default:
+		response["error"] = map[string]any{"code": -32601, "message": "method not available in test"}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// sent reports whether anything but a read went to the RPC
+func (f *fakeRPC) sent() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, method := range f.methods {
+		if method != "eth_call" && method != "eth_getTransactionReceipt" {
+			return true
+		}
+	}
+	return false
+}
+
+// newRedriveRelayer returns a relayer whose single tenant sends the default verify flow to rpc
+func newRedriveRelayer(t *testing.T, rpc *fakeRPC) (*Relayer, *Destination, *Tenant) {
+	t.Helper()
+	server := httptest.NewServer(rpc)
+	t.Cleanup(server.Close)
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	dest := &Destination{
+		DestinationConfig: DestinationConfig{Name: "test", ReceiptTimeout: time.Second},
+		client: &EVMClient{
+			client:  client,
+			breaker: &CircuitBreaker{name: "test"},
+			logger:  zap.NewNop(),
+			pending: newPendingTxs(),
+		},
+	}
+	flow, err := defaultFlow(Config{TargetFunction: "verify(bytes)", TargetFunctionArgs: []string{FlowArgVAA}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := newTestTenant()
+	tenant.dest, tenant.target, tenant.flow = dest, common.HexToAddress("0x1234"), flow
+
+	r := &Relayer{
+		config:        Config{SendTimeout: time.Second},
+		logger:        zap.NewNop(),
+		store:         NewMemoryStore(),
+		inflightVAAs:  make(map[string]struct{}),
+		processedVAAs: newDedupeCache(time.Hour, 0),
+		dedupePolicy:  dedupePolicies[DedupePolicyBytes],
+		retries:       make(map[string]RetryEntry),
+	}
+	return r, dest, tenant
+}
+
+// newRedriveVAA returns a signed test VAA ready for the call flow
+func newRedriveVAA(t *testing.T) *VAAData {
+	t.Helper()
+	keys, _ := testGuardianKeys(t, 1)
+	v := newTestVAA(1, 7)
+	v.Payload = make([]byte, 133) // Sized as a v0 recovery payload
+	vaaBytes, err := signTestVAA(v, keys, 0).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaaData.Payload = &sdk.RecoveryPayload{}
+	vaaData.CorrelationID = "test"
+	return vaaData
+}
+
+func TestRedriveAfterCrashSkipsConsumedVAA(t *testing.T) {
+	rpc := &fakeRPC{consumed: true}
+	r, dest, tenant := newRedriveRelayer(t, rpc)
+	vaaData := newRedriveVAA(t)
+	key := r.dedupeKey(vaaData.RawBytes)
+
+	// The previous run crashed after broadcasting the verify transaction, which landed
+	if err := r.store.SaveInflight(InflightEntry{Key: key, VAABytes: vaaData.RawBytes, StartedAt: time.Now(), CorrelationID: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.recoverInflight(); err != nil {
+		t.Fatal(err)
+	}
+	if !r.isQueuedForRetry(key) {
+		t.Fatal("interrupted VAA was not queued for retry")
+	}
+	if inflight, err := r.store.LoadInflight(); err != nil || len(inflight) != 0 {
+		t.Fatalf("inflight entries after recovery: %v, %v", inflight, err)
+	}
+
+	_, err := r.runCallFlow(context.Background(), dest, tenant, vaaData, FeeStrategy{})
+	if !errors.Is(err, errVAAConsumed) {
+		t.Fatalf("re-drive returned %v, want %v", err, errVAAConsumed)
+	}
+	if rpc.sent() {
+		t.Fatalf("re-drive sent a transaction: %v", rpc.methods)
+	}
+}
+
+func TestRedriveWaitsForPendingTransaction(t *testing.T) {
+	txHash := common.HexToHash("0xabc")
+	rpc := &fakeRPC{receipt: &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      txHash,
+		BlockHash:   common.HexToHash("0x1"),
+		BlockNumber: big.NewInt(1),
+		Logs:        []*types.Log{},
+	}}
+	r, dest, tenant := newRedriveRelayer(t, rpc)
+	vaaData := newRedriveVAA(t)
+	key := r.dedupeKey(vaaData.RawBytes)
+
+	// The first attempt's receipt timed out while its transaction was still pending
+	dest.client.pending.add(pendingTx{Hash: txHash, Description: describeVAA(vaaData.RawBytes), SentAt: time.Now()})
+	r.scheduleRetry(key, "test", vaaData.RawBytes, pipelineError(ErrorKindTimeout, errors.New("transaction not confirmed")))
+
+	receipt, err := r.sendFlowStep(context.Background(), dest, tenant, vaaData, tenant.flow[0], nil, FeeStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != txHash || vaaData.TxHash != txHash.Hex() {
+		t.Fatalf("confirmed %s, want the pending %s", receipt.TxHash.Hex(), txHash.Hex())
+	}
+	if rpc.sent() {
+		t.Fatalf("re-drive sent a transaction: %v", rpc.methods)
+	}
+	if _, pending := dest.client.pending.forDescription(describeVAA(vaaData.RawBytes)); pending {
+		t.Fatal("mined transaction is still tracked as pending")
+	}
+}
+
+func TestCallFlowWaitGoesThroughRetryQueue(t *testing.T) {
+	rpc := &fakeRPC{}
+	r, dest, tenant := newRedriveRelayer(t, rpc)
+	flow, err := compileFlow([]FlowStep{
+		{Function: "verify(bytes)", Args: []string{FlowArgVAA}},
+		{Function: "execute(bytes32)", Args: []string{FlowArgVAAHash}, Wait: "24h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant.flow = flow
+	vaaData := newRedriveVAA(t)
+	key := r.dedupeKey(vaaData.RawBytes)
+
+	// The first step was just mined
+	readyAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	captured := map[string]common.Hash{"recoveryId": common.HexToHash("0x1")}
+	r.flowProgress.save(key, &flowProgress{next: 1, captured: captured, readyAt: readyAt}, false)
+
+	_, err = r.runCallFlow(context.Background(), dest, tenant, vaaData, FeeStrategy{})
+	var wait *flowWaitError
+	if !errors.As(err, &wait) || !wait.until.Equal(readyAt) {
+		t.Fatalf("runCallFlow returned %v, want a wait until %s", err, readyAt)
+	}
+	if len(rpc.methods) != 0 {
+		t.Fatalf("waiting flow called the RPC: %v", rpc.methods)
+	}
+
+	r.scheduleRetry(key, "test", vaaData.RawBytes, err)
+	entries, err := r.store.LoadRetries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("retry entries: %v, %v", entries, err)
+	}
+	entry := entries[0]
+	if entry.ErrorKind != ErrorKindFlowWait || !entry.NextAttempt.Equal(readyAt) || entry.Attempts != 0 {
+		t.Fatalf("retry entry %+v, want a flow wait due at %s using no attempt", entry, readyAt)
+	}
+	if entry.FlowStep != 1 || entry.FlowCaptured["recoveryId"] != captured["recoveryId"] {
+		t.Fatalf("retry entry kept flow step %d and captures %v", entry.FlowStep, entry.FlowCaptured)
+	}
+
+	// A restart resumes the flow with the waiting step
+	restarted, _, _ := newRedriveRelayer(t, rpc)
+	restarted.store = r.store
+	if err := restarted.loadRetries(); err != nil {
+		t.Fatal(err)
+	}
+	progress, ok := restarted.flowProgress.peek(key)
+	if !ok || progress.next != 1 || !progress.readyAt.Equal(readyAt) || progress.captured["recoveryId"] != captured["recoveryId"] {
+		t.Fatalf("restored progress %+v, want step 2 ready at %s", progress, readyAt)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Revert classes, as counted by relayer_reverts_total
+const (
+	RevertAlreadyConsumed     = "already_consumed"     // The VAA or recovery nonce was used before
+	RevertInvalidVAA          = "invalid_vaa"          // Guardian signatures or set rejected by the core
+	RevertUnregisteredEmitter = "unregistered_emitter" // The module doesn't accept the emitter for the Safe
+	RevertWrongChain          = "wrong_chain"          // Payload names another chain
+	RevertExpired             = "expired"              // Recovery request past its expiry
+	RevertPaused              = "paused"               // Module or validator paused by its owner
+	RevertSafeCall            = "safe_call_failed"     // The Safe refused the module's call
+	RevertPanic               = "panic"                // Solidity Panic(uint256)
+	RevertOther               = "other"                // A reason not classified above
+	RevertNoData              = "no_data"              // The node returned no revert data
+)
+
+// permanentReverts are the classes a retry can't change
+var permanentReverts = map[string]bool{
+	RevertAlreadyConsumed:     true,
+	RevertInvalidVAA:          true,
+	RevertUnregisteredEmitter: true,
+	RevertWrongChain:          true,
+	RevertExpired:             true,
+}
+
+// moduleErrors classifies the custom errors of the recovery modules and validators
+var moduleErrors = map[string]string{
+	"VaaAlreadyConsumed()":     RevertAlreadyConsumed,
+	"AlreadyConsumed()":        RevertAlreadyConsumed,
+	"MessageAlreadyConsumed()": RevertAlreadyConsumed,
+	"NonceAlreadyConsumed()":   RevertAlreadyConsumed,
+	"InvalidVaa()":             RevertInvalidVAA,
+	"InvalidVAA()":             RevertInvalidVAA,
+	"ValidationFailed()":       RevertUnregisteredEmitter,
+	"NotAuthorized()":          RevertUnregisteredEmitter,
+	"WrongSafe()":              RevertUnregisteredEmitter,
+	"SafeMismatch()":           RevertUnregisteredEmitter,
+	"WrongChain()":             RevertWrongChain,
+	"Expired()":                RevertExpired,
+	"ModulePaused()":           RevertPaused,
+	"ValidatorPaused()":        RevertPaused,
+	"SafeCallFailed()":         RevertSafeCall,
+	"NotAuthorizedModule()":    RevertSafeCall,
+	"ConsumeNonceFailed()":     RevertOther,
+	"NonceMismatch()":          RevertOther,
+	"OwnersMismatch()":         RevertOther,
+	"ThresholdMismatch()":      RevertOther,
+	"BadSelector()":            RevertOther,
+	"BadVersion()":             RevertOther,
+	"InvalidVersion()":         RevertOther,
+	"InvalidTxID()":            RevertOther,
+}
+
+// moduleErrorSelectors maps each custom error's selector to its signature
+var moduleErrorSelectors = func() map[[4]byte]string {
+	selectors := make(map[[4]byte]string, len(moduleErrors))
+	for sig := range moduleErrors {
+		selectors[[4]byte(crypto.Keccak256([]byte(sig))[:4])] = sig
+	}
+	return selectors
+}()
+
+// panicSelector is the selector of Solidity's Panic(uint256)
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+// reasonClasses classifies Error(string) reasons by substring, checked in order; these cover
+// the Wormhole core's require messages and the string reverts of older modules
+var reasonClasses = []struct {
+	substr string
+	class  string
+}{
+	{"already consumed", RevertAlreadyConsumed},
+	{"replay", RevertAlreadyConsumed},
+	{"signature invalid", RevertInvalidVAA},
+	{"invalid guardian set", RevertInvalidVAA},
+	{"guardian set has expired", RevertInvalidVAA},
+	{"no quorum", RevertInvalidVAA},
+	{"signature indices", RevertInvalidVAA},
+	{"vm version incompatible", RevertInvalidVAA},
+	{"invalid vaa", RevertInvalidVAA},
+	{"validation failed", RevertUnregisteredEmitter},
+	{"wrong safe", RevertUnregisteredEmitter},
+	{"safe mismatch", RevertUnregisteredEmitter},
+	{"wrong chain", RevertWrongChain},
+	{"expired", RevertExpired},
+	{"paused", RevertPaused},
+	{"safe module call failed", RevertSafeCall},
+}
+
+// RevertError is a decoded and classified revert
+type RevertError struct {
+	Class  string // One of the Revert* classes
+	Reason string // Decoded reason: the error string, custom error or panic
+}
+
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("reverted: %s (%s)", e.Reason, e.Class)
+}
+
+// Permanent reports whether retrying can't succeed
+func (e *RevertError) Permanent() bool {
+	return permanentReverts[e.Class]
+}
+
+// decodeRevert decodes revert data into its reason and class
+func decodeRevert(data []byte) *RevertError {
+	if len(data) < 4 {
+		return &RevertError{Class: RevertNoData, Reason: "no revert data"}
+	}
+
+	if bytes.Equal(data[:4], panicSelector) {
+		reason, err := abi.UnpackRevert(data)
+		if err != nil {
+			reason = "undecodable panic"
+		}
+		return &RevertError{Class: RevertPanic, Reason: "panic: " + reason}
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		lower := strings.ToLower(reason)
+		for _, rc := range reasonClasses {
+			if strings.Contains(lower, rc.substr) {
+				return &RevertError{Class: rc.class, Reason: reason}
+			}
+		}
+		return &RevertError{Class: RevertOther, Reason: reason}
+	}
+	if sig, ok := moduleErrorSelectors[[4]byte(data[:4])]; ok {
+		return &RevertError{Class: moduleErrors[sig], Reason: sig}
+	}
+	return &RevertError{Class: RevertOther, Reason: fmt.Sprintf("unknown error 0x%x", data)}
+}
+
+// revertFromCallError decodes the revert data a node attached to a failed eth_call or
+// eth_estimateGas, or returns nil if err is not a revert
+func revertFromCallError(err error) *RevertError {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(hexData); decodeErr == nil {
+				return decodeRevert(data)
+			}
+		}
+	}
+	if errorKindOf(err) == ErrorKindSimulationRevert {
+		return &RevertError{Class: RevertNoData, Reason: err.Error()}
+	}
+	return nil
+}
+
+// revertError counts a decoded revert, with the VAA's correlation ID as exemplar, and wraps it
+// as the error the caller returns, so errors.As finds the RevertError and the retry policy
+// can see its class
+func revertError(revert *RevertError, stage, correlationID string, format string, args ...any) error {
+	incWithExemplar(reverts.WithLabelValues(revert.Class, stage), correlationID)
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), revert)
+}
+
+// replayRevert re-runs a mined transaction that reverted as an eth_call against its block, to
+// recover the revert data receipts don't carry
+func (c *EVMClient) replayRevert(ctx context.Context, receipt *types.Receipt) *RevertError {
+	tx, _, err := c.client.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return &RevertError{Class: RevertNoData, Reason: fmt.Sprintf("transaction lookup failed: %v", err)}
+	}
+	chain, err := c.chain(ctx)
+	if err != nil {
+		return &RevertError{Class: RevertNoData, Reason: err.Error()}
+	}
+	from, err := types.Sender(chain.signer, tx)
+	if err != nil {
+		return &RevertError{Class: RevertNoData, Reason: fmt.Sprintf("sender recovery failed: %v", err)}
+	}
+
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Gas: tx.Gas(), Value: tx.Value(), Data: tx.Data()}
+	_, err = c.client.CallContract(ctx, msg, receipt.BlockNumber)
+	if err == nil {
+		return &RevertError{Class: RevertNoData, Reason: "replay did not revert"}
+	}
+	if revert := revertFromCallError(err); revert != nil {
+		return revert
+	}
+	return &RevertError{Class: RevertNoData, Reason: fmt.Sprintf("replay failed: %v", err)}
+}
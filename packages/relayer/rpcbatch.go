@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// batchCall sends calls as one JSON-RPC batch request. The error covers the request as a
+// whole; each call's own error is in its Error field.
+func (c *EVMClient) batchCall(ctx context.Context, calls []rpc.BatchElem) error {
+	if err := c.client.Client().BatchCallContext(ctx, calls); err != nil {
+		return classifyError(fmt.Errorf("batch request failed: %v", err), ErrorKindRPC)
+	}
+	return nil
+}
+
+// feeHistoryResult is the eth_feeHistory response
+type feeHistoryResult struct {
+	OldestBlock *hexutil.Big     `json:"oldestBlock"`
+	Reward      [][]*hexutil.Big `json:"reward"`
+	BaseFee     []*hexutil.Big   `json:"baseFeePerGas"`
+}
+
+// feeCalls are the lookups a strategy prices a transaction from, sent in one batch
+type feeCalls struct {
+	history  feeHistoryResult
+	gasPrice hexutil.Big
+	tip      hexutil.Big
+	elems    []rpc.BatchElem
+}
+
+// newFeeCalls prepares eth_feeHistory, eth_gasPrice and eth_maxPriorityFeePerGas for strategy
+func newFeeCalls(strategy FeeStrategy) *feeCalls {
+	f := &feeCalls{}
+	f.elems = []rpc.BatchElem{
+		{Method: "eth_feeHistory", Args: []any{hexutil.Uint(feeHistoryBlocks), "latest", []float64{strategy.PriorityPercentile}}, Result: &f.history},
+		{Method: "eth_gasPrice", Result: &f.gasPrice},
+		{Method: "eth_maxPriorityFeePerGas", Result: &f.tip},
+	}
+	return f
+}
+
+// fees computes the tip and fee cap from the batch results, as EstimateFees documents
+func (f *feeCalls) fees(c *EVMClient, strategy FeeStrategy) (*big.Int, *big.Int, error) {
+	history := f.history
+	if f.elems[0].Error == nil && len(history.BaseFee) > 0 && len(history.Reward) > 0 {
+		// The last base fee is the one for the next block
+		baseFee := history.BaseFee[len(history.BaseFee)-1].ToInt()
+
+		tips := make([]*big.Int, 0, len(history.Reward))
+		for _, reward := range history.Reward {
+			if len(reward) > 0 && reward[0] != nil {
+				tips = append(tips, reward[0].ToInt())
+			}
+		}
+		tip := medianBig(tips)
+
+		gasPrice := mulPct(baseFee, strategy.BaseFeeMultiplierPct)
+		gasPrice.Add(gasPrice, tip)
+
+		c.logger.Debug("Estimated gas price from fee history",
+			zap.String("strategy", strategy.Name),
+			zap.String("baseFee", baseFee.String()),
+			zap.String("tip", tip.String()),
+			zap.String("gasPrice", gasPrice.String()))
+		return tip, gasPrice, nil
+	}
+
+	c.logger.Debug("Fee history unavailable, falling back to eth_gasPrice",
+		zap.String("strategy", strategy.Name),
+		zap.Error(f.elems[0].Error))
+
+	if err := f.elems[1].Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get gas price: %v", err)
+	}
+	gasPrice := mulPct(f.gasPrice.ToInt(), strategy.GasPriceMultiplierPct)
+
+	tip := f.tip.ToInt()
+	if f.elems[2].Error != nil || tip.Cmp(gasPrice) > 0 {
+		tip = new(big.Int).Set(gasPrice)
+	}
+	return tip, gasPrice, nil
+}
+
+// txParams are the per-attempt inputs of a transaction
+type txParams struct {
+	chain  *chainInfo
+	nonce  uint64 // Higher of the confirmed and pending nonce
+	tip    *big.Int
+	feeCap *big.Int
+}
+
+// fetchTxParams reads the account's confirmed and pending nonces and the fee inputs for
+// strategy in a single batch request, alongside the cached chain info
+func (c *EVMClient) fetchTxParams(ctx context.Context, address common.Address, strategy FeeStrategy) (txParams, error) {
+	chain, err := c.chain(ctx)
+	if err != nil {
+		return txParams{}, err
+	}
+
+	var confirmed, pending hexutil.Uint64
+	fees := newFeeCalls(strategy)
+	calls := append([]rpc.BatchElem{
+		{Method: "eth_getTransactionCount", Args: []any{address, "latest"}, Result: &confirmed},
+		{Method: "eth_getTransactionCount", Args: []any{address, "pending"}, Result: &pending},
+	}, fees.elems...)
+	if err := c.batchCall(ctx, calls); err != nil {
+		return txParams{}, err
+	}
+	fees.elems = calls[2:]
+
+	if err := calls[0].Error; err != nil {
+		return txParams{}, classifyError(fmt.Errorf("failed to get confirmed nonce: %v", err), ErrorKindRPC)
+	}
+	if err := calls[1].Error; err != nil {
+		return txParams{}, classifyError(fmt.Errorf("failed to get pending nonce: %v", err), ErrorKindRPC)
+	}
+	tip, feeCap, err := fees.fees(c, strategy)
+	if err != nil {
+		return txParams{}, classifyError(err, ErrorKindRPC)
+	}
+	tip, feeCap = c.withGasStation(ctx, strategy, tip, feeCap)
+
+	// Use the higher of the two nonces to avoid conflicts
+	nonce := uint64(confirmed)
+	if uint64(pending) > nonce {
+		nonce = uint64(pending)
+	}
+	c.logger.Debug("Fresh nonce fetched",
+		zap.String("signer", address.Hex()),
+		zap.Uint64("confirmed", uint64(confirmed)),
+		zap.Uint64("pending", uint64(pending)),
+		zap.Uint64("using", nonce))
+
+	return txParams{chain: chain, nonce: nonce, tip: tip, feeCap: feeCap}, nil
+}
+
+// balancesAndChainID reads the chain ID and the balance of each address in a single batch
+// request. A failed balance is reported as nil with its error.
+func (c *EVMClient) balancesAndChainID(ctx context.Context, addresses []common.Address) (*big.Int, []*big.Int, []error, error) {
+	var chainID hexutil.Big
+	balances := make([]hexutil.Big, len(addresses))
+	calls := []rpc.BatchElem{{Method: "eth_chainId", Result: &chainID}}
+	for i, address := range addresses {
+		calls = append(calls, rpc.BatchElem{Method: "eth_getBalance", Args: []any{address, "latest"}, Result: &balances[i]})
+	}
+	if err := c.batchCall(ctx, calls); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := calls[0].Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	result := make([]*big.Int, len(addresses))
+	errs := make([]error, len(addresses))
+	for i := range addresses {
+		if errs[i] = calls[i+1].Error; errs[i] == nil {
+			result[i] = balances[i].ToInt()
+		}
+	}
+	return chainID.ToInt(), result, errs, nil
+}
+
+// filterLogsPaged returns the logs matching query between from and to (inclusive), querying
+// LOG_SCAN_CHUNK_SIZE blocks per eth_getLogs call and sending LOG_SCAN_BATCH_SIZE calls per
+// batch request. Logs are returned in chain order. When the RPC rejects a page's range, the
+// page is halved and retried, and the smaller size is kept for later scans.
+func (c *EVMClient) filterLogsPaged(ctx context.Context, query ethereum.FilterQuery, from, to uint64) ([]types.Log, error) {
+	perBatch := int(c.logScanBatch.Load())
+	if perBatch <= 0 {
+		perBatch = 1
+	}
+
+	var logs []types.Log
+	for start := from; start <= to; {
+		chunk := c.logScanPageSize()
+		var calls []rpc.BatchElem
+		var results []*[]types.Log
+		var ranges [][2]uint64
+		for next := start; len(calls) < perBatch && next <= to; {
+			end := to
+			if to-next >= chunk {
+				end = next + chunk - 1
+			}
+			page := query
+			page.FromBlock = new(big.Int).SetUint64(next)
+			page.ToBlock = new(big.Int).SetUint64(end)
+
+			result := new([]types.Log)
+			calls = append(calls, rpc.BatchElem{Method: "eth_getLogs", Args: []any{toFilterArg(page)}, Result: result})
+			results = append(results, result)
+			ranges = append(ranges, [2]uint64{next, end})
+			if end == to {
+				break
+			}
+			next = end + 1
+		}
+
+		if err := c.batchCall(ctx, calls); err != nil {
+			return nil, err
+		}
+		for i, call := range calls {
+			if call.Error != nil {
+				size := ranges[i][1] - ranges[i][0] + 1
+				if !isLogRangeError(call.Error) || size == 1 {
+					return nil, fmt.Errorf("eth_getLogs for blocks %d-%d failed (lower LOG_SCAN_CHUNK_SIZE if the RPC limits the range): %v",
+						ranges[i][0], ranges[i][1], call.Error)
+				}
+				c.logScanLimit.Store(size / 2)
+				c.logger.Warn("RPC rejected the eth_getLogs range, halving it",
+					zap.Uint64("blocks", size),
+					zap.Uint64("newBlocks", size/2),
+					zap.Error(call.Error))
+				break
+			}
+			logs = append(logs, *results[i]...)
+			start = ranges[i][1] + 1
+			if ranges[i][1] == to {
+				return logs, nil
+			}
+		}
+	}
+	return logs, nil
+}
+
+// logScanPageSize returns the blocks queried per eth_getLogs call: LOG_SCAN_CHUNK_SIZE, or
+// the smaller range the RPC was found to accept
+func (c *EVMClient) logScanPageSize() uint64 {
+	chunk := c.logScanChunk
+	if chunk == 0 {
+		chunk = 10000
+	}
+	if limit := c.logScanLimit.Load(); limit > 0 && limit < chunk {
+		chunk = limit
+	}
+	return chunk
+}
+
+// isLogRangeError reports whether an eth_getLogs error is a provider's limit on the block
+// range or result size, which a smaller range avoids
+func isLogRangeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "rate limit") {
+		return false
+	}
+	for _, hint := range []string{"range", "more than", "too many", "too large", "limit", "exceed"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFilterArg encodes a filter query with explicit block bounds for eth_getLogs
+func toFilterArg(q ethereum.FilterQuery) map[string]any {
+	return map[string]any{
+		"address":   q.Addresses,
+		"topics":    q.Topics,
+		"fromBlock": hexutil.EncodeBig(q.FromBlock),
+		"toBlock":   hexutil.EncodeBig(q.ToBlock),
+	}
+}
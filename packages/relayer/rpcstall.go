@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// watchRPCStall polls the destination's head block and marks its circuit breaker stalled once
+// the block number has not advanced for RPCStallTimeout. Submissions then wait on the breaker,
+// queued rather than each burning retries against an RPC stuck in the past, and are released
+// as soon as the head moves again.
+func (r *Relayer) watchRPCStall(ctx context.Context, dest *Destination) {
+	if r.config.RPCStallTimeout <= 0 {
+		return
+	}
+
+	checkInterval := r.config.RPCStallTimeout / 4
+	if checkInterval > 30*time.Second {
+		checkInterval = 30 * time.Second
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	logger := r.logger.With(zap.String("destination", dest.Name))
+	var head uint64
+	lastAdvance := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Timeouts and failed calls feed the breaker through the RPC transport
+		callCtx, cancel := context.WithTimeout(ctx, checkInterval)
+		block, err := dest.client.client.BlockNumber(callCtx)
+		cancel()
+		if err != nil {
+			logger.Debug("Head block check failed", zap.Error(err))
+			continue
+		}
+
+		if block > head {
+			head = block
+			lastAdvance = time.Now()
+			dest.client.breaker.SetStalled(false, head, 0)
+			continue
+		}
+		if since := time.Since(lastAdvance); since >= r.config.RPCStallTimeout {
+			dest.client.breaker.SetStalled(true, head, since)
+		}
+	}
+}
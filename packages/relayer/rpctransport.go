@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RPC request results used as metric labels
+const (
+	rpcResultOK             = "ok"
+	rpcResultRPCError       = "rpc_error"
+	rpcResultRateLimited    = "rate_limited"
+	rpcResultHTTPError      = "http_error"
+	rpcResultTimeout        = "timeout"
+	rpcResultTransportError = "transport_error"
+	rpcResultCancelled      = "cancelled"
+)
+
+// rpcTransport instruments every HTTP round trip to an EVM RPC endpoint: it records
+// request counts by JSON-RPC method and result, latency, and feeds the endpoint's
+// circuit breaker. Transport errors, timeouts, rate limiting and server errors count as
+// breaker failures; any other response means the RPC is answering, even if the
+// JSON-RPC call itself failed.
+type rpcTransport struct {
+	base     http.RoundTripper
+	endpoint string
+	breaker  *CircuitBreaker
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := rpcRequestMethod(req)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	result := rpcResultOK
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		// Cancelled by the caller, says nothing about the RPC
+		result = rpcResultCancelled
+	case err != nil && req.Context().Err() != nil:
+		// The caller's deadline ran out while the RPC was still answering
+		result = rpcResultTimeout
+		t.breaker.Record(err)
+	case err != nil:
+		result = rpcResultTransportError
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			result = rpcResultTimeout
+		}
+		t.breaker.Record(err)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		result = rpcResultRateLimited
+		t.breaker.Record(fmt.Errorf("HTTP %s", resp.Status))
+	case resp.StatusCode >= 500:
+		result = rpcResultHTTPError
+		t.breaker.Record(fmt.Errorf("HTTP %s", resp.Status))
+	case resp.StatusCode >= 400:
+		result = rpcResultHTTPError
+		t.breaker.Record(nil)
+	default:
+		if rpcResponseHasError(resp) {
+			result = rpcResultRPCError
+		}
+		t.breaker.Record(nil)
+	}
+
+	evmRPCRequests.WithLabelValues(t.endpoint, method, result).Inc()
+	evmRPCDuration.WithLabelValues(t.endpoint, method).Observe(elapsed.Seconds())
+
+	return resp, err
+}
+
+// rpcRequestMethod returns the JSON-RPC method of a request, "batch" for batch requests
+func rpcRequestMethod(req *http.Request) string {
+	if req.GetBody == nil {
+		return "unknown"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "unknown"
+	}
+	defer body.Close()
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return "unknown"
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		return "batch"
+	}
+
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Method == "" {
+		return "unknown"
+	}
+	return msg.Method
+}
+
+// rpcResponseHasError reports whether a JSON-RPC response (or any response in a batch)
+// carries an error object. The body is buffered and restored for the caller.
+func rpcResponseHasError(resp *http.Response) bool {
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+	if err != nil {
+		return false
+	}
+
+	type rpcResponse struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []rpcResponse
+		if json.Unmarshal(raw, &batch) != nil {
+			return false
+		}
+		for _, item := range batch {
+			if len(item.Error) > 0 && string(item.Error) != "null" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var single rpcResponse
+	if json.Unmarshal(raw, &single) != nil {
+		return false
+	}
+	return len(single.Error) > 0 && string(single.Error) != "null"
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// queueDepthInterval is how often the queue depth gauges are sampled
+const queueDepthInterval = 15 * time.Second
+
+// The default registry's Go collector only exports the classic memstats; replace it with one
+// that also exports the runtime/metrics GC pause, heap and scheduler histograms
+func init() {
+	prometheus.Unregister(collectors.NewGoCollector())
+	prometheus.MustRegister(collectors.NewGoCollector(
+		collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC, collectors.MetricsMemory, collectors.MetricsScheduler),
+	))
+}
+
+// countingDialer dials like dialer and tracks the connection in relayer_open_connections
+// until it is closed
+func countingDialer(dialer *net.Dialer, peer string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	gauge := openConnections.WithLabelValues(peer)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		gauge.Inc()
+		return &countedConn{Conn: conn, gauge: gauge}, nil
+	}
+}
+
+// countedConn decrements its gauge once when closed
+type countedConn struct {
+	net.Conn
+	gauge prometheus.Gauge
+	once  sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(c.gauge.Dec)
+	return c.Conn.Close()
+}
+
+// sampleQueueDepths updates the queue depth gauges every queueDepthInterval until ctx is done
+func (r *Relayer) sampleQueueDepths(ctx context.Context) {
+	ticker := time.NewTicker(queueDepthInterval)
+	defer ticker.Stop()
+
+	for {
+		r.retriesMu.Lock()
+		retries, parked, deferred := len(r.retries), 0, 0
+		for _, entry := range r.retries {
+			switch {
+			case entry.deferred():
+				deferred++
+			case entry.parked():
+				parked++
+			}
+		}
+		r.retriesMu.Unlock()
+		queueDepth.WithLabelValues("retry").Set(float64(retries))
+		queueDepth.WithLabelValues("parked").Set(float64(parked))
+		queueDepth.WithLabelValues("cost_deferred").Set(float64(deferred))
+		queueDepth.WithLabelValues("inflight").Set(float64(r.inflightCount()))
+		queueDepth.WithLabelValues("paused").Set(float64(r.queuedVAAs.Load()))
+		queueDepth.WithLabelValues("safe_order").Set(float64(r.safeOrder.pendingCount()))
+		queueDepth.WithLabelValues("intake").Set(float64(len(r.intake.items)))
+		queueDepth.WithLabelValues("maintenance").Set(float64(r.deferredVAAs.Load()))
+		queueDepth.WithLabelValues("spilled").Set(float64(r.intake.spilledCount()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// recordSafeCost charges a confirmed transaction, and the L1 fee it paid on top of its gas, to
+// the Safe named in the payload. relay is set for the transaction completing the relay, so
+// multi-step flows count one relay.
+func (r *Relayer) recordSafeCost(dest *Destination, payload *RecoveryPayload, receipt *types.Receipt, l1Fee *big.Int, relay bool) {
+	cost := SafeCost{
+		Safe:    payload.Safe,
+		ChainID: dest.ChainID,
+		Day:     time.Now(),
+		GasUsed: receipt.GasUsed,
+		CostWei: receiptCost(receipt, l1Fee),
+	}
+
+	if relay {
+		cost.Relays = 1
+	}
+
+	if err := r.store.AddSafeCost(cost); err != nil {
+		r.logger.Error("Failed to record Safe gas cost",
+			zap.String("safeAddress", payload.Safe.Hex()),
+			zap.String("txHash", receipt.TxHash.Hex()),
+			zap.Error(err))
+	}
+}
+
+// recordRelay stores the gas used and fee paid by a confirmed flow step for the history report
+func (r *Relayer) recordRelay(dest *Destination, tenant *Tenant, vaaData *VAAData, step *flowStep, receipt *types.Receipt, l1Fee *big.Int) {
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	record := RelayRecord{
+		TxHash:            receipt.TxHash,
+		VAAHash:           computeVAAKey(vaaData.RawBytes),
+		CorrelationID:     vaaData.CorrelationID,
+		Tenant:            tenant.Name,
+		Function:          step.Function,
+		Safe:              vaaData.Payload.Safe,
+		ChainID:           dest.ChainID,
+		Block:             receipt.BlockNumber.Uint64(),
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: gasPrice,
+		CostWei:           receiptCost(receipt, l1Fee),
+		ConfirmedAt:       time.Now().UTC(),
+		VAA:               vaaData.RawBytes,
+	}
+	record.Receipt = r.signRelayReceipt(vaaData, record)
+
+	if err := r.store.AddRelayRecord(record); err != nil {
+		r.logger.Error("Failed to record relay gas",
+			zap.String("txHash", receipt.TxHash.Hex()),
+			zap.String("correlationId", vaaData.CorrelationID),
+			zap.Error(err))
+	}
+}
+
+// SafeCostTotal sums a Safe's relays across the days and chains of a report
+type SafeCostTotal struct {
+	Safe    common.Address `json:"safe"`
+	Relays  uint64         `json:"relays"`
+	GasUsed uint64         `json:"gasUsed"`
+	CostWei string         `json:"costWei"`
+	Days    []SafeCostDay  `json:"days"`
+}
+
+// SafeCostDay is one day of a Safe's costs on one chain
+type SafeCostDay struct {
+	Day     string `json:"day"`
+	ChainID uint64 `json:"chainId"`
+	Relays  uint64 `json:"relays"`
+	GasUsed uint64 `json:"gasUsed"`
+	CostWei string `json:"costWei"`
+}
+
+// SafeCostReport groups the daily totals between from and to by Safe
+func (r *Relayer) SafeCostReport(safe *common.Address, from, to time.Time) ([]SafeCostTotal, error) {
+	costs, err := r.store.SafeCosts(safe, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var report []SafeCostTotal
+	index := make(map[common.Address]int)
+	totals := make(map[common.Address]*big.Int)
+	for _, cost := range costs {
+		i, ok := index[cost.Safe]
+		if !ok {
+			i = len(report)
+			index[cost.Safe] = i
+			report = append(report, SafeCostTotal{Safe: cost.Safe})
+			totals[cost.Safe] = new(big.Int)
+		}
+		entry := &report[i]
+		entry.Relays += cost.Relays
+		entry.GasUsed += cost.GasUsed
+		totals[cost.Safe].Add(totals[cost.Safe], cost.CostWei)
+		entry.Days = append(entry.Days, SafeCostDay{
+			Day:     cost.Day.Format(time.DateOnly),
+			ChainID: cost.ChainID,
+			Relays:  cost.Relays,
+			GasUsed: cost.GasUsed,
+			CostWei: cost.CostWei.String(),
+		})
+	}
+	for i := range report {
+		report[i].CostWei = totals[report[i].Safe].String()
+	}
+	return report, nil
+}
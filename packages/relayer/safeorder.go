@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// errSafeSuperseded rejects a VAA arriving after a later message for the same Safe executed
+var errSafeSuperseded = errors.New("superseded by a later message for the Safe")
+
+// safeQueue orders the messages one emitter sends about one Safe on one chain
+type safeQueue struct {
+	pending  map[uint64]string // Sequences that entered processing and did not finish, to dedupe key
+	inLine   map[uint64]bool   // Pending sequences holding the Safe or waiting for it
+	busy     bool              // A transaction for the Safe is being sent or awaited
+	lastDone uint64            // Highest sequence executed
+	hasDone  bool
+	wake     chan struct{} // Closed and replaced whenever the queue changes
+}
+
+// safeOrdering serializes each Safe's transactions in sequence order
+type safeOrdering struct {
+	mu     sync.Mutex
+	queues map[string]*safeQueue
+	byKey  map[string]string // Dedupe key of a pending VAA -> its queue
+}
+
+// safeOrderKey identifies a Safe's message stream; sequences only compare within one emitter
+func safeOrderKey(chainID uint64, safe common.Address, emitterHex string) string {
+	return fmt.Sprintf("%d/%s/%s", chainID, safe.Hex(), emitterHex)
+}
+
+// queue returns the Safe's queue, creating it; callers hold mu
+func (o *safeOrdering) queue(key string) *safeQueue {
+	if o.queues == nil {
+		o.queues = make(map[string]*safeQueue)
+		o.byKey = make(map[string]string)
+	}
+	q, ok := o.queues[key]
+	if !ok {
+		q = &safeQueue{
+			pending: make(map[uint64]string),
+			inLine:  make(map[uint64]bool),
+			wake:    make(chan struct{}),
+		}
+		o.queues[key] = q
+	}
+	return q
+}
+
+// lowestPending returns the lowest pending sequence; callers hold mu
+func (q *safeQueue) lowestPending() uint64 {
+	first := true
+	var lowest uint64
+	for seq := range q.pending {
+		if first || seq < lowest {
+			lowest = seq
+			first = false
+		}
+	}
+	return lowest
+}
+
+// signal wakes every VAA waiting on the queue; callers hold mu
+func (q *safeQueue) signal() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// acquireSafe waits until the VAA's Safe is free and no earlier message for it is pending,
+// then holds the Safe until the returned release is called with whether the message executed.
+// A VAA older than a message already executed for the Safe fails with errSafeSuperseded; one
+// whose predecessor is waiting in the retry queue fails with an out-of-order error so it is
+// retried after it.
+func (r *Relayer) acquireSafe(ctx context.Context, dest *Destination, vaaData *VAAData) (func(executed bool), error) {
+	log := withCorrelation(r.logger, vaaData.CorrelationID)
+
+	o := &r.safeOrder
+	key := safeOrderKey(dest.ChainID, vaaData.Payload.Safe, vaaData.EmitterHex)
+	dedupeKey := r.dedupeKey(vaaData.RawBytes)
+	seq := vaaData.Sequence
+
+	o.mu.Lock()
+	q := o.queue(key)
+	if q.hasDone && seq <= q.lastDone {
+		lastDone := q.lastDone
+		o.mu.Unlock()
+		return nil, fmt.Errorf("%w (sequence %d executed)", errSafeSuperseded, lastDone)
+	}
+	q.pending[seq] = dedupeKey
+	o.byKey[dedupeKey] = key
+	q.inLine[seq] = true
+
+	logged := false
+	for {
+		lowest := q.lowestPending()
+		if lowest < seq && !q.inLine[lowest] {
+			delete(q.inLine, seq)
+			lowestKey := q.pending[lowest]
+			o.mu.Unlock()
+			// Behind a call flow waiting out a step's delay, come back when it resumes
+			if until, waiting := r.flowWaitUntil(lowestKey); waiting {
+				return nil, pipelineError(ErrorKindFlowWait, &flowWaitError{
+					reason: fmt.Sprintf("earlier message %d for Safe %s is waiting in its call flow", lowest, vaaData.Payload.Safe.Hex()),
+					until:  until,
+				})
+			}
+			return nil, pipelineError(ErrorKindOutOfOrder,
+				fmt.Errorf("earlier message %d for Safe %s is awaiting retry", lowest, vaaData.Payload.Safe.Hex()))
+		}
+		if !q.busy && lowest == seq {
+			q.busy = true
+			break
+		}
+
+		wake := q.wake
+		o.mu.Unlock()
+		if !logged {
+			log.Info("Waiting for earlier message for the same Safe",
+				zap.Uint64("sequence", seq),
+				zap.Uint64("waitingFor", lowest),
+				zap.String("safeAddress", vaaData.Payload.Safe.Hex()),
+				zap.String("destination", dest.Name))
+			logged = true
+		}
+
+		select {
+		case <-wake:
+			o.mu.Lock()
+		case <-ctx.Done():
+			o.mu.Lock()
+			delete(q.inLine, seq)
+			q.signal()
+			o.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	o.mu.Unlock()
+
+	var once sync.Once
+	return func(executed bool) {
+		once.Do(func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			q.busy = false
+			delete(q.inLine, seq)
+			if executed {
+				delete(q.pending, seq)
+				delete(o.byKey, dedupeKey)
+				if !q.hasDone || seq > q.lastDone {
+					q.lastDone = seq
+					q.hasDone = true
+				}
+			}
+			q.signal()
+		})
+	}, nil
+}
+
+// forgetSafeMessage drops a VAA given up on from its Safe's queue, so later messages for the
+// Safe stop waiting for it
+func (r *Relayer) forgetSafeMessage(dedupeKey string) {
+	o := &r.safeOrder
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key, ok := o.byKey[dedupeKey]
+	if !ok {
+		return
+	}
+	delete(o.byKey, dedupeKey)
+	q := o.queues[key]
+	for seq, k := range q.pending {
+		if k == dedupeKey {
+			delete(q.pending, seq)
+		}
+	}
+	q.signal()
+}
+
+// reopen marks a sequence recorded as executed for a Safe as not executed, so it can be relayed
+// again. It returns false when a later sequence for the Safe has executed.
+func (o *safeOrdering) reopen(key string, seq uint64) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	q, ok := o.queues[key]
+	if !ok || !q.hasDone || q.lastDone < seq {
+		return true
+	}
+	if q.lastDone > seq {
+		return false
+	}
+	if seq == 0 {
+		q.hasDone = false
+	} else {
+		q.lastDone = seq - 1
+	}
+	q.signal()
+	return true
+}
+
+// pendingCount returns how many VAAs entered the Safe queues and have not executed
+func (o *safeOrdering) pendingCount() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.byKey)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// isModuleEnabledSelector is the Safe's isModuleEnabled(address) getter
+var isModuleEnabledSelector = crypto.Keccak256([]byte("isModuleEnabled(address)"))[:4]
+
+// errPreflightFailed rejects a VAA the destination's current state shows would revert
+var errPreflightFailed = errors.New("Safe preflight failed")
+
+// Preflight checks, as counted by relayer_safe_preflight_failures_total
+const (
+	preflightNoCode          = "no_code"
+	preflightNotSafe         = "not_safe"
+	preflightModuleDisabled  = "module_disabled"
+	preflightEmitterMismatch = "emitter_mismatch"
+)
+
+// preflightSafe reads, in one batch request, whether the payload's Safe is a contract that
+// answers as a Safe, whether it still has the tenant's module enabled and whether the module
+// has the VAA's emitter registered for it. A check that fails is reported as errPreflightFailed;
+// any other error means the state could not be read.
+func (r *Relayer) preflightSafe(ctx context.Context, dest *Destination, tenant *Tenant, vaaData *VAAData) error {
+	safe := vaaData.Payload.Safe
+
+	var code, enabled, registered hexutil.Bytes
+	calls := []rpc.BatchElem{
+		{Method: "eth_getCode", Args: []any{safe, "latest"}, Result: &code},
+		{Method: "eth_call", Args: []any{map[string]any{
+			"to":   safe,
+			"data": hexutil.Bytes(append(append([]byte{}, isModuleEnabledSelector...), common.LeftPadBytes(tenant.target.Bytes(), 32)...)),
+		}, "latest"}, Result: &enabled},
+		{Method: "eth_call", Args: []any{map[string]any{
+			"to":   tenant.target,
+			"data": hexutil.Bytes(append(append([]byte{}, getAztecRecoveryContractSelector...), common.LeftPadBytes(safe.Bytes(), 32)...)),
+		}, "latest"}, Result: &registered},
+	}
+	if err := dest.client.batchCall(ctx, calls); err != nil {
+		return err
+	}
+
+	if err := calls[0].Error; err != nil {
+		return classifyError(fmt.Errorf("failed to read Safe code: %v", err), ErrorKindRPC)
+	}
+	if len(code) == 0 {
+		return r.preflightFailed(vaaData, preflightNoCode, fmt.Errorf("Safe %s has no contract code on %s", safe.Hex(), dest.Name))
+	}
+
+	if err := calls[1].Error; err != nil {
+		if errorKindOf(err) != ErrorKindSimulationRevert {
+			return classifyError(fmt.Errorf("failed to read Safe modules: %v", err), ErrorKindRPC)
+		}
+		return r.preflightFailed(vaaData, preflightNotSafe, fmt.Errorf("%s does not answer isModuleEnabled, not a Safe: %v", safe.Hex(), err))
+	}
+	if len(enabled) != 32 {
+		return r.preflightFailed(vaaData, preflightNotSafe, fmt.Errorf("%s returned %d bytes from isModuleEnabled, not a Safe", safe.Hex(), len(enabled)))
+	}
+	if enabled[31] != 1 {
+		return r.preflightFailed(vaaData, preflightModuleDisabled, fmt.Errorf("recovery module %s is not enabled on Safe %s", tenant.target.Hex(), safe.Hex()))
+	}
+
+	// Mirrors routeVAA, which doesn't tie the emitter to the Safe either
+	if r.config.AcceptAnyEmitter {
+		return nil
+	}
+	if _, ok := r.configuredEmitter(r.decodeEmitter(vaaData.EmitterHex)); ok {
+		return nil
+	}
+	if err := calls[2].Error; err != nil {
+		return classifyError(fmt.Errorf("failed to read the Safe's Aztec recovery contract: %v", err), ErrorKindRPC)
+	}
+	if len(registered) != 32 {
+		return fmt.Errorf("getAztecRecoveryContract returned %d bytes, want 32", len(registered))
+	}
+	if emitter := fmt.Sprintf("%x", []byte(registered)); emitter != vaaData.EmitterHex {
+		return r.preflightFailed(vaaData, preflightEmitterMismatch, fmt.Errorf("module %s has emitter %s registered for Safe %s, not the VAA's",
+			tenant.target.Hex(), emitter, safe.Hex()))
+	}
+	return nil
+}
+
+// preflightFailed counts a failed check and tags err as errPreflightFailed
+func (r *Relayer) preflightFailed(vaaData *VAAData, check string, err error) error {
+	incWithExemplar(safePreflightFailures.WithLabelValues(check), vaaData.CorrelationID)
+	withCorrelation(r.logger, vaaData.CorrelationID).Debug("Safe preflight check failed", zap.String("check", check), zap.Error(err))
+	return fmt.Errorf("%w: %v", errPreflightFailed, err)
+}
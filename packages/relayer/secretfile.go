@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// readSecretFile reads a file holding a key, token or passphrase. It must be a regular file,
+// not readable or writable by group or others, and owned by the user the relayer runs as;
+// with ALLOW_INSECURE_KEY_FILES a file failing these checks is read with a warning instead.
+func readSecretFile(path string, allowInsecure bool) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Checked on the opened file, so it can't be swapped between the check and the read
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if problem := secretFileProblem(info); problem != "" {
+		if !allowInsecure {
+			return nil, fmt.Errorf("%s %s (set ALLOW_INSECURE_KEY_FILES=true to start anyway)", path, problem)
+		}
+		logger.Warn("Reading insecure secret file", zap.String("path", path), zap.String("problem", problem))
+	}
+	return io.ReadAll(io.LimitReader(f, 1<<20))
+}
+
+// secretFileProblem describes why a secret file is not safe to read, or returns ""
+func secretFileProblem(info os.FileInfo) string {
+	if !info.Mode().IsRegular() {
+		return "is not a regular file"
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Sprintf("has mode %04o; it must not be accessible to group or others (chmod 600)", perm)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Geteuid() {
+		return fmt.Sprintf("is owned by uid %d, not uid %d the relayer runs as", stat.Uid, os.Geteuid())
+	}
+	return ""
+}
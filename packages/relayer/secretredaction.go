@@ -0,0 +1,225 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactingSinkScheme prefixes log output paths whose output passes through logSecrets, e.g.
+// redacted:stderr
+const redactingSinkScheme = "redacted"
+
+// Shortest configured value treated as a secret; shorter ones would redact ordinary words
+const minSecretLength = 8
+
+var (
+	// urlPattern finds URLs in text, stopping at quotes and the escapes JSON adds around them
+	urlPattern = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s"'<>\\]+`)
+	// credentialQuery matches query parameters that carry credentials
+	credentialQuery = regexp.MustCompile(`(?i)([?&;](?:api[-_]?key|key|token|access[-_]?token|auth|secret|password|passwd|pwd)=)[^&;#]+`)
+	// keyLikeSegment matches a URL path segment shaped like a provider API key, e.g. Infura's
+	// /v3/<key> or Alchemy's /v2/<key>
+	keyLikeSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+)
+
+// logSecrets redacts every log line and admin API response. Configured secrets are added once
+// the configuration is loaded; credentials in URLs are redacted from the start.
+var logSecrets = &secretRedactor{secrets: make(map[string]bool)}
+
+// secretRedactor replaces known secrets and URL credentials with a placeholder
+type secretRedactor struct {
+	mu       sync.RWMutex
+	secrets  map[string]bool
+	replacer *strings.Replacer
+}
+
+// add registers secrets to redact; hex values are matched with and without 0x, in either case
+func (s *secretRedactor) add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		for _, form := range []string{value, strings.TrimPrefix(strings.ToLower(value), "0x"), strings.ToUpper(strings.TrimPrefix(strings.ToLower(value), "0x"))} {
+			if len(form) >= minSecretLength {
+				s.secrets[form] = true
+			}
+		}
+	}
+
+	// The longest secrets go first, so one containing another is redacted whole
+	all := make([]string, 0, len(s.secrets))
+	for secret := range s.secrets {
+		all = append(all, secret)
+	}
+	sort.Slice(all, func(i, j int) bool { return len(all[i]) > len(all[j]) })
+	pairs := make([]string, 0, 2*len(all))
+	for _, secret := range all {
+		pairs = append(pairs, secret, redacted)
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+}
+
+// redact returns text with known secrets and URL credentials replaced
+func (s *secretRedactor) redact(text string) string {
+	s.mu.RLock()
+	replacer := s.replacer
+	s.mu.RUnlock()
+	if replacer != nil {
+		text = replacer.Replace(text)
+	}
+	if strings.Contains(text, "://") {
+		text = urlPattern.ReplaceAllStringFunc(text, redactURLCredentials)
+	}
+	return text
+}
+
+// redactURLCredentials replaces the user info, credential query parameters and key-like path
+// segments of a URL found in text
+func redactURLCredentials(raw string) string {
+	scheme := strings.Index(raw, "://") + len("://")
+	rest := raw[scheme:]
+	hostEnd := strings.IndexAny(rest, "/?#")
+	if hostEnd < 0 {
+		hostEnd = len(rest)
+	}
+	if at := strings.LastIndex(rest[:hostEnd], "@"); at >= 0 {
+		rest = redacted + rest[at:]
+		hostEnd += len(redacted) - at
+	}
+
+	host, path := rest[:hostEnd], rest[hostEnd:]
+	query := ""
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path, query = path[:i], path[i:]
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isKeyLike(segment) {
+			segments[i] = redacted
+		}
+	}
+	query = credentialQuery.ReplaceAllString(query, "${1}"+redacted)
+	return raw[:scheme] + host + strings.Join(segments, "/") + query
+}
+
+// isKeyLike reports whether a URL path segment looks like an API key rather than a name or a
+// hash: long, mixing letters and digits, and not 0x-prefixed hex
+func isKeyLike(segment string) bool {
+	if !keyLikeSegment.MatchString(segment) || strings.HasPrefix(segment, "0x") {
+		return false
+	}
+	return strings.ContainsAny(segment, "0123456789") &&
+		strings.ContainsAny(strings.ToLower(segment), "abcdefghijklmnopqrstuvwxyz")
+}
+
+// configSecrets returns the secrets in the configuration: signing keys, tokens, passwords and
+// the credentials embedded in endpoint URLs
+func configSecrets(config Config) []string {
+	secrets := append([]string{}, config.signingKeys()...)
+	secrets = append(secrets, config.NextPrivateKeys...)
+	secrets = append(secrets,
+		config.TreasuryPrivateKey,
+		config.ReceiptSigningKey,
+		config.AdminJWTSecret,
+		config.QueryAPIKey,
+		config.SpyAuthToken,
+		config.PushgatewayPassword,
+		config.FireblocksAPIKey,
+		config.BiconomyOwnerKey,
+	)
+	for _, entry := range config.AdminAPIKeys {
+		secrets = append(secrets, entry[strings.LastIndex(entry, ":")+1:])
+	}
+
+	urls := []string{
+		config.EVMRPCURL,
+		config.RemoteSignerURL,
+		config.StatePostgresURL,
+		config.QueryServerURL,
+		config.GuardianAPIURL,
+		config.PushgatewayURL,
+		config.OutboundProxy,
+	}
+	for _, dest := range config.Destinations {
+		urls = append(urls, dest.RPCURL, dest.BundlerURL, dest.PaymasterURL)
+	}
+	urls = append(urls, config.CosignerURLs...)
+	for _, raw := range urls {
+		secrets = append(secrets, urlSecrets(raw)...)
+	}
+	return secrets
+}
+
+// urlSecrets returns the password, query parameter values and key-like path segments of a URL
+func urlSecrets(raw string) []string {
+	parsed, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return nil
+	}
+	var secrets []string
+	if password, ok := parsed.User.Password(); ok {
+		secrets = append(secrets, password)
+	}
+	for _, values := range parsed.Query() {
+		secrets = append(secrets, values...)
+	}
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if isKeyLike(segment) {
+			secrets = append(secrets, segment)
+		}
+	}
+	return secrets
+}
+
+// redactingSink is a log output whose lines pass through logSecrets; zap writes each encoded
+// entry with a single Write
+type redactingSink struct {
+	zapcore.WriteSyncer
+	close func()
+}
+
+func init() {
+	if err := zap.RegisterSink(redactingSinkScheme, openRedactingSink); err != nil {
+		panic(err)
+	}
+}
+
+// openRedactingSink opens the output named after the scheme, e.g. stderr for redacted:stderr
+func openRedactingSink(u *url.URL) (zap.Sink, error) {
+	target := u.Opaque
+	if target == "" {
+		target = u.Path
+	}
+	out, closeOut, err := zap.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	return redactingSink{WriteSyncer: out, close: closeOut}, nil
+}
+
+func (s redactingSink) Write(p []byte) (int, error) {
+	if _, err := s.WriteSyncer.Write([]byte(logSecrets.redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s redactingSink) Close() error {
+	s.close()
+	return nil
+}
+
+// redactingPaths routes log output paths through the redacting sink
+func redactingPaths(paths []string) []string {
+	out := make([]string, len(paths))
+	for i, path := range paths {
+		out[i] = redactingSinkScheme + ":" + path
+	}
+	return out
+}
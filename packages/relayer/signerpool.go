@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signer signs transactions for one relayer account, which has its own nonce sequence.
+// Submission only goes through this interface, so a new backend (a cloud KMS, say) needs
+// nothing but these two methods; programs embedding the relayer pass theirs in Config.signers.
+type Signer interface {
+	// Address is the account transactions are sent from
+	Address() common.Address
+	// SignTx signs tx with the chain's EIP-155 signer. description identifies the VAA being
+	// relayed, for signers that ask an operator to approve each transaction.
+	SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error)
+}
+
+// typedDataSigner is a Signer that can also sign EIP-712 typed data, which zkSync's native
+// transactions are. The signature is 65 bytes with a recovery ID of 27 or 28.
+type typedDataSigner interface {
+	Signer
+	SignTypedData(ctx context.Context, data apitypes.TypedData, description string) ([]byte, error)
+}
+
+// localSigner signs in-process with a private key
+type localSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func (s *localSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *localSigner) SignTx(ctx context.Context, tx *types.Transaction, signer types.Signer, description string) (*types.Transaction, error) {
+	return types.SignTx(tx, signer, s.key)
+}
+
+func (s *localSigner) SignTypedData(ctx context.Context, data apitypes.TypedData, description string) ([]byte, error) {
+	digest, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := crypto.Sign(digest, s.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// localSigners creates signers for hex-encoded private keys
+func localSigners(privateKeysHex []string) ([]Signer, error) {
+	accounts := make([]Signer, 0, len(privateKeysHex))
+	for i, keyHex := range privateKeysHex {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(keyHex, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key #%d: %v", i+1, err)
+		}
+
+		publicKeyECDSA, ok := privateKey.Public().(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("error casting public key to ECDSA")
+		}
+
+		accounts = append(accounts, &localSigner{key: privateKey, address: crypto.PubkeyToAddress(*publicKeyECDSA)})
+	}
+	return accounts, nil
+}
+
+// signerBackends are the external signers behind a set of signer accounts
+type signerBackends struct {
+	ledger *ledgerSigner
+	remote *remoteSigner
+}
+
+// openSigners creates the accounts configured through PRIVATE_KEY/PRIVATE_KEYS, LEDGER_*,
+// REMOTE_SIGNER_* and FIREBLOCKS_*, followed by any passed in Config.signers, all of them
+// behind the COSIGNER_* co-signers when configured
+func openSigners(config Config) ([]Signer, *signerBackends, error) {
+	accounts, err := localSigners(config.signingKeys())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backends := &signerBackends{}
+	if config.LedgerEnabled {
+		ledger, err := openLedgerSigner(config.LedgerPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		backends.ledger = ledger
+		accounts = append(accounts, ledger)
+	}
+	if config.RemoteSignerURL != "" {
+		remote, remoteAccounts, err := openRemoteSigner(config.RemoteSignerURL, config.RemoteSignerAddrs, config.OutboundProxy)
+		if err != nil {
+			backends.Close()
+			return nil, nil, err
+		}
+		backends.remote = remote
+		accounts = append(accounts, remoteAccounts...)
+	}
+	fireblocks, err := openFireblocksSigner(config)
+	if err != nil {
+		backends.Close()
+		return nil, nil, err
+	}
+	if fireblocks != nil {
+		accounts = append(accounts, fireblocks)
+	}
+	accounts = append(accounts, config.signers...)
+
+	cosigners, err := newCosigners(config)
+	if err != nil {
+		backends.Close()
+		return nil, nil, err
+	}
+	if cosigners != nil {
+		for i, account := range accounts {
+			accounts[i] = &cosignedSigner{Signer: account, cosigners: cosigners}
+		}
+	}
+
+	return accounts, backends, nil
+}
+
+// Close releases the external signers
+func (b *signerBackends) Close() {
+	if b.ledger != nil {
+		b.ledger.Close()
+	}
+	if b.remote != nil {
+		b.remote.Close()
+	}
+}
+
+// signerPool lends accounts to submissions so that each account has at most one
+// transaction being built and sent at a time, while different accounts send in parallel.
+// Released accounts go to a waiting priority submission before any routine one.
+type signerPool struct {
+	mu        sync.Mutex
+	accounts  []Signer
+	available chan Signer // Replaced, and the old one closed, when the accounts are rotated
+	priority  chan Signer // Unbuffered hand-off to waiting priority submissions
+	lent      map[common.Address]bool
+	retired   map[common.Address]bool // Accounts a key rotation stopped lending
+}
+
+// newSignerPool creates a pool lending out the given accounts
+func newSignerPool(accounts []Signer) (*signerPool, error) {
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no signers configured")
+	}
+
+	pool := &signerPool{
+		available: make(chan Signer, len(accounts)),
+		priority:  make(chan Signer),
+		lent:      make(map[common.Address]bool),
+		retired:   make(map[common.Address]bool),
+	}
+	seen := make(map[common.Address]bool, len(accounts))
+
+	for _, account := range accounts {
+		// Two pool slots sharing an account would race on its nonce
+		if seen[account.Address()] {
+			return nil, fmt.Errorf("signer %s is configured more than once", account.Address().Hex())
+		}
+		seen[account.Address()] = true
+
+		pool.accounts = append(pool.accounts, account)
+		pool.available <- account
+	}
+
+	return pool, nil
+}
+
+// acquire waits for a free account; accounts are handed out in rotation. A ctx marked with
+// withPriority is also handed accounts as they are released, ahead of routine waiters.
+func (p *signerPool) acquire(ctx context.Context) (Signer, error) {
+	for {
+		p.mu.Lock()
+		available := p.available
+		p.mu.Unlock()
+
+		var account Signer
+		ok := true
+		if isPriority(ctx) {
+			select {
+			case account, ok = <-available:
+			case account = <-p.priority:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("no signer available: %v", ctx.Err())
+			}
+		} else {
+			select {
+			case account, ok = <-available:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("no signer available: %v", ctx.Err())
+			}
+		}
+		// A closed channel or a retired account means the keys were rotated; wait on the new ones
+		if ok && p.lend(account) {
+			return account, nil
+		}
+	}
+}
+
+// lend marks an account as lent out, unless it was retired
+func (p *signerPool) lend(account Signer) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.retired[account.Address()] {
+		return false
+	}
+	p.lent[account.Address()] = true
+	return true
+}
+
+// release returns an account to the pool, handing it straight to a waiting priority
+// submission if there is one. Retired accounts are dropped.
+func (p *signerPool) release(account Signer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.lent, account.Address())
+	if p.retired[account.Address()] {
+		return
+	}
+	select {
+	case p.priority <- account:
+	default:
+		// Never blocks: the channel has room for every account not retired
+		p.available <- account
+	}
+}
+
+// rotate stops lending the pool's accounts and lends next in their place. Accounts lent out
+// finish their submission and are dropped on release; retired accounts stay in addresses until
+// dropRetired, so their balances and nonces are still watched while their transactions drain.
+func (p *signerPool) rotate(next []Signer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, account := range p.accounts {
+		p.retired[account.Address()] = true
+	}
+	available := make(chan Signer, len(next))
+	for _, account := range next {
+		available <- account
+	}
+	p.accounts = append(p.accounts, next...)
+	close(p.available)
+	p.available = available
+}
+
+// retiredLent reports whether a retired account is still lent to a submission
+func (p *signerPool) retiredLent() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for address := range p.lent {
+		if p.retired[address] {
+			return true
+		}
+	}
+	return false
+}
+
+// dropRetired removes the retired accounts from the pool
+func (p *signerPool) dropRetired() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	accounts := make([]Signer, 0, len(p.accounts))
+	for _, account := range p.accounts {
+		if !p.retired[account.Address()] {
+			accounts = append(accounts, account)
+		}
+	}
+	p.accounts = accounts
+}
+
+// addresses returns the address of every account in the pool
+func (p *signerPool) addresses() []common.Address {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addresses := make([]common.Address, len(p.accounts))
+	for i, account := range p.accounts {
+		addresses[i] = account.Address()
+	}
+	return addresses
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// runSimulate takes a VAA through the relayer's routing and registry checks and simulates the
+// verify call it would send, printing the estimated gas and whether it would succeed. Nothing
+// is signed or broadcast.
+func runSimulate(ctx context.Context, config Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: relayer simulate <vaa-hex>")
+		return 2
+	}
+	vaaBytes, err := decodeVAAHex(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	report := &checkReport{out: os.Stdout}
+	fmt.Fprintln(report.out, "VAA simulation")
+	defer func() {
+		fmt.Fprintln(report.out)
+		if report.failures > 0 {
+			fmt.Fprintln(report.out, "WOULD FAIL")
+		} else {
+			fmt.Fprintln(report.out, "WOULD SUCCEED")
+		}
+	}()
+
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		report.fail("parse VAA: %v", err)
+		return 1
+	}
+	report.ok("VAA chain %d emitter %s sequence %d", vaaData.ChainID, vaaData.EmitterHex, vaaData.Sequence)
+	if vaaData.ChainID != config.SourceChainID {
+		report.fail("VAA is from chain %d, the relayer only relays chain %d", vaaData.ChainID, config.SourceChainID)
+		return 1
+	}
+
+	r, err := openCommandRelayer(ctx, config)
+	if err != nil {
+		report.fail("%v", err)
+		return 1
+	}
+	defer r.Close()
+
+	if !r.config.AcceptAnyEmitter && !r.isRegisteredEmitter(vaaData.EmitterHex) {
+		report.fail("emitter is not registered with any tenant; the relayer would skip this VAA")
+		return 1
+	}
+
+	dest, tenant, err := r.routeVAA(vaaData)
+	if err != nil {
+		report.fail("would be rejected: %v", err)
+		return 1
+	}
+	payload := vaaData.Payload
+	report.ok("payload v%d: safe %s, candidate %s", payload.Version, payload.Safe.Hex(), payload.Candidate.Hex())
+	report.ok("routed to tenant %q on %s (chain %d), module %s", tenant.Name, dest.Name, dest.ChainID, tenant.TargetContract)
+
+	if err := r.preflightSafe(ctx, dest, tenant, vaaData); err != nil {
+		report.fail("%v", err)
+		return 1
+	}
+	report.ok("Safe preflight passed: module enabled, emitter registered")
+
+	// Later steps depend on state the earlier ones leave, so only the first can be simulated
+	flow, _ := tenant.flowFor(payload.Type)
+	step := flow[0]
+	data, err := step.pack(vaaData, nil)
+	if err != nil {
+		report.fail("%v", err)
+		return 1
+	}
+	gas, err := dest.client.SimulateCall(ctx, tenant.target, data)
+	if err != nil {
+		report.fail("%s: %v", step.Function, err)
+		return 1
+	}
+	if len(flow) > 1 {
+		report.warn("call flow has %d steps; only %s is simulated", len(flow), step.Function)
+	}
+
+	strategy := r.feeStrategyFor(dest, payload)
+	gasPrice, err := dest.client.EstimateGasPrice(ctx, strategy)
+	if err != nil {
+		report.ok("%s succeeds, estimated gas %d (gas price unavailable: %v)", step.Function, gas, err)
+		return 0
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice)
+	l1, err := dest.client.estimateL1(ctx, types.NewTransaction(0, tenant.target, big.NewInt(0), gas, gasPrice, data))
+	switch {
+	case err != nil:
+		report.warn("L1 data fee unavailable, not included: %v", err)
+	case l1.fee != nil:
+		cost.Add(cost, l1.fee)
+		report.ok("L1 data fee about %s ETH", formatWei(l1.fee))
+	case l1.gas > 0:
+		// eth_estimateGas already counts Arbitrum's L1 gas
+		report.ok("L1 data gas about %d of the estimate", l1.gas)
+	}
+	report.ok("%s succeeds, estimated gas %d at %s wei (%s strategy), about %s ETH",
+		step.Function, gas, gasPrice, strategy.Name, formatWei(cost))
+	return 0
+}
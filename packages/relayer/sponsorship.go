@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// What happens to a VAA on a sponsored destination that the sponsorship policy turns down
+const (
+	SponsorshipFallbackEOA    = "eoa"    // Sent from the signer pool like on any other destination
+	SponsorshipFallbackReject = "reject" // Handed to the retry queue, e.g. until the next day's caps
+)
+
+// errNotSponsored is returned when a VAA does not qualify for sponsored gas
+var errNotSponsored = errors.New("not eligible for sponsored gas")
+
+// sponsorshipPolicy decides which VAAs on destinations with the biconomy submitter get
+// sponsored gas: those for listed Safes or from listed emitters (every VAA when neither is
+// listed), within per-Safe, per-emitter and per-destination daily caps. Caps count user
+// operations the bundler accepted, per UTC day; the counts start over after a restart.
+type sponsorshipPolicy struct {
+	safes      map[common.Address]int // Sponsored Safes and their daily caps (0 for no cap)
+	emitters   map[string]int         // Sponsored emitters, normalized, and their daily caps
+	dailyLimit int                    // Sponsored operations per destination per day (0 for no limit)
+	fallback   string
+
+	mu   sync.Mutex
+	day  time.Time
+	used map[string]int // Counter key -> operations today
+}
+
+// newSponsorshipPolicy creates the policy from the SPONSOR* settings, or returns nil when no
+// destination uses the biconomy submitter
+func newSponsorshipPolicy(config Config) (*sponsorshipPolicy, error) {
+	sponsored := false
+	for _, dest := range config.Destinations {
+		sponsored = sponsored || strings.EqualFold(dest.Submitter, SubmitterBiconomy)
+	}
+	if !sponsored {
+		return nil, nil
+	}
+
+	switch config.SponsorshipFallback {
+	case SponsorshipFallbackEOA, SponsorshipFallbackReject:
+	default:
+		return nil, fmt.Errorf("SPONSORSHIP_FALLBACK must be %s or %s, got %q",
+			SponsorshipFallbackEOA, SponsorshipFallbackReject, config.SponsorshipFallback)
+	}
+	if config.SponsorshipDailyLimit < 0 {
+		return nil, fmt.Errorf("SPONSORSHIP_DAILY_LIMIT must not be negative")
+	}
+
+	p := &sponsorshipPolicy{
+		safes:      make(map[common.Address]int),
+		emitters:   make(map[string]int),
+		dailyLimit: config.SponsorshipDailyLimit,
+		fallback:   config.SponsorshipFallback,
+		used:       make(map[string]int),
+	}
+	for _, entry := range config.SponsoredSafes {
+		address, limit, err := parseSponsorshipEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("SPONSORED_SAFES: %v", err)
+		}
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("SPONSORED_SAFES: invalid Safe address %q", address)
+		}
+		p.safes[common.HexToAddress(address)] = limit
+	}
+	for _, entry := range config.SponsoredEmitters {
+		emitter, limit, err := parseSponsorshipEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("SPONSORED_EMITTERS: %v", err)
+		}
+		p.emitters[normalizeSponsoredEmitter(emitter)] = limit
+	}
+	return p, nil
+}
+
+// parseSponsorshipEntry splits an "address" or "address:cap" entry
+func parseSponsorshipEntry(entry string) (string, int, error) {
+	address, cap, hasCap := strings.Cut(strings.TrimSpace(entry), ":")
+	if !hasCap {
+		return address, 0, nil
+	}
+	limit, err := strconv.Atoi(cap)
+	if err != nil || limit < 1 {
+		return "", 0, fmt.Errorf("invalid daily cap in %q", entry)
+	}
+	return address, limit, nil
+}
+
+// normalizeSponsoredEmitter lowercases an emitter and strips its 0x prefix and leading zeros,
+// matching emitters as PRIORITY_EMITTERS does
+func normalizeSponsoredEmitter(emitter string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimPrefix(strings.TrimSpace(emitter), "0x"), "0"))
+}
+
+// limit returns the sponsored operations allowed per destination per day (0 for no limit)
+func (p *sponsorshipPolicy) limit() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dailyLimit
+}
+
+// setLimit changes the sponsored operations allowed per destination per day; operations
+// already sponsored today count toward it
+func (p *sponsorshipPolicy) setLimit(limit int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dailyLimit = limit
+}
+
+// reserve counts a sponsored operation for the Safe and emitters against the caps, returning
+// the counters it was counted under, or an error wrapping errNotSponsored when the VAA does not
+// qualify. emitters are the normalized forms the VAA's emitter may be listed under.
+func (p *sponsorshipPolicy) reserve(destination string, safe common.Address, emitters ...string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if today := safeCostDay(time.Now()); !today.Equal(p.day) {
+		p.day = today
+		p.used = make(map[string]int)
+	}
+
+	keys := []string{"destination:" + destination}
+	limits := []int{p.dailyLimit}
+	if len(p.safes) > 0 || len(p.emitters) > 0 {
+		if limit, ok := p.safes[safe]; ok {
+			keys = append(keys, "safe:"+safe.Hex())
+			limits = append(limits, limit)
+		} else if emitter, limit, ok := p.emitterRule(emitters); ok {
+			keys = append(keys, "emitter:"+emitter)
+			limits = append(limits, limit)
+		} else {
+			return nil, fmt.Errorf("%w: Safe %s and its emitter are not sponsored", errNotSponsored, safe.Hex())
+		}
+	}
+
+	for i, key := range keys {
+		if limits[i] > 0 && p.used[key] >= limits[i] {
+			return nil, fmt.Errorf("%w: daily cap of %d reached for %s", errNotSponsored, limits[i], key)
+		}
+	}
+	for _, key := range keys {
+		p.used[key]++
+	}
+	return keys, nil
+}
+
+// emitterRule finds the first of emitters that is sponsored
+func (p *sponsorshipPolicy) emitterRule(emitters []string) (string, int, bool) {
+	for _, emitter := range emitters {
+		if limit, ok := p.emitters[emitter]; ok {
+			return emitter, limit, true
+		}
+	}
+	return "", 0, false
+}
+
+// unreserve returns a reserved operation that the bundler never accepted
+func (p *sponsorshipPolicy) unreserve(keys []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range keys {
+		if p.used[key] > 0 {
+			p.used[key]--
+		}
+	}
+}
+
+// reserveSponsorship evaluates the sponsorship policy for a VAA about to be sent to dest. It
+// returns the reserved counters when the call is to be sponsored, nil when it is to be sent
+// from the signer pool instead, or an error when the VAA is to be retried later.
+func (r *Relayer) reserveSponsorship(dest *Destination, vaaData *VAAData) ([]string, error) {
+	var safe common.Address
+	if vaaData.Payload != nil {
+		safe = vaaData.Payload.Safe
+	}
+	normalizedEmitter, decodedEmitter := r.decodeEmitter(vaaData.EmitterHex)
+	keys, err := r.sponsorship.reserve(dest.Name, safe, normalizedEmitter, normalizeSponsoredEmitter(decodedEmitter))
+	if err == nil {
+		sponsorshipDecisions.WithLabelValues(dest.Name, "sponsored").Inc()
+		return keys, nil
+	}
+
+	if r.sponsorship.fallback == SponsorshipFallbackReject {
+		sponsorshipDecisions.WithLabelValues(dest.Name, "rejected").Inc()
+		return nil, pipelineError(ErrorKindNotSponsored, err)
+	}
+	sponsorshipDecisions.WithLabelValues(dest.Name, "signer_pool").Inc()
+	withCorrelation(r.logger, vaaData.CorrelationID).Info("Sending from the signer pool instead",
+		zap.String("destination", dest.Name),
+		zap.String("reason", err.Error()))
+	return nil, nil
+}
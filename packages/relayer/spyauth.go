@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// loadSpyAuthToken reads SPY_AUTH_TOKEN_FILE, if set, into the config's SpyAuthToken
+func loadSpyAuthToken(config *Config) error {
+	if config.SpyAuthTokenFile == "" {
+		return nil
+	}
+	if config.SpyAuthToken != "" {
+		return fmt.Errorf("SPY_AUTH_TOKEN and SPY_AUTH_TOKEN_FILE are mutually exclusive")
+	}
+	data, err := readSecretFile(config.SpyAuthTokenFile, config.AllowInsecureKeyFiles)
+	if err != nil {
+		return fmt.Errorf("failed to read spy auth token file: %v", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("spy auth token file %s is empty", config.SpyAuthTokenFile)
+	}
+	config.SpyAuthToken = token
+	logger.Info("Loaded spy auth token", zap.String("path", config.SpyAuthTokenFile))
+	return nil
+}
+
+// spyCredentials returns the transport credentials for the spy connection, TLS when SPY_TLS
+// is set, and the per-call credentials carrying the auth token, if any. The token is only sent
+// in plaintext to a spy on the local host.
+func spyCredentials(config Config) (credentials.TransportCredentials, credentials.PerRPCCredentials, error) {
+	if !config.SpyTLS && (config.SpyTLSCAFile != "" || config.SpyTLSServerName != "") {
+		return nil, nil, fmt.Errorf("SPY_TLS_CA_FILE and SPY_TLS_SERVER_NAME require SPY_TLS=true")
+	}
+
+	transport := insecure.NewCredentials()
+	if config.SpyTLS {
+		tlsConfig := &tls.Config{ServerName: config.SpyTLSServerName, MinVersion: tls.VersionTLS12}
+		if config.SpyTLSCAFile != "" {
+			pem, err := os.ReadFile(config.SpyTLSCAFile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read spy CA file: %v", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, nil, fmt.Errorf("spy CA file %s holds no PEM certificates", config.SpyTLSCAFile)
+			}
+		}
+		transport = credentials.NewTLS(tlsConfig)
+	}
+
+	if config.SpyAuthToken == "" {
+		return transport, nil, nil
+	}
+	local := isLoopbackEndpoint(config.SpyRPCHost)
+	if !config.SpyTLS && !local {
+		return nil, nil, fmt.Errorf("refusing to send the spy auth token in plaintext to %s; set SPY_TLS=true", config.SpyRPCHost)
+	}
+	auth := newSpyAuth(config.SpyAuthHeader, config.SpyAuthToken)
+	auth.requireTLS = !local
+	return transport, auth, nil
+}
+
+// isLoopbackEndpoint reports whether a gRPC target such as "localhost:7073" or
+// "dns:///127.0.0.1:7073" names the local host
+func isLoopbackEndpoint(endpoint string) bool {
+	if i := strings.LastIndex(endpoint, "/"); i >= 0 {
+		endpoint = endpoint[i+1:]
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	return err == nil && addr.IsLoopback()
+}
+
+// spyAuth attaches the spy auth token to every call as gRPC metadata, for spies exposed
+// through an authenticating gateway
+type spyAuth struct {
+	header     string // Lowercase metadata key
+	value      string
+	requireTLS bool // Refuse to send the token over a plaintext connection
+}
+
+// newSpyAuth creates credentials sending token under header. For the authorization header a
+// bare token is sent as a bearer token.
+func newSpyAuth(header, token string) spyAuth {
+	header = strings.ToLower(header)
+	value := token
+	if header == "authorization" && !strings.Contains(token, " ") {
+		value = "Bearer " + token
+	}
+	return spyAuth{header: header, value: value, requireTLS: true}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (a spyAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{a.header: a.value}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. Only a spy on the local
+// host receives the token without TLS.
+func (a spyAuth) RequireTransportSecurity() bool {
+	return a.requireTLS
+}
+
+var _ credentials.PerRPCCredentials = spyAuth{}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpyCredentials(t *testing.T) {
+	garbageCA := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(garbageCA, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		config         Config
+		wantTLS        bool
+		wantAuth       bool
+		wantRequireTLS bool
+		wantErr        string // Empty when the config is accepted
+	}{
+		{name: "plaintext without a token", config: Config{SpyRPCHost: "spy.internal:7073"}},
+		{name: "TLS without a token", config: Config{SpyRPCHost: "spy.internal:7073", SpyTLS: true}, wantTLS: true},
+		{name: "token to localhost", config: Config{SpyRPCHost: "localhost:7073", SpyAuthToken: "secret"}, wantAuth: true},
+		{name: "token to IPv4 loopback", config: Config{SpyRPCHost: "127.0.0.2:7073", SpyAuthToken: "secret"}, wantAuth: true},
+		{name: "token to IPv6 loopback", config: Config{SpyRPCHost: "[::1]:7073", SpyAuthToken: "secret"}, wantAuth: true},
+		{name: "token to loopback target with scheme", config: Config{SpyRPCHost: "dns:///localhost:7073", SpyAuthToken: "secret"}, wantAuth: true},
+		{name: "token over TLS", config: Config{SpyRPCHost: "spy.example.com:443", SpyTLS: true, SpyAuthToken: "secret"}, wantTLS: true, wantAuth: true, wantRequireTLS: true},
+		{
+			name:    "token in plaintext to a remote spy",
+			config:  Config{SpyRPCHost: "spy.internal:7073", SpyAuthToken: "secret"},
+			wantErr: "refusing to send the spy auth token in plaintext",
+		},
+		{
+			name:    "token in plaintext to a remote address",
+			config:  Config{SpyRPCHost: "10.0.0.5:7073", SpyAuthToken: "secret"},
+			wantErr: "refusing to send the spy auth token in plaintext",
+		},
+		{
+			name:    "CA file without TLS",
+			config:  Config{SpyRPCHost: "spy.internal:7073", SpyTLSCAFile: garbageCA},
+			wantErr: "require SPY_TLS=true",
+		},
+		{
+			name:    "server name without TLS",
+			config:  Config{SpyRPCHost: "spy.internal:7073", SpyTLSServerName: "spy.example.com"},
+			wantErr: "require SPY_TLS=true",
+		},
+		{
+			name:    "CA file without certificates",
+			config:  Config{SpyRPCHost: "spy.internal:7073", SpyTLS: true, SpyTLSCAFile: garbageCA},
+			wantErr: "holds no PEM certificates",
+		},
+		{
+			name:    "missing CA file",
+			config:  Config{SpyRPCHost: "spy.internal:7073", SpyTLS: true, SpyTLSCAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: "failed to read spy CA file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.SpyAuthHeader = "authorization"
+			transport, auth, err := spyCredentials(tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if protocol := transport.Info().SecurityProtocol; (protocol == "tls") != tt.wantTLS {
+				t.Errorf("transport security %q, want TLS %v", protocol, tt.wantTLS)
+			}
+			if (auth != nil) != tt.wantAuth {
+				t.Fatalf("got per-call credentials %v, want %v", auth != nil, tt.wantAuth)
+			}
+			if auth == nil {
+				return
+			}
+			if auth.RequireTransportSecurity() != tt.wantRequireTLS {
+				t.Errorf("RequireTransportSecurity() = %v, want %v", auth.RequireTransportSecurity(), tt.wantRequireTLS)
+			}
+			metadata, _ := auth.GetRequestMetadata(context.Background())
+			if metadata["authorization"] != "Bearer secret" {
+				t.Errorf("got metadata %v", metadata)
+			}
+		})
+	}
+}
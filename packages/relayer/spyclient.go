@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// spyReadyTimeout bounds how long one subscribe attempt waits for the connection to come up
+const spyReadyTimeout = 10 * time.Second
+
+// spyConnectionStates are the gRPC connectivity states exported in relayer_spy_connection_state
+var spyConnectionStates = []connectivity.State{
+	connectivity.Idle, connectivity.Connecting, connectivity.Ready, connectivity.TransientFailure, connectivity.Shutdown,
+}
+
+// SpyClient handles connections to the Wormhole spy service. It keeps one gRPC connection for
+// its lifetime; gRPC reconnects it with backoff, and every subscription reuses it.
+type SpyClient struct {
+	conn        *grpc.ClientConn
+	client      spyv1.SpyRPCServiceClient
+	logger      *zap.Logger
+	stopWatcher context.CancelFunc
+}
+
+// NewSpyClient creates a new client for the Wormhole spy service at SPY_RPC_HOST
+func NewSpyClient(config Config) (*SpyClient, error) {
+	client := &SpyClient{
+		logger: logger.With(zap.String("component", "SpyClient")),
+	}
+
+	endpoint := config.SpyRPCHost
+	client.logger.Info("Connecting to spy service",
+		zap.String("endpoint", endpoint),
+		zap.Duration("keepaliveTime", config.SpyKeepaliveTime),
+		zap.Duration("reconnectMaxDelay", config.SpyReconnectMaxDelay),
+		zap.Int("maxMessageSize", config.SpyMaxMessageSize),
+		zap.Bool("tls", config.SpyTLS),
+		zap.Bool("authenticated", config.SpyAuthToken != ""))
+
+	transport, auth, err := spyCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := outboundProxy(config.OutboundProxy)
+	if err != nil {
+		return nil, err
+	}
+	// gRPC only proxies by itself without a custom dialer, so the dialer tunnels instead
+	dial := proxyDialer(proxy, "https", countingDialer(&net.Dialer{}, "spy"))
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(transport),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp", addr)
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  time.Second,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   config.SpyReconnectMaxDelay,
+			},
+			MinConnectTimeout: spyReadyTimeout,
+		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(config.SpyMaxMessageSize)),
+	}
+	if auth != nil {
+		options = append(options, grpc.WithPerRPCCredentials(auth))
+	}
+	if config.SpyKeepaliveTime > 0 {
+		options = append(options, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    config.SpyKeepaliveTime,
+			Timeout: config.SpyKeepaliveTimeout,
+		}))
+	}
+
+	conn, err := grpc.Dial(endpoint, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to spy: %v", err)
+	}
+
+	client.conn = conn
+	client.client = spyv1.NewSpyRPCServiceClient(conn)
+
+	watchCtx, stopWatcher := context.WithCancel(context.Background())
+	client.stopWatcher = stopWatcher
+	go client.watchState(watchCtx)
+	return client, nil
+}
+
+// Close closes the connection to the spy service
+func (c *SpyClient) Close() {
+	if c == nil {
+		return
+	}
+	if c.stopWatcher != nil {
+		c.stopWatcher()
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// watchState logs every connectivity change of the spy connection and exports it as
+// relayer_spy_connection_state, until ctx is done or the connection shuts down
+func (c *SpyClient) watchState(ctx context.Context) {
+	state := c.conn.GetState()
+	setSpyConnectionState(state)
+	for c.conn.WaitForStateChange(ctx, state) {
+		previous := state
+		state = c.conn.GetState()
+		setSpyConnectionState(state)
+		spyConnectionTransitions.WithLabelValues(state.String()).Inc()
+
+		fields := []zap.Field{zap.String("from", previous.String()), zap.String("to", state.String())}
+		switch state {
+		case connectivity.TransientFailure:
+			c.logger.Warn("Spy connection failed, reconnecting with backoff", fields...)
+		case connectivity.Ready:
+			c.logger.Info("Spy connection ready", fields...)
+		default:
+			c.logger.Debug("Spy connection state changed", fields...)
+		}
+		if state == connectivity.Shutdown {
+			return
+		}
+	}
+}
+
+// setSpyConnectionState sets the gauge of the current state to 1 and the others to 0
+func setSpyConnectionState(current connectivity.State) {
+	for _, state := range spyConnectionStates {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		spyConnectionState.WithLabelValues(state.String()).Set(value)
+	}
+}
+
+// waitReady waits up to spyReadyTimeout for the connection to be ready, asking an idle
+// connection to connect
+func (c *SpyClient) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, spyReadyTimeout)
+	defer cancel()
+
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return fmt.Errorf("spy connection is closed")
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("spy connection not ready (%s): %v", state, ctx.Err())
+		}
+	}
+}
+
+// SubscribeSignedVAA subscribes to all signed VAAs over the client's connection, retrying
+// while the spy is unreachable
+func (c *SpyClient) SubscribeSignedVAA(ctx context.Context) (spyv1.SpyRPCService_SubscribeSignedVAAClient, error) {
+	const maxRetries = 5
+	const retryDelay = 2 * time.Second
+
+	c.logger.Debug("Subscribing to signed VAAs")
+
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if err = c.waitReady(ctx); err == nil {
+			var stream spyv1.SpyRPCService_SubscribeSignedVAAClient
+			stream, err = c.client.SubscribeSignedVAA(ctx, &spyv1.SubscribeSignedVAARequest{})
+			if err == nil {
+				return stream, nil
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("context cancelled during retry: %v", ctx.Err())
+		}
+
+		if attempt < maxRetries {
+			c.logger.Warn("Subscribe attempt failed",
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+				zap.Duration("retryIn", retryDelay))
+
+			select {
+			case <-time.After(retryDelay):
+				// Continue to next retry
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context cancelled during retry: %v", ctx.Err())
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("failed to subscribe after %d attempts: %v", maxRetries, err)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"go.uber.org/zap"
+)
+
+// vaaStream delivers signed VAAs, from the spy or straight from the gossip network
+type vaaStream interface {
+	Recv() (*spyv1.SubscribeSignedVAAResponse, error)
+}
+
+// subscribeVAAs opens a spy or gossip subscription with its own context, so the staleness
+// watcher can tear down a silent stream and force a resubscribe without stopping the relayer
+func (r *Relayer) subscribeVAAs(ctx context.Context) (vaaStream, error) {
+	subCtx, cancelSub := context.WithCancel(ctx)
+	var stream vaaStream
+	var err error
+	if r.config.VAASource == vaaSourceGossip {
+		stream, err = r.gossip.SubscribeSignedVAA(subCtx, r.acceptGossipVAA)
+	} else {
+		stream, err = r.spyClient.SubscribeSignedVAA(subCtx)
+	}
+	if err != nil {
+		cancelSub()
+		return nil, err
+	}
+
+	r.subscriptionMu.Lock()
+	if r.cancelSubscription != nil {
+		r.cancelSubscription()
+	}
+	r.cancelSubscription = cancelSub
+	r.subscriptionMu.Unlock()
+
+	// A fresh stream gets a full silence window before it can be considered stale;
+	// the stale flag is only cleared once a message actually arrives
+	r.lastVAAReceived.Store(time.Now().UnixNano())
+	return stream, nil
+}
+
+// markVAAReceived records that the spy stream delivered a message
+func (r *Relayer) markVAAReceived() {
+	now := time.Now()
+	r.lastVAAReceived.Store(now.UnixNano())
+	spyLastMessageTimestamp.Set(float64(now.Unix()))
+	if r.streamStale.Swap(false) {
+		r.logger.Info("Spy stream recovered")
+	}
+}
+
+// watchStreamStaleness flags the spy stream as stale after SpyStaleTimeout without messages
+// and cancels the subscription, which makes the blocked Recv return and the loop resubscribe
+func (r *Relayer) watchStreamStaleness(ctx context.Context) {
+	if r.config.SpyStaleTimeout <= 0 {
+		return
+	}
+
+	checkInterval := r.config.SpyStaleTimeout / 4
+	if checkInterval > 30*time.Second {
+		checkInterval = 30 * time.Second
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			silence := time.Since(time.Unix(0, r.lastVAAReceived.Load()))
+			if silence < r.config.SpyStaleTimeout {
+				continue
+			}
+
+			r.streamStale.Store(true)
+			spyStreamStale.Inc()
+			r.logger.Error("Spy stream stale, forcing resubscribe",
+				zap.Duration("silence", silence.Round(time.Second)),
+				zap.Duration("staleTimeout", r.config.SpyStaleTimeout))
+
+			r.subscriptionMu.Lock()
+			if r.cancelSubscription != nil {
+				r.cancelSubscription()
+			}
+			r.subscriptionMu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/wormhole-foundation/wormhole/aztec/relayer/pkg/sdk"
+)
+
+// State store backends
+const (
+	StateStoreMemory   = "memory"
+	StateStoreBolt     = "bolt"
+	StateStorePostgres = "postgres"
+)
+
+// StateStore persists the relayer state that must survive restarts. Implementations must be
+// safe for concurrent use.
+type StateStore interface {
+	// MarkProcessed records that the VAA with the dedupe key was handled at the given time
+	MarkProcessed(key string, at time.Time) error
+	// LoadProcessed returns dedupe entries recorded at or after since
+	LoadProcessed(since time.Time) (map[string]time.Time, error)
+	// PruneProcessed deletes dedupe entries recorded before the given time
+	PruneProcessed(before time.Time) error
+
+	// SaveEmitter records the Aztec contract registered by a Safe with a tenant's module
+	SaveEmitter(tenant string, safe common.Address, aztecContract string) error
+	// RemoveEmitter deletes the Safe's registration with the tenant's module
+	RemoveEmitter(tenant string, safe common.Address) error
+	// LoadEmitters returns the Aztec contract registered by each Safe with the tenant's module
+	LoadEmitters(tenant string) (map[common.Address]string, error)
+	// SaveScanCursor records the last block whose registry events were applied for the tenant
+	SaveScanCursor(tenant string, block uint64) error
+	// LoadScanCursor returns the tenant's scan cursor, and false when none was saved
+	LoadScanCursor(tenant string) (uint64, bool, error)
+
+	// SaveEmitterOverride inserts or replaces an operator's override of a tenant's registry
+	SaveEmitterOverride(override EmitterOverride) error
+	// RemoveEmitterOverride deletes the override of the emitter in the tenant's registry
+	RemoveEmitterOverride(tenant, emitter string) error
+	// LoadEmitterOverrides returns the overrides of the tenant's registry
+	LoadEmitterOverrides(tenant string) ([]EmitterOverride, error)
+
+	// SaveCheckpoint stores cp unless a higher sequence is already recorded for the emitter
+	SaveCheckpoint(cp Checkpoint) error
+	// LoadCheckpoints returns all checkpoints keyed by checkpointKey
+	LoadCheckpoints() (map[string]Checkpoint, error)
+
+	// SaveRetry inserts or replaces a retry queue entry
+	SaveRetry(entry RetryEntry) error
+	// RemoveRetry deletes the retry queue entry with the dedupe key
+	RemoveRetry(key string) error
+	// LoadRetries returns every queued retry
+	LoadRetries() ([]RetryEntry, error)
+
+	// SaveInflight records a VAA that has entered processing
+	SaveInflight(entry InflightEntry) error
+	// RemoveInflight deletes the inflight record with the dedupe key
+	RemoveInflight(key string) error
+	// LoadInflight returns the VAAs that were still being processed when the store was last used
+	LoadInflight() ([]InflightEntry, error)
+
+	// ClaimLease renews the failover lease for holder, or takes it over when nobody renewed it
+	// within ttl, and returns the lease as stored afterwards
+	ClaimLease(holder string, ttl time.Duration) (Lease, error)
+
+	// AddSafeCost adds a confirmed relay to the Safe's daily gas totals on its chain
+	AddSafeCost(cost SafeCost) error
+	// SafeCosts returns the daily totals from the from day through the to day, for one Safe or
+	// for all when safe is nil
+	SafeCosts(safe *common.Address, from, to time.Time) ([]SafeCost, error)
+
+	// AddRelayRecord stores the gas and fee of a confirmed relay transaction
+	AddRelayRecord(record RelayRecord) error
+	// RelayRecords returns the transactions confirmed between from and to, for one Safe or
+	// for all when safe is nil, oldest first
+	RelayRecords(safe *common.Address, from, to time.Time) ([]RelayRecord, error)
+
+	Close() error
+}
+
+// Checkpoint is the highest VAA sequence processed for an emitter
+type Checkpoint struct {
+	EmitterChain   uint16    `json:"emitterChain"`
+	EmitterAddress string    `json:"emitterAddress"`
+	Sequence       uint64    `json:"sequence"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// checkpointKey identifies an emitter across chains
+func checkpointKey(chain uint16, emitterHex string) string {
+	return fmt.Sprintf("%d/%s", chain, emitterHex)
+}
+
+// RetryEntry is a VAA whose processing failed and is waiting for another attempt
+type RetryEntry struct {
+	Key         string    `json:"key"` // Dedupe key of the VAA
+	VAABytes    []byte    `json:"vaaBytes"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError"`
+	ErrorKind   ErrorKind `json:"errorKind"`           // Kind of the last failure
+	DeferredAt  time.Time `json:"deferredAt,omitzero"` // When the VAA was first deferred for its cost
+
+	// Call flow progress: the next step to send and the values earlier steps captured
+	FlowStep     int                    `json:"flowStep,omitempty"`
+	FlowCaptured map[string]common.Hash `json:"flowCaptured,omitempty"`
+
+	CorrelationID string `json:"correlationId"` // Kept across attempts, see newCorrelationID
+}
+
+// InflightEntry is a VAA that entered processing and has not yet been confirmed or queued
+// for retry
+type InflightEntry struct {
+	Key       string    `json:"key"` // Dedupe key of the VAA
+	VAABytes  []byte    `json:"vaaBytes"`
+	StartedAt time.Time `json:"startedAt"`
+
+	CorrelationID string `json:"correlationId"`
+}
+
+// Lease records which relayer instance is active and when it last renewed its claim
+type Lease struct {
+	Holder    string    `json:"holder"`
+	RenewedAt time.Time `json:"renewedAt"`
+}
+
+// SafeCost is the gas spent relaying recoveries for a Safe on one chain during one UTC day
+type SafeCost struct {
+	Safe    common.Address `json:"safe"`
+	ChainID uint64         `json:"chainId"`
+	Day     time.Time      `json:"day"`
+	Relays  uint64         `json:"relays"`
+	GasUsed uint64         `json:"gasUsed"`
+	CostWei *big.Int       `json:"costWei"`
+}
+
+// safeCostDay truncates t to its UTC day
+func safeCostDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// safeCostKey identifies a Safe's daily total on a chain
+func safeCostKey(safe common.Address, chainID uint64, day time.Time) string {
+	return fmt.Sprintf("%s/%d/%s", safe.Hex(), chainID, day.Format(time.DateOnly))
+}
+
+// addSafeCost merges cost into total
+func addSafeCost(total *SafeCost, cost SafeCost) {
+	total.Relays += cost.Relays
+	total.GasUsed += cost.GasUsed
+	if total.CostWei == nil {
+		total.CostWei = new(big.Int)
+	}
+	total.CostWei = new(big.Int).Add(total.CostWei, cost.CostWei)
+}
+
+// safeCostMatches reports whether cost falls within a SafeCosts query
+func safeCostMatches(cost SafeCost, safe *common.Address, from, to time.Time) bool {
+	if safe != nil && cost.Safe != *safe {
+		return false
+	}
+	return !cost.Day.Before(safeCostDay(from)) && !cost.Day.After(safeCostDay(to))
+}
+
+// sortSafeCosts orders totals by day, then Safe, then chain
+func sortSafeCosts(costs []SafeCost) {
+	sort.Slice(costs, func(i, j int) bool {
+		if !costs[i].Day.Equal(costs[j].Day) {
+			return costs[i].Day.Before(costs[j].Day)
+		}
+		if costs[i].Safe != costs[j].Safe {
+			return costs[i].Safe.Hex() < costs[j].Safe.Hex()
+		}
+		return costs[i].ChainID < costs[j].ChainID
+	})
+}
+
+// RelayRecord is the gas a confirmed relay transaction used and what it cost, read from its
+// receipt. It is served to pkg/sdk clients as is.
+type RelayRecord = sdk.RelayRecord
+
+// relayRecordMatches reports whether record falls within a RelayRecords query
+func relayRecordMatches(record RelayRecord, safe *common.Address, from, to time.Time) bool {
+	if safe != nil && record.Safe != *safe {
+		return false
+	}
+	return !record.ConfirmedAt.Before(from) && !record.ConfirmedAt.After(to)
+}
+
+// sortRelayRecords orders records by confirmation time
+func sortRelayRecords(records []RelayRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ConfirmedAt.Before(records[j].ConfirmedAt)
+	})
+}
+
+// OpenStateStore opens the backend selected by STATE_STORE
+func OpenStateStore(config Config) (StateStore, error) {
+	switch config.StateStore {
+	case StateStoreMemory:
+		return NewMemoryStore(), nil
+	case StateStoreBolt:
+		return OpenBoltStore(config.StateDBPath)
+	case StateStorePostgres:
+		if config.StatePostgresURL == "" {
+			return nil, fmt.Errorf("STATE_POSTGRES_URL is required for the postgres state store")
+		}
+		return OpenPostgresStore(config.StatePostgresURL)
+	default:
+		return nil, fmt.Errorf("unknown state store %q (want %s, %s or %s)",
+			config.StateStore, StateStoreMemory, StateStoreBolt, StateStorePostgres)
+	}
+}
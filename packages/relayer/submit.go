@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// runSubmit relays a single VAA through the daemon's signing, broadcast and confirmation path,
+// for operators recovering a VAA the daemon failed to deliver
+func runSubmit(ctx context.Context, config Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: relayer submit <vaa-file|->")
+		return 2
+	}
+	vaaBytes, err := readVAAFile(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	vaaData, err := parseVAAData(vaaBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse VAA: %v\n", err)
+		return 1
+	}
+	vaaData.CorrelationID = newCorrelationID()
+	ctx = withCorrelationID(ctx, vaaData.CorrelationID)
+	fmt.Printf("VAA chain %d emitter %s sequence %d (correlation ID %s)\n", vaaData.ChainID, vaaData.EmitterHex, vaaData.Sequence, vaaData.CorrelationID)
+	if vaaData.ChainID != config.SourceChainID {
+		fmt.Fprintf(os.Stderr, "VAA is from chain %d, the relayer only relays chain %d\n", vaaData.ChainID, config.SourceChainID)
+		return 1
+	}
+
+	r, err := openCommandRelayer(ctx, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer r.Close()
+
+	// Refuse up front what the processor would reject silently
+	if !r.config.AcceptAnyEmitter && !r.isRegisteredEmitter(vaaData.EmitterHex) {
+		fmt.Fprintln(os.Stderr, "emitter is not registered with any tenant")
+		return 1
+	}
+	if _, _, err := r.routeVAA(vaaData); err != nil {
+		fmt.Fprintf(os.Stderr, "VAA would be rejected: %v\n", err)
+		return 1
+	}
+
+	err = r.vaaProcessor(ctx, r, vaaData)
+	if vaaData.TxHash != "" {
+		fmt.Printf("tx hash: %s\n", vaaData.TxHash)
+	}
+	if vaaData.Receipt != nil {
+		status := "success"
+		if vaaData.Receipt.Status != types.ReceiptStatusSuccessful {
+			status = "reverted"
+		}
+		fmt.Printf("receipt: %s in block %d, gas used %d\n", status, vaaData.Receipt.BlockNumber.Uint64(), vaaData.Receipt.GasUsed)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "submission failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readVAAFile reads a VAA from path, or from stdin when path is "-". The content may be the
+// raw VAA or its hex encoding.
+func readVAAFile(path string) ([]byte, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VAA: %v", err)
+	}
+
+	if vaaBytes, err := decodeVAAHex(string(bytes.TrimSpace(content))); err == nil {
+		return vaaBytes, nil
+	}
+	return content, nil
+}
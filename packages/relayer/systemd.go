@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sdNotify sends a state string to systemd's notification socket.
+// It is a no-op when the relayer is not running under a Type=notify unit.
+func sdNotify(state string) error {
+	socketAddr := os.Getenv("NOTIFY_SOCKET")
+	if socketAddr == "" {
+		return nil
+	}
+
+	// A leading '@' denotes a Linux abstract socket
+	if socketAddr[0] == '@' {
+		socketAddr = "\x00" + socketAddr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketAddr, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to notify socket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to write to notify socket: %v", err)
+	}
+	return nil
+}
+
+// sdWatchdogInterval returns the watchdog timeout systemd expects pings within,
+// or false if the watchdog is not enabled for this process
+func sdWatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+
+	// WATCHDOG_PID is set when the watchdog is meant for a specific process
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid != os.Getpid() {
+			return 0, false
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// notifySystemd sends a state to systemd, logging rather than failing on errors
+func (r *Relayer) notifySystemd(state string) {
+	if err := sdNotify(state); err != nil {
+		r.logger.Warn("systemd notification failed", zap.String("state", state), zap.Error(err))
+	}
+}
+
+// runWatchdog pings the systemd watchdog while the pipeline is alive. When the spy stream goes
+// silent or the EVM RPC stops responding the pings stop and systemd restarts the relayer.
+func (r *Relayer) runWatchdog(ctx context.Context) {
+	interval, ok := sdWatchdogInterval()
+	if !ok {
+		return
+	}
+
+	r.logger.Info("systemd watchdog enabled",
+		zap.Duration("timeout", interval),
+		zap.Duration("streamTimeout", r.config.WatchdogStreamTimeout))
+
+	// Ping at half the timeout as recommended by sd_watchdog_enabled(3)
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.checkLiveness(ctx, interval/2); err != nil {
+				r.logger.Warn("Pipeline not live, withholding watchdog ping", zap.Error(err))
+				r.notifySystemd("STATUS=" + err.Error())
+				continue
+			}
+			r.notifySystemd("WATCHDOG=1")
+		}
+	}
+}
+
+// checkLiveness verifies the spy stream is delivering VAAs and the EVM RPC is answering
+func (r *Relayer) checkLiveness(ctx context.Context, rpcTimeout time.Duration) error {
+	lastReceived := time.Unix(0, r.lastVAAReceived.Load())
+	if silence := time.Since(lastReceived); silence > r.config.WatchdogStreamTimeout {
+		return fmt.Errorf("no VAAs received from spy for %s", silence.Round(time.Second))
+	}
+
+	rpcCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	if _, err := r.evmClient.client.BlockNumber(rpcCtx); err != nil {
+		return fmt.Errorf("EVM RPC not responding: %v", err)
+	}
+
+	return nil
+}
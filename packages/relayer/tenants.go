@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// TenantConfig describes a SafeRecoveryModule deployment the relayer serves
+type TenantConfig struct {
+	Name           string // Label used in logs and metrics
+	Destination    string // Name of the destination chain the module is deployed on
+	TargetContract string // SafeRecoveryModule contract
+	ScanStartBlock int64  // First block scanned for emitter registry events
+}
+
+// Tenant is a module deployment with its own emitter registry
+type Tenant struct {
+	TenantConfig
+	dest   *Destination
+	target common.Address
+	store  StateStore
+	flow   []*flowStep // Transactions sent per VAA, for payload types without their own flow
+	logger *zap.Logger
+
+	// Flows of specific payload types (nil when the tenant's flow doesn't depend on the type)
+	flowsByType map[uint8][]*flowStep
+
+	emittersMu         sync.RWMutex
+	registeredEmitters map[string]common.Address  // aztecContract -> safeAddress
+	safeEmitters       map[common.Address]string  // safeAddress -> aztecContract
+	replacedEmitters   map[common.Address]string  // safeAddress -> aztecContract its latest set event replaced
+	overrides          map[string]EmitterOverride // Operator overrides by normalized emitter
+
+	// Last block of the startup registry scan, where polling resumes
+	scannedTo int64
+	// Registry seeded from the state store, so scans can resume at the saved cursor
+	restored bool
+}
+
+// loadTenantsFromEnv builds the tenant list. Every destination with a target contract is a
+// tenant named after it; TENANTS lists further deployments by name, each configured through
+// TENANT_<NAME>_DESTINATION (defaults to the primary destination), TENANT_<NAME>_TARGET_CONTRACT
+// and TENANT_<NAME>_SCAN_START_BLOCK.
+func loadTenantsFromEnv(destinations []DestinationConfig) []TenantConfig {
+	var tenants []TenantConfig
+	for _, dest := range destinations {
+		if dest.TargetContract == "" {
+			continue
+		}
+		tenants = append(tenants, TenantConfig{
+			Name:           dest.Name,
+			Destination:    dest.Name,
+			TargetContract: dest.TargetContract,
+			ScanStartBlock: dest.ScanStartBlock,
+		})
+	}
+
+	for _, name := range getEnvListOrDefault("TENANTS", nil) {
+		prefix := "TENANT_" + strings.ToUpper(name) + "_"
+		tenants = append(tenants, TenantConfig{
+			Name:           name,
+			Destination:    getEnvOrDefault(prefix+"DESTINATION", destinations[0].Name),
+			TargetContract: getEnvOrDefault(prefix+"TARGET_CONTRACT", ""),
+			ScanStartBlock: int64(getEnvIntOrDefault(prefix+"SCAN_START_BLOCK", 0)),
+		})
+	}
+
+	return tenants
+}
+
+// newTenants attaches each configured tenant to its destination
+func newTenants(configs []TenantConfig, destinations []*Destination, store StateStore) ([]*Tenant, error) {
+	byName := make(map[string]*Destination, len(destinations))
+	for _, dest := range destinations {
+		byName[dest.Name] = dest
+	}
+
+	names := make(map[string]bool, len(configs))
+	tenants := make([]*Tenant, 0, len(configs))
+	for _, cfg := range configs {
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("tenant %q is configured more than once", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		dest, ok := byName[cfg.Destination]
+		if !ok {
+			return nil, fmt.Errorf("tenant %q uses unknown destination %q", cfg.Name, cfg.Destination)
+		}
+		if !common.IsHexAddress(cfg.TargetContract) {
+			return nil, fmt.Errorf("tenant %q has invalid target contract %q", cfg.Name, cfg.TargetContract)
+		}
+
+		tenants = append(tenants, &Tenant{
+			TenantConfig:       cfg,
+			dest:               dest,
+			target:             common.HexToAddress(cfg.TargetContract),
+			store:              store,
+			logger:             logger.With(zap.String("component", "Tenant"), zap.String("tenant", cfg.Name)),
+			registeredEmitters: make(map[string]common.Address),
+			safeEmitters:       make(map[common.Address]string),
+			replacedEmitters:   make(map[common.Address]string),
+			overrides:          make(map[string]EmitterOverride),
+		})
+	}
+	return tenants, nil
+}
+
+// indexTenants indexes tenants by destination chain ID and module for routing; destination
+// chain IDs must be resolved first
+func (r *Relayer) indexTenants() error {
+	index := make(map[uint64]map[common.Address]*Tenant)
+	for _, tenant := range r.tenants {
+		byModule, ok := index[tenant.dest.ChainID]
+		if !ok {
+			byModule = make(map[common.Address]*Tenant)
+			index[tenant.dest.ChainID] = byModule
+		}
+		if existing, ok := byModule[tenant.target]; ok {
+			return fmt.Errorf("tenants %q and %q both use module %s on chain %d",
+				existing.Name, tenant.Name, tenant.target.Hex(), tenant.dest.ChainID)
+		}
+		byModule[tenant.target] = tenant
+
+		r.logger.Info("Tenant ready",
+			zap.String("tenant", tenant.Name),
+			zap.String("destination", tenant.dest.Name),
+			zap.String("target", tenant.TargetContract),
+			zap.Int64("scanStartBlock", tenant.ScanStartBlock))
+	}
+	r.tenantsByChainID = index
+	return nil
+}
+
+// tenantFor returns the tenant serving the module on the given chain
+func (r *Relayer) tenantFor(chainID uint64, module common.Address) (*Tenant, bool) {
+	tenant, ok := r.tenantsByChainID[chainID][module]
+	return tenant, ok
+}
+
+// updateEmitterGauge exports the registry size; callers hold emittersMu
+func (t *Tenant) updateEmitterGauge() {
+	tenantRegisteredEmitters.WithLabelValues(t.Name).Set(float64(len(t.registeredEmitters)))
+}
+
+// tenantLabel is the tenant metric label for a VAA
+func tenantLabel(vaaData *VAAData) string {
+	if vaaData.Tenant == "" {
+		return "none"
+	}
+	return vaaData.Tenant
+}
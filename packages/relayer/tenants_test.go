@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+func newTestTenant() *Tenant {
+	return &Tenant{
+		TenantConfig:       TenantConfig{Name: "test"},
+		store:              NewMemoryStore(),
+		logger:             zap.NewNop(),
+		registeredEmitters: make(map[string]common.Address),
+		safeEmitters:       make(map[common.Address]string),
+		replacedEmitters:   make(map[common.Address]string),
+		overrides:          make(map[string]EmitterOverride),
+	}
+}
+
+func TestSetEmitterKeepsContractTakenByAnotherSafe(t *testing.T) {
+	safeA := common.HexToAddress("0xa")
+	safeB := common.HexToAddress("0xb")
+	tenant := newTestTenant()
+
+	tenant.setEmitter(safeA, "x", 1)
+	tenant.setEmitter(safeB, "x", 2)
+	tenant.setEmitter(safeA, "y", 3)
+
+	if owner := tenant.registeredEmitters["x"]; owner != safeB {
+		t.Fatalf("x maps to %s, want %s", owner.Hex(), safeB.Hex())
+	}
+	if owner := tenant.registeredEmitters["y"]; owner != safeA {
+		t.Fatalf("y maps to %s, want %s", owner.Hex(), safeA.Hex())
+	}
+}
+
+func TestRevertEmitter(t *testing.T) {
+	safeA := common.HexToAddress("0xa")
+	safeB := common.HexToAddress("0xb")
+
+	tests := []struct {
+		name       string
+		setup      func(*Tenant)
+		reverted   string
+		wantSafeA  string // Contract safeA maps to after the revert ("" for none)
+		wantOwners map[string]common.Address
+	}{
+		{
+			name:       "first registration is removed",
+			setup:      func(tenant *Tenant) { tenant.setEmitter(safeA, "x", 1) },
+			reverted:   "x",
+			wantOwners: map[string]common.Address{},
+		},
+		{
+			name: "replaced contract is restored",
+			setup: func(tenant *Tenant) {
+				tenant.setEmitter(safeA, "x", 1)
+				tenant.setEmitter(safeA, "y", 2)
+			},
+			reverted:   "y",
+			wantSafeA:  "x",
+			wantOwners: map[string]common.Address{"x": safeA},
+		},
+		{
+			name: "stale event leaves the current mapping",
+			setup: func(tenant *Tenant) {
+				tenant.setEmitter(safeA, "x", 1)
+				tenant.setEmitter(safeA, "y", 2)
+			},
+			reverted:   "x",
+			wantSafeA:  "y",
+			wantOwners: map[string]common.Address{"y": safeA},
+		},
+		{
+			name: "contract taken by another Safe is not restored",
+			setup: func(tenant *Tenant) {
+				tenant.setEmitter(safeA, "x", 1)
+				tenant.setEmitter(safeB, "x", 2)
+				tenant.setEmitter(safeA, "y", 3)
+			},
+			reverted:   "y",
+			wantOwners: map[string]common.Address{"x": safeB},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := newTestTenant()
+			tt.setup(tenant)
+			tenant.revertEmitter(safeA, tt.reverted, 10)
+
+			if got := tenant.safeEmitters[safeA]; got != tt.wantSafeA {
+				t.Errorf("safeA maps to %q, want %q", got, tt.wantSafeA)
+			}
+			if len(tenant.registeredEmitters) != len(tt.wantOwners) {
+				t.Errorf("registered emitters %v, want %v", tenant.registeredEmitters, tt.wantOwners)
+			}
+			for contract, owner := range tt.wantOwners {
+				if got := tenant.registeredEmitters[contract]; got != owner {
+					t.Errorf("%s maps to %s, want %s", contract, got.Hex(), owner.Hex())
+				}
+			}
+		})
+	}
+}
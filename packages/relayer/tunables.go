@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Parameters adjustable at runtime through the admin API. Changes last until the relayer
+// restarts, when the environment applies again.
+const (
+	TunableMaxFeePerGas          = "max_fee_per_gas"         // Per destination: fee cap ceiling in gwei (0 for no limit)
+	TunableMaxTxCost             = "max_tx_cost"             // Per destination: most ETH a transaction may cost (0 for no limit)
+	TunableFeeStrategy           = "fee_strategy"            // Per destination: fee strategy name
+	TunableLogScanBatchSize      = "log_scan_batch_size"     // Per destination: eth_getLogs calls per batch request
+	TunableRetryMaxAttempts      = "retry_max_attempts"      // Attempts before a VAA is given up on (0 retries forever)
+	TunableRetryBackoff          = "retry_backoff"           // Delay before the first retry
+	TunableSponsorshipDailyLimit = "sponsorship_daily_limit" // Sponsored operations per destination per day (0 for no limit)
+	TunableRefillDailyLimit      = "refill_daily_limit"      // Most ETH refilled per destination per day (0 for no limit)
+)
+
+// Bounds on the tunables that have one
+const (
+	maxTunableLogScanBatch = 1000
+	maxTunableRetries      = 1000
+)
+
+// destinationTunables are the tunables set per destination
+var destinationTunables = map[string]bool{
+	TunableMaxFeePerGas:     true,
+	TunableMaxTxCost:        true,
+	TunableFeeStrategy:      true,
+	TunableLogScanBatchSize: true,
+}
+
+// Tunables reports the current value of every tunable, the per-destination ones by destination
+func (r *Relayer) Tunables() map[string]any {
+	destinations := make(map[string]map[string]string, len(r.destinations))
+	for _, dest := range r.destinations {
+		values := make(map[string]string, len(destinationTunables))
+		for name := range destinationTunables {
+			values[name] = r.destinationTunable(dest, name)
+		}
+		destinations[dest.Name] = values
+	}
+
+	tunables := map[string]any{
+		TunableRetryMaxAttempts: strconv.FormatInt(r.retryMaxAttempts.Load(), 10),
+		TunableRetryBackoff:     time.Duration(r.retryBackoff.Load()).String(),
+		"destinations":          destinations,
+	}
+	if r.sponsorship != nil {
+		tunables[TunableSponsorshipDailyLimit] = strconv.Itoa(r.sponsorship.limit())
+	}
+	if r.refiller != nil {
+		tunables[TunableRefillDailyLimit] = formatLimit(r.refiller.limit(), formatWei)
+	}
+	return tunables
+}
+
+// destinationTunable formats a per-destination tunable's current value
+func (r *Relayer) destinationTunable(dest *Destination, name string) string {
+	switch name {
+	case TunableMaxFeePerGas:
+		return formatLimit(dest.client.maxFeePerGas.Load(), formatGwei)
+	case TunableMaxTxCost:
+		return formatLimit(dest.client.maxTxCost.Load(), formatWei)
+	case TunableFeeStrategy:
+		return dest.feeStrategyName()
+	case TunableLogScanBatchSize:
+		return strconv.FormatInt(dest.client.logScanBatch.Load(), 10)
+	}
+	return ""
+}
+
+// formatLimit formats an optional amount, nil being no limit
+func formatLimit(amount *big.Int, format func(*big.Int) string) string {
+	if amount == nil {
+		return "0"
+	}
+	return format(amount)
+}
+
+// optionalLimit parses an amount where 0 means no limit, returning nil for it
+func optionalLimit(value string, parse func(string) (*big.Int, error)) (*big.Int, error) {
+	amount, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() == 0 {
+		return nil, nil
+	}
+	return amount, nil
+}
+
+// SetTunable validates and applies a new value for a tunable. Per-destination tunables apply
+// to the named destination, or to every destination when it is empty; destination must be
+// empty for the others. The change is logged and audited with its previous value.
+func (r *Relayer) SetTunable(name, destination, value, principal string) error {
+	if !slices.Contains(tunableNames(), name) {
+		return fmt.Errorf("unknown tunable %q (want one of %v)", name, tunableNames())
+	}
+	var targets []*Destination
+	if destinationTunables[name] {
+		for _, dest := range r.destinations {
+			if destination == "" || dest.Name == destination {
+				targets = append(targets, dest)
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("unknown destination %q", destination)
+		}
+	} else if destination != "" {
+		return fmt.Errorf("%s is not set per destination", name)
+	}
+
+	var previous string
+	var apply func()
+	switch name {
+	case TunableMaxFeePerGas:
+		ceiling, err := optionalLimit(value, parseGwei)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		apply = func() {
+			for _, dest := range targets {
+				dest.client.maxFeePerGas.Store(ceiling)
+			}
+		}
+	case TunableMaxTxCost:
+		maxTxCost, err := optionalLimit(value, parseEther)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		apply = func() {
+			for _, dest := range targets {
+				dest.client.maxTxCost.Store(maxTxCost)
+			}
+		}
+	case TunableFeeStrategy:
+		strategy, err := lookupFeeStrategy(value)
+		if err != nil {
+			return err
+		}
+		apply = func() {
+			for _, dest := range targets {
+				dest.feeStrategyOverride.Store(&strategy.Name)
+			}
+		}
+	case TunableLogScanBatchSize:
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxTunableLogScanBatch {
+			return fmt.Errorf("%s must be a number from 1 to %d", name, maxTunableLogScanBatch)
+		}
+		apply = func() {
+			for _, dest := range targets {
+				dest.client.logScanBatch.Store(int64(size))
+			}
+		}
+	case TunableRetryMaxAttempts:
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 0 || attempts > maxTunableRetries {
+			return fmt.Errorf("%s must be a number from 0 to %d", name, maxTunableRetries)
+		}
+		previous = strconv.FormatInt(r.retryMaxAttempts.Load(), 10)
+		apply = func() { r.retryMaxAttempts.Store(int64(attempts)) }
+	case TunableRetryBackoff:
+		backoff, err := time.ParseDuration(value)
+		if err != nil || backoff < time.Second || backoff > maxRetryBackoff {
+			return fmt.Errorf("%s must be a duration from 1s to %s", name, maxRetryBackoff)
+		}
+		previous = time.Duration(r.retryBackoff.Load()).String()
+		apply = func() { r.retryBackoff.Store(int64(backoff)) }
+	case TunableSponsorshipDailyLimit:
+		if r.sponsorship == nil {
+			return fmt.Errorf("no destination uses sponsored submission")
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("%s must be a number, 0 for no limit", name)
+		}
+		previous = strconv.Itoa(r.sponsorship.limit())
+		apply = func() { r.sponsorship.setLimit(limit) }
+	case TunableRefillDailyLimit:
+		if r.refiller == nil {
+			return fmt.Errorf("treasury refill is not configured")
+		}
+		limit, err := optionalLimit(value, parseEther)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		previous = formatLimit(r.refiller.limit(), formatWei)
+		apply = func() { r.refiller.setLimit(limit) }
+	}
+
+	if targets != nil {
+		previousByDest := make(map[string]string, len(targets))
+		for _, dest := range targets {
+			previousByDest[dest.Name] = r.destinationTunable(dest, name)
+		}
+		apply()
+		for _, dest := range targets {
+			r.recordTunable(name, dest.Name, previousByDest[dest.Name], r.destinationTunable(dest, name), principal)
+		}
+		return nil
+	}
+	apply()
+	current, _ := r.Tunables()[name].(string)
+	r.recordTunable(name, "", previous, current, principal)
+	return nil
+}
+
+// recordTunable logs and audits a changed tunable
+func (r *Relayer) recordTunable(name, destination, previous, current, principal string) {
+	r.logger.Warn("Tunable changed through the admin API",
+		zap.String("tunable", name),
+		zap.String("destination", destination),
+		zap.String("previous", previous),
+		zap.String("value", current),
+		zap.String("principal", principal))
+	r.audit.Record(AuditTunable, map[string]string{
+		"tunable":     name,
+		"destination": destination,
+		"previous":    previous,
+		"value":       current,
+		"principal":   principal,
+	})
+}
+
+// tunableNames lists the tunables, sorted
+func tunableNames() []string {
+	names := []string{
+		TunableMaxFeePerGas, TunableMaxTxCost, TunableFeeStrategy, TunableLogScanBatchSize,
+		TunableRetryMaxAttempts, TunableRetryBackoff, TunableSponsorshipDailyLimit, TunableRefillDailyLimit,
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Transaction types a destination can be sent
+const (
+	TxTypeAuto    = "auto"    // Dynamic-fee when the chain reports a base fee, legacy otherwise
+	TxTypeLegacy  = "legacy"  // Pre-EIP-2718 transaction with a gas price, EIP-155 replay protected
+	TxTypeDynamic = "dynamic" // EIP-1559 (type 2) transaction with a fee cap and a priority tip
+	TxTypeZkSync  = "zksync"  // zkSync Era EIP-712 (type 0x71) transaction with a gas per pubdata limit
+)
+
+// lookupTxType validates a configured transaction type
+func lookupTxType(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", TxTypeAuto:
+		return TxTypeAuto, nil
+	case TxTypeLegacy, "0":
+		return TxTypeLegacy, nil
+	case TxTypeDynamic, "eip1559", "2":
+		return TxTypeDynamic, nil
+	case TxTypeZkSync, "eip712", "113":
+		return TxTypeZkSync, nil
+	default:
+		return "", fmt.Errorf("unknown transaction type %q (want %s, %s, %s or %s)", name, TxTypeAuto, TxTypeLegacy, TxTypeDynamic, TxTypeZkSync)
+	}
+}
+
+// newTransaction builds an unsigned transaction of the chain's type from params, with its
+// fees raised by bumpPct percent (0 for none) to replace a pending one. zkSync transactions are
+// built as dynamic-fee ones, whose fields signTx re-encodes.
+func newTransaction(params txParams, to common.Address, value *big.Int, gasLimit uint64, data []byte, bumpPct int64) *types.Transaction {
+	nonce, tip, feeCap := params.nonce, params.tip, params.feeCap
+	if bumpPct > 0 {
+		tip = mulPct(tip, 100+bumpPct)
+		feeCap = mulPct(feeCap, 100+bumpPct)
+	}
+
+	if params.chain.txType == TxTypeLegacy {
+		return types.NewTransaction(nonce, to, value, gasLimit, feeCap, data)
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   params.chain.chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gasLimit,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+}
+
+// signTx has signer sign tx as the chain's transaction type, returning the signed encoding and
+// the transaction hash. zkSync transactions carry tx's fields in their own EIP-712 type.
+func (c *EVMClient) signTx(ctx context.Context, signer Signer, params txParams, tx *types.Transaction, description string) ([]byte, common.Hash, error) {
+	if params.chain.txType == TxTypeZkSync {
+		return c.signZkSync(ctx, signer, params, tx, description)
+	}
+	signed, err := signer.SignTx(ctx, tx, params.chain.signer, description)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return raw, signed.Hash(), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// zkSyncTxType is the EIP-2718 type of zkSync Era's native EIP-712 transactions
+const zkSyncTxType = 0x71
+
+// zkSyncTxTypes are the EIP-712 types a zkSync transaction is signed as
+var zkSyncTxTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+	},
+	"Transaction": {
+		{Name: "txType", Type: "uint256"},
+		{Name: "from", Type: "uint256"},
+		{Name: "to", Type: "uint256"},
+		{Name: "gasLimit", Type: "uint256"},
+		{Name: "gasPerPubdataByteLimit", Type: "uint256"},
+		{Name: "maxFeePerGas", Type: "uint256"},
+		{Name: "maxPriorityFeePerGas", Type: "uint256"},
+		{Name: "paymaster", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "value", Type: "uint256"},
+		{Name: "data", Type: "bytes"},
+		{Name: "factoryDeps", Type: "bytes32[]"},
+		{Name: "paymasterInput", Type: "bytes"},
+	},
+}
+
+// zkSyncTypedData is the EIP-712 message the sender signs for a zkSync transaction carrying
+// tx's fields, with no paymaster and no factory dependencies
+func zkSyncTypedData(tx *types.Transaction, from common.Address, chainID *big.Int, gasPerPubdata uint64) apitypes.TypedData {
+	word := func(address common.Address) string { return new(big.Int).SetBytes(address.Bytes()).String() }
+	return apitypes.TypedData{
+		Types:       zkSyncTxTypes,
+		PrimaryType: "Transaction",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "zkSync",
+			Version: "2",
+			ChainId: (*math.HexOrDecimal256)(chainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"txType":                 fmt.Sprint(zkSyncTxType),
+			"from":                   word(from),
+			"to":                     word(*tx.To()),
+			"gasLimit":               fmt.Sprint(tx.Gas()),
+			"gasPerPubdataByteLimit": fmt.Sprint(gasPerPubdata),
+			"maxFeePerGas":           tx.GasFeeCap().String(),
+			"maxPriorityFeePerGas":   tx.GasTipCap().String(),
+			"paymaster":              "0",
+			"nonce":                  fmt.Sprint(tx.Nonce()),
+			"value":                  tx.Value().String(),
+			"data":                   "0x" + common.Bytes2Hex(tx.Data()),
+			"factoryDeps":            []interface{}{},
+			"paymasterInput":         "0x",
+		},
+	}
+}
+
+// signZkSync signs the fields of tx as a zkSync EIP-712 transaction from signer, returning its
+// encoding for eth_sendRawTransaction and its hash
+func (c *EVMClient) signZkSync(ctx context.Context, signer Signer, params txParams, tx *types.Transaction, description string) ([]byte, common.Hash, error) {
+	typedSigner, ok := signer.(typedDataSigner)
+	if !ok {
+		return nil, common.Hash{}, fmt.Errorf("signer %s cannot sign zkSync transactions", signer.Address().Hex())
+	}
+	from := signer.Address()
+	chainID := params.chain.chainID
+	typed := zkSyncTypedData(tx, from, chainID, c.gasPerPubdata)
+	digest, _, err := apitypes.TypedDataAndHash(typed)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	signature, err := typedSigner.SignTypedData(ctx, typed, description)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if len(signature) != crypto.SignatureLength {
+		return nil, common.Hash{}, fmt.Errorf("signature is %d bytes, want %d", len(signature), crypto.SignatureLength)
+	}
+	signature = append([]byte{}, signature...)
+	if signature[crypto.RecoveryIDOffset] < 27 {
+		signature[crypto.RecoveryIDOffset] += 27
+	}
+
+	// Never broadcast a transaction from an account other than the signer's
+	yParity := signature[crypto.RecoveryIDOffset] - 27
+	recoverable := append(append([]byte{}, signature[:crypto.RecoveryIDOffset]...), yParity)
+	pub, err := crypto.SigToPub(digest, recoverable)
+	if err != nil || crypto.PubkeyToAddress(*pub) != from {
+		return nil, common.Hash{}, fmt.Errorf("zkSync transaction signature does not recover to %s", from.Hex())
+	}
+
+	fields := []interface{}{
+		tx.Nonce(),
+		tx.GasTipCap(),
+		tx.GasFeeCap(),
+		tx.Gas(),
+		*tx.To(),
+		tx.Value(),
+		tx.Data(),
+		uint64(yParity),
+		new(big.Int).SetBytes(signature[:32]),
+		new(big.Int).SetBytes(signature[32:64]),
+		chainID,
+		from,
+		c.gasPerPubdata,
+		[][]byte{},      // Factory dependencies
+		signature,       // Custom signature field, the sender's ECDSA signature for EOAs
+		[]interface{}{}, // No paymaster
+	}
+	encoded, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	raw := append([]byte{zkSyncTxType}, encoded...)
+	return raw, crypto.Keccak256Hash(digest, crypto.Keccak256(signature)), nil
+}